@@ -0,0 +1,54 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+)
+
+func TestDialector(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		sqlitePath string
+		wantName   string
+		wantDSN    string
+	}{
+		{"default sqlite", "", "", "sqlite", "review_tasks.db"},
+		{"DB_PATH", "", "/data/tasks.db", "sqlite", "/data/tasks.db"},
+		{"sqlite URL", "sqlite:///data/tasks.db", "", "sqlite", "/data/tasks.db"},
+		{"postgres", "postgres://app:secret@db:5432/review?sslmode=disable", "", "postgres", "postgres://app:secret@db:5432/review?sslmode=disable"},
+		{"postgresql", "postgresql://app@db/review", "", "postgres", "postgresql://app@db/review"},
+		{"mysql", "mysql://app:secret@db:3306/review", "", "mysql", "app:secret@tcp(db:3306)/review?parseTime=true"},
+		{"mysql params", "mysql://app:secret@db/review?charset=utf8mb4&loc=UTC", "", "mysql", "app:secret@tcp(db:3306)/review?parseTime=true&charset=utf8mb4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialector, err := Dialector(tt.url, tt.sqlitePath)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantName, dialector.Name())
+
+			switch d := dialector.(type) {
+			case *sqlite.Dialector:
+				assert.Equal(t, tt.wantDSN, d.DSN)
+			case *postgres.Dialector:
+				assert.Equal(t, tt.wantDSN, d.Config.DSN)
+			case *mysql.Dialector:
+				assert.Equal(t, tt.wantDSN, d.Config.DSN)
+			}
+		})
+	}
+}
+
+func TestDialector_InvalidURL(t *testing.T) {
+	_, err := Dialector("oracle://db/review", "")
+	assert.ErrorContains(t, err, `unsupported DATABASE_URL scheme "oracle"`)
+
+	_, err = Dialector("mysql://app@db/review?timeout=soon", "")
+	assert.ErrorContains(t, err, "invalid DATABASE_URL")
+}
@@ -5,6 +5,9 @@ package main
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,10 +15,12 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"slack-review-notify/database/dbtest"
 	"slack-review-notify/handlers"
 	"slack-review-notify/models"
 	"slack-review-notify/services"
@@ -24,7 +29,6 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -77,16 +81,21 @@ type slackhogPostResponse struct {
 	TS      string `json:"ts"`
 }
 
+// e2eSlackClient calls slackhog through SLACK_API_BASE_URL.
+func e2eSlackClient() services.SlackClient {
+	return services.NewSlackClient(os.Getenv("SLACK_BOT_TOKEN"))
+}
+
 func setupE2EApp(t *testing.T) (*gorm.DB, *httptest.Server) {
 	t.Helper()
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	require.NoError(t, err)
-	require.NoError(t, db.AutoMigrate(&models.ReviewTask{}, &models.ChannelConfig{}, &models.UserMapping{}, &models.ReviewerAvailability{}))
+	db := dbtest.Open(t)
+	require.NoError(t, models.Migrate(db))
 
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
-	r.POST("/webhook", handlers.HandleGitHubWebhook(db))
-	r.POST("/slack/actions", handlers.HandleSlackAction(db))
+	slack := e2eSlackClient()
+	r.POST("/webhook", handlers.HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
+	r.POST("/slack/actions", handlers.HandleSlackAction(db, slack))
 
 	ts := httptest.NewServer(r)
 	return db, ts
@@ -177,7 +186,6 @@ func TestE2E_ReReview_DuringBusinessHours_SendsImmediately(t *testing.T) {
 	os.Setenv("SLACK_API_BASE_URL", slackhogBaseURL+"/api")
 	defer os.Setenv("SLACK_API_BASE_URL", originalURL)
 
-	services.IsTestMode = true
 	db, ts := setupE2EApp(t)
 	defer ts.Close()
 	clearSlackhogMessages(t)
@@ -254,7 +262,6 @@ func TestE2E_ReReview_OutsideBusinessHours_DefersAndSendsLater(t *testing.T) {
 	os.Setenv("SLACK_API_BASE_URL", slackhogBaseURL+"/api")
 	defer os.Setenv("SLACK_API_BASE_URL", originalURL)
 
-	services.IsTestMode = true
 	db, ts := setupE2EApp(t)
 	defer ts.Close()
 	clearSlackhogMessages(t)
@@ -330,7 +337,7 @@ func TestE2E_ReReview_OutsideBusinessHours_DefersAndSendsLater(t *testing.T) {
 	})
 
 	// Step 5: Run the checker
-	services.CheckPendingReReviewNotifications(db)
+	services.CheckPendingReReviewNotifications(db, e2eSlackClient())
 	time.Sleep(500 * time.Millisecond)
 
 	// Step 6: Verify deferred notification NOW appears (total: 1 feedback + 1 deferred = 2 replies)
@@ -354,7 +361,6 @@ func TestE2E_MultipleReReviews_OutsideBusinessHours_AllSendLater(t *testing.T) {
 	os.Setenv("SLACK_API_BASE_URL", slackhogBaseURL+"/api")
 	defer os.Setenv("SLACK_API_BASE_URL", originalURL)
 
-	services.IsTestMode = true
 	db, ts := setupE2EApp(t)
 	defer ts.Close()
 	clearSlackhogMessages(t)
@@ -445,7 +451,7 @@ func TestE2E_MultipleReReviews_OutsideBusinessHours_AllSendLater(t *testing.T) {
 		"business_hours_end":   "23:59",
 	})
 
-	services.CheckPendingReReviewNotifications(db)
+	services.CheckPendingReReviewNotifications(db, e2eSlackClient())
 	time.Sleep(500 * time.Millisecond)
 
 	// Verify both deferred re-review notifications also appear (total: 2 feedback + 2 re-review = 4)
@@ -472,7 +478,6 @@ func TestE2E_ScheduledAway_NotExcludedFromReviewerAssignment(t *testing.T) {
 	os.Setenv("SLACK_API_BASE_URL", slackhogBaseURL+"/api")
 	defer os.Setenv("SLACK_API_BASE_URL", originalURL)
 
-	services.IsTestMode = true
 	db, ts := setupE2EApp(t)
 	defer ts.Close()
 	clearSlackhogMessages(t)
@@ -583,7 +588,6 @@ func TestE2E_MultipleAwayPeriods_ExcludedFromReviewerAssignment(t *testing.T) {
 	os.Setenv("SLACK_API_BASE_URL", slackhogBaseURL+"/api")
 	defer os.Setenv("SLACK_API_BASE_URL", originalURL)
 
-	services.IsTestMode = true
 	db, ts := setupE2EApp(t)
 	defer ts.Close()
 	clearSlackhogMessages(t)
@@ -693,6 +697,7 @@ func sendSlashCommand(t *testing.T, ts *httptest.Server, channelID, text string)
 	req, err := http.NewRequest("POST", ts.URL+"/slack/command", strings.NewReader(form.Encode()))
 	require.NoError(t, err)
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	signSlackRequest(req, form.Encode())
 	resp, err := http.DefaultClient.Do(req)
 	require.NoError(t, err)
 	defer resp.Body.Close()
@@ -700,17 +705,30 @@ func sendSlashCommand(t *testing.T, ts *httptest.Server, channelID, text string)
 	return string(body)
 }
 
+// e2eSigningSecret is the Slack signing secret slash commands are signed with.
+const e2eSigningSecret = "e2e-signing-secret"
+
+// signSlackRequest sets the timestamp and signature headers Slack sends.
+func signSlackRequest(req *http.Request, body string) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(e2eSigningSecret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+}
+
 // setupE2EAppWithCommands returns a test server that includes the slash command handler.
 func setupE2EAppWithCommands(t *testing.T) (*gorm.DB, *httptest.Server) {
 	t.Helper()
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	require.NoError(t, err)
-	require.NoError(t, db.AutoMigrate(&models.ReviewTask{}, &models.ChannelConfig{}, &models.UserMapping{}, &models.ReviewerAvailability{}))
+	t.Setenv("SLACK_SIGNING_SECRET", e2eSigningSecret)
+	db := dbtest.Open(t)
+	require.NoError(t, models.Migrate(db))
 
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
-	r.POST("/webhook", handlers.HandleGitHubWebhook(db))
-	r.POST("/slack/actions", handlers.HandleSlackAction(db))
+	slack := e2eSlackClient()
+	r.POST("/webhook", handlers.HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
+	r.POST("/slack/actions", handlers.HandleSlackAction(db, slack))
 	r.POST("/slack/command", handlers.HandleSlackCommand(db))
 
 	ts := httptest.NewServer(r)
@@ -722,9 +740,6 @@ func setupE2EAppWithCommands(t *testing.T) (*gorm.DB, *httptest.Server) {
 // older cleanUserID) can be fully removed via the unset-away slash command.
 // This exercises the LIKE fallback added to unsetAway in PR #108.
 func TestE2E_UnsetAway_LegacyUserID(t *testing.T) {
-	services.IsTestMode = true
-	defer func() { services.IsTestMode = false }()
-
 	db, ts := setupE2EAppWithCommands(t)
 	defer ts.Close()
 
@@ -759,9 +774,6 @@ func TestE2E_UnsetAway_LegacyUserID(t *testing.T) {
 // record is reachable through the real slash-command path by its bare id —
 // proving normalization makes set-away/unset-away's exact-match work end to end.
 func TestE2E_MigrateNormalizeSlackUserIDs(t *testing.T) {
-	services.IsTestMode = true
-	defer func() { services.IsTestMode = false }()
-
 	db, ts := setupE2EAppWithCommands(t)
 	defer ts.Close()
 
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"slack-review-notify/models"
+	"slack-review-notify/services"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v71/github"
+	"gorm.io/gorm"
+)
+
+// RequireAdminToken lets a request through only with the header
+// "Authorization: Bearer <ADMIN_TOKEN>". The admin endpoints are disabled
+// while ADMIN_TOKEN is not set.
+func RequireAdminToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := os.Getenv("ADMIN_TOKEN")
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "admin endpoints are disabled"})
+			return
+		}
+
+		given := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// HandleListWebhookDeliveries lists recent GitHub webhook deliveries, newest
+// first. The status query parameter filters by outcome and limit caps the
+// number returned.
+func HandleListWebhookDeliveries(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := services.DefaultWebhookDeliveryLimit
+		if value := c.Query("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+				return
+			}
+			limit = n
+		}
+
+		deliveries, err := services.ListWebhookDeliveries(db, c.Query("status"), limit)
+		if err != nil {
+			log.Printf("webhook delivery list error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list deliveries"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(deliveries))
+		for _, d := range deliveries {
+			items = append(items, webhookDeliveryJSON(d))
+		}
+		c.JSON(http.StatusOK, gin.H{"deliveries": items})
+	}
+}
+
+// HandleReplayWebhookDelivery processes a stored webhook delivery again on
+// the queue, as if GitHub had redelivered it, and responds with the new
+// outcome.
+func HandleReplayWebhookDelivery(db *gorm.DB, slack services.SlackClient, queue *services.WebhookQueue) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+
+		delivery, err := services.BeginWebhookDeliveryReplay(db, id)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "delivery not found"})
+			return
+		case errors.Is(err, services.ErrWebhookDeliveryInProgress):
+			c.JSON(http.StatusConflict, gin.H{"error": "delivery is being processed"})
+			return
+		case err != nil:
+			log.Printf("webhook delivery replay error (delivery: %s): %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to replay delivery"})
+			return
+		}
+
+		event, err := github.ParseWebHook(delivery.EventType, []byte(delivery.Payload))
+		if err != nil {
+			services.FinishWebhookDelivery(db, id, services.WebhookDeliveryFailed, err.Error())
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "cannot parse stored payload"})
+			return
+		}
+
+		log.Printf("replaying webhook delivery %s (event_type=%s, action=%s)", id, delivery.EventType, delivery.Action)
+		var status string
+		job := webhookJob(db, slack, id, delivery.EventType, event, func(s string) { status = s })
+		if err := queue.Do(job); errors.Is(err, services.ErrWebhookQueueClosed) {
+			services.FinishWebhookDelivery(db, id, services.WebhookDeliveryFailed, err.Error())
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "shutting down"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"id": id, "status": status, "attempts": delivery.Attempts})
+	}
+}
+
+// HandleLinkGitHubInstallation links a GitHub App installation to the Slack
+// team given as team_id in the JSON body, or shares it with every workspace
+// when team_id is empty.
+func HandleLinkGitHubInstallation(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid installation id"})
+			return
+		}
+		var body struct {
+			TeamID string `json:"team_id"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "body must be JSON with team_id"})
+			return
+		}
+
+		err = services.LinkGitHubInstallation(db, id, body.TeamID)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "installation not found"})
+			return
+		case err != nil:
+			log.Printf("github installation link error (installation: %d): %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to link installation"})
+			return
+		}
+		log.Printf("github installation %d linked to slack team %q", id, body.TeamID)
+		c.JSON(http.StatusOK, gin.H{"id": id, "team_id": body.TeamID})
+	}
+}
+
+func webhookDeliveryJSON(d models.WebhookDelivery) gin.H {
+	var processedAt interface{}
+	if d.ProcessedAt != nil {
+		processedAt = d.ProcessedAt.UTC().Format(time.RFC3339)
+	}
+	return gin.H{
+		"id":           d.ID,
+		"event_type":   d.EventType,
+		"action":       d.Action,
+		"status":       d.Status,
+		"error":        d.Error,
+		"attempts":     d.Attempts,
+		"received_at":  d.CreatedAt.UTC().Format(time.RFC3339),
+		"processed_at": processedAt,
+	}
+}
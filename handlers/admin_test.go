@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slack-review-notify/models"
+	"slack-review-notify/services"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupAdminRouter(t *testing.T, db *gorm.DB, slack services.SlackClient) *gin.Engine {
+	t.Setenv("ADMIN_TOKEN", "admin-secret")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	admin := r.Group("/admin", RequireAdminToken())
+	admin.GET("/webhook-deliveries", HandleListWebhookDeliveries(db))
+	admin.POST("/webhook-deliveries/:id/replay", HandleReplayWebhookDelivery(db, slack, services.NewWebhookQueue(0)))
+	admin.PUT("/github-installations/:id", HandleLinkGitHubInstallation(db))
+	return r
+}
+
+func adminRequest(router http.Handler, method, path, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequireAdminToken(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupAdminRouter(t, db, services.NewFakeSlackClient())
+
+	assert.Equal(t, http.StatusUnauthorized, adminRequest(router, "GET", "/admin/webhook-deliveries", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, adminRequest(router, "GET", "/admin/webhook-deliveries", "wrong").Code)
+	assert.Equal(t, http.StatusOK, adminRequest(router, "GET", "/admin/webhook-deliveries", "admin-secret").Code)
+
+	// Disabled without ADMIN_TOKEN
+	t.Setenv("ADMIN_TOKEN", "")
+	assert.Equal(t, http.StatusNotFound, adminRequest(router, "GET", "/admin/webhook-deliveries", "admin-secret").Code)
+}
+
+func TestHandleListWebhookDeliveries(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupAdminRouter(t, db, services.NewFakeSlackClient())
+
+	now := time.Now()
+	db.Create(&models.WebhookDelivery{ID: "d-old", EventType: "pull_request", Action: "labeled", Status: services.WebhookDeliveryFailed, Error: "boom", Attempts: 1, CreatedAt: now.Add(-time.Hour)})
+	db.Create(&models.WebhookDelivery{ID: "d-new", EventType: "pull_request_review", Action: "submitted", Status: services.WebhookDeliveryProcessed, Attempts: 1, ProcessedAt: &now, CreatedAt: now})
+
+	w := adminRequest(router, "GET", "/admin/webhook-deliveries", "admin-secret")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var body struct {
+		Deliveries []struct {
+			ID        string  `json:"id"`
+			EventType string  `json:"event_type"`
+			Status    string  `json:"status"`
+			Error     string  `json:"error"`
+			Processed *string `json:"processed_at"`
+		} `json:"deliveries"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	if assert.Len(t, body.Deliveries, 2) {
+		assert.Equal(t, "d-new", body.Deliveries[0].ID)
+		assert.Equal(t, "pull_request_review", body.Deliveries[0].EventType)
+		assert.NotNil(t, body.Deliveries[0].Processed)
+		assert.Equal(t, "d-old", body.Deliveries[1].ID)
+		assert.Equal(t, "boom", body.Deliveries[1].Error)
+		assert.Nil(t, body.Deliveries[1].Processed)
+	}
+
+	w = adminRequest(router, "GET", "/admin/webhook-deliveries?status=failed", "admin-secret")
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	if assert.Len(t, body.Deliveries, 1) {
+		assert.Equal(t, "d-old", body.Deliveries[0].ID)
+	}
+
+	assert.Equal(t, http.StatusBadRequest, adminRequest(router, "GET", "/admin/webhook-deliveries?limit=x", "admin-secret").Code)
+}
+
+func TestHandleReplayWebhookDelivery(t *testing.T) {
+	db := setupTestDB(t)
+	slack := services.NewFakeSlackClient()
+	router := setupAdminRouter(t, db, slack)
+
+	db.Create(&models.ReviewTask{
+		ID:           "replay-task",
+		PRURL:        "https://github.com/owner/repo/pull/55",
+		Repo:         "owner/repo",
+		PRNumber:     55,
+		Title:        "Test PR",
+		SlackTS:      "1234.5678",
+		SlackChannel: "C12345",
+		Status:       "in_review",
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	})
+	db.Create(&models.WebhookDelivery{
+		ID:        "d-1",
+		EventType: "pull_request",
+		Action:    "closed",
+		Payload: `{"action": "closed",
+			"pull_request": {"number": 55, "merged": true, "html_url": "https://github.com/owner/repo/pull/55"},
+			"repository": {"full_name": "owner/repo", "owner": {"login": "owner"}, "name": "repo"}}`,
+		Status:    services.WebhookDeliveryFailed,
+		Error:     "boom",
+		Attempts:  1,
+		CreatedAt: time.Now(),
+	})
+
+	w := adminRequest(router, "POST", "/admin/webhook-deliveries/d-1/replay", "admin-secret")
+	assert.Equal(t, http.StatusOK, w.Code, "body: %s", w.Body.String())
+	assert.JSONEq(t, `{"id": "d-1", "status": "processed", "attempts": 2}`, w.Body.String())
+
+	var task models.ReviewTask
+	db.First(&task, "id = ?", "replay-task")
+	assert.Equal(t, "completed", task.Status)
+
+	var delivery models.WebhookDelivery
+	db.First(&delivery, "id = ?", "d-1")
+	assert.Equal(t, services.WebhookDeliveryProcessed, delivery.Status)
+	assert.Empty(t, delivery.Error)
+
+	assert.Equal(t, http.StatusNotFound, adminRequest(router, "POST", "/admin/webhook-deliveries/missing/replay", "admin-secret").Code)
+}
+
+func TestHandleLinkGitHubInstallation(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupAdminRouter(t, db, services.NewFakeSlackClient())
+	db.Create(&models.GithubInstallation{ID: 42, AccountLogin: "acme"})
+
+	link := func(path, body string) int {
+		req, _ := http.NewRequest("PUT", path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, link("/admin/github-installations/42", `{"team_id": "T1"}`))
+	var installation models.GithubInstallation
+	db.First(&installation, 42)
+	assert.Equal(t, "T1", installation.TeamID)
+
+	assert.Equal(t, http.StatusNotFound, link("/admin/github-installations/43", `{"team_id": "T1"}`))
+	assert.Equal(t, http.StatusBadRequest, link("/admin/github-installations/acme", `{"team_id": "T1"}`))
+	assert.Equal(t, http.StatusBadRequest, link("/admin/github-installations/42", `team_id=T1`))
+}
@@ -18,11 +18,11 @@ import (
 // when the "🌴 休暇管理を開く" button is clicked. trigger_id expires within
 // a few seconds so the API call is fired in a goroutine; the HTTP response
 // to Slack must return immediately.
-func handleOpenAwayManagement(c *gin.Context, db *gorm.DB, payload SlackActionPayload) {
+func handleOpenAwayManagement(c slackResponder, db *gorm.DB, slack services.SlackClient, payload SlackActionPayload) {
 	channelID := payload.Container.ChannelID
 	userID := payload.User.ID
 
-	configs := loadChannelConfigs(db, channelID)
+	configs := loadChannelConfigs(db, slackTeamID(c), channelID)
 	lang := pickModalLanguage(configs, "")
 
 	view := services.BuildAwayManagementModalView(services.AwayManagementModalInputs{
@@ -32,11 +32,11 @@ func handleOpenAwayManagement(c *gin.Context, db *gorm.DB, payload SlackActionPa
 	})
 	triggerID := payload.TriggerID
 
-	go func() {
-		if err := services.OpenView(triggerID, view); err != nil {
+	services.Go(func() {
+		if err := slack.OpenView(triggerID, view); err != nil {
 			log.Printf("away views.open failed: %v", err)
 		}
-	}()
+	})
 
 	c.Status(http.StatusOK)
 }
@@ -45,7 +45,7 @@ func handleOpenAwayManagement(c *gin.Context, db *gorm.DB, payload SlackActionPa
 // either delete every row for the target user (delete-all branch) or upsert
 // a single leave period. Validation errors are surfaced as
 // response_action=errors so Slack highlights the offending field in-place.
-func handleAwayModalSubmission(c *gin.Context, db *gorm.DB, payload SlackActionPayload) {
+func handleAwayModalSubmission(c slackResponder, db *gorm.DB, slack services.SlackClient, payload SlackActionPayload) {
 	meta, err := services.DecodeAwayModalMetadata(payload.View.PrivateMetadata)
 	if err != nil {
 		log.Printf("away view_submission has invalid private_metadata: %q (err=%v)", payload.View.PrivateMetadata, err)
@@ -58,7 +58,7 @@ func handleAwayModalSubmission(c *gin.Context, db *gorm.DB, payload SlackActionP
 		return
 	}
 
-	configs := loadChannelConfigs(db, meta.ChannelID)
+	configs := loadChannelConfigs(db, slackTeamID(c), meta.ChannelID)
 	lang := pickModalLanguage(configs, "")
 	loc := pickModalTimezone(configs)
 
@@ -92,14 +92,14 @@ func handleAwayModalSubmission(c *gin.Context, db *gorm.DB, payload SlackActionP
 			})
 			return
 		}
-		if !services.IsTestMode && meta.UserID != "" {
+		if meta.UserID != "" {
 			var msg string
 			if res.RowsAffected == 0 {
 				msg = i18n.TWithLang(lang, "modal.away.nothing_deleted", form.SlackUserID)
 			} else {
 				msg = i18n.TWithLang(lang, "modal.away.deleted", form.SlackUserID)
 			}
-			if err := services.PostEphemeral(meta.ChannelID, meta.UserID, msg); err != nil {
+			if err := slack.PostEphemeral(meta.ChannelID, meta.UserID, msg); err != nil {
 				log.Printf("away delete confirmation post failed: %v", err)
 			}
 		}
@@ -154,9 +154,9 @@ func handleAwayModalSubmission(c *gin.Context, db *gorm.DB, payload SlackActionP
 		return
 	}
 
-	if !services.IsTestMode && meta.UserID != "" {
+	if meta.UserID != "" {
 		msg := i18n.TWithLang(lang, "modal.away.saved", form.SlackUserID)
-		if err := services.PostEphemeral(meta.ChannelID, meta.UserID, msg); err != nil {
+		if err := slack.PostEphemeral(meta.ChannelID, meta.UserID, msg); err != nil {
 			log.Printf("away saved confirmation post failed: %v", err)
 		}
 	}
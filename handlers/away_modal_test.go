@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -20,7 +21,7 @@ import (
 // only reachable via API and the feature is dead UX-wise.
 func TestHelpCommand_IncludesAwayManagementButton(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupTestRouter(db)
+	router := setupTestRouter(t, db)
 
 	form := url.Values{}
 	form.Add("command", "/slack-review-notify")
@@ -40,11 +41,11 @@ func TestHelpCommand_IncludesAwayManagementButton(t *testing.T) {
 }
 
 // TestHandleSlackAction_OpenAwayManagement: block_actions with
-// action_id=open_away_management must be accepted (would call views.open in
-// production; IsTestMode skips the network call).
+// action_id=open_away_management must be accepted and open the modal.
 func TestHandleSlackAction_OpenAwayManagement(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupActionRouter(db)
+	slack := services.NewFakeSlackClient()
+	router := setupActionRouter(t, db, slack)
 
 	payload := `{
 		"type": "block_actions",
@@ -57,6 +58,12 @@ func TestHandleSlackAction_OpenAwayManagement(t *testing.T) {
 
 	w := postPayload(t, router, payload)
 	assert.Equal(t, http.StatusOK, w.Code, "body: %s", w.Body.String())
+	// The view is opened in the background
+	assert.NoError(t, services.WaitForBackgroundTasks(context.Background()))
+	if views := slack.Views(); assert.Len(t, views, 1) {
+		assert.Equal(t, "views.open", views[0].Method)
+		assert.Equal(t, "trigger-xyz", views[0].Target)
+	}
 }
 
 // TestAwayModal_ViewSubmission_CreatesRecord: happy-path save.
@@ -64,7 +71,7 @@ func TestHandleSlackAction_OpenAwayManagement(t *testing.T) {
 // row matching the input.
 func TestAwayModal_ViewSubmission_CreatesRecord(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupActionRouter(db)
+	router := setupActionRouter(t, db, services.NewFakeSlackClient())
 
 	payload := buildAwayViewSubmission(t, awaySubmission{
 		channelID: "C12345",
@@ -91,7 +98,7 @@ func TestAwayModal_ViewSubmission_CreatesRecord(t *testing.T) {
 // leave. Mirrors the slash-command `set-away @user` (no period) behavior.
 func TestAwayModal_ViewSubmission_Indefinite(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupActionRouter(db)
+	router := setupActionRouter(t, db, services.NewFakeSlackClient())
 
 	payload := buildAwayViewSubmission(t, awaySubmission{
 		channelID: "C12345",
@@ -113,7 +120,7 @@ func TestAwayModal_ViewSubmission_Indefinite(t *testing.T) {
 // the target user, regardless of the date inputs.
 func TestAwayModal_ViewSubmission_DeleteAll(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupActionRouter(db)
+	router := setupActionRouter(t, db, services.NewFakeSlackClient())
 
 	now := time.Now()
 	later := now.Add(72 * time.Hour)
@@ -142,7 +149,7 @@ func TestAwayModal_ViewSubmission_DeleteAll(t *testing.T) {
 // errors map with the offending block_id, no DB write.
 func TestAwayModal_ViewSubmission_FromAfterUntil(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupActionRouter(db)
+	router := setupActionRouter(t, db, services.NewFakeSlackClient())
 
 	payload := buildAwayViewSubmission(t, awaySubmission{
 		channelID: "C12345",
@@ -177,7 +184,7 @@ func TestAwayModal_ViewSubmission_FromAfterUntil(t *testing.T) {
 // place. This is a regression test for the timezone fix.
 func TestAwayModal_ViewSubmission_UpsertMatchesSlashCommandRecord(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupActionRouter(db)
+	router := setupActionRouter(t, db, services.NewFakeSlackClient())
 
 	// Configure the channel's timezone so pickModalTimezone selects JST.
 	db.Create(&models.ChannelConfig{
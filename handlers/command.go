@@ -42,258 +42,330 @@ func HandleSlackCommand(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		command := c.PostForm("command")
-		text := c.PostForm("text")
-		channelID := c.PostForm("channel_id")
-		userID := c.PostForm("user_id")
-
-		log.Printf("slack command received: command=%s, text=%s, channel=%s, user=%s",
-			command, text, channelID, userID)
-
-		// Output all channel configs for debugging
-		var allConfigs []models.ChannelConfig
-		db.Find(&allConfigs)
-		log.Printf("all channel configs in database (%d):", len(allConfigs))
-		for i, cfg := range allConfigs {
-			log.Printf("[%d] ID=%s, Channel=%s, Label=%s", i, cfg.ID, cfg.SlackChannelID, cfg.LabelName)
-		}
-
-		// Process the /slack-review-notify command
-		if command == "/slack-review-notify" {
-			// Separate the command part from parameters
-			var labelName, subCommand, params string
-
-			// Split text with quote support
-			parts := parseCommand(text)
-
-			if len(parts) == 0 {
-				// Show help when no arguments are provided
-				var emptyHelpConfig models.ChannelConfig
-				emptyHelpLang := "ja"
-				if err := db.Where("slack_channel_id = ?", channelID).First(&emptyHelpConfig).Error; err == nil {
-					emptyHelpLang = getLang(&emptyHelpConfig)
-				}
-				showHelp(c, db, channelID, emptyHelpLang)
-				return
+		processSlackCommand(c, db, slackCommand{
+			Command:             c.PostForm("command"),
+			Text:                c.PostForm("text"),
+			ChannelID:           c.PostForm("channel_id"),
+			UserID:              c.PostForm("user_id"),
+			TeamID:              c.PostForm("team_id"),
+			EnterpriseID:        c.PostForm("enterprise_id"),
+			IsEnterpriseInstall: c.PostForm("is_enterprise_install") == "true",
+		})
+	}
+}
+
+// slackCommand is a slash command invocation, whichever transport it came over.
+type slackCommand struct {
+	Command             string
+	Text                string
+	ChannelID           string
+	UserID              string
+	TeamID              string
+	EnterpriseID        string
+	IsEnterpriseInstall bool
+}
+
+// processSlackCommand runs a slash command and answers it through c.
+func processSlackCommand(c slackResponder, db *gorm.DB, cmd slackCommand) {
+	command := cmd.Command
+	text := cmd.Text
+	channelID := cmd.ChannelID
+	userID := cmd.UserID
+
+	// Scope the rows the command reads and writes to the workspace it came from
+	setSlackTeam(c, cmd.TeamID, cmd.EnterpriseID, cmd.IsEnterpriseInstall)
+
+	log.Printf("slack command received: command=%s, text=%s, channel=%s, user=%s",
+		command, text, channelID, userID)
+
+	// Output all channel configs for debugging
+	var allConfigs []models.ChannelConfig
+	db.Where("team_id = ?", slackTeamID(c)).Find(&allConfigs)
+	log.Printf("all channel configs in database (%d):", len(allConfigs))
+	for i, cfg := range allConfigs {
+		log.Printf("[%d] ID=%s, Channel=%s, Label=%s", i, cfg.ID, cfg.SlackChannelID, cfg.LabelName)
+	}
+
+	// Process the /slack-review-notify command
+	if command == "/slack-review-notify" {
+		// Separate the command part from parameters
+		var labelName, subCommand, params string
+
+		// Split text with quote support
+		parts := parseCommand(text)
+
+		if len(parts) == 0 {
+			// Show help when no arguments are provided
+			var emptyHelpConfig models.ChannelConfig
+			emptyHelpLang := "ja"
+			if err := db.Where("team_id = ? AND slack_channel_id = ?", slackTeamID(c), channelID).First(&emptyHelpConfig).Error; err == nil {
+				emptyHelpLang = getLang(&emptyHelpConfig)
+			}
+			showHelp(c, db, channelID, emptyHelpLang)
+			return
+		}
+
+		// Determine whether the first argument is a subcommand or a label name
+		potentialSubCommands := []string{"show", "help", "set-mention", "add-reviewer",
+			"show-reviewers", "clear-reviewers", "add-repo", "remove-repo",
+			"set-label", "activate", "deactivate", "set-reviewer-reminder-interval",
+			"set-business-hours-start", "set-business-hours-end", "set-timezone",
+			"map-user", "show-user-mappings", "remove-user-mapping",
+			"map-team", "show-team-mappings", "remove-team-mapping",
+			"set-required-approvals", "set-strategy", "set-github-sync", "set-digest-time", "set-language",
+			"set-away", "unset-away", "show-availability", "dm-digest", "stats", "resync"}
+
+		isSubCommand := false
+		for _, cmd := range potentialSubCommands {
+			if parts[0] == cmd {
+				isSubCommand = true
+				break
 			}
+		}
 
-			// Determine whether the first argument is a subcommand or a label name
-			potentialSubCommands := []string{"show", "help", "set-mention", "add-reviewer",
-				"show-reviewers", "clear-reviewers", "add-repo", "remove-repo",
-				"set-label", "activate", "deactivate", "set-reviewer-reminder-interval",
-				"set-business-hours-start", "set-business-hours-end", "set-timezone",
-				"map-user", "show-user-mappings", "remove-user-mapping",
-				"set-required-approvals", "set-language",
-				"set-away", "unset-away", "show-availability"}
-
-			isSubCommand := false
-			for _, cmd := range potentialSubCommands {
-				if parts[0] == cmd {
-					isSubCommand = true
-					break
-				}
+		if isSubCommand {
+			// If the first argument is a subcommand, use the default label name
+			subCommand = parts[0]
+			labelName = "needs-review" // Default label name
+
+			if len(parts) > 1 {
+				params = strings.Join(parts[1:], " ")
 			}
+		} else {
+			// If the first argument is a label name
+			labelName = parts[0]
 
-			if isSubCommand {
-				// If the first argument is a subcommand, use the default label name
-				subCommand = parts[0]
-				labelName = "needs-review" // Default label name
+			if len(parts) > 1 {
+				subCommand = parts[1]
 
-				if len(parts) > 1 {
-					params = strings.Join(parts[1:], " ")
+				if len(parts) > 2 {
+					params = strings.Join(parts[2:], " ")
 				}
 			} else {
-				// If the first argument is a label name
-				labelName = parts[0]
-
-				if len(parts) > 1 {
-					subCommand = parts[1]
-
-					if len(parts) > 2 {
-						params = strings.Join(parts[2:], " ")
-					}
-				} else {
-					// If only the label name is specified, show its settings
-					subCommand = "show"
-				}
+				// If only the label name is specified, show its settings
+				subCommand = "show"
+			}
+		}
+
+		// Log information about the label name
+		log.Printf("command parsed: label=%s, subCommand=%s, params=%s",
+			labelName, subCommand, params)
+
+		if subCommand == "" || subCommand == "help" {
+			// Show help - try to get lang from any config for this channel
+			var helpConfig models.ChannelConfig
+			helpLang := "ja"
+			if err := db.Where("team_id = ? AND slack_channel_id = ?", slackTeamID(c), channelID).First(&helpConfig).Error; err == nil {
+				helpLang = getLang(&helpConfig)
 			}
+			showHelp(c, db, channelID, helpLang)
+			return
+		}
 
-			// Log information about the label name
-			log.Printf("command parsed: label=%s, subCommand=%s, params=%s",
-				labelName, subCommand, params)
+		// Get existing config by label name
+		var config models.ChannelConfig
+		result := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, labelName).First(&config)
 
-			if subCommand == "" || subCommand == "help" {
-				// Show help - try to get lang from any config for this channel
-				var helpConfig models.ChannelConfig
-				helpLang := "ja"
-				if err := db.Where("slack_channel_id = ?", channelID).First(&helpConfig).Error; err == nil {
-					helpLang = getLang(&helpConfig)
-				}
-				showHelp(c, db, channelID, helpLang)
+		// If no config found for the specified label name, create a new one
+		if result.Error != nil {
+			log.Printf("config for channel(%s) and label(%s) not found: %v",
+				channelID, labelName, result.Error)
+
+			// New config creation is handled within each command processor
+		}
+
+		lang := getLang(&config)
+		t := i18n.L(lang)
+
+		switch subCommand {
+		case "show":
+			// Show current settings
+			if isSubCommand && len(parts) == 1 {
+				// If no label name is specified, show all labels
+				showAllLabels(c, db, channelID, lang)
+			} else {
+				// Show settings for a specific label
+				showConfig(c, db, channelID, labelName, lang)
+			}
+
+		case "set-mention":
+			if params == "" {
+				c.String(200, t("cmd.set_mention.usage", labelName))
+				return
+			}
+			mentionID := strings.TrimSpace(params)
+			setMention(c, db, channelID, labelName, mentionID, lang)
+
+		case "add-reviewer":
+			if params == "" {
+				c.String(200, t("cmd.add_reviewer.usage", labelName))
 				return
 			}
+			// Use regex to handle all space patterns
+			re := regexp.MustCompile(`\s*,\s*`)
+			reviewerIDs := re.ReplaceAllString(params, ",")
+
+			// Also trim leading and trailing whitespace
+			reviewerIDs = strings.TrimSpace(reviewerIDs)
+			addReviewers(c, db, channelID, labelName, reviewerIDs, lang)
 
-			// Get existing config by label name
-			var config models.ChannelConfig
-			result := db.Where("slack_channel_id = ? AND label_name = ?", channelID, labelName).First(&config)
+		case "show-reviewers":
+			// Show the reviewer list
+			showReviewers(c, db, channelID, labelName, lang)
 
-			// If no config found for the specified label name, create a new one
-			if result.Error != nil {
-				log.Printf("config for channel(%s) and label(%s) not found: %v",
-					channelID, labelName, result.Error)
+		case "clear-reviewers":
+			// Clear the reviewer list
+			clearReviewers(c, db, channelID, labelName, lang)
 
-				// New config creation is handled within each command processor
+		case "add-repo":
+			if params == "" {
+				c.String(200, t("cmd.add_repo.usage", labelName))
+				return
 			}
+			repoName := params
+			addRepository(c, db, channelID, labelName, repoName, lang)
 
-			lang := getLang(&config)
-			t := i18n.L(lang)
-
-			switch subCommand {
-			case "show":
-				// Show current settings
-				if isSubCommand && len(parts) == 1 {
-					// If no label name is specified, show all labels
-					showAllLabels(c, db, channelID, lang)
-				} else {
-					// Show settings for a specific label
-					showConfig(c, db, channelID, labelName, lang)
-				}
+		case "remove-repo":
+			if params == "" {
+				c.String(200, t("cmd.remove_repo.usage", labelName))
+				return
+			}
+			repoName := params
+			removeRepository(c, db, channelID, labelName, repoName, lang)
 
-			case "set-mention":
-				if params == "" {
-					c.String(200, t("cmd.set_mention.usage", labelName))
-					return
-				}
-				mentionID := strings.TrimSpace(params)
-				setMention(c, db, channelID, labelName, mentionID, lang)
+		case "set-label":
+			// set-label is actually a rename operation for the label name
+			if params == "" {
+				c.String(200, t("cmd.set_label.usage", labelName))
+				return
+			}
+			newLabelName := params
+			changeLabelName(c, db, channelID, labelName, newLabelName, lang)
 
-			case "add-reviewer":
-				if params == "" {
-					c.String(200, t("cmd.add_reviewer.usage", labelName))
-					return
-				}
-				// Use regex to handle all space patterns
-				re := regexp.MustCompile(`\s*,\s*`)
-				reviewerIDs := re.ReplaceAllString(params, ",")
-
-				// Also trim leading and trailing whitespace
-				reviewerIDs = strings.TrimSpace(reviewerIDs)
-				addReviewers(c, db, channelID, labelName, reviewerIDs, lang)
-
-			case "show-reviewers":
-				// Show the reviewer list
-				showReviewers(c, db, channelID, labelName, lang)
-
-			case "clear-reviewers":
-				// Clear the reviewer list
-				clearReviewers(c, db, channelID, labelName, lang)
-
-			case "add-repo":
-				if params == "" {
-					c.String(200, t("cmd.add_repo.usage", labelName))
-					return
-				}
-				repoName := params
-				addRepository(c, db, channelID, labelName, repoName, lang)
+		case "activate":
+			activateChannel(c, db, channelID, labelName, lang, true)
 
-			case "remove-repo":
-				if params == "" {
-					c.String(200, t("cmd.remove_repo.usage", labelName))
-					return
-				}
-				repoName := params
-				removeRepository(c, db, channelID, labelName, repoName, lang)
-
-			case "set-label":
-				// set-label is actually a rename operation for the label name
-				if params == "" {
-					c.String(200, t("cmd.set_label.usage", labelName))
-					return
-				}
-				newLabelName := params
-				changeLabelName(c, db, channelID, labelName, newLabelName, lang)
+		case "deactivate":
+			activateChannel(c, db, channelID, labelName, lang, false)
 
-			case "activate":
-				activateChannel(c, db, channelID, labelName, lang, true)
+		case "set-reviewer-reminder-interval":
+			if params == "" {
+				c.String(200, t("cmd.set_reminder_interval.usage", labelName))
+				return
+			}
+			setReminderInterval(c, db, channelID, labelName, strings.TrimSpace(params), lang, true)
 
-			case "deactivate":
-				activateChannel(c, db, channelID, labelName, lang, false)
+		case "set-business-hours-start":
+			if params == "" {
+				c.String(200, t("cmd.set_business_hours_start.usage", labelName))
+				return
+			}
+			setBusinessHoursStart(c, db, channelID, labelName, strings.TrimSpace(params), lang)
 
-			case "set-reviewer-reminder-interval":
-				if params == "" {
-					c.String(200, t("cmd.set_reminder_interval.usage", labelName))
-					return
-				}
-				setReminderInterval(c, db, channelID, labelName, strings.TrimSpace(params), lang, true)
+		case "set-business-hours-end":
+			if params == "" {
+				c.String(200, t("cmd.set_business_hours_end.usage", labelName))
+				return
+			}
+			setBusinessHoursEnd(c, db, channelID, labelName, strings.TrimSpace(params), lang)
 
-			case "set-business-hours-start":
-				if params == "" {
-					c.String(200, t("cmd.set_business_hours_start.usage", labelName))
-					return
-				}
-				setBusinessHoursStart(c, db, channelID, labelName, strings.TrimSpace(params), lang)
+		case "set-timezone":
+			if params == "" {
+				c.String(200, t("cmd.set_timezone.usage", labelName))
+				return
+			}
+			setTimezone(c, db, channelID, labelName, strings.TrimSpace(params), lang)
 
-			case "set-business-hours-end":
-				if params == "" {
-					c.String(200, t("cmd.set_business_hours_end.usage", labelName))
-					return
-				}
-				setBusinessHoursEnd(c, db, channelID, labelName, strings.TrimSpace(params), lang)
+		case "map-user":
+			mapUser(c, db, params, lang)
 
-			case "set-timezone":
-				if params == "" {
-					c.String(200, t("cmd.set_timezone.usage", labelName))
-					return
-				}
-				setTimezone(c, db, channelID, labelName, strings.TrimSpace(params), lang)
+		case "show-user-mappings":
+			showUserMappings(c, db, lang)
 
-			case "map-user":
-				mapUser(c, db, params, lang)
+		case "remove-user-mapping":
+			removeUserMapping(c, db, params, lang)
 
-			case "show-user-mappings":
-				showUserMappings(c, db, lang)
+		case "map-team":
+			mapTeam(c, db, params, lang)
 
-			case "remove-user-mapping":
-				removeUserMapping(c, db, params, lang)
+		case "show-team-mappings":
+			showTeamMappings(c, db, lang)
 
-			case "set-required-approvals":
-				if params == "" {
-					c.String(200, t("cmd.set_required_approvals.usage", labelName))
-					return
-				}
-				setRequiredApprovals(c, db, channelID, labelName, strings.TrimSpace(params), lang)
+		case "remove-team-mapping":
+			removeTeamMapping(c, db, params, lang)
 
-			case "set-language":
-				if params == "" {
-					c.String(200, t("cmd.set_language.usage", labelName))
-					return
-				}
-				setLanguage(c, db, channelID, labelName, strings.TrimSpace(params))
+		case "set-required-approvals":
+			if params == "" {
+				c.String(200, t("cmd.set_required_approvals.usage", labelName))
+				return
+			}
+			setRequiredApprovals(c, db, channelID, labelName, strings.TrimSpace(params), lang)
+
+		case "set-strategy":
+			if params == "" {
+				c.String(200, t("cmd.set_strategy.usage", strings.Join(services.ReviewerStrategies(), ", "), labelName))
+				return
+			}
+			setReviewerStrategy(c, db, channelID, labelName, strings.TrimSpace(params), lang)
+
+		case "set-github-sync":
+			if params == "" {
+				c.String(200, t("cmd.set_github_sync.usage", labelName))
+				return
+			}
+			setGitHubSync(c, db, channelID, labelName, strings.TrimSpace(params), lang)
 
-			case "set-away":
-				setAway(c, db, channelID, labelName, params, lang)
+		case "set-digest-time":
+			if params == "" {
+				c.String(200, t("cmd.set_digest_time.usage", labelName))
+				return
+			}
+			setDigestTime(c, db, channelID, labelName, strings.TrimSpace(params), lang)
+
+		case "set-language":
+			if params == "" {
+				c.String(200, t("cmd.set_language.usage", labelName))
+				return
+			}
+			setLanguage(c, db, channelID, labelName, strings.TrimSpace(params))
 
-			case "unset-away":
-				unsetAway(c, db, channelID, labelName, params, lang)
+		case "set-away":
+			setAway(c, db, channelID, labelName, params, lang)
 
-			case "show-availability":
-				showAvailability(c, db, lang)
+		case "unset-away":
+			unsetAway(c, db, channelID, labelName, params, lang)
 
-			default:
-				c.String(200, t("cmd.unknown_with_help"))
+		case "show-availability":
+			showAvailability(c, db, lang)
+
+		case "dm-digest":
+			setDMDigest(c, db, userID, params, lang)
+
+		case "stats":
+			// Without a label name the stats cover every label in the channel
+			statsLabel := labelName
+			if isSubCommand {
+				statsLabel = ""
 			}
+			showStats(c, db, channelID, statsLabel, params, lang)
 
-			return
+		case "resync":
+			resyncChannel(c, channelID, userID, lang)
+
+		default:
+			c.String(200, t("cmd.unknown_with_help"))
 		}
 
-		// Try to get language from channel config for fallback message
+		return
+	}
+
+	// Try to get language from channel config for fallback message
 	var fallbackConfig models.ChannelConfig
 	fallbackLang := "ja"
-	if err := db.Where("slack_channel_id = ?", channelID).First(&fallbackConfig).Error; err == nil {
+	if err := db.Where("team_id = ? AND slack_channel_id = ?", slackTeamID(c), channelID).First(&fallbackConfig).Error; err == nil {
 		fallbackLang = getLang(&fallbackConfig)
 	}
 	c.String(200, i18n.TWithLang(fallbackLang, "cmd.unknown"))
-	}
 }
 
 // parseCommand parses command text with quote support
@@ -351,11 +423,11 @@ func getLang(config *models.ChannelConfig) string {
 // "create new" button. The buttons all use action_id=open_settings; their
 // value carries the target label (or the create-new sentinel), so the modal
 // opens preselected to the right row.
-func showHelp(c *gin.Context, db *gorm.DB, channelID, lang string) {
+func showHelp(c slackResponder, db *gorm.DB, channelID, lang string) {
 	t := i18n.L(lang)
 
 	var configs []models.ChannelConfig
-	if err := db.Where("slack_channel_id = ?", channelID).Order("label_name").Find(&configs).Error; err != nil {
+	if err := db.Where("team_id = ? AND slack_channel_id = ?", slackTeamID(c), channelID).Order("label_name").Find(&configs).Error; err != nil {
 		log.Printf("showHelp: failed to list configs: %v", err)
 	}
 
@@ -439,11 +511,11 @@ func showHelp(c *gin.Context, db *gorm.DB, channelID, lang string) {
 }
 
 // showAllLabels displays all label configurations
-func showAllLabels(c *gin.Context, db *gorm.DB, channelID, lang string) {
+func showAllLabels(c slackResponder, db *gorm.DB, channelID, lang string) {
 	t := i18n.L(lang)
 	var configs []models.ChannelConfig
 
-	err := db.Where("slack_channel_id = ?", channelID).Find(&configs).Error
+	err := db.Where("team_id = ? AND slack_channel_id = ?", slackTeamID(c), channelID).Find(&configs).Error
 	if err != nil {
 		c.String(200, t("cmd.show.error"))
 		return
@@ -471,11 +543,11 @@ func showAllLabels(c *gin.Context, db *gorm.DB, channelID, lang string) {
 }
 
 // showConfig displays the configuration
-func showConfig(c *gin.Context, db *gorm.DB, channelID, labelName, lang string) {
+func showConfig(c slackResponder, db *gorm.DB, channelID, labelName, lang string) {
 	t := i18n.L(lang)
 	var config models.ChannelConfig
 
-	err := db.Where("slack_channel_id = ? AND label_name = ?", channelID, labelName).First(&config).Error
+	err := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, labelName).First(&config).Error
 	if err != nil {
 		c.String(200, t("cmd.show_config.no_config", labelName, labelName))
 		return
@@ -501,13 +573,28 @@ func showConfig(c *gin.Context, db *gorm.DB, channelID, labelName, lang string)
 		requiredApprovals = 1
 	}
 
+	strategy := config.ReviewerStrategy
+	if strategy == "" {
+		strategy = services.ReviewerStrategyRandom
+	}
+
+	githubSync := t("common.inactive")
+	if config.SyncReviewersToGitHub {
+		githubSync = t("common.active")
+	}
+
+	digestTime := config.DigestTime
+	if digestTime == "" {
+		digestTime = t("common.inactive")
+	}
+
 	language := config.Language
 	if language == "" {
 		language = "ja"
 	}
 
 	response := t("cmd.show_config.response", labelName, status, config.DefaultMentionID, formatReviewerList(config.ReviewerList, lang),
-		config.RepositoryList, reviewerReminderInterval, config.BusinessHoursStart, config.BusinessHoursEnd, timezone, requiredApprovals, language)
+		config.RepositoryList, reviewerReminderInterval, config.BusinessHoursStart, config.BusinessHoursEnd, timezone, requiredApprovals, strategy, githubSync, digestTime, language)
 
 	c.String(200, response)
 }
@@ -582,15 +669,16 @@ func cleanupUserIDs(userIDs string) string {
 }
 
 // addReviewers adds reviewers
-func addReviewers(c *gin.Context, db *gorm.DB, channelID, labelName, reviewerIDs, lang string) {
+func addReviewers(c slackResponder, db *gorm.DB, channelID, labelName, reviewerIDs, lang string) {
 	t := i18n.L(lang)
 	var config models.ChannelConfig
 
-	result := db.Where("slack_channel_id = ? AND label_name = ?", channelID, labelName).First(&config)
+	result := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, labelName).First(&config)
 	if result.Error != nil {
 		// Create new config if none exists yet
 		config = models.ChannelConfig{
 			ID:             uuid.NewString(),
+			TeamID:         slackTeamID(c),
 			SlackChannelID: channelID,
 			LabelName:      labelName,
 			ReviewerList:   cleanupUserIDs(reviewerIDs), // Clean up user ID format
@@ -639,11 +727,11 @@ func addReviewers(c *gin.Context, db *gorm.DB, channelID, labelName, reviewerIDs
 }
 
 // showReviewers displays the reviewer list
-func showReviewers(c *gin.Context, db *gorm.DB, channelID, labelName, lang string) {
+func showReviewers(c slackResponder, db *gorm.DB, channelID, labelName, lang string) {
 	t := i18n.L(lang)
 	var config models.ChannelConfig
 
-	err := db.Where("slack_channel_id = ? AND label_name = ?", channelID, labelName).First(&config).Error
+	err := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, labelName).First(&config).Error
 	if err != nil {
 		c.String(200, t("cmd.show_reviewers.no_config", labelName, labelName))
 		return
@@ -659,11 +747,11 @@ func showReviewers(c *gin.Context, db *gorm.DB, channelID, labelName, lang strin
 }
 
 // clearReviewers clears the reviewer list
-func clearReviewers(c *gin.Context, db *gorm.DB, channelID, labelName, lang string) {
+func clearReviewers(c slackResponder, db *gorm.DB, channelID, labelName, lang string) {
 	t := i18n.L(lang)
 	var config models.ChannelConfig
 
-	err := db.Where("slack_channel_id = ? AND label_name = ?", channelID, labelName).First(&config).Error
+	err := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, labelName).First(&config).Error
 	if err != nil {
 		c.String(200, t("cmd.clear_reviewers.no_config", labelName))
 		return
@@ -677,18 +765,19 @@ func clearReviewers(c *gin.Context, db *gorm.DB, channelID, labelName, lang stri
 }
 
 // setMention sets the mention target
-func setMention(c *gin.Context, db *gorm.DB, channelID, labelName, mentionID, lang string) {
+func setMention(c slackResponder, db *gorm.DB, channelID, labelName, mentionID, lang string) {
 	t := i18n.L(lang)
 	var config models.ChannelConfig
 
 	// Clean up the mention ID
 	cleanedMentionID := cleanUserID(mentionID)
 
-	result := db.Where("slack_channel_id = ? AND label_name = ?", channelID, labelName).First(&config)
+	result := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, labelName).First(&config)
 	if result.Error != nil {
 		// Create new config
 		config = models.ChannelConfig{
 			ID:               uuid.NewString(),
+			TeamID:           slackTeamID(c),
 			SlackChannelID:   channelID,
 			LabelName:        labelName,
 			DefaultMentionID: cleanedMentionID,
@@ -727,15 +816,29 @@ func setMention(c *gin.Context, db *gorm.DB, channelID, labelName, mentionID, la
 }
 
 // addRepository adds a repository
-func addRepository(c *gin.Context, db *gorm.DB, channelID, labelName, repoNames, lang string) {
+func addRepository(c slackResponder, db *gorm.DB, channelID, labelName, repoNames, lang string) {
 	t := i18n.L(lang)
 	var config models.ChannelConfig
 
-	result := db.Where("slack_channel_id = ? AND label_name = ?", channelID, labelName).First(&config)
+	// Only repositories the GitHub App can access are accepted
+	repoNames, rejected := resolveRepositoryNames(db, slackTeamID(c), repoNames, lang)
+	if repoNames == "" && rejected != "" {
+		c.String(200, rejected)
+		return
+	}
+	withRejected := func(response string) string {
+		if rejected != "" {
+			response += "\n\n" + rejected
+		}
+		return response
+	}
+
+	result := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, labelName).First(&config)
 	if result.Error != nil {
 		// Create new config if none exists yet
 		config = models.ChannelConfig{
 			ID:             uuid.NewString(),
+			TeamID:         slackTeamID(c),
 			SlackChannelID: channelID,
 			LabelName:      labelName,
 			RepositoryList: repoNames,
@@ -744,17 +847,10 @@ func addRepository(c *gin.Context, db *gorm.DB, channelID, labelName, repoNames,
 			UpdatedAt:      time.Now(),
 		}
 		db.Create(&config)
-		c.String(200, t("cmd.add_repo.created", labelName, repoNames))
+		c.String(200, withRejected(t("cmd.add_repo.created", labelName, repoNames)))
 		return
 	}
 
-	// Use regex to handle all space patterns
-	re := regexp.MustCompile(`\s*,\s*`)
-	repoNames = re.ReplaceAllString(repoNames, ",")
-
-	// Also trim leading and trailing whitespace
-	repoNames = strings.TrimSpace(repoNames)
-
 	// Check the existing repository list
 	currentRepos := []string{}
 	if config.RepositoryList != "" {
@@ -812,15 +908,57 @@ func addRepository(c *gin.Context, db *gorm.DB, channelID, labelName, repoNames,
 		response = t("cmd.add_repo.no_valid")
 	}
 
-	c.String(200, response)
+	c.String(200, withRejected(response))
+}
+
+// resolveRepositoryNames normalizes comma-separated repository names and,
+// once GitHub App installations are recorded, keeps only the repositories the
+// App can access from the Slack team, completing bare names and using GitHub's spelling. The
+// rejected names are described in the returned message.
+func resolveRepositoryNames(db *gorm.DB, teamID, repoNames, lang string) (string, string) {
+	t := i18n.L(lang)
+
+	// Use regex to handle all space patterns
+	re := regexp.MustCompile(`\s*,\s*`)
+	repoNames = strings.TrimSpace(re.ReplaceAllString(repoNames, ","))
+
+	installed, err := services.GitHubInstallationRepositories(db, teamID)
+	if err != nil {
+		log.Printf("failed to load github installation repositories: %v", err)
+		return repoNames, ""
+	}
+	if len(installed) == 0 {
+		return repoNames, ""
+	}
+
+	var resolved, rejected []string
+	for _, name := range strings.Split(repoNames, ",") {
+		if name == "" {
+			continue
+		}
+		match, suggestions := services.MatchGitHubRepository(installed, name)
+		switch {
+		case match != "":
+			resolved = append(resolved, match)
+		case len(suggestions) > 0:
+			rejected = append(rejected, t("cmd.add_repo.not_installed_suggest", name, strings.Join(suggestions, "`, `")))
+		default:
+			rejected = append(rejected, t("cmd.add_repo.not_installed_repo", name))
+		}
+	}
+
+	if len(rejected) == 0 {
+		return strings.Join(resolved, ","), ""
+	}
+	return strings.Join(resolved, ","), t("cmd.add_repo.not_installed") + "\n" + strings.Join(rejected, "\n")
 }
 
 // removeRepository removes a repository
-func removeRepository(c *gin.Context, db *gorm.DB, channelID, labelName, repoName, lang string) {
+func removeRepository(c slackResponder, db *gorm.DB, channelID, labelName, repoName, lang string) {
 	t := i18n.L(lang)
 	var config models.ChannelConfig
 
-	result := db.Where("slack_channel_id = ? AND label_name = ?", channelID, labelName).First(&config)
+	result := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, labelName).First(&config)
 	if result.Error != nil {
 		c.String(200, t("cmd.remove_repo.no_config", labelName))
 		return
@@ -858,12 +996,12 @@ func removeRepository(c *gin.Context, db *gorm.DB, channelID, labelName, repoNam
 }
 
 // changeLabelName renames a label
-func changeLabelName(c *gin.Context, db *gorm.DB, channelID, oldLabelName, newLabelName, lang string) {
+func changeLabelName(c slackResponder, db *gorm.DB, channelID, oldLabelName, newLabelName, lang string) {
 	t := i18n.L(lang)
 	var config models.ChannelConfig
 
 	// Get the current config
-	result := db.Where("slack_channel_id = ? AND label_name = ?", channelID, oldLabelName).First(&config)
+	result := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, oldLabelName).First(&config)
 	if result.Error != nil {
 		c.String(200, t("cmd.set_label.no_config", oldLabelName))
 		return
@@ -871,7 +1009,7 @@ func changeLabelName(c *gin.Context, db *gorm.DB, channelID, oldLabelName, newLa
 
 	// Check if a config already exists with the new label name
 	var existingConfig models.ChannelConfig
-	existingResult := db.Where("slack_channel_id = ? AND label_name = ?", channelID, newLabelName).First(&existingConfig)
+	existingResult := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, newLabelName).First(&existingConfig)
 	if existingResult.Error == nil {
 		c.String(200, t("cmd.set_label.already_exists", newLabelName))
 		return
@@ -886,11 +1024,11 @@ func changeLabelName(c *gin.Context, db *gorm.DB, channelID, oldLabelName, newLa
 }
 
 // activateChannel toggles channel activation on/off
-func activateChannel(c *gin.Context, db *gorm.DB, channelID, labelName, lang string, active bool) {
+func activateChannel(c slackResponder, db *gorm.DB, channelID, labelName, lang string, active bool) {
 	t := i18n.L(lang)
 	var config models.ChannelConfig
 
-	result := db.Where("slack_channel_id = ? AND label_name = ?", channelID, labelName).First(&config)
+	result := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, labelName).First(&config)
 	if result.Error != nil {
 		if active {
 			c.String(200, t("cmd.activate.no_config", labelName, labelName))
@@ -913,7 +1051,7 @@ func activateChannel(c *gin.Context, db *gorm.DB, channelID, labelName, lang str
 }
 
 // setReminderInterval sets the reminder frequency
-func setReminderInterval(c *gin.Context, db *gorm.DB, channelID, labelName, intervalStr, lang string, isReviewer bool) {
+func setReminderInterval(c slackResponder, db *gorm.DB, channelID, labelName, intervalStr, lang string, isReviewer bool) {
 	t := i18n.L(lang)
 	var config models.ChannelConfig
 
@@ -924,11 +1062,12 @@ func setReminderInterval(c *gin.Context, db *gorm.DB, channelID, labelName, inte
 		return
 	}
 
-	result := db.Where("slack_channel_id = ? AND label_name = ?", channelID, labelName).First(&config)
+	result := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, labelName).First(&config)
 	if result.Error != nil {
 		// Create new config if none exists yet
 		config = models.ChannelConfig{
 			ID:             uuid.NewString(),
+			TeamID:         slackTeamID(c),
 			SlackChannelID: channelID,
 			LabelName:      labelName,
 			IsActive:       true,
@@ -966,7 +1105,7 @@ func setReminderInterval(c *gin.Context, db *gorm.DB, channelID, labelName, inte
 }
 
 // setBusinessHoursStart sets the business hours start time
-func setBusinessHoursStart(c *gin.Context, db *gorm.DB, channelID, labelName, startTime, lang string) {
+func setBusinessHoursStart(c slackResponder, db *gorm.DB, channelID, labelName, startTime, lang string) {
 	t := i18n.L(lang)
 	if !isValidTimeFormat(startTime) {
 		c.String(200, t("cmd.time_format_invalid", "09:00"))
@@ -974,12 +1113,13 @@ func setBusinessHoursStart(c *gin.Context, db *gorm.DB, channelID, labelName, st
 	}
 
 	var config models.ChannelConfig
-	result := db.Where("slack_channel_id = ? AND label_name = ?", channelID, labelName).First(&config)
+	result := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, labelName).First(&config)
 
 	if result.Error != nil {
 		// Create new config
 		config = models.ChannelConfig{
 			ID:                 uuid.NewString(),
+			TeamID:             slackTeamID(c),
 			SlackChannelID:     channelID,
 			LabelName:          labelName,
 			BusinessHoursStart: startTime,
@@ -1002,7 +1142,7 @@ func setBusinessHoursStart(c *gin.Context, db *gorm.DB, channelID, labelName, st
 }
 
 // setBusinessHoursEnd sets the business hours end time
-func setBusinessHoursEnd(c *gin.Context, db *gorm.DB, channelID, labelName, endTime, lang string) {
+func setBusinessHoursEnd(c slackResponder, db *gorm.DB, channelID, labelName, endTime, lang string) {
 	t := i18n.L(lang)
 	if !isValidTimeFormat(endTime) {
 		c.String(200, t("cmd.time_format_invalid", "18:00"))
@@ -1010,11 +1150,12 @@ func setBusinessHoursEnd(c *gin.Context, db *gorm.DB, channelID, labelName, endT
 	}
 
 	var config models.ChannelConfig
-	result := db.Where("slack_channel_id = ? AND label_name = ?", channelID, labelName).First(&config)
+	result := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, labelName).First(&config)
 
 	if result.Error != nil {
 		config = models.ChannelConfig{
 			ID:                 uuid.NewString(),
+			TeamID:             slackTeamID(c),
 			SlackChannelID:     channelID,
 			LabelName:          labelName,
 			BusinessHoursStart: "09:00",
@@ -1038,7 +1179,7 @@ func setBusinessHoursEnd(c *gin.Context, db *gorm.DB, channelID, labelName, endT
 }
 
 // setTimezone sets the timezone
-func setTimezone(c *gin.Context, db *gorm.DB, channelID, labelName, timezone, lang string) {
+func setTimezone(c slackResponder, db *gorm.DB, channelID, labelName, timezone, lang string) {
 	t := i18n.L(lang)
 	// Validate the timezone
 	if !isValidTimezone(timezone) {
@@ -1047,12 +1188,13 @@ func setTimezone(c *gin.Context, db *gorm.DB, channelID, labelName, timezone, la
 	}
 
 	var config models.ChannelConfig
-	result := db.Where("slack_channel_id = ? AND label_name = ?", channelID, labelName).First(&config)
+	result := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, labelName).First(&config)
 
 	if result.Error != nil {
 		// Create new config
 		config = models.ChannelConfig{
 			ID:                 uuid.NewString(),
+			TeamID:             slackTeamID(c),
 			SlackChannelID:     channelID,
 			LabelName:          labelName,
 			Timezone:           timezone,
@@ -1101,7 +1243,7 @@ func isValidTimeFormat(timeStr string) bool {
 	return true
 }
 
-func mapUser(c *gin.Context, db *gorm.DB, params, lang string) {
+func mapUser(c slackResponder, db *gorm.DB, params, lang string) {
 	t := i18n.L(lang)
 	parts := strings.Fields(params)
 	if len(parts) < 2 {
@@ -1128,7 +1270,7 @@ func mapUser(c *gin.Context, db *gorm.DB, params, lang string) {
 	}
 
 	var existingMapping models.UserMapping
-	result := db.Where("github_username = ?", githubUsername).First(&existingMapping)
+	result := db.Where("team_id = ? AND github_username = ?", slackTeamID(c), githubUsername).First(&existingMapping)
 
 	if result.Error == nil {
 		existingMapping.SlackUserID = slackUserID
@@ -1140,6 +1282,7 @@ func mapUser(c *gin.Context, db *gorm.DB, params, lang string) {
 
 	newMapping := models.UserMapping{
 		ID:             uuid.NewString(),
+		TeamID:         slackTeamID(c),
 		GithubUsername: githubUsername,
 		SlackUserID:    slackUserID,
 		CreatedAt:      time.Now(),
@@ -1155,11 +1298,11 @@ func mapUser(c *gin.Context, db *gorm.DB, params, lang string) {
 	c.String(200, t("cmd.map_user.created", githubUsername, slackUserID))
 }
 
-func showUserMappings(c *gin.Context, db *gorm.DB, lang string) {
+func showUserMappings(c slackResponder, db *gorm.DB, lang string) {
 	t := i18n.L(lang)
 	var mappings []models.UserMapping
 
-	if err := db.Find(&mappings).Error; err != nil {
+	if err := db.Where("team_id = ?", slackTeamID(c)).Find(&mappings).Error; err != nil {
 		log.Printf("failed to get user mappings: %v", err)
 		c.String(200, t("cmd.show_user_mappings.error"))
 		return
@@ -1178,7 +1321,7 @@ func showUserMappings(c *gin.Context, db *gorm.DB, lang string) {
 	c.String(200, response)
 }
 
-func removeUserMapping(c *gin.Context, db *gorm.DB, githubUsername, lang string) {
+func removeUserMapping(c slackResponder, db *gorm.DB, githubUsername, lang string) {
 	t := i18n.L(lang)
 	githubUsername = strings.TrimSpace(githubUsername)
 
@@ -1188,7 +1331,7 @@ func removeUserMapping(c *gin.Context, db *gorm.DB, githubUsername, lang string)
 	}
 
 	var mapping models.UserMapping
-	result := db.Where("github_username = ?", githubUsername).First(&mapping)
+	result := db.Where("team_id = ? AND github_username = ?", slackTeamID(c), githubUsername).First(&mapping)
 
 	if result.Error != nil {
 		c.String(200, t("cmd.remove_user_mapping.not_found", githubUsername))
@@ -1204,6 +1347,144 @@ func removeUserMapping(c *gin.Context, db *gorm.DB, githubUsername, lang string)
 	c.String(200, t("cmd.remove_user_mapping.success", githubUsername))
 }
 
+func mapTeam(c slackResponder, db *gorm.DB, params, lang string) {
+	t := i18n.L(lang)
+	parts := strings.Fields(params)
+	if len(parts) < 2 {
+		c.String(200, t("cmd.map_team.usage"))
+		return
+	}
+
+	teamSlug := services.NormalizeTeamSlug(parts[0])
+	if teamSlug == "" {
+		c.String(200, t("cmd.map_team.usage"))
+		return
+	}
+	// Teams of different organizations may share a slug
+	if org, slug, ok := strings.Cut(teamSlug, "/"); !ok || org == "" || slug == "" {
+		c.String(200, t("cmd.map_team.org_required", teamSlug))
+		return
+	}
+
+	// The remaining arguments are a Slack user group, members, and the
+	// optional "pick" keyword, in any order
+	var userGroupID string
+	var memberIDs []string
+	pickReviewer := false
+	for _, part := range parts[1:] {
+		if strings.EqualFold(part, "pick") {
+			pickReviewer = true
+			continue
+		}
+		id := strings.TrimPrefix(cleanUserID(part), "subteam^")
+		switch {
+		case services.LooksLikeSlackUserGroupID(id):
+			userGroupID = id
+		case services.LooksLikeResolvedSlackUserID(id):
+			memberIDs = append(memberIDs, id)
+		default:
+			c.String(200, t("cmd.map_team.invalid_target", part))
+			return
+		}
+	}
+
+	if userGroupID == "" && len(memberIDs) == 0 {
+		c.String(200, t("cmd.map_team.usage"))
+		return
+	}
+	if pickReviewer && len(memberIDs) == 0 {
+		c.String(200, t("cmd.map_team.pick_requires_members"))
+		return
+	}
+
+	var mapping models.TeamMapping
+	if err := db.Where("team_id = ? AND github_team_slug = ?", slackTeamID(c), teamSlug).First(&mapping).Error; err == nil {
+		mapping.SlackUserGroupID = userGroupID
+		mapping.MemberIDs = strings.Join(memberIDs, ",")
+		mapping.PickReviewer = pickReviewer
+		mapping.UpdatedAt = time.Now()
+		db.Save(&mapping)
+		c.String(200, t("cmd.map_team.updated", teamSlug))
+		return
+	}
+
+	mapping = models.TeamMapping{
+		ID:               uuid.NewString(),
+		TeamID:           slackTeamID(c),
+		GithubTeamSlug:   teamSlug,
+		SlackUserGroupID: userGroupID,
+		MemberIDs:        strings.Join(memberIDs, ","),
+		PickReviewer:     pickReviewer,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+	}
+	if err := db.Create(&mapping).Error; err != nil {
+		log.Printf("failed to create team mapping: %v", err)
+		c.String(200, t("cmd.map_team.create_error"))
+		return
+	}
+
+	c.String(200, t("cmd.map_team.created", teamSlug))
+}
+
+func showTeamMappings(c slackResponder, db *gorm.DB, lang string) {
+	t := i18n.L(lang)
+	var mappings []models.TeamMapping
+
+	if err := db.Where("team_id = ?", slackTeamID(c)).Order("github_team_slug").Find(&mappings).Error; err != nil {
+		log.Printf("failed to get team mappings: %v", err)
+		c.String(200, t("cmd.show_team_mappings.error"))
+		return
+	}
+
+	if len(mappings) == 0 {
+		c.String(200, t("cmd.show_team_mappings.empty"))
+		return
+	}
+
+	response := t("cmd.show_team_mappings.header")
+	for _, mapping := range mappings {
+		response += fmt.Sprintf("• GitHub: `%s` → Slack: %s", mapping.GithubTeamSlug, services.TeamMention(&mapping))
+		if mapping.SlackUserGroupID != "" && mapping.MemberIDs != "" {
+			response += " (" + formatReviewerList(mapping.MemberIDs, lang) + ")"
+		}
+		if mapping.PickReviewer {
+			response += " " + t("cmd.show_team_mappings.pick")
+		}
+		// Registered before mappings were keyed by organization
+		if !strings.Contains(mapping.GithubTeamSlug, "/") {
+			response += " " + t("cmd.show_team_mappings.no_org")
+		}
+		response += "\n"
+	}
+
+	c.String(200, response)
+}
+
+func removeTeamMapping(c slackResponder, db *gorm.DB, teamSlug, lang string) {
+	t := i18n.L(lang)
+	teamSlug = services.NormalizeTeamSlug(teamSlug)
+
+	if teamSlug == "" {
+		c.String(200, t("cmd.remove_team_mapping.usage"))
+		return
+	}
+
+	var mapping models.TeamMapping
+	if err := db.Where("team_id = ? AND github_team_slug = ?", slackTeamID(c), teamSlug).First(&mapping).Error; err != nil {
+		c.String(200, t("cmd.remove_team_mapping.not_found", teamSlug))
+		return
+	}
+
+	if err := db.Delete(&mapping).Error; err != nil {
+		log.Printf("failed to delete team mapping: %v", err)
+		c.String(200, t("cmd.remove_team_mapping.error"))
+		return
+	}
+
+	c.String(200, t("cmd.remove_team_mapping.success", teamSlug))
+}
+
 // awayPeriod holds the parsed leave period and reason from a set-away/unset-away command.
 type awayPeriod struct {
 	from   *time.Time
@@ -1219,10 +1500,10 @@ const (
 )
 
 // resolveTimezone returns the location configured for the channel/label, falling back to Asia/Tokyo.
-func resolveTimezone(db *gorm.DB, channelID, labelName string) *time.Location {
+func resolveTimezone(db *gorm.DB, teamID, channelID, labelName string) *time.Location {
 	timezone := "Asia/Tokyo"
 	var tzConfig models.ChannelConfig
-	if err := db.Where("slack_channel_id = ? AND label_name = ?", channelID, labelName).First(&tzConfig).Error; err == nil {
+	if err := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", teamID, channelID, labelName).First(&tzConfig).Error; err == nil {
 		if tzConfig.Timezone != "" {
 			timezone = tzConfig.Timezone
 		}
@@ -1311,7 +1592,7 @@ func parseAwayPeriod(parts []string, loc *time.Location, now time.Time, rejectPa
 }
 
 // setAway marks a user as away/on leave
-func setAway(c *gin.Context, db *gorm.DB, channelID, labelName, params, lang string) {
+func setAway(c slackResponder, db *gorm.DB, channelID, labelName, params, lang string) {
 	t := i18n.L(lang)
 	if params == "" {
 		c.String(200, t("cmd.set_away.usage"))
@@ -1330,7 +1611,7 @@ func setAway(c *gin.Context, db *gorm.DB, channelID, labelName, params, lang str
 		return
 	}
 
-	loc := resolveTimezone(db, channelID, labelName)
+	loc := resolveTimezone(db, slackTeamID(c), channelID, labelName)
 	nowLocal := time.Now().In(loc)
 
 	period, errKey := parseAwayPeriod(parts, loc, nowLocal, rejectPastDates)
@@ -1390,7 +1671,7 @@ func setAway(c *gin.Context, db *gorm.DB, channelID, labelName, params, lang str
 		openParen, closeParen = " (", ")"
 	}
 	response := t("cmd.set_away.success", slackUserID)
-	response += openParen + formatDateRange(awayFrom, awayUntil, t)
+	response += openParen + services.FormatAwayDateRange(awayFrom, awayUntil, t)
 
 	if reason != "" {
 		response += t("common.reason", reason) + closeParen
@@ -1404,7 +1685,7 @@ func setAway(c *gin.Context, db *gorm.DB, channelID, labelName, params, lang str
 // unsetAway removes a user's away/leave status.
 // Without a date, all leave periods for the user are removed.
 // With "on"/"from"/"until", only the period that exactly matches is removed.
-func unsetAway(c *gin.Context, db *gorm.DB, channelID, labelName, params, lang string) {
+func unsetAway(c slackResponder, db *gorm.DB, channelID, labelName, params, lang string) {
 	t := i18n.L(lang)
 	if params == "" {
 		c.String(200, t("cmd.unset_away.usage"))
@@ -1440,7 +1721,7 @@ func unsetAway(c *gin.Context, db *gorm.DB, channelID, labelName, params, lang s
 
 	// If a date is specified, delete only the matching period; otherwise delete all.
 	if len(parts) > 1 {
-		loc := resolveTimezone(db, channelID, labelName)
+		loc := resolveTimezone(db, slackTeamID(c), channelID, labelName)
 		nowLocal := time.Now().In(loc)
 		period, errKey := parseAwayPeriod(parts, loc, nowLocal, allowPastDates)
 		if errKey != "" {
@@ -1464,27 +1745,8 @@ func unsetAway(c *gin.Context, db *gorm.DB, channelID, labelName, params, lang s
 	c.String(200, t("cmd.unset_away.success", slackUserID))
 }
 
-// formatDateRange returns a human-readable date range string for away periods.
-func formatDateRange(awayFrom, awayUntil *time.Time, t func(string, ...interface{}) string) string {
-	isSameDay := awayFrom != nil && awayUntil != nil &&
-		awayFrom.Year() == awayUntil.Year() && awayFrom.YearDay() == awayUntil.YearDay()
-
-	switch {
-	case isSameDay:
-		return t("common.on_date", awayFrom.Format("2006-01-02"))
-	case awayFrom != nil && awayUntil != nil:
-		return t("common.from_until", awayFrom.Format("2006-01-02"), awayUntil.Format("2006-01-02"))
-	case awayFrom != nil:
-		return t("common.from_until", awayFrom.Format("2006-01-02"), t("common.indefinite"))
-	case awayUntil != nil:
-		return t("common.until", awayUntil.Format("2006-01-02"))
-	default:
-		return t("common.indefinite")
-	}
-}
-
 // showAvailability displays a list of users currently on leave
-func showAvailability(c *gin.Context, db *gorm.DB, lang string) {
+func showAvailability(c slackResponder, db *gorm.DB, lang string) {
 	t := i18n.L(lang)
 	var records []models.ReviewerAvailability
 	now := time.Now()
@@ -1512,7 +1774,7 @@ func showAvailability(c *gin.Context, db *gorm.DB, lang string) {
 		}
 
 		line := fmt.Sprintf("• <@%s> [%s] ", r.SlackUserID, statusLabel)
-		line += formatDateRange(r.AwayFrom, r.AwayUntil, t)
+		line += services.FormatAwayDateRange(r.AwayFrom, r.AwayUntil, t)
 
 		if r.Reason != "" {
 			line += t("common.reason_paren", r.Reason)
@@ -1524,7 +1786,7 @@ func showAvailability(c *gin.Context, db *gorm.DB, lang string) {
 }
 
 // setRequiredApprovals sets the number of required approvals
-func setRequiredApprovals(c *gin.Context, db *gorm.DB, channelID, labelName, countStr, lang string) {
+func setRequiredApprovals(c slackResponder, db *gorm.DB, channelID, labelName, countStr, lang string) {
 	t := i18n.L(lang)
 	count, err := strconv.Atoi(countStr)
 	if err != nil || count < 1 || count > 10 {
@@ -1533,10 +1795,11 @@ func setRequiredApprovals(c *gin.Context, db *gorm.DB, channelID, labelName, cou
 	}
 
 	var config models.ChannelConfig
-	result := db.Where("slack_channel_id = ? AND label_name = ?", channelID, labelName).First(&config)
+	result := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, labelName).First(&config)
 	if result.Error != nil {
 		config = models.ChannelConfig{
 			ID:                uuid.NewString(),
+			TeamID:            slackTeamID(c),
 			SlackChannelID:    channelID,
 			LabelName:         labelName,
 			RequiredApprovals: count,
@@ -1556,13 +1819,283 @@ func setRequiredApprovals(c *gin.Context, db *gorm.DB, channelID, labelName, cou
 	c.String(200, t("cmd.set_required_approvals.updated", labelName, count))
 }
 
+// setReviewerStrategy sets how reviewers are picked for the label
+func setReviewerStrategy(c slackResponder, db *gorm.DB, channelID, labelName, strategy, lang string) {
+	t := i18n.L(lang)
+	strategy = strings.ToLower(strategy)
+	if !services.IsValidReviewerStrategy(strategy) {
+		c.String(200, t("cmd.set_strategy.invalid", strings.Join(services.ReviewerStrategies(), ", ")))
+		return
+	}
+
+	var config models.ChannelConfig
+	result := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, labelName).First(&config)
+	if result.Error != nil {
+		config = models.ChannelConfig{
+			ID:               uuid.NewString(),
+			TeamID:           slackTeamID(c),
+			SlackChannelID:   channelID,
+			LabelName:        labelName,
+			ReviewerStrategy: strategy,
+			IsActive:         true,
+			CreatedAt:        time.Now(),
+			UpdatedAt:        time.Now(),
+		}
+		db.Create(&config)
+		c.String(200, t("cmd.set_strategy.set", labelName, strategy))
+		return
+	}
+
+	config.ReviewerStrategy = strategy
+	config.UpdatedAt = time.Now()
+	db.Save(&config)
+
+	c.String(200, t("cmd.set_strategy.updated", labelName, strategy))
+}
+
+// setGitHubSync enables or disables requesting the Slack-assigned reviewers on GitHub
+func setGitHubSync(c slackResponder, db *gorm.DB, channelID, labelName, value, lang string) {
+	t := i18n.L(lang)
+	var enabled bool
+	switch strings.ToLower(value) {
+	case "on":
+		enabled = true
+	case "off":
+		enabled = false
+	default:
+		c.String(200, t("cmd.set_github_sync.invalid"))
+		return
+	}
+
+	status := t("common.inactive")
+	if enabled {
+		status = t("common.active")
+	}
+
+	var response string
+	var config models.ChannelConfig
+	result := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, labelName).First(&config)
+	if result.Error != nil {
+		config = models.ChannelConfig{
+			ID:                    uuid.NewString(),
+			TeamID:                slackTeamID(c),
+			SlackChannelID:        channelID,
+			LabelName:             labelName,
+			SyncReviewersToGitHub: enabled,
+			IsActive:              true,
+			CreatedAt:             time.Now(),
+			UpdatedAt:             time.Now(),
+		}
+		db.Create(&config)
+		response = t("cmd.set_github_sync.set", labelName, status)
+	} else {
+		config.SyncReviewersToGitHub = enabled
+		config.UpdatedAt = time.Now()
+		db.Save(&config)
+		response = t("cmd.set_github_sync.updated", labelName, status)
+	}
+
+	if enabled && !services.IsGitHubAPIEnabled() {
+		response += "\n" + t("cmd.set_github_sync.no_credentials")
+	}
+	c.String(200, response)
+}
+
+// setDigestTime sets the daily digest time, or disables the digest with "off"
+func setDigestTime(c slackResponder, db *gorm.DB, channelID, labelName, digestTime, lang string) {
+	t := i18n.L(lang)
+	if strings.EqualFold(digestTime, "off") {
+		digestTime = ""
+	} else if !isValidTimeFormat(digestTime) {
+		c.String(200, t("cmd.time_format_invalid", "10:00"))
+		return
+	}
+
+	display := digestTime
+	if display == "" {
+		display = t("common.inactive")
+	}
+
+	var config models.ChannelConfig
+	result := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, labelName).First(&config)
+	if result.Error != nil {
+		config = models.ChannelConfig{
+			ID:             uuid.NewString(),
+			TeamID:         slackTeamID(c),
+			SlackChannelID: channelID,
+			LabelName:      labelName,
+			DigestTime:     digestTime,
+			IsActive:       true,
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		}
+		db.Create(&config)
+		c.String(200, t("cmd.set_digest_time.set", labelName, display))
+		return
+	}
+
+	config.DigestTime = digestTime
+	config.UpdatedAt = time.Now()
+	db.Save(&config)
+
+	c.String(200, t("cmd.set_digest_time.updated", labelName, display))
+}
+
+// setDMDigest turns the invoking user's daily DM digest on ("on [HH:MM] [timezone]")
+// or off ("off"); without params it shows the current setting
+func setDMDigest(c slackResponder, db *gorm.DB, userID, params, lang string) {
+	t := i18n.L(lang)
+	args := strings.Fields(params)
+
+	if len(args) == 0 {
+		sub, err := services.GetDMDigestSubscription(db, slackTeamID(c), userID)
+		if err != nil {
+			c.String(200, t("cmd.dm_digest.error"))
+			return
+		}
+		if sub == nil || !sub.Enabled {
+			c.String(200, t("cmd.dm_digest.status_off"))
+			return
+		}
+		c.String(200, t("cmd.dm_digest.status_on", sub.DigestTime, sub.Timezone))
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "on":
+		if len(args) > 3 {
+			c.String(200, t("cmd.dm_digest.usage"))
+			return
+		}
+		var digestTime, timezone string
+		if len(args) > 1 {
+			digestTime = args[1]
+			if !isValidTimeFormat(digestTime) {
+				c.String(200, t("cmd.time_format_invalid", "10:00"))
+				return
+			}
+		}
+		if len(args) > 2 {
+			timezone = args[2]
+			if !isValidTimezone(timezone) {
+				c.String(200, t("cmd.set_timezone.invalid"))
+				return
+			}
+		}
+		sub, err := services.SetDMDigest(db, slackTeamID(c), userID, true, digestTime, timezone)
+		if err != nil {
+			c.String(200, t("cmd.dm_digest.error"))
+			return
+		}
+		c.String(200, t("cmd.dm_digest.enabled", sub.DigestTime, sub.Timezone))
+
+	case "off":
+		if _, err := services.SetDMDigest(db, slackTeamID(c), userID, false, "", ""); err != nil {
+			c.String(200, t("cmd.dm_digest.error"))
+			return
+		}
+		c.String(200, t("cmd.dm_digest.disabled"))
+
+	default:
+		c.String(200, t("cmd.dm_digest.usage"))
+	}
+}
+
+// showStats reports review latency and reviewer activity for the channel.
+// params may hold a label name (when none was given before the subcommand)
+// and a period such as "7d" or "30d"
+func showStats(c slackResponder, db *gorm.DB, channelID, labelName, params, lang string) {
+	t := i18n.L(lang)
+
+	days := 7
+	periodRe := regexp.MustCompile(`^(\d+)d$`)
+	for _, arg := range strings.Fields(params) {
+		if m := periodRe.FindStringSubmatch(arg); m != nil {
+			n, err := strconv.Atoi(m[1])
+			if err != nil || n < 1 || n > services.MaxReviewStatsDays {
+				c.String(200, t("cmd.stats.invalid_period", services.MaxReviewStatsDays))
+				return
+			}
+			days = n
+		} else if labelName == "" {
+			labelName = arg
+		} else {
+			c.String(200, t("cmd.stats.usage"))
+			return
+		}
+	}
+
+	stats, err := services.LoadReviewStats(db, slackTeamID(c), channelID, labelName, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Printf("failed to load review stats (channel: %s, label: %s): %v", channelID, labelName, err)
+		c.String(200, t("cmd.stats.error"))
+		return
+	}
+
+	scope := labelName
+	if scope == "" {
+		scope = t("cmd.stats.all_labels")
+	}
+	latency := func(key string, d services.DurationStats) string {
+		if d.Count == 0 {
+			return t(key, t("cmd.stats.no_data"))
+		}
+		return t(key, t("cmd.stats.latency",
+			services.FormatWaitingDuration(d.Median, lang), services.FormatWaitingDuration(d.P90, lang), d.Count))
+	}
+
+	lines := []string{
+		t("cmd.stats.header", scope, days),
+		t("cmd.stats.summary", stats.Labeled, stats.Completed, stats.Merged),
+		latency("cmd.stats.first_review", stats.FirstReview),
+		latency("cmd.stats.full_approval", stats.FullApproval),
+		t("cmd.stats.reassignments", stats.Reassignments),
+		"",
+		t("cmd.stats.reviewers_header"),
+	}
+	if len(stats.Reviewers) == 0 {
+		lines = append(lines, t("cmd.stats.no_reviewers"))
+	}
+	for _, r := range stats.Reviewers {
+		name := r.ID
+		if services.LooksLikeResolvedSlackUserID(r.ID) {
+			name = fmt.Sprintf("<@%s>", r.ID)
+		}
+		lines = append(lines, t("cmd.stats.reviewer", name, r.Assigned, r.Approved, r.HandedOff))
+	}
+
+	c.String(200, strings.Join(lines, "\n"))
+}
+
+// resyncChannel asks the reconciler to recheck the channel's active tasks
+// with GitHub. It runs in the background, since a channel's PRs take longer
+// than Slack waits for an answer, and tells the user the result.
+func resyncChannel(c slackResponder, channelID, userID, lang string) {
+	t := i18n.L(lang)
+
+	if !services.IsGitHubAPIEnabled() {
+		c.String(200, t("cmd.resync.github_disabled"))
+		return
+	}
+	if !services.RequestReconcile(services.ReconcileRequest{
+		TeamID:    slackTeamID(c),
+		ChannelID: channelID,
+		UserID:    userID,
+		Language:  lang,
+	}) {
+		c.String(200, t("cmd.resync.busy"))
+		return
+	}
+	c.String(200, t("cmd.resync.started"))
+}
+
 // setLanguage sets the language for the channel config
-func setLanguage(c *gin.Context, db *gorm.DB, channelID, labelName, newLang string) {
+func setLanguage(c slackResponder, db *gorm.DB, channelID, labelName, newLang string) {
 	if newLang != "ja" && newLang != "en" {
 		// Use current config language for the error message
 		var currentConfig models.ChannelConfig
 		currentLang := "ja"
-		if err := db.Where("slack_channel_id = ? AND label_name = ?", channelID, labelName).First(&currentConfig).Error; err == nil {
+		if err := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, labelName).First(&currentConfig).Error; err == nil {
 			currentLang = getLang(&currentConfig)
 		}
 		t := i18n.L(currentLang)
@@ -1570,10 +2103,11 @@ func setLanguage(c *gin.Context, db *gorm.DB, channelID, labelName, newLang stri
 		return
 	}
 	var config models.ChannelConfig
-	result := db.Where("slack_channel_id = ? AND label_name = ?", channelID, labelName).First(&config)
+	result := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), channelID, labelName).First(&config)
 	if result.Error != nil {
 		config = models.ChannelConfig{
 			ID:             uuid.NewString(),
+			TeamID:         slackTeamID(c),
 			SlackChannelID: channelID,
 			LabelName:      labelName,
 			Language:        newLang,
@@ -4,25 +4,24 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"slack-review-notify/database/dbtest"
 	"slack-review-notify/models"
 	"slack-review-notify/services"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v71/github"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 // setupCommandIntegrationTestDB creates an in-memory database for integration tests
 func setupCommandIntegrationTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err != nil {
-		t.Fatalf("fail to open test db: %v", err)
-	}
+	db := dbtest.Open(t)
 
-	if err := db.AutoMigrate(&models.ChannelConfig{}, &models.ReviewTask{}, &models.UserMapping{}, &models.ReviewerAvailability{}); err != nil {
+	if err := models.Migrate(db); err != nil {
 		t.Fatalf("fail to migrate test db: %v", err)
 	}
 
@@ -48,11 +47,6 @@ func setupHTTPRequest(t *testing.T, text, channelID string) *http.Request {
 func TestSetBusinessHoursStartCommand_Integration(t *testing.T) {
 	db := setupCommandIntegrationTestDB(t)
 
-	services.IsTestMode = true
-	defer func() {
-		services.IsTestMode = false
-	}()
-
 	tests := []struct {
 		name           string
 		text           string
@@ -92,7 +86,7 @@ func TestSetBusinessHoursStartCommand_Integration(t *testing.T) {
 			req := setupHTTPRequest(t, tt.text, tt.channelID)
 			w := httptest.NewRecorder()
 
-			router := gin.New()
+			router := newSlackTestRouter(t)
 			router.POST("/slack/command", HandleSlackCommand(db))
 			router.ServeHTTP(w, req)
 
@@ -113,11 +107,6 @@ func TestSetBusinessHoursStartCommand_Integration(t *testing.T) {
 func TestSetTimezoneCommand_Integration(t *testing.T) {
 	db := setupCommandIntegrationTestDB(t)
 
-	services.IsTestMode = true
-	defer func() {
-		services.IsTestMode = false
-	}()
-
 	tests := []struct {
 		name             string
 		text             string
@@ -157,7 +146,7 @@ func TestSetTimezoneCommand_Integration(t *testing.T) {
 			req := setupHTTPRequest(t, tt.text, tt.channelID)
 			w := httptest.NewRecorder()
 
-			router := gin.New()
+			router := newSlackTestRouter(t)
 			router.POST("/slack/command", HandleSlackCommand(db))
 			router.ServeHTTP(w, req)
 
@@ -178,11 +167,6 @@ func TestSetTimezoneCommand_Integration(t *testing.T) {
 func TestSetRequiredApprovals_Integration(t *testing.T) {
 	db := setupCommandIntegrationTestDB(t)
 
-	services.IsTestMode = true
-	defer func() {
-		services.IsTestMode = false
-	}()
-
 	tests := []struct {
 		name              string
 		text              string
@@ -236,7 +220,7 @@ func TestSetRequiredApprovals_Integration(t *testing.T) {
 			req := setupHTTPRequest(t, tt.text, tt.channelID)
 			w := httptest.NewRecorder()
 
-			router := gin.New()
+			router := newSlackTestRouter(t)
 			router.POST("/slack/command", HandleSlackCommand(db))
 			router.ServeHTTP(w, req)
 
@@ -259,13 +243,345 @@ func TestSetRequiredApprovals_Integration(t *testing.T) {
 	}
 }
 
-func TestSetAway_Integration(t *testing.T) {
+func TestSetStrategy_Integration(t *testing.T) {
+	db := setupCommandIntegrationTestDB(t)
+
+	tests := []struct {
+		name             string
+		text             string
+		channelID        string
+		expectedStrategy string
+		expectedBody     string
+	}{
+		{
+			name:             "Least-loaded strategy",
+			text:             "needs-review set-strategy least-loaded",
+			channelID:        "C_STRATEGY",
+			expectedStrategy: "least-loaded",
+			expectedBody:     "least-loaded に設定しました",
+		},
+		{
+			name:             "Back to random with default label",
+			text:             "set-strategy random",
+			channelID:        "C_STRATEGY",
+			expectedStrategy: "random",
+			expectedBody:     "random に更新しました",
+		},
+		{
+			name:             "Round-robin strategy",
+			text:             "needs-review set-strategy round-robin",
+			channelID:        "C_STRATEGY",
+			expectedStrategy: "round-robin",
+			expectedBody:     "round-robin に更新しました",
+		},
+		{
+			name:             "Unsupported strategy",
+			text:             "needs-review set-strategy fastest",
+			channelID:        "C_STRATEGY",
+			expectedStrategy: "round-robin",
+			expectedBody:     "対応していない選び方です",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			req := setupHTTPRequest(t, tt.text, tt.channelID)
+			w := httptest.NewRecorder()
+
+			router := newSlackTestRouter(t)
+			router.POST("/slack/command", HandleSlackCommand(db))
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, 200, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+
+			var config models.ChannelConfig
+			err := db.Where("slack_channel_id = ? AND label_name = ?", tt.channelID, "needs-review").First(&config).Error
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStrategy, config.ReviewerStrategy)
+		})
+	}
+}
+
+func TestSetGitHubSync_Integration(t *testing.T) {
+	db := setupCommandIntegrationTestDB(t)
+
+	tests := []struct {
+		name         string
+		text         string
+		expectedSync bool
+		expectedBody string
+	}{
+		{
+			name:         "Enable sync",
+			text:         "needs-review set-github-sync on",
+			expectedSync: true,
+			expectedBody: "有効 に設定しました",
+		},
+		{
+			name:         "Invalid value",
+			text:         "needs-review set-github-sync maybe",
+			expectedSync: true,
+			expectedBody: "on または off を指定してください",
+		},
+		{
+			name:         "Disable sync",
+			text:         "set-github-sync OFF",
+			expectedSync: false,
+			expectedBody: "無効 に更新しました",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			req := setupHTTPRequest(t, tt.text, "C_GITHUB_SYNC")
+			w := httptest.NewRecorder()
+
+			router := newSlackTestRouter(t)
+			router.POST("/slack/command", HandleSlackCommand(db))
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, 200, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+
+			var config models.ChannelConfig
+			err := db.Where("slack_channel_id = ? AND label_name = ?", "C_GITHUB_SYNC", "needs-review").First(&config).Error
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedSync, config.SyncReviewersToGitHub)
+		})
+	}
+}
+
+// Enabling the sync without GitHub credentials warns that nothing will be
+// requested, whether the command creates the config or updates it.
+func TestSetGitHubSync_NoCredentials(t *testing.T) {
 	db := setupCommandIntegrationTestDB(t)
+	t.Setenv("GITHUB_APP_ID", "")
+
+	tests := []struct {
+		name        string
+		text        string
+		token       string
+		expectedSet string
+		warns       bool
+	}{
+		{name: "Create", text: "needs-review set-github-sync on", expectedSet: "有効 に設定しました", warns: true},
+		{name: "Update", text: "needs-review set-github-sync on", expectedSet: "有効 に更新しました", warns: true},
+		{name: "Update with a token", text: "needs-review set-github-sync on", token: "test-github-token", expectedSet: "有効 に更新しました"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_TOKEN", tt.token)
+			req := setupHTTPRequest(t, tt.text, "C_GITHUB_SYNC_NO_CREDS")
+			w := httptest.NewRecorder()
+
+			router := newSlackTestRouter(t)
+			router.POST("/slack/command", HandleSlackCommand(db))
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, 200, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedSet)
+			if tt.warns {
+				assert.Contains(t, w.Body.String(), "GITHUB_TOKEN")
+			} else {
+				assert.NotContains(t, w.Body.String(), "GITHUB_TOKEN")
+			}
+		})
+	}
+}
 
-	services.IsTestMode = true
-	defer func() {
-		services.IsTestMode = false
-	}()
+func TestSetDigestTime_Integration(t *testing.T) {
+	db := setupCommandIntegrationTestDB(t)
+
+	tests := []struct {
+		name           string
+		text           string
+		expectedDigest string
+		expectedBody   string
+	}{
+		{
+			name:           "Set digest time",
+			text:           "needs-review set-digest-time 10:30",
+			expectedDigest: "10:30",
+			expectedBody:   "10:30 に設定しました",
+		},
+		{
+			name:           "Invalid time",
+			text:           "needs-review set-digest-time 25:00",
+			expectedDigest: "10:30",
+			expectedBody:   "10:00",
+		},
+		{
+			name:           "Disable digest",
+			text:           "set-digest-time off",
+			expectedDigest: "",
+			expectedBody:   "無効 に更新しました",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			req := setupHTTPRequest(t, tt.text, "C_DIGEST")
+			w := httptest.NewRecorder()
+
+			router := newSlackTestRouter(t)
+			router.POST("/slack/command", HandleSlackCommand(db))
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, 200, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+
+			var config models.ChannelConfig
+			err := db.Where("slack_channel_id = ? AND label_name = ?", "C_DIGEST", "needs-review").First(&config).Error
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedDigest, config.DigestTime)
+		})
+	}
+}
+
+func TestDMDigest_Integration(t *testing.T) {
+	db := setupCommandIntegrationTestDB(t)
+
+	tests := []struct {
+		name            string
+		text            string
+		expectedEnabled bool
+		expectedTime    string
+		expectedTZ      string
+		expectedBody    string
+	}{
+		{
+			name:         "Status before opting in",
+			text:         "dm-digest",
+			expectedBody: "オフです",
+		},
+		{
+			name:            "Opt in with defaults",
+			text:            "dm-digest on",
+			expectedEnabled: true,
+			expectedTime:    "10:00",
+			expectedTZ:      "Asia/Tokyo",
+			expectedBody:    "10:00 (Asia/Tokyo)",
+		},
+		{
+			name:            "Change time and timezone",
+			text:            "dm-digest on 09:30 UTC",
+			expectedEnabled: true,
+			expectedTime:    "09:30",
+			expectedTZ:      "UTC",
+			expectedBody:    "09:30 (UTC)",
+		},
+		{
+			name:            "Invalid timezone",
+			text:            "dm-digest on 09:30 Mars/Base",
+			expectedEnabled: true,
+			expectedTime:    "09:30",
+			expectedTZ:      "UTC",
+			expectedBody:    "Asia/Tokyo, UTC",
+		},
+		{
+			name:         "Opt out keeps the time",
+			text:         "dm-digest off",
+			expectedTime: "09:30",
+			expectedTZ:   "UTC",
+			expectedBody: "停止しました",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			req := setupHTTPRequest(t, tt.text, "C_DM_DIGEST")
+			w := httptest.NewRecorder()
+
+			router := newSlackTestRouter(t)
+			router.POST("/slack/command", HandleSlackCommand(db))
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, 200, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+
+			var sub models.DMDigestSubscription
+			err := db.Where("slack_user_id = ?", "U12345").First(&sub).Error
+			if tt.expectedTime == "" {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedEnabled, sub.Enabled)
+			assert.Equal(t, tt.expectedTime, sub.DigestTime)
+			assert.Equal(t, tt.expectedTZ, sub.Timezone)
+		})
+	}
+}
+
+func TestStats_Integration(t *testing.T) {
+	db := setupCommandIntegrationTestDB(t)
+
+	db.Create(&models.ChannelConfig{ID: "stats-config", SlackChannelID: "C_STATS", LabelName: "needs-review", Language: "en", IsActive: true})
+	now := time.Now()
+	events := []models.ReviewEvent{
+		{ID: "s1", TaskID: "t1", SlackChannel: "C_STATS", LabelName: "needs-review", EventType: services.ReviewEventLabeled, CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: "s2", TaskID: "t1", SlackChannel: "C_STATS", LabelName: "needs-review", EventType: services.ReviewEventReviewerAssigned, ActorID: "UREV1", CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: "s3", TaskID: "t1", SlackChannel: "C_STATS", LabelName: "needs-review", EventType: services.ReviewEventFirstReview, ActorID: "UREV1", CreatedAt: now.Add(-1 * time.Hour)},
+		{ID: "s4", TaskID: "t2", SlackChannel: "C_STATS", LabelName: "backend", EventType: services.ReviewEventLabeled, CreatedAt: now.AddDate(0, 0, -20)},
+	}
+	for _, e := range events {
+		db.Create(&e)
+	}
+
+	tests := []struct {
+		name         string
+		text         string
+		expectedBody []string
+	}{
+		{
+			name:         "All labels, default period",
+			text:         "stats",
+			expectedBody: []string{"all labels* (last 7 days)", "PRs labeled: 1", "Time to first review: median 2h · p90 2h (1 PRs)", "Time to full approval: no data", "<@UREV1>: assigned 1"},
+		},
+		{
+			name:         "Label and period after the subcommand",
+			text:         "stats backend 30d",
+			expectedBody: []string{"backend* (last 30 days)", "PRs labeled: 1", "No reviewer activity"},
+		},
+		{
+			// backend has no config, so the default language applies
+			name:         "Label before the subcommand",
+			text:         "backend stats",
+			expectedBody: []string{"*backend のレビュー統計*（過去 7 日間）", "ラベル付与: 0 件"},
+		},
+		{
+			name:         "Invalid period",
+			text:         "stats 400d",
+			expectedBody: []string{"1d and 180d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			req := setupHTTPRequest(t, tt.text, "C_STATS")
+			w := httptest.NewRecorder()
+
+			router := newSlackTestRouter(t)
+			router.POST("/slack/command", HandleSlackCommand(db))
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, 200, w.Code)
+			for _, expected := range tt.expectedBody {
+				assert.Contains(t, w.Body.String(), expected)
+			}
+		})
+	}
+}
+
+func TestSetAway_Integration(t *testing.T) {
+	db := setupCommandIntegrationTestDB(t)
 
 	tests := []struct {
 		name           string
@@ -359,7 +675,7 @@ func TestSetAway_Integration(t *testing.T) {
 			req := setupHTTPRequest(t, tt.text, tt.channelID)
 			w := httptest.NewRecorder()
 
-			router := gin.New()
+			router := newSlackTestRouter(t)
 			router.POST("/slack/command", HandleSlackCommand(db))
 			router.ServeHTTP(w, req)
 
@@ -380,13 +696,8 @@ func TestSetAway_Integration(t *testing.T) {
 func TestSetAway_MultiplePeriods(t *testing.T) {
 	db := setupCommandIntegrationTestDB(t)
 
-	services.IsTestMode = true
-	defer func() {
-		services.IsTestMode = false
-	}()
-
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
+	router := newSlackTestRouter(t)
 	router.POST("/slack/command", HandleSlackCommand(db))
 
 	send := func(text string) *httptest.ResponseRecorder {
@@ -415,13 +726,8 @@ func TestSetAway_MultiplePeriods(t *testing.T) {
 func TestUnsetAway_SpecificPeriod(t *testing.T) {
 	db := setupCommandIntegrationTestDB(t)
 
-	services.IsTestMode = true
-	defer func() {
-		services.IsTestMode = false
-	}()
-
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
+	router := newSlackTestRouter(t)
 	router.POST("/slack/command", HandleSlackCommand(db))
 
 	send := func(text string) *httptest.ResponseRecorder {
@@ -460,13 +766,8 @@ func TestUnsetAway_SpecificPeriod(t *testing.T) {
 func TestShowAvailability_AfterUnsetOneOfTwo(t *testing.T) {
 	db := setupCommandIntegrationTestDB(t)
 
-	services.IsTestMode = true
-	defer func() {
-		services.IsTestMode = false
-	}()
-
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
+	router := newSlackTestRouter(t)
 	router.POST("/slack/command", HandleSlackCommand(db))
 
 	send := func(text string) string {
@@ -498,13 +799,8 @@ func TestShowAvailability_AfterUnsetOneOfTwo(t *testing.T) {
 func TestUnsetAway_FromUntilPeriod(t *testing.T) {
 	db := setupCommandIntegrationTestDB(t)
 
-	services.IsTestMode = true
-	defer func() {
-		services.IsTestMode = false
-	}()
-
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
+	router := newSlackTestRouter(t)
 	router.POST("/slack/command", HandleSlackCommand(db))
 
 	send := func(text string) *httptest.ResponseRecorder {
@@ -555,13 +851,8 @@ func TestUnsetAway_FromUntilPeriod(t *testing.T) {
 func TestUnsetAway_NoMatchKeepsPeriods(t *testing.T) {
 	db := setupCommandIntegrationTestDB(t)
 
-	services.IsTestMode = true
-	defer func() {
-		services.IsTestMode = false
-	}()
-
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
+	router := newSlackTestRouter(t)
 	router.POST("/slack/command", HandleSlackCommand(db))
 
 	send := func(text string) *httptest.ResponseRecorder {
@@ -587,16 +878,11 @@ func TestUnsetAway_NoMatchKeepsPeriods(t *testing.T) {
 func TestUnsetAway_Integration(t *testing.T) {
 	db := setupCommandIntegrationTestDB(t)
 
-	services.IsTestMode = true
-	defer func() {
-		services.IsTestMode = false
-	}()
-
 	// First, set the leave status
 	gin.SetMode(gin.TestMode)
 	req := setupHTTPRequest(t, "set-away <@UAWAY>", "C12345")
 	w := httptest.NewRecorder()
-	router := gin.New()
+	router := newSlackTestRouter(t)
 	router.POST("/slack/command", HandleSlackCommand(db))
 	router.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
@@ -628,11 +914,6 @@ func TestUnsetAway_Integration(t *testing.T) {
 func TestUnsetAway_LegacyPipeFormat(t *testing.T) {
 	db := setupCommandIntegrationTestDB(t)
 
-	services.IsTestMode = true
-	defer func() {
-		services.IsTestMode = false
-	}()
-
 	// Seed a legacy-format record directly.
 	require := db.Create(&models.ReviewerAvailability{
 		ID:          "rec-legacy-pipe",
@@ -642,7 +923,7 @@ func TestUnsetAway_LegacyPipeFormat(t *testing.T) {
 	assert.NoError(t, require.Error)
 
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
+	router := newSlackTestRouter(t)
 	router.POST("/slack/command", HandleSlackCommand(db))
 
 	// cleanUserID(<@ULEGACY|username>) -> "ULEGACY", a well-formed id, so the
@@ -666,11 +947,6 @@ func TestUnsetAway_LegacyPipeFormat(t *testing.T) {
 func TestUnsetAway_WildcardDoesNotMatchLegacy(t *testing.T) {
 	db := setupCommandIntegrationTestDB(t)
 
-	services.IsTestMode = true
-	defer func() {
-		services.IsTestMode = false
-	}()
-
 	// A legacy row that a naive `LIKE 'U%|%'` would have matched.
 	res := db.Create(&models.ReviewerAvailability{
 		ID:          "rec-victim",
@@ -680,7 +956,7 @@ func TestUnsetAway_WildcardDoesNotMatchLegacy(t *testing.T) {
 	assert.NoError(t, res.Error)
 
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
+	router := newSlackTestRouter(t)
 	router.POST("/slack/command", HandleSlackCommand(db))
 
 	// "U%" must not delete UVICTIM's legacy record.
@@ -703,13 +979,8 @@ func TestUnsetAway_WildcardDoesNotMatchLegacy(t *testing.T) {
 func TestUnsetAway_LegacyPipeFormat_SpecificPeriod(t *testing.T) {
 	db := setupCommandIntegrationTestDB(t)
 
-	services.IsTestMode = true
-	defer func() {
-		services.IsTestMode = false
-	}()
-
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
+	router := newSlackTestRouter(t)
 	router.POST("/slack/command", HandleSlackCommand(db))
 
 	send := func(text string) *httptest.ResponseRecorder {
@@ -745,13 +1016,8 @@ func TestUnsetAway_LegacyPipeFormat_SpecificPeriod(t *testing.T) {
 func TestShowAvailability_Integration(t *testing.T) {
 	db := setupCommandIntegrationTestDB(t)
 
-	services.IsTestMode = true
-	defer func() {
-		services.IsTestMode = false
-	}()
-
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
+	router := newSlackTestRouter(t)
 	router.POST("/slack/command", HandleSlackCommand(db))
 
 	// When no one is on leave
@@ -800,11 +1066,9 @@ func TestShowAvailability_Integration(t *testing.T) {
 // the PR author ends up in the candidate pool.
 func TestMapUserCommand_RejectsPlainHandle(t *testing.T) {
 	db := setupCommandIntegrationTestDB(t)
-	services.IsTestMode = true
-	defer func() { services.IsTestMode = false }()
 
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
+	router := newSlackTestRouter(t)
 	router.POST("/slack/command", HandleSlackCommand(db))
 
 	req := setupHTTPRequest(t, "map-user octocat @octocat", "C12345")
@@ -823,11 +1087,9 @@ func TestMapUserCommand_RejectsPlainHandle(t *testing.T) {
 // shape — a Slack-resolved `<@U…>` mention or a bare U-id — still works.
 func TestMapUserCommand_AcceptsResolvedUserID(t *testing.T) {
 	db := setupCommandIntegrationTestDB(t)
-	services.IsTestMode = true
-	defer func() { services.IsTestMode = false }()
 
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
+	router := newSlackTestRouter(t)
 	router.POST("/slack/command", HandleSlackCommand(db))
 
 	req := setupHTTPRequest(t, "map-user octocat <@U01ABCDE234>", "C12345")
@@ -847,11 +1109,9 @@ func TestMapUserCommand_AcceptsResolvedUserID(t *testing.T) {
 // form `<@U…|displayname>` is normalized down to the bare user id.
 func TestMapUserCommand_AcceptsEscapedMentionWithName(t *testing.T) {
 	db := setupCommandIntegrationTestDB(t)
-	services.IsTestMode = true
-	defer func() { services.IsTestMode = false }()
 
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
+	router := newSlackTestRouter(t)
 	router.POST("/slack/command", HandleSlackCommand(db))
 
 	req := setupHTTPRequest(t, "map-user octocat <@U01ABCDE234|octocat>", "C12345")
@@ -866,3 +1126,205 @@ func TestMapUserCommand_AcceptsEscapedMentionWithName(t *testing.T) {
 	}
 	assert.Equal(t, "U01ABCDE234", mapping.SlackUserID)
 }
+
+// TestMapTeamCommand covers registering a team with a user group, updating it
+// to a member list with picking, and removing it.
+func TestMapTeamCommand(t *testing.T) {
+	db := setupCommandIntegrationTestDB(t)
+
+	gin.SetMode(gin.TestMode)
+	router := newSlackTestRouter(t)
+	router.POST("/slack/command", HandleSlackCommand(db))
+
+	run := func(text string) string {
+		req := setupHTTPRequest(t, text, "C12345")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, 200, w.Code)
+		return w.Body.String()
+	}
+
+	// The organization is required: team slugs are only unique within one
+	body := run("map-team backend <!subteam^S01BACKEND|@backend>")
+	assert.Contains(t, body, "my-org/backend")
+
+	body = run("map-team @my-org/Backend <!subteam^S01BACKEND|@backend>")
+	assert.Contains(t, body, "my-org/backend")
+
+	var mapping models.TeamMapping
+	if err := db.Where("github_team_slug = ?", "my-org/backend").First(&mapping).Error; err != nil {
+		t.Fatalf("expected team mapping to be created, got: %v", err)
+	}
+	assert.Equal(t, "S01BACKEND", mapping.SlackUserGroupID)
+	assert.False(t, mapping.PickReviewer)
+
+	// pick without members is rejected and leaves the mapping untouched
+	body = run("map-team my-org/backend S01BACKEND pick")
+	assert.Contains(t, body, "pick")
+	db.Where("github_team_slug = ?", "my-org/backend").First(&mapping)
+	assert.False(t, mapping.PickReviewer)
+
+	run("map-team my-org/backend <@U01ALICE|alice> <@U01BOB> pick")
+	db.Where("github_team_slug = ?", "my-org/backend").First(&mapping)
+	assert.Equal(t, "", mapping.SlackUserGroupID)
+	assert.Equal(t, "U01ALICE,U01BOB", mapping.MemberIDs)
+	assert.True(t, mapping.PickReviewer)
+
+	// A mapping registered without the organization is flagged as unused
+	db.Create(&models.TeamMapping{ID: "legacy", TeamID: mapping.TeamID, GithubTeamSlug: "frontend", MemberIDs: "U01CAROL"})
+	body = run("show-team-mappings")
+	assert.Contains(t, body, "<@U01ALICE> <@U01BOB>")
+	assert.Contains(t, body, "<@U01CAROL> [未使用")
+	run("remove-team-mapping frontend")
+
+	// plain handles cannot be resolved to user IDs
+	body = run("map-team my-org/frontend @someone")
+	assert.Contains(t, body, "@someone")
+
+	run("remove-team-mapping my-org/backend")
+	var count int64
+	db.Model(&models.TeamMapping{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestCommands_ScopedBySlackTeam(t *testing.T) {
+	db := setupCommandIntegrationTestDB(t)
+
+	run := func(teamID, text string) string {
+		gin.SetMode(gin.TestMode)
+		data := url.Values{}
+		data.Set("command", "/slack-review-notify")
+		data.Set("text", text)
+		data.Set("channel_id", "C_SHARED")
+		data.Set("user_id", "U12345")
+		data.Set("team_id", teamID)
+		req, _ := http.NewRequest("POST", "/slack/command", strings.NewReader(data.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+
+		router := newSlackTestRouter(t)
+		router.POST("/slack/command", HandleSlackCommand(db))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, 200, w.Code)
+		return w.Body.String()
+	}
+
+	// Two workspaces configure the same shared channel and map the same GitHub user
+	run("T1", "needs-review add-reviewer <@U1AAA>")
+	run("T2", "needs-review add-reviewer <@U2BBB>")
+	run("T1", "map-user octocat <@U1OCTO>")
+	run("T2", "map-user octocat <@U2OCTO>")
+	run("T1", "map-team acme/backend S01BACKEND")
+	run("T2", "map-team acme/backend S02BACKEND")
+
+	var configs []models.ChannelConfig
+	db.Where("slack_channel_id = ?", "C_SHARED").Order("team_id").Find(&configs)
+	if assert.Len(t, configs, 2) {
+		assert.Equal(t, "T1", configs[0].TeamID)
+		assert.Equal(t, "U1AAA", configs[0].ReviewerList)
+		assert.Equal(t, "T2", configs[1].TeamID)
+		assert.Equal(t, "U2BBB", configs[1].ReviewerList)
+	}
+	assert.Equal(t, "U1OCTO", services.GetSlackUserIDFromGitHub(db, "T1", "octocat"))
+	assert.Equal(t, "U2OCTO", services.GetSlackUserIDFromGitHub(db, "T2", "octocat"))
+
+	// Each workspace only sees its own rows
+	body := run("T2", "show-user-mappings")
+	assert.Contains(t, body, "U2OCTO")
+	assert.NotContains(t, body, "U1OCTO")
+	body = run("T1", "needs-review show-reviewers")
+	assert.Contains(t, body, "U1AAA")
+	assert.NotContains(t, body, "U2BBB")
+	body = run("T1", "show-team-mappings")
+	assert.Contains(t, body, "S01BACKEND")
+	assert.NotContains(t, body, "S02BACKEND")
+
+	run("T1", "remove-team-mapping acme/backend")
+	mapping, err := services.GetTeamMapping(db, "T2", "acme/backend")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "S02BACKEND", mapping.SlackUserGroupID)
+	}
+}
+
+func TestAddRepo_OnlyRepositoriesOfGitHubApp(t *testing.T) {
+	db := setupCommandIntegrationTestDB(t)
+
+	run := func(text string) string {
+		gin.SetMode(gin.TestMode)
+		data := url.Values{}
+		data.Set("command", "/slack-review-notify")
+		data.Set("text", text)
+		data.Set("channel_id", "C_REPOS")
+		data.Set("user_id", "U12345")
+		req, _ := http.NewRequest("POST", "/slack/command", strings.NewReader(data.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+
+		router := newSlackTestRouter(t)
+		router.POST("/slack/command", HandleSlackCommand(db))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, 200, w.Code)
+		return w.Body.String()
+	}
+	repositoryList := func() string {
+		var config models.ChannelConfig
+		db.Where("slack_channel_id = ?", "C_REPOS").First(&config)
+		return config.RepositoryList
+	}
+
+	// Without a recorded installation any name is accepted
+	run("needs-review add-repo someone/anything")
+	assert.Equal(t, "someone/anything", repositoryList())
+
+	installation := &github.Installation{ID: github.Ptr(int64(9))}
+	repos := []*github.Repository{{FullName: github.Ptr("acme/api-server")}, {FullName: github.Ptr("acme/Web")}}
+	assert.NoError(t, services.SaveGitHubInstallation(db, installation, repos))
+
+	// Bare names are completed and GitHub's spelling is used; unknown names are
+	// rejected with suggestions
+	body := run("needs-review add-repo api-server, acme/web, acme/api, nobody/nothing")
+	assert.Equal(t, "someone/anything,acme/api-server,acme/Web", repositoryList())
+	assert.Contains(t, body, "• `acme/api`（もしかして: `acme/api-server`）")
+	assert.Contains(t, body, "nobody/nothing")
+
+	// Repositories of an installation linked to another workspace are rejected
+	other := &github.Installation{ID: github.Ptr(int64(10))}
+	assert.NoError(t, services.SaveGitHubInstallation(db, other, []*github.Repository{{FullName: github.Ptr("other/secret")}}))
+	assert.NoError(t, services.LinkGitHubInstallation(db, 10, "T_OTHER"))
+	body = run("needs-review add-repo other/secret")
+	assert.Contains(t, body, "other/secret")
+	assert.Equal(t, "someone/anything,acme/api-server,acme/Web", repositoryList())
+
+	// Nothing is added when every name is rejected
+	body = run("needs-review add-repo nobody/nothing")
+	assert.Contains(t, body, "アクセスできない")
+	assert.Equal(t, "someone/anything,acme/api-server,acme/Web", repositoryList())
+}
+
+func TestResync_Integration(t *testing.T) {
+	db := setupCommandIntegrationTestDB(t)
+	gin.SetMode(gin.TestMode)
+
+	db.Create(&models.ChannelConfig{ID: "resync-config", SlackChannelID: "C_RESYNC", LabelName: "needs-review", Language: "en", IsActive: true})
+
+	resync := func() string {
+		w := httptest.NewRecorder()
+		router := newSlackTestRouter(t)
+		router.POST("/slack/command", HandleSlackCommand(db))
+		router.ServeHTTP(w, setupHTTPRequest(t, "resync", "C_RESYNC"))
+		assert.Equal(t, 200, w.Code)
+		return w.Body.String()
+	}
+
+	t.Setenv("GITHUB_TOKEN", "")
+	assert.Contains(t, resync(), "GitHub credentials")
+
+	t.Setenv("GITHUB_TOKEN", "test-github-token")
+	assert.Contains(t, resync(), "Rechecking this channel")
+	select {
+	case req := <-services.ReconcileRequests():
+		assert.Equal(t, services.ReconcileRequest{ChannelID: "C_RESYNC", UserID: "U12345", Language: "en"}, req)
+	default:
+		t.Fatal("resync request not queued")
+	}
+}
@@ -1,39 +1,74 @@
 package handlers
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"slack-review-notify/database/dbtest"
 	"slack-review-notify/models"
-	"slack-review-notify/services"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
+	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 )
 
-func setupTestRouter(db *gorm.DB) *gin.Engine {
-	// Enable test mode
-	services.IsTestMode = true
+func setupTestRouter(t *testing.T, db *gorm.DB) *gin.Engine {
+	r := newSlackTestRouter(t)
+	r.POST("/slack/command", HandleSlackCommand(db))
+	return r
+}
+
+// testSigningSecret is the Slack signing secret of the tests.
+const testSigningSecret = "test-signing-secret"
 
+// newSlackTestRouter returns a router that signs every request with
+// testSigningSecret the way Slack does, so the handlers verify the
+// signature as they do in production.
+func newSlackTestRouter(t *testing.T) *gin.Engine {
+	t.Setenv("SLACK_SIGNING_SECRET", testSigningSecret)
 	gin.SetMode(gin.TestMode)
-	r := gin.Default()
-	r.POST("/slack/command", HandleSlackCommand(db))
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		signSlackRequest(t, c.Request)
+	})
 	return r
 }
 
-func setupTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err != nil {
-		t.Fatalf("fail to open test db: %v", err)
+// signSlackRequest sets the timestamp and signature headers Slack sends
+// with a request.
+func signSlackRequest(t *testing.T, req *http.Request) {
+	t.Helper()
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		require.NoError(t, err)
 	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(testSigningSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+}
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db := dbtest.Open(t)
 
 	// Run migrations
-	if err := db.AutoMigrate(&models.ChannelConfig{}, &models.ReviewTask{}, &models.UserMapping{}, &models.ReviewerAvailability{}); err != nil {
+	if err := models.Migrate(db); err != nil {
 		t.Fatalf("fail to migrate test db: %v", err)
 	}
 
@@ -42,7 +77,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 
 func TestHandleSlackCommand_Help(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupTestRouter(db)
+	router := setupTestRouter(t, db)
 
 	// Test the help command
 	form := url.Values{}
@@ -70,9 +105,32 @@ func TestHandleSlackCommand_Help(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "指定ラベルの詳細設定を表示")
 }
 
+func TestHandleSlackCommand_InvalidSignature(t *testing.T) {
+	db := setupTestDB(t)
+	t.Setenv("SLACK_SIGNING_SECRET", testSigningSecret)
+	router := gin.New()
+	router.POST("/slack/command", HandleSlackCommand(db))
+
+	form := url.Values{}
+	form.Add("command", "/slack-review-notify")
+	form.Add("text", "help")
+	form.Add("channel_id", "C12345")
+	form.Add("user_id", "U12345")
+
+	req, _ := http.NewRequest("POST", "/slack/command", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+	req.Header.Set("X-Slack-Signature", "v0=0000")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
 func TestHandleSlackCommand_Show(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupTestRouter(db)
+	router := setupTestRouter(t, db)
 
 	// Create a test channel config
 	testConfig := models.ChannelConfig{
@@ -108,7 +166,7 @@ func TestHandleSlackCommand_Show(t *testing.T) {
 
 func TestHandleSlackCommand_ShowSpecificLabel(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupTestRouter(db)
+	router := setupTestRouter(t, db)
 
 	// Create a test channel config
 	testConfig := models.ChannelConfig{
@@ -144,7 +202,7 @@ func TestHandleSlackCommand_ShowSpecificLabel(t *testing.T) {
 
 func TestHandleSlackCommand_SetMention(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupTestRouter(db)
+	router := setupTestRouter(t, db)
 
 	// Test the set-mention command
 	form := url.Values{}
@@ -170,7 +228,7 @@ func TestHandleSlackCommand_SetMention(t *testing.T) {
 
 func TestHandleSlackCommand_AddRepo(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupTestRouter(db)
+	router := setupTestRouter(t, db)
 
 	// Create a test channel config
 	testConfig := models.ChannelConfig{
@@ -211,7 +269,7 @@ func TestHandleSlackCommand_AddRepo(t *testing.T) {
 // Test with label specification
 func TestHandleSlackCommand_WithLabel(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupTestRouter(db)
+	router := setupTestRouter(t, db)
 
 	// Create a test channel config
 	testConfig := models.ChannelConfig{
@@ -245,7 +303,7 @@ func TestHandleSlackCommand_WithLabel(t *testing.T) {
 
 func TestHandleSlackCommand_ShowAllLabels(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupTestRouter(db)
+	router := setupTestRouter(t, db)
 
 	// Create multiple test channel configs
 	testConfigs := []models.ChannelConfig{
@@ -14,7 +14,7 @@ import (
 )
 
 // HandleSlackEvents is a handler that processes Slack events
-func HandleSlackEvents(db *gorm.DB) gin.HandlerFunc {
+func HandleSlackEvents(db *gorm.DB, slack services.SlackClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		body, err := io.ReadAll(c.Request.Body)
 		if err != nil {
@@ -33,33 +33,63 @@ func HandleSlackEvents(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		var payload struct {
+		processSlackEvent(c, db, slack, body)
+	}
+}
+
+// processSlackEvent handles an Events API payload and answers it through c.
+func processSlackEvent(c slackResponder, db *gorm.DB, slack services.SlackClient, body []byte) {
+	var payload struct {
+		Type           string `json:"type"`
+		Challenge      string `json:"challenge"`
+		TeamID         string `json:"team_id"`
+		EnterpriseID   string `json:"enterprise_id"`
+		Authorizations []struct {
+			IsEnterpriseInstall bool `json:"is_enterprise_install"`
+		} `json:"authorizations"`
+		Event struct {
 			Type      string `json:"type"`
-			Challenge string `json:"challenge"`
-			Event     struct {
-				Type      string `json:"type"`
-				Channel   string `json:"channel"`
-				User      string `json:"user"`
-				Timestamp string `json:"event_ts"`
-			} `json:"event"`
-		}
+			Channel   string `json:"channel"`
+			User      string `json:"user"`
+			Tab       string `json:"tab"`
+			Timestamp string `json:"event_ts"`
+		} `json:"event"`
+	}
 
-		if err := json.Unmarshal(body, &payload); err != nil {
-			log.Printf("json parse error: %v", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
-			return
-		}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		log.Printf("json parse error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
 
-		// Handle URL verification request
-		if payload.Type == "url_verification" {
-			c.JSON(http.StatusOK, gin.H{"challenge": payload.Challenge})
-			return
-		}
+	// Handle URL verification request
+	if payload.Type == "url_verification" {
+		c.JSON(http.StatusOK, gin.H{"challenge": payload.Challenge})
+		return
+	}
 
-		if payload.Event.Type != "" {
-			log.Printf("event details: type=%s, channel=%s, user=%s",
-				payload.Event.Type, payload.Event.Channel, payload.Event.User)
-		}
-		c.Status(http.StatusOK)
+	if payload.Event.Type != "" {
+		log.Printf("event details: type=%s, channel=%s, user=%s",
+			payload.Event.Type, payload.Event.Channel, payload.Event.User)
+	}
+
+	// Reply as the workspace the event came from
+	isEnterpriseInstall := len(payload.Authorizations) > 0 && payload.Authorizations[0].IsEnterpriseInstall
+	teamID := setSlackTeam(c, payload.TeamID, payload.EnterpriseID, isEnterpriseInstall)
+	slack = slack.ForTeam(teamID)
+
+	// Publish the review dashboard whenever the user opens the Home tab.
+	// Slack expects the ack within 3 seconds, so render it in the background.
+	if payload.Event.Type == "app_home_opened" && payload.Event.Tab == "home" && payload.Event.User != "" {
+		services.Go(func() { publishHomeView(db, slack, teamID, payload.Event.User) })
+	}
+	c.Status(http.StatusOK)
+}
+
+// publishHomeView publishes the App Home tab for a user of the team, logging
+// failures.
+func publishHomeView(db *gorm.DB, slack services.SlackClient, teamID, userID string) {
+	if err := services.PublishHomeView(db, slack, teamID, userID); err != nil {
+		log.Printf("failed to publish home view (user: %s): %v", userID, err)
 	}
 }
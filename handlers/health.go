@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"slack-review-notify/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// HandleHealthz is the liveness probe. It fails when a background loop has
+// not completed a pass for LOOP_STALE_INTERVALS intervals, so the pod is
+// restarted instead of silently dropping reminders.
+func HandleHealthz() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var stalled []string
+		for _, loop := range services.LoopStatuses() {
+			if loop.Stalled {
+				stalled = append(stalled, loop.Name)
+			}
+		}
+
+		if len(stalled) > 0 {
+			log.Printf("healthz: stalled background loops: %v", stalled)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "stalled", "stalled_loops": stalled})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
+
+// HandleReadyz is the readiness probe. It fails when the database cannot be
+// reached, and reports when each background loop last completed.
+func HandleReadyz(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		loops := make([]gin.H, 0)
+		for _, loop := range services.LoopStatuses() {
+			var lastCompleted interface{}
+			if loop.LastCompleted != nil {
+				lastCompleted = loop.LastCompleted.UTC().Format(time.RFC3339)
+			}
+			loops = append(loops, gin.H{
+				"name":              loop.Name,
+				"interval_seconds":  int(loop.Interval.Seconds()),
+				"last_completed_at": lastCompleted,
+				"stalled":           loop.Stalled,
+			})
+		}
+
+		status, database := http.StatusOK, "ok"
+		if err := pingDB(db); err != nil {
+			log.Printf("readyz: database ping error: %v", err)
+			status, database = http.StatusServiceUnavailable, "unavailable"
+		}
+
+		body := gin.H{"status": "ok", "database": database, "loops": loops}
+		if status != http.StatusOK {
+			body["status"] = "not_ready"
+		}
+		c.JSON(status, body)
+	}
+}
+
+func pingDB(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Ping()
+}
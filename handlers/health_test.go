@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slack-review-notify/services"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleHealthz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/healthz", HandleHealthz())
+
+	services.RegisterLoop("test_loop", time.Hour)
+	services.MarkLoopCompleted("test_loop")
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// A loop that cannot complete within LOOP_STALE_INTERVALS intervals is stalled
+	t.Setenv("LOOP_STALE_INTERVALS", "1")
+	services.RegisterLoop("test_loop", time.Nanosecond)
+	t.Cleanup(func() { services.RegisterLoop("test_loop", time.Hour) })
+	time.Sleep(time.Millisecond)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), `"stalled_loops":["test_loop"]`)
+}
+
+func TestHandleReadyz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+	router := gin.New()
+	router.GET("/readyz", HandleReadyz(db))
+
+	services.RegisterLoop("test_ready_loop", time.Minute)
+	services.MarkLoopCompleted("test_ready_loop")
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var body struct {
+		Status   string `json:"status"`
+		Database string `json:"database"`
+		Loops    []struct {
+			Name            string  `json:"name"`
+			IntervalSeconds int     `json:"interval_seconds"`
+			LastCompletedAt *string `json:"last_completed_at"`
+			Stalled         bool    `json:"stalled"`
+		} `json:"loops"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "ok", body.Status)
+	assert.Equal(t, "ok", body.Database)
+	found := false
+	for _, loop := range body.Loops {
+		if loop.Name == "test_ready_loop" {
+			found = true
+			assert.Equal(t, 60, loop.IntervalSeconds)
+			assert.NotNil(t, loop.LastCompletedAt)
+			assert.False(t, loop.Stalled)
+		}
+	}
+	assert.True(t, found)
+
+	// A closed database makes the pod unready
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), `"database":"unavailable"`)
+}
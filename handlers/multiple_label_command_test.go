@@ -9,7 +9,6 @@ import (
 	"time"
 
 	"slack-review-notify/models"
-	"slack-review-notify/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/h2non/gock"
@@ -69,7 +68,6 @@ func TestMultipleLabelCommandHandler(t *testing.T) {
 			// Setup
 			db := setupTestDB(t)
 			gin.SetMode(gin.TestMode)
-			services.IsTestMode = true
 
 			// Create existing config if provided
 			if tt.setupConfig != nil {
@@ -88,14 +86,10 @@ func TestMultipleLabelCommandHandler(t *testing.T) {
 			req, _ := http.NewRequest("POST", "/slack/command", strings.NewReader(data.Encode()))
 			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-			// Mock Slack signature verification (skipped in test mode)
-			req.Header.Set("X-Slack-Signature", "test")
-			req.Header.Set("X-Slack-Request-Timestamp", "1234567890")
-
 			w := httptest.NewRecorder()
 
 			// Create Gin router and execute request
-			router := gin.Default()
+			router := newSlackTestRouter(t)
 			router.POST("/slack/command", HandleSlackCommand(db))
 			router.ServeHTTP(w, req)
 
@@ -132,7 +126,6 @@ func TestMultipleLabelHelp(t *testing.T) {
 	// Setup
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
 
 	// Request for the help command
 	data := url.Values{}
@@ -143,12 +136,10 @@ func TestMultipleLabelHelp(t *testing.T) {
 
 	req, _ := http.NewRequest("POST", "/slack/command", strings.NewReader(data.Encode()))
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("X-Slack-Signature", "test")
-	req.Header.Set("X-Slack-Request-Timestamp", "1234567890")
 
 	w := httptest.NewRecorder()
 
-	router := gin.Default()
+	router := newSlackTestRouter(t)
 	router.POST("/slack/command", HandleSlackCommand(db))
 	router.ServeHTTP(w, req)
 
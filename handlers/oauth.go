@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"slack-review-notify/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// slackOAuthStateCookie carries the state of an install from /slack/install
+// to the OAuth callback, so a callback not started by this browser is refused.
+const slackOAuthStateCookie = "slack_oauth_state"
+
+// slackOAuthStateMaxAge is how long, in seconds, an install may take.
+const slackOAuthStateMaxAge = 600
+
+// HandleSlackInstall starts the OAuth install flow by sending the browser to
+// Slack's consent page. It is disabled unless SLACK_CLIENT_ID and
+// SLACK_CLIENT_SECRET are set.
+func HandleSlackInstall() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !services.IsSlackOAuthEnabled() {
+			c.JSON(http.StatusNotFound, gin.H{"error": "slack oauth is not configured"})
+			return
+		}
+
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			log.Printf("failed to generate oauth state: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start install"})
+			return
+		}
+		state := hex.EncodeToString(buf)
+
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(slackOAuthStateCookie, state, slackOAuthStateMaxAge, "/slack/", "", c.Request.TLS != nil, true)
+		c.Redirect(http.StatusFound, services.SlackOAuthAuthorizeURL(state))
+	}
+}
+
+// HandleSlackOAuthCallback completes the install: it checks the state, trades
+// the code for the workspace's bot token and stores the installation.
+func HandleSlackOAuthCallback(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !services.IsSlackOAuthEnabled() {
+			c.JSON(http.StatusNotFound, gin.H{"error": "slack oauth is not configured"})
+			return
+		}
+
+		// The installer declined on the consent page
+		if reason := c.Query("error"); reason != "" {
+			log.Printf("slack install was not approved: %s", reason)
+			c.String(http.StatusOK, "Installation was cancelled.")
+			return
+		}
+
+		state, err := c.Cookie(slackOAuthStateCookie)
+		given := c.Query("state")
+		if err != nil || given == "" || subtle.ConstantTimeCompare([]byte(given), []byte(state)) != 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid oauth state"})
+			return
+		}
+		c.SetCookie(slackOAuthStateCookie, "", -1, "/slack/", "", c.Request.TLS != nil, true)
+
+		code := c.Query("code")
+		if code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+			return
+		}
+
+		installation, err := services.ExchangeSlackOAuthCode(code)
+		if err != nil {
+			log.Printf("slack oauth exchange error: %v", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "failed to complete install"})
+			return
+		}
+		if err := services.SaveSlackInstallation(db, installation); err != nil {
+			log.Printf("slack installation save error (team: %s): %v", installation.TeamID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save installation"})
+			return
+		}
+
+		log.Printf("slack app installed to team %s (%s) by %s", installation.TeamID, installation.TeamName, installation.InstalledBy)
+		c.String(http.StatusOK, "slack-review-notify is installed to %s. Invite the bot to a channel and run /slack-review-notify help.", installation.TeamName)
+	}
+}
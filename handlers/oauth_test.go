@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slack-review-notify/models"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupOAuthRouter(db *gorm.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/slack/install", HandleSlackInstall())
+	r.GET("/slack/oauth/callback", HandleSlackOAuthCallback(db))
+	return r
+}
+
+func TestSlackOAuth_Disabled(t *testing.T) {
+	t.Setenv("SLACK_CLIENT_ID", "")
+	t.Setenv("SLACK_CLIENT_SECRET", "")
+	router := setupOAuthRouter(setupTestDB(t))
+
+	for _, path := range []string{"/slack/install", "/slack/oauth/callback?code=x&state=y"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+	}
+}
+
+func TestSlackOAuth_InstallFlow(t *testing.T) {
+	t.Setenv("SLACK_CLIENT_ID", "123.456")
+	t.Setenv("SLACK_CLIENT_SECRET", "secret")
+	db := setupTestDB(t)
+	router := setupOAuthRouter(db)
+
+	// /slack/install redirects to Slack with a state remembered in a cookie
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/slack/install", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, "/oauth/v2/authorize", location.Path)
+	state := location.Query().Get("state")
+	assert.NotEmpty(t, state)
+	cookies := w.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+	assert.Equal(t, state, cookies[0].Value)
+
+	callback := func(query string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/slack/oauth/callback?"+query, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// A callback without the state of this browser is refused
+	assert.Equal(t, http.StatusBadRequest, callback("code=c1&state="+state, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, callback("code=c1&state=forged", cookies[0]).Code)
+
+	defer gock.Off()
+	gock.New("https://slack.com").
+		Post("/api/oauth.v2.access").
+		Reply(200).
+		JSON(map[string]interface{}{
+			"ok":           true,
+			"access_token": "xoxb-team-1",
+			"token_type":   "bot",
+			"bot_user_id":  "UBOT",
+			"team":         map[string]interface{}{"id": "T1", "name": "Team One"},
+			"authed_user":  map[string]interface{}{"id": "UINSTALLER"},
+		})
+
+	w = callback("code=c1&state="+state, cookies[0])
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Team One")
+
+	var installation models.SlackInstallation
+	if assert.NoError(t, db.First(&installation, "team_id = ?", "T1").Error) {
+		assert.Equal(t, "xoxb-team-1", installation.BotToken)
+		assert.Equal(t, "UINSTALLER", installation.InstalledBy)
+	}
+}
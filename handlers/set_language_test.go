@@ -15,7 +15,7 @@ import (
 // Test 1: set-language en updates existing config to English
 func TestSetLanguage_UpdateToEnglish(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupTestRouter(db)
+	router := setupTestRouter(t, db)
 
 	// Create existing config with default language (ja)
 	db.Create(&models.ChannelConfig{
@@ -54,7 +54,7 @@ func TestSetLanguage_UpdateToEnglish(t *testing.T) {
 // Test 2: set-language ja updates existing config to Japanese
 func TestSetLanguage_UpdateToJapanese(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupTestRouter(db)
+	router := setupTestRouter(t, db)
 
 	// Create existing config with English
 	db.Create(&models.ChannelConfig{
@@ -92,7 +92,7 @@ func TestSetLanguage_UpdateToJapanese(t *testing.T) {
 // Test 3: set-language fr rejects invalid language
 func TestSetLanguage_InvalidLanguage(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupTestRouter(db)
+	router := setupTestRouter(t, db)
 
 	form := url.Values{}
 	form.Add("command", "/slack-review-notify")
@@ -114,7 +114,7 @@ func TestSetLanguage_InvalidLanguage(t *testing.T) {
 // Test 4: set-language en creates new config when none exists
 func TestSetLanguage_CreatesNewConfig(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupTestRouter(db)
+	router := setupTestRouter(t, db)
 
 	form := url.Values{}
 	form.Add("command", "/slack-review-notify")
@@ -144,7 +144,7 @@ func TestSetLanguage_CreatesNewConfig(t *testing.T) {
 // Test 5: set-language with no argument shows usage
 func TestSetLanguage_NoArgument(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupTestRouter(db)
+	router := setupTestRouter(t, db)
 
 	form := url.Values{}
 	form.Add("command", "/slack-review-notify")
@@ -166,7 +166,7 @@ func TestSetLanguage_NoArgument(t *testing.T) {
 // Test 6: set-language with specific label
 func TestSetLanguage_WithSpecificLabel(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupTestRouter(db)
+	router := setupTestRouter(t, db)
 
 	// Create existing config for "bug" label
 	db.Create(&models.ChannelConfig{
@@ -204,7 +204,7 @@ func TestSetLanguage_WithSpecificLabel(t *testing.T) {
 // Test 7: help command returns English when channel is set to English
 func TestHelp_ReturnsEnglishWhenLanguageIsEn(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupTestRouter(db)
+	router := setupTestRouter(t, db)
 
 	// Create config with English language
 	db.Create(&models.ChannelConfig{
@@ -241,7 +241,7 @@ func TestHelp_ReturnsEnglishWhenLanguageIsEn(t *testing.T) {
 // Test 8: show command returns English when channel is set to English
 func TestShow_ReturnsEnglishWhenLanguageIsEn(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupTestRouter(db)
+	router := setupTestRouter(t, db)
 
 	// Create config with English language
 	db.Create(&models.ChannelConfig{
@@ -17,11 +17,11 @@ import (
 // Matches the slash-command path's default in resolveTimezone.
 const defaultModalTimezone = "Asia/Tokyo"
 
-// loadChannelConfigs returns every ChannelConfig row for a channel, used to
-// populate the modal's label dropdown.
-func loadChannelConfigs(db *gorm.DB, channelID string) []*models.ChannelConfig {
+// loadChannelConfigs returns every ChannelConfig row the team has for a
+// channel, used to populate the modal's label dropdown.
+func loadChannelConfigs(db *gorm.DB, teamID, channelID string) []*models.ChannelConfig {
 	var configs []*models.ChannelConfig
-	if err := db.Where("slack_channel_id = ?", channelID).Order("label_name").Find(&configs).Error; err != nil {
+	if err := db.Where("team_id = ? AND slack_channel_id = ?", teamID, channelID).Order("label_name").Find(&configs).Error; err != nil {
 		log.Printf("loadChannelConfigs failed: %v", err)
 		return nil
 	}
@@ -71,7 +71,7 @@ func pickModalLanguage(configs []*models.ChannelConfig, selectedLabel string) st
 // sentinel). We load all the channel's label configs and open the modal with
 // the dropdown preselected accordingly. Slack's trigger_id expires ~3 seconds
 // after issue, so views.open runs asynchronously.
-func handleOpenSettings(c *gin.Context, db *gorm.DB, payload SlackActionPayload) {
+func handleOpenSettings(c slackResponder, db *gorm.DB, slack services.SlackClient, payload SlackActionPayload) {
 	channelID := payload.Container.ChannelID
 	userID := payload.User.ID
 	selectedLabel := payload.Actions[0].Value
@@ -79,7 +79,7 @@ func handleOpenSettings(c *gin.Context, db *gorm.DB, payload SlackActionPayload)
 		selectedLabel = "needs-review"
 	}
 
-	configs := loadChannelConfigs(db, channelID)
+	configs := loadChannelConfigs(db, slackTeamID(c), channelID)
 	lang := pickModalLanguage(configs, selectedLabel)
 
 	view := services.BuildSettingsModalView(services.SettingsModalInputs{
@@ -91,11 +91,11 @@ func handleOpenSettings(c *gin.Context, db *gorm.DB, payload SlackActionPayload)
 	})
 	triggerID := payload.TriggerID
 
-	go func() {
-		if err := services.OpenView(triggerID, view); err != nil {
+	services.Go(func() {
+		if err := slack.OpenView(triggerID, view); err != nil {
 			log.Printf("views.open failed: %v", err)
 		}
-	}()
+	})
 
 	c.Status(http.StatusOK)
 }
@@ -105,7 +105,7 @@ func handleOpenSettings(c *gin.Context, db *gorm.DB, payload SlackActionPayload)
 // we rebuild the modal with the chosen label's prefilled values and push the
 // new view via views.update — Slack does NOT persist field values across
 // re-renders, so the other fields visually "reset" to the selected label.
-func handleLabelSelectChanged(c *gin.Context, db *gorm.DB, payload SlackActionPayload) {
+func handleLabelSelectChanged(c slackResponder, db *gorm.DB, slack services.SlackClient, payload SlackActionPayload) {
 	if payload.View == nil {
 		c.Status(http.StatusOK)
 		return
@@ -122,7 +122,7 @@ func handleLabelSelectChanged(c *gin.Context, db *gorm.DB, payload SlackActionPa
 		newSelected = services.CreateNewLabelSentinel
 	}
 
-	configs := loadChannelConfigs(db, meta.ChannelID)
+	configs := loadChannelConfigs(db, slackTeamID(c), meta.ChannelID)
 	lang := pickModalLanguage(configs, newSelected)
 
 	view := services.BuildSettingsModalView(services.SettingsModalInputs{
@@ -134,11 +134,11 @@ func handleLabelSelectChanged(c *gin.Context, db *gorm.DB, payload SlackActionPa
 	})
 
 	viewID := payload.View.ID
-	go func() {
-		if err := services.UpdateView(viewID, view); err != nil {
+	services.Go(func() {
+		if err := slack.UpdateView(viewID, view); err != nil {
 			log.Printf("views.update failed: %v", err)
 		}
-	}()
+	})
 
 	c.Status(http.StatusOK)
 }
@@ -147,7 +147,7 @@ func handleLabelSelectChanged(c *gin.Context, db *gorm.DB, payload SlackActionPa
 // the change: upsert when editing/creating, soft-delete when the delete
 // checkbox is checked. The target (channel, label) is derived from form values
 // plus private_metadata, NOT from any free-text field.
-func handleSettingsModalSubmission(c *gin.Context, db *gorm.DB, payload SlackActionPayload) {
+func handleSettingsModalSubmission(c slackResponder, db *gorm.DB, slack services.SlackClient, payload SlackActionPayload) {
 	meta, err := services.DecodeSettingsModalMetadata(payload.View.PrivateMetadata)
 	if err != nil || meta.ChannelID == "" {
 		log.Printf("view_submission has invalid private_metadata: %q (err=%v)", payload.View.PrivateMetadata, err)
@@ -191,7 +191,7 @@ func handleSettingsModalSubmission(c *gin.Context, db *gorm.DB, payload SlackAct
 	// user from recreating a configuration with the same label name.
 	if form.DeleteConfig && !form.CreateNew {
 		var existing models.ChannelConfig
-		if err := db.Where("slack_channel_id = ? AND label_name = ?", meta.ChannelID, form.LabelName).First(&existing).Error; err == nil {
+		if err := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), meta.ChannelID, form.LabelName).First(&existing).Error; err == nil {
 			if err := db.Unscoped().Delete(&existing).Error; err != nil {
 				log.Printf("settings modal delete failed: %v", err)
 				c.JSON(http.StatusOK, gin.H{
@@ -200,9 +200,9 @@ func handleSettingsModalSubmission(c *gin.Context, db *gorm.DB, payload SlackAct
 				})
 				return
 			}
-			if !services.IsTestMode && meta.UserID != "" {
+			if meta.UserID != "" {
 				msg := i18n.TWithLang(form.Language, "modal.deleted", form.LabelName)
-				if err := services.PostEphemeral(meta.ChannelID, meta.UserID, msg); err != nil {
+				if err := slack.PostEphemeral(meta.ChannelID, meta.UserID, msg); err != nil {
 					log.Printf("settings deleted confirmation post failed: %v", err)
 				}
 			}
@@ -214,7 +214,7 @@ func handleSettingsModalSubmission(c *gin.Context, db *gorm.DB, payload SlackAct
 	// Create-new path must not silently overwrite an existing label.
 	if form.CreateNew {
 		var dup models.ChannelConfig
-		if err := db.Where("slack_channel_id = ? AND label_name = ?", meta.ChannelID, form.LabelName).First(&dup).Error; err == nil {
+		if err := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), meta.ChannelID, form.LabelName).First(&dup).Error; err == nil {
 			c.JSON(http.StatusOK, gin.H{
 				"response_action": "errors",
 				"errors":          gin.H{"new_label_name": "this label already has a configuration; pick it from the dropdown instead"},
@@ -224,10 +224,11 @@ func handleSettingsModalSubmission(c *gin.Context, db *gorm.DB, payload SlackAct
 	}
 
 	var cfg models.ChannelConfig
-	result := db.Where("slack_channel_id = ? AND label_name = ?", meta.ChannelID, form.LabelName).First(&cfg)
+	result := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", slackTeamID(c), meta.ChannelID, form.LabelName).First(&cfg)
 	if result.Error != nil {
 		cfg = models.ChannelConfig{
 			ID:             uuid.NewString(),
+			TeamID:         slackTeamID(c),
 			SlackChannelID: meta.ChannelID,
 			LabelName:      form.LabelName,
 			CreatedAt:      now,
@@ -242,6 +243,7 @@ func handleSettingsModalSubmission(c *gin.Context, db *gorm.DB, payload SlackAct
 	cfg.BusinessHoursEnd = form.BusinessHoursEnd
 	cfg.Timezone = form.Timezone
 	cfg.RequiredApprovals = form.RequiredApprovals
+	cfg.ReviewerStrategy = form.ReviewerStrategy
 	cfg.Language = form.Language
 	cfg.IsActive = form.IsActive
 	cfg.UpdatedAt = now
@@ -266,9 +268,9 @@ func handleSettingsModalSubmission(c *gin.Context, db *gorm.DB, payload SlackAct
 		}
 	}
 
-	if !services.IsTestMode && meta.UserID != "" {
+	if meta.UserID != "" {
 		msg := i18n.TWithLang(form.Language, "modal.saved", form.LabelName)
-		if err := services.PostEphemeral(meta.ChannelID, meta.UserID, msg); err != nil {
+		if err := slack.PostEphemeral(meta.ChannelID, meta.UserID, msg); err != nil {
 			log.Printf("settings saved confirmation post failed: %v", err)
 		}
 	}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -15,11 +16,9 @@ import (
 	"gorm.io/gorm"
 )
 
-func setupActionRouter(db *gorm.DB) *gin.Engine {
-	services.IsTestMode = true
-	gin.SetMode(gin.TestMode)
-	r := gin.New()
-	r.POST("/slack/actions", HandleSlackAction(db))
+func setupActionRouter(t *testing.T, db *gorm.DB, slack services.SlackClient) *gin.Engine {
+	r := newSlackTestRouter(t)
+	r.POST("/slack/actions", HandleSlackAction(db, slack))
 	return r
 }
 
@@ -38,7 +37,7 @@ func postPayload(t *testing.T, router http.Handler, payloadJSON string) *httptes
 // includes a button with action_id=open_settings so users can launch the modal.
 func TestHandleSlackCommand_HelpHasOpenSettingsButton(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupTestRouter(db)
+	router := setupTestRouter(t, db)
 
 	form := url.Values{}
 	form.Add("command", "/slack-review-notify")
@@ -58,10 +57,11 @@ func TestHandleSlackCommand_HelpHasOpenSettingsButton(t *testing.T) {
 }
 
 // TestHandleSlackAction_OpenSettings verifies the block_actions payload with action_id=open_settings
-// is accepted (would call views.open in production; IsTestMode skips the call).
+// opens the settings modal.
 func TestHandleSlackAction_OpenSettings(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupActionRouter(db)
+	slack := services.NewFakeSlackClient()
+	router := setupActionRouter(t, db, slack)
 
 	// Existing config so the modal can be pre-filled
 	db.Create(&models.ChannelConfig{
@@ -83,6 +83,12 @@ func TestHandleSlackAction_OpenSettings(t *testing.T) {
 
 	w := postPayload(t, router, payload)
 	assert.Equal(t, http.StatusOK, w.Code, "body: %s", w.Body.String())
+	// The view is opened in the background
+	assert.NoError(t, services.WaitForBackgroundTasks(context.Background()))
+	if views := slack.Views(); assert.Len(t, views, 1) {
+		assert.Equal(t, "views.open", views[0].Method)
+		assert.Equal(t, "trigger-xyz", views[0].Target)
+	}
 }
 
 // TestHandleSlackAction_OpenSettings_NoFallback verifies that when the target label
@@ -91,7 +97,7 @@ func TestHandleSlackAction_OpenSettings(t *testing.T) {
 // (Regression guard for the previous "fallback pre-fills another label" UX bug.)
 func TestHandleSlackAction_OpenSettings_NoFallback(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupActionRouter(db)
+	router := setupActionRouter(t, db, services.NewFakeSlackClient())
 
 	// Unrelated label exists; the modal must NOT pre-fill from it.
 	db.Create(&models.ChannelConfig{
@@ -123,7 +129,7 @@ func TestHandleSlackAction_OpenSettings_NoFallback(t *testing.T) {
 // and a label name is provided in the new_label_name input.
 func TestHandleSlackAction_ViewSubmission_CreatesConfig(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupActionRouter(db)
+	router := setupActionRouter(t, db, services.NewFakeSlackClient())
 
 	payload := `{
 		"type": "view_submission",
@@ -175,7 +181,7 @@ func TestHandleSlackAction_ViewSubmission_CreatesConfig(t *testing.T) {
 // TestHandleSlackAction_ViewSubmission_UpdatesConfig verifies an existing label config is updated.
 func TestHandleSlackAction_ViewSubmission_UpdatesConfig(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupActionRouter(db)
+	router := setupActionRouter(t, db, services.NewFakeSlackClient())
 
 	db.Create(&models.ChannelConfig{
 		ID:                       "existing",
@@ -210,6 +216,7 @@ func TestHandleSlackAction_ViewSubmission_UpdatesConfig(t *testing.T) {
 					"business_hours_end": {"business_hours_end": {"value": "18:30"}},
 					"timezone": {"timezone": {"value": "Asia/Tokyo"}},
 					"required_approvals": {"required_approvals": {"value": "3"}},
+					"reviewer_strategy": {"reviewer_strategy": {"selected_option": {"value": "round-robin"}}},
 					"language": {"language": {"selected_option": {"value": "ja"}}},
 					"is_active": {"is_active": {"selected_option": {"value": "false"}}}
 				}
@@ -229,6 +236,7 @@ func TestHandleSlackAction_ViewSubmission_UpdatesConfig(t *testing.T) {
 	assert.Equal(t, "09:30", cfg.BusinessHoursStart)
 	assert.Equal(t, "18:30", cfg.BusinessHoursEnd)
 	assert.Equal(t, 3, cfg.RequiredApprovals)
+	assert.Equal(t, "round-robin", cfg.ReviewerStrategy)
 	assert.False(t, cfg.IsActive)
 
 	// Only one row should exist (no duplicate from upsert)
@@ -241,7 +249,7 @@ func TestHandleSlackAction_ViewSubmission_UpdatesConfig(t *testing.T) {
 // response_action: errors body so Slack shows inline errors.
 func TestHandleSlackAction_ViewSubmission_ValidationError(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupActionRouter(db)
+	router := setupActionRouter(t, db, services.NewFakeSlackClient())
 
 	payload := `{
 		"type": "view_submission",
@@ -284,7 +292,7 @@ func TestHandleSlackAction_ViewSubmission_ValidationError(t *testing.T) {
 // removed (soft-deleted via gorm).
 func TestHandleSlackAction_ViewSubmission_DeletesConfig(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupActionRouter(db)
+	router := setupActionRouter(t, db, services.NewFakeSlackClient())
 
 	db.Create(&models.ChannelConfig{
 		ID:               "victim",
@@ -320,12 +328,12 @@ func TestHandleSlackAction_ViewSubmission_DeletesConfig(t *testing.T) {
 }
 
 // TestHandleSlackAction_LabelSelectDispatch verifies the label dropdown's
-// dispatch_action fires a views.update flow without erroring. The actual
-// views.update call is short-circuited by IsTestMode; here we just confirm the
-// block_actions branch is routed correctly and acknowledges with 200.
+// dispatch_action is routed to the block_actions branch, acknowledged with
+// 200 and re-renders the open modal with views.update.
 func TestHandleSlackAction_LabelSelectDispatch(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupActionRouter(db)
+	slack := services.NewFakeSlackClient()
+	router := setupActionRouter(t, db, slack)
 
 	db.Create(&models.ChannelConfig{
 		ID:               "c1",
@@ -350,6 +358,12 @@ func TestHandleSlackAction_LabelSelectDispatch(t *testing.T) {
 
 	w := postPayload(t, router, payload)
 	assert.Equal(t, http.StatusOK, w.Code, "body: %s", w.Body.String())
+	// The view is updated in the background
+	assert.NoError(t, services.WaitForBackgroundTasks(context.Background()))
+	if views := slack.Views(); assert.Len(t, views, 1) {
+		assert.Equal(t, "views.update", views[0].Method)
+		assert.Equal(t, "V1", views[0].Target)
+	}
 }
 
 // TestHandleSlackAction_ViewSubmission_CreateNewDuplicateRejected verifies
@@ -358,7 +372,7 @@ func TestHandleSlackAction_LabelSelectDispatch(t *testing.T) {
 // existing row through the rename footgun.
 func TestHandleSlackAction_ViewSubmission_CreateNewDuplicateRejected(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupActionRouter(db)
+	router := setupActionRouter(t, db, services.NewFakeSlackClient())
 
 	db.Create(&models.ChannelConfig{
 		ID:             "existing",
@@ -412,7 +426,7 @@ func TestHandleSlackAction_ViewSubmission_CreateNewDuplicateRejected(t *testing.
 // from the UI rather than being stuck on the previously hardcoded "needs-review".
 func TestHandleSlackCommand_HelpListsPerLabelButtons(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupTestRouter(db)
+	router := setupTestRouter(t, db)
 
 	db.Create(&models.ChannelConfig{ID: "a", SlackChannelID: "C12345", LabelName: "needs-review", IsActive: true})
 	db.Create(&models.ChannelConfig{ID: "b", SlackChannelID: "C12345", LabelName: "urgent", IsActive: true})
@@ -474,7 +488,7 @@ func TestHandleSlackCommand_HelpListsPerLabelButtons(t *testing.T) {
 // blocks the recreate INSERT at the DB layer.
 func TestHandleSlackAction_ViewSubmission_DeleteThenRecreate(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupActionRouter(db)
+	router := setupActionRouter(t, db, services.NewFakeSlackClient())
 
 	db.Create(&models.ChannelConfig{
 		ID:               "victim",
@@ -545,7 +559,7 @@ func TestHandleSlackAction_ViewSubmission_DeleteThenRecreate(t *testing.T) {
 // loses modal context can't silently no-op or write to the wrong row.
 func TestHandleSlackAction_ViewSubmission_MissingPrivateMetadata(t *testing.T) {
 	db := setupTestDB(t)
-	router := setupActionRouter(db)
+	router := setupActionRouter(t, db, services.NewFakeSlackClient())
 
 	payload := `{
 		"type": "view_submission",
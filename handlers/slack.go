@@ -29,7 +29,14 @@ type SlackActionPayload struct {
 	User      struct {
 		ID string `json:"id"`
 	} `json:"user"`
-	Actions []struct {
+	Team struct {
+		ID string `json:"id"`
+	} `json:"team"`
+	Enterprise *struct {
+		ID string `json:"id"`
+	} `json:"enterprise,omitempty"`
+	IsEnterpriseInstall bool `json:"is_enterprise_install"`
+	Actions             []struct {
 		ActionID string `json:"action_id"`
 		Value    string `json:"value,omitempty"`
 		// Fields for selection menu
@@ -52,6 +59,7 @@ type SlackActionPayload struct {
 // SlackViewPayload is the subset of the view object Slack sends with view_submission.
 type SlackViewPayload struct {
 	ID              string `json:"id"`
+	Type            string `json:"type"`
 	CallbackID      string `json:"callback_id"`
 	PrivateMetadata string `json:"private_metadata"`
 	State           struct {
@@ -59,7 +67,7 @@ type SlackViewPayload struct {
 	} `json:"state"`
 }
 
-func HandleSlackAction(db *gorm.DB) gin.HandlerFunc {
+func HandleSlackAction(db *gorm.DB, slack services.SlackClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		bodyBytes, err := io.ReadAll(c.Request.Body)
 		if err != nil {
@@ -88,307 +96,366 @@ func HandleSlackAction(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Handle view_submission (settings modal save) before the block_actions logic.
-		if payload.Type == "view_submission" && payload.View != nil && payload.View.CallbackID == services.SettingsModalCallbackID {
-			handleSettingsModalSubmission(c, db, payload)
-			return
-		}
-		if payload.Type == "view_submission" && payload.View != nil && payload.View.CallbackID == services.AwayManagementModalCallbackID {
-			handleAwayModalSubmission(c, db, payload)
-			return
-		}
-		if payload.Type == "view_submission" && payload.View != nil && payload.View.CallbackID == services.UserMappingModalCallbackID {
-			handleUserMappingModalSubmission(c, db, payload)
-			return
-		}
+		processSlackAction(c, db, slack, payload)
+	}
+}
+
+// processSlackAction handles a block action or view submission and answers it
+// through c.
+func processSlackAction(c slackResponder, db *gorm.DB, slack services.SlackClient, payload SlackActionPayload) {
+	// Scope the rows and the Slack calls to the team the action came from
+	var enterpriseID string
+	if payload.Enterprise != nil {
+		enterpriseID = payload.Enterprise.ID
+	}
+	slack = slack.ForTeam(setSlackTeam(c, payload.Team.ID, enterpriseID, payload.IsEnterpriseInstall))
+
+	// Handle view_submission (settings modal save) before the block_actions logic.
+	if payload.Type == "view_submission" && payload.View != nil && payload.View.CallbackID == services.SettingsModalCallbackID {
+		handleSettingsModalSubmission(c, db, slack, payload)
+		return
+	}
+	if payload.Type == "view_submission" && payload.View != nil && payload.View.CallbackID == services.AwayManagementModalCallbackID {
+		handleAwayModalSubmission(c, db, slack, payload)
+		return
+	}
+	if payload.Type == "view_submission" && payload.View != nil && payload.View.CallbackID == services.UserMappingModalCallbackID {
+		handleUserMappingModalSubmission(c, db, slack, payload)
+		return
+	}
 
-		slackUserID := payload.User.ID
-		ts := payload.Message.Ts
-		channel := payload.Container.ChannelID
+	slackUserID := payload.User.ID
+	ts := payload.Message.Ts
+	channel := payload.Container.ChannelID
 
-		log.Printf("slack action received: ts=%s, channel=%s, userID=%s", ts, channel, slackUserID)
+	log.Printf("slack action received: ts=%s, channel=%s, userID=%s", ts, channel, slackUserID)
 
-		// Error if no actions are present
-		if len(payload.Actions) == 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "no action provided"})
-			return
-		}
+	// Error if no actions are present
+	if len(payload.Actions) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no action provided"})
+		return
+	}
 
-		// Get the action ID
-		actionID := payload.Actions[0].ActionID
+	// Get the action ID
+	actionID := payload.Actions[0].ActionID
+
+	// Handle "Open Settings" button: opens the settings modal via views.open.
+	// The help command renders one button per label, each with a unique
+	// action_id like "open_settings:<index>" (Slack requires action_id
+	// uniqueness within an actions block). Route on prefix; the target
+	// label is carried in the button's value field, not the action_id.
+	if actionID == actionOpenSettings || strings.HasPrefix(actionID, actionOpenSettings+":") {
+		handleOpenSettings(c, db, slack, payload)
+		return
+	}
 
-		// Handle "Open Settings" button: opens the settings modal via views.open.
-		// The help command renders one button per label, each with a unique
-		// action_id like "open_settings:<index>" (Slack requires action_id
-		// uniqueness within an actions block). Route on prefix; the target
-		// label is carried in the button's value field, not the action_id.
-		if actionID == actionOpenSettings || strings.HasPrefix(actionID, actionOpenSettings+":") {
-			handleOpenSettings(c, db, payload)
-			return
+	// "🌴 Manage availability" button → open the away-management modal.
+	if actionID == services.OpenAwayManagementActionID {
+		handleOpenAwayManagement(c, db, slack, payload)
+		return
+	}
+
+	// "👥 User mapping" button → open the user-mapping modal.
+	if actionID == services.OpenUserMappingActionID {
+		handleOpenUserMapping(c, db, slack, payload)
+		return
+	}
+
+	// "DM digest" toggle on the App Home tab
+	if actionID == services.ToggleDMDigestActionID {
+		handleToggleDMDigest(c, db, slack, payload)
+		return
+	}
+
+	// Label dropdown changed inside the settings modal → re-render via views.update
+	// so prefilled values reflect the newly chosen label (or the create-new mode).
+	if actionID == services.LabelSelectActionID && payload.View != nil {
+		handleLabelSelectChanged(c, db, slack, payload)
+		return
+	}
+
+	// Handle "Pause Reminder" selection menu
+	if actionID == "pause_reminder" || actionID == "pause_reminder_initial" {
+		// Get value from selection menu
+		var selectedValue string
+		if payload.Actions[0].SelectedOption.Value != "" {
+			selectedValue = payload.Actions[0].SelectedOption.Value
+		} else {
+			selectedValue = payload.Actions[0].Value
 		}
 
-		// "🌴 Manage availability" button → open the away-management modal.
-		if actionID == services.OpenAwayManagementActionID {
-			handleOpenAwayManagement(c, db, payload)
+		if selectedValue == "" {
+			log.Printf("selected value is empty")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "selected value is empty"})
 			return
 		}
 
-		// "👥 User mapping" button → open the user-mapping modal.
-		if actionID == services.OpenUserMappingActionID {
-			handleOpenUserMapping(c, db, payload)
+		// Extract task ID and duration from value (format: "taskID:duration")
+		parts := strings.Split(selectedValue, ":")
+		if len(parts) != 2 {
+			log.Printf("invalid value format: %s", selectedValue)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid value format"})
 			return
 		}
 
-		// Label dropdown changed inside the settings modal → re-render via views.update
-		// so prefilled values reflect the newly chosen label (or the create-new mode).
-		if actionID == services.LabelSelectActionID && payload.View != nil {
-			handleLabelSelectChanged(c, db, payload)
+		taskID := parts[0]
+		duration := parts[1]
+
+		// Search for task directly in database using task ID
+		var taskToUpdate models.ReviewTask
+		if err := db.Where("id = ?", taskID).First(&taskToUpdate).Error; err != nil {
+			log.Printf("task id %s not found: %v", taskID, err)
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found by ID"})
 			return
 		}
 
-		// Handle "Pause Reminder" selection menu
-		if actionID == "pause_reminder" || actionID == "pause_reminder_initial" {
-			// Get value from selection menu
-			var selectedValue string
-			if payload.Actions[0].SelectedOption.Value != "" {
-				selectedValue = payload.Actions[0].SelectedOption.Value
+		// Pause reminder based on the selected duration
+		var pauseUntil time.Time
+
+		switch duration {
+		case "1h":
+			pauseUntil = time.Now().Add(1 * time.Hour)
+			taskToUpdate.ReminderPausedUntil = &pauseUntil
+		case "2h":
+			pauseUntil = time.Now().Add(2 * time.Hour)
+			taskToUpdate.ReminderPausedUntil = &pauseUntil
+		case "4h":
+			pauseUntil = time.Now().Add(4 * time.Hour)
+			taskToUpdate.ReminderPausedUntil = &pauseUntil
+		case "today":
+			// Pause until the next business day's opening time
+			// Get channel config
+			var config models.ChannelConfig
+			if err := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", taskToUpdate.TeamID, taskToUpdate.SlackChannel, taskToUpdate.LabelName).First(&config).Error; err != nil {
+				// Use default (10:00) if config is not found
+				pauseUntil = services.GetNextBusinessDayMorningWithConfig(time.Now(), nil)
 			} else {
-				selectedValue = payload.Actions[0].Value
+				// Use business hours start time from config
+				pauseUntil = services.GetNextBusinessDayMorningWithConfig(time.Now(), &config)
 			}
+			taskToUpdate.ReminderPausedUntil = &pauseUntil
+		case "stop":
+			// Do not notify until a reviewer is assigned
+			taskToUpdate.Status = "paused"
+		default:
+			pauseUntil = time.Now().Add(1 * time.Hour) // Default
+			taskToUpdate.ReminderPausedUntil = &pauseUntil
+		}
 
-			if selectedValue == "" {
-				log.Printf("selected value is empty")
-				c.JSON(http.StatusBadRequest, gin.H{"error": "selected value is empty"})
-				return
-			}
+		db.Save(&taskToUpdate)
 
-			// Extract task ID and duration from value (format: "taskID:duration")
-			parts := strings.Split(selectedValue, ":")
-			if len(parts) != 2 {
-				log.Printf("invalid value format: %s", selectedValue)
-				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid value format"})
-				return
-			}
+		// Notify about the pause
+		err := services.SendReminderPausedMessage(slack, taskToUpdate, duration)
+		if err != nil {
+			log.Printf("pause reminder send error: %v", err)
+		}
 
-			taskID := parts[0]
-			duration := parts[1]
+		refreshHomeViewIfOpen(c, db, slack, payload)
 
-			// Search for task directly in database using task ID
-			var taskToUpdate models.ReviewTask
-			if err := db.Where("id = ?", taskID).First(&taskToUpdate).Error; err != nil {
-				log.Printf("task id %s not found: %v", taskID, err)
-				c.JSON(http.StatusNotFound, gin.H{"error": "task not found by ID"})
-				return
-			}
+		c.Status(http.StatusOK)
+		return
+	}
 
-			// Pause reminder based on the selected duration
-			var pauseUntil time.Time
-
-			switch duration {
-			case "1h":
-				pauseUntil = time.Now().Add(1 * time.Hour)
-				taskToUpdate.ReminderPausedUntil = &pauseUntil
-			case "2h":
-				pauseUntil = time.Now().Add(2 * time.Hour)
-				taskToUpdate.ReminderPausedUntil = &pauseUntil
-			case "4h":
-				pauseUntil = time.Now().Add(4 * time.Hour)
-				taskToUpdate.ReminderPausedUntil = &pauseUntil
-			case "today":
-				// Pause until the next business day's opening time
-				// Get channel config
-				var config models.ChannelConfig
-				if err := db.Where("slack_channel_id = ? AND label_name = ?", taskToUpdate.SlackChannel, taskToUpdate.LabelName).First(&config).Error; err != nil {
-					// Use default (10:00) if config is not found
-					pauseUntil = services.GetNextBusinessDayMorningWithConfig(time.Now(), nil)
-				} else {
-					// Use business hours start time from config
-					pauseUntil = services.GetNextBusinessDayMorningWithConfig(time.Now(), &config)
-				}
-				taskToUpdate.ReminderPausedUntil = &pauseUntil
-			case "stop":
-				// Do not notify until a reviewer is assigned
-				taskToUpdate.Status = "paused"
-			default:
-				pauseUntil = time.Now().Add(1 * time.Hour) // Default
-				taskToUpdate.ReminderPausedUntil = &pauseUntil
+	// Handle each action
+	switch actionID {
+	case "review_done":
+		// Search for task using ts and channel (retry after a short delay if in pending state)
+		var task models.ReviewTask
+		const maxRetries = 5
+		const retryDelay = 200 * time.Millisecond
+
+		var err error
+		for retry := 0; retry < maxRetries; retry++ {
+			err = db.Where("slack_ts = ? AND slack_channel = ?", ts, channel).First(&task).Error
+			if err == nil {
+				break
 			}
 
-			db.Save(&taskToUpdate)
-
-			// Notify about the pause
-			err := services.SendReminderPausedMessage(taskToUpdate, duration)
-			if err != nil {
-				log.Printf("pause reminder send error: %v", err)
+			// If record not found, wait briefly and retry
+			if retry < maxRetries-1 {
+				log.Printf("task not found (attempt %d/%d): ts=%s, channel=%s, retrying in %v",
+					retry+1, maxRetries, ts, channel, retryDelay)
+				time.Sleep(retryDelay)
 			}
+		}
 
-			c.Status(http.StatusOK)
+		if err != nil {
+			log.Printf("task not found after %d retries: ts=%s, channel=%s", maxRetries, ts, channel)
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
 			return
 		}
 
-		// Handle each action
-		switch actionID {
-		case "review_done":
-			// Search for task using ts and channel (retry after a short delay if in pending state)
-			var task models.ReviewTask
-			const maxRetries = 5
-			const retryDelay = 200 * time.Millisecond
-
-			var err error
-			for retry := 0; retry < maxRetries; retry++ {
-				err = db.Where("slack_ts = ? AND slack_channel = ?", ts, channel).First(&task).Error
-				if err == nil {
-					break
-				}
-
-				// If record not found, wait briefly and retry
-				if retry < maxRetries-1 {
-					log.Printf("task not found (attempt %d/%d): ts=%s, channel=%s, retrying in %v",
-						retry+1, maxRetries, ts, channel, retryDelay)
-					time.Sleep(retryDelay)
-				}
-			}
-
-			if err != nil {
-				log.Printf("task not found after %d retries: ts=%s, channel=%s", maxRetries, ts, channel)
-				c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
-				return
-			}
-
-			// Post review completion notification to thread
-			t := i18n.L(task.Language)
-			message := t("notify.review_done_button", slackUserID)
-			if err := services.PostToThread(task.SlackChannel, task.SlackTS, message); err != nil {
-				log.Printf("review done notification error: %v", err)
-			}
-
-			// Change status to done
-			task.Status = "done"
-			task.UpdatedAt = time.Now()
+		// Post review completion notification to thread
+		t := i18n.L(task.Language)
+		message := t("notify.review_done_button", slackUserID)
+		if err := services.PostToThread(slack, task.SlackChannel, task.SlackTS, message); err != nil {
+			log.Printf("review done notification error: %v", err)
+		}
 
-			if err := db.Save(&task).Error; err != nil {
-				log.Printf("task save error: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save task"})
-				return
-			}
+		// Change status to done
+		task.Status = "done"
+		task.UpdatedAt = time.Now()
 
-			c.Status(http.StatusOK)
+		if err := db.Save(&task).Error; err != nil {
+			log.Printf("task save error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save task"})
 			return
+		}
 
-		case "change_reviewer":
-			// Parse value: "taskID" or "taskID:replacingReviewerID" format
-			actionValue := payload.Actions[0].Value
-			var taskID string
-			var replacingReviewerID string
-			if idx := strings.Index(actionValue, ":"); idx >= 0 {
-				taskID = actionValue[:idx]
-				replacingReviewerID = actionValue[idx+1:]
-			} else {
-				taskID = actionValue
-			}
+		c.Status(http.StatusOK)
+		return
+
+	case "change_reviewer":
+		// Parse value: "taskID" or "taskID:replacingReviewerID" format
+		actionValue := payload.Actions[0].Value
+		var taskID string
+		var replacingReviewerID string
+		if idx := strings.Index(actionValue, ":"); idx >= 0 {
+			taskID = actionValue[:idx]
+			replacingReviewerID = actionValue[idx+1:]
+		} else {
+			taskID = actionValue
+		}
 
-			// Search for task in database using task ID
-			var taskToUpdate models.ReviewTask
-			if err := db.Where("id = ?", taskID).First(&taskToUpdate).Error; err != nil {
-				log.Printf("task id %s not found: %v", taskID, err)
-				c.JSON(http.StatusNotFound, gin.H{"error": "task not found by ID"})
-				return
-			}
+		// Search for task in database using task ID
+		var taskToUpdate models.ReviewTask
+		if err := db.Where("id = ?", taskID).First(&taskToUpdate).Error; err != nil {
+			log.Printf("task id %s not found: %v", taskID, err)
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found by ID"})
+			return
+		}
 
-			// Use default value if LabelName is not set on existing task
-			if taskToUpdate.LabelName == "" {
-				taskToUpdate.LabelName = "needs-review"
-				db.Save(&taskToUpdate)
-			}
+		// Use default value if LabelName is not set on existing task
+		if taskToUpdate.LabelName == "" {
+			taskToUpdate.LabelName = "needs-review"
+			db.Save(&taskToUpdate)
+		}
 
-			// Exclusions: PR author + other current reviewers
-			excludeIDs := []string{}
-			if taskToUpdate.PRAuthorSlackID != "" {
-				excludeIDs = append(excludeIDs, taskToUpdate.PRAuthorSlackID)
-			}
-			if taskToUpdate.Reviewers != "" {
-				for _, id := range strings.Split(taskToUpdate.Reviewers, ",") {
-					if trimmed := strings.TrimSpace(id); trimmed != "" {
-						excludeIDs = append(excludeIDs, trimmed)
-					}
+		// Exclusions: PR author + other current reviewers
+		excludeIDs := []string{}
+		if taskToUpdate.PRAuthorSlackID != "" {
+			excludeIDs = append(excludeIDs, taskToUpdate.PRAuthorSlackID)
+		}
+		if taskToUpdate.Reviewers != "" {
+			for _, id := range strings.Split(taskToUpdate.Reviewers, ",") {
+				if trimmed := strings.TrimSpace(id); trimmed != "" {
+					excludeIDs = append(excludeIDs, trimmed)
 				}
-			} else if taskToUpdate.Reviewer != "" {
-				excludeIDs = append(excludeIDs, taskToUpdate.Reviewer)
 			}
+		} else if taskToUpdate.Reviewer != "" {
+			excludeIDs = append(excludeIDs, taskToUpdate.Reviewer)
+		}
 
-			// Select one new reviewer
-			newReviewerIDs := services.SelectRandomReviewers(db, taskToUpdate.SlackChannel, taskToUpdate.LabelName, 1, excludeIDs)
-
-			// No real candidates if SelectRandomReviewers only returned DefaultMentionID
-			noRealCandidate := false
-			if len(newReviewerIDs) == 0 {
-				noRealCandidate = true
-			} else {
-				var cfg models.ChannelConfig
-				if err := db.Where("slack_channel_id = ? AND label_name = ?", taskToUpdate.SlackChannel, taskToUpdate.LabelName).First(&cfg).Error; err == nil {
-					if cfg.ReviewerList != "" && len(newReviewerIDs) == 1 && newReviewerIDs[0] == cfg.DefaultMentionID {
-						noRealCandidate = true
-					}
+		// Select one new reviewer
+		newReviewerIDs := services.SelectRandomReviewers(db, taskToUpdate.TeamID, taskToUpdate.SlackChannel, taskToUpdate.LabelName, 1, excludeIDs)
+
+		// No real candidates if SelectRandomReviewers only returned DefaultMentionID
+		noRealCandidate := false
+		if len(newReviewerIDs) == 0 {
+			noRealCandidate = true
+		} else {
+			var cfg models.ChannelConfig
+			if err := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", taskToUpdate.TeamID, taskToUpdate.SlackChannel, taskToUpdate.LabelName).First(&cfg).Error; err == nil {
+				if cfg.ReviewerList != "" && len(newReviewerIDs) == 1 && newReviewerIDs[0] == cfg.DefaultMentionID {
+					noRealCandidate = true
 				}
 			}
+		}
 
-			if noRealCandidate {
-				t := i18n.L(taskToUpdate.Language)
-				message := t("notify.cannot_change_reviewer")
-				if err := services.PostToThread(taskToUpdate.SlackChannel, taskToUpdate.SlackTS, message); err != nil {
-					log.Printf("notification error: %v", err)
+		if noRealCandidate {
+			t := i18n.L(taskToUpdate.Language)
+			message := t("notify.cannot_change_reviewer")
+			if err := services.PostToThread(slack, taskToUpdate.SlackChannel, taskToUpdate.SlackTS, message); err != nil {
+				log.Printf("notification error: %v", err)
+			}
+			c.Status(http.StatusOK)
+			return
+		}
+		newReviewerID := newReviewerIDs[0]
+
+		// Save the old reviewer ID
+		oldReviewerID := taskToUpdate.Reviewer
+
+		// Update the Reviewers field
+		if replacingReviewerID != "" && taskToUpdate.Reviewers != "" {
+			// Replace a specific reviewer
+			var updatedReviewers []string
+			for _, id := range strings.Split(taskToUpdate.Reviewers, ",") {
+				trimmed := strings.TrimSpace(id)
+				if trimmed == replacingReviewerID {
+					updatedReviewers = append(updatedReviewers, newReviewerID)
+				} else {
+					updatedReviewers = append(updatedReviewers, trimmed)
 				}
-				c.Status(http.StatusOK)
-				return
 			}
-			newReviewerID := newReviewerIDs[0]
-
-			// Save the old reviewer ID
-			oldReviewerID := taskToUpdate.Reviewer
-
-			// Update the Reviewers field
-			if replacingReviewerID != "" && taskToUpdate.Reviewers != "" {
-				// Replace a specific reviewer
+			taskToUpdate.Reviewers = strings.Join(updatedReviewers, ",")
+			oldReviewerID = replacingReviewerID
+		} else {
+			// Backward compatibility: single reviewer change
+			if taskToUpdate.Reviewers != "" {
 				var updatedReviewers []string
 				for _, id := range strings.Split(taskToUpdate.Reviewers, ",") {
 					trimmed := strings.TrimSpace(id)
-					if trimmed == replacingReviewerID {
+					if trimmed == taskToUpdate.Reviewer {
 						updatedReviewers = append(updatedReviewers, newReviewerID)
 					} else {
 						updatedReviewers = append(updatedReviewers, trimmed)
 					}
 				}
 				taskToUpdate.Reviewers = strings.Join(updatedReviewers, ",")
-				oldReviewerID = replacingReviewerID
-			} else {
-				// Backward compatibility: single reviewer change
-				if taskToUpdate.Reviewers != "" {
-					var updatedReviewers []string
-					for _, id := range strings.Split(taskToUpdate.Reviewers, ",") {
-						trimmed := strings.TrimSpace(id)
-						if trimmed == taskToUpdate.Reviewer {
-							updatedReviewers = append(updatedReviewers, newReviewerID)
-						} else {
-							updatedReviewers = append(updatedReviewers, trimmed)
-						}
-					}
-					taskToUpdate.Reviewers = strings.Join(updatedReviewers, ",")
-				}
 			}
+		}
 
-			// Update the Reviewer field (backward compatibility)
-			taskToUpdate.Reviewer = newReviewerID
-			taskToUpdate.UpdatedAt = time.Now()
-			db.Save(&taskToUpdate)
+		// Update the Reviewer field (backward compatibility)
+		taskToUpdate.Reviewer = newReviewerID
+		taskToUpdate.UpdatedAt = time.Now()
+		db.Save(&taskToUpdate)
 
-			// Notify that the reviewer has been changed
-			err := services.SendReviewerChangedMessage(taskToUpdate, oldReviewerID)
-			if err != nil {
-				log.Printf("reviewer change notification error: %v", err)
-			}
+		services.RecordReviewEvent(db, taskToUpdate, services.ReviewEventReviewerReassigned, newReviewerID, oldReviewerID)
 
-			c.Status(http.StatusOK)
-			return
+		// Notify that the reviewer has been changed
+		err := services.SendReviewerChangedMessage(slack, taskToUpdate, oldReviewerID)
+		if err != nil {
+			log.Printf("reviewer change notification error: %v", err)
+		}
+
+		// Swap the review request on GitHub as well (when enabled for the config).
+		// Run it in the background so the action responds within Slack's deadline.
+		removedIDs := []string{}
+		if oldReviewerID != "" {
+			removedIDs = append(removedIDs, oldReviewerID)
 		}
+		services.Go(func() { services.PushReviewersToGitHub(db, taskToUpdate, []string{newReviewerID}, removedIDs) })
+
+		refreshHomeViewIfOpen(c, db, slack, payload)
+
+		c.Status(http.StatusOK)
+		return
 	}
 }
+
+// refreshHomeViewIfOpen re-publishes the App Home tab when the action was
+// taken from it, so the handled review disappears or shows its new state.
+func refreshHomeViewIfOpen(c slackResponder, db *gorm.DB, slack services.SlackClient, payload SlackActionPayload) {
+	if payload.View == nil || payload.View.Type != "home" || payload.User.ID == "" {
+		return
+	}
+	teamID := slackTeamID(c)
+	services.Go(func() { publishHomeView(db, slack, teamID, payload.User.ID) })
+}
+
+// handleToggleDMDigest turns the user's DM digest on or off as the App Home
+// button's value says and re-renders the tab to show the new state.
+func handleToggleDMDigest(c slackResponder, db *gorm.DB, slack services.SlackClient, payload SlackActionPayload) {
+	userID := payload.User.ID
+	enabled := payload.Actions[0].Value == "on"
+	if _, err := services.SetDMDigest(db, slackTeamID(c), userID, enabled, "", ""); err != nil {
+		log.Printf("failed to toggle dm digest (user: %s): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update dm digest"})
+		return
+	}
+	log.Printf("dm digest toggled from home (user: %s, enabled: %t)", userID, enabled)
+
+	refreshHomeViewIfOpen(c, db, slack, payload)
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,13 @@
+package handlers
+
+// slackResponder is what the Slack handlers need from the transport a request
+// came over: a way to answer it and per-request values such as the Slack
+// team (see slack_team.go). *gin.Context answers the HTTP endpoints and
+// socketModeResponse (see socket_mode.go) answers over Socket Mode.
+type slackResponder interface {
+	String(code int, format string, values ...any)
+	JSON(code int, obj any)
+	Status(code int)
+	Set(key string, value any)
+	GetString(key string) string
+}
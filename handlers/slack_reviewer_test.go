@@ -1,13 +1,16 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"slack-review-notify/models"
 	"slack-review-notify/services"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -16,15 +19,11 @@ import (
 )
 
 func TestHandleSlackAction_ChangeReviewer_MultipleLabels(t *testing.T) {
-	// Enable test mode
-	services.IsTestMode = true
-	defer func() { services.IsTestMode = false }()
-
 	// Create test DB
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
-	router.POST("/slack/action", HandleSlackAction(db))
+	router := newSlackTestRouter(t)
+	router.POST("/slack/action", HandleSlackAction(db, services.NewFakeSlackClient()))
 
 	// Create configs for multiple labels
 	// For label-1 (2 reviewers)
@@ -127,15 +126,11 @@ func TestHandleSlackAction_ChangeReviewer_MultipleLabels(t *testing.T) {
 }
 
 func TestHandleSlackAction_ChangeReviewer_SingleReviewer(t *testing.T) {
-	// Enable test mode
-	services.IsTestMode = true
-	defer func() { services.IsTestMode = false }()
-
 	// Create test DB
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
-	router.POST("/slack/action", HandleSlackAction(db))
+	router := newSlackTestRouter(t)
+	router.POST("/slack/action", HandleSlackAction(db, services.NewFakeSlackClient()))
 
 	// Create config with only one reviewer
 	config := models.ChannelConfig{
@@ -220,15 +215,11 @@ func TestHandleSlackAction_ChangeReviewer_SingleReviewer(t *testing.T) {
 }
 
 func TestHandleSlackAction_ChangeReviewer_NoLabelName(t *testing.T) {
-	// Enable test mode
-	services.IsTestMode = true
-	defer func() { services.IsTestMode = false }()
-
 	// Create test DB
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
-	router.POST("/slack/action", HandleSlackAction(db))
+	router := newSlackTestRouter(t)
+	router.POST("/slack/action", HandleSlackAction(db, services.NewFakeSlackClient()))
 
 	// Create config for default label
 	config := models.ChannelConfig{
@@ -317,13 +308,10 @@ func TestHandleSlackAction_ChangeReviewer_NoLabelName(t *testing.T) {
 
 // Test that change_reviewer button works correctly with English language setting
 func TestHandleSlackAction_ChangeReviewer_EnglishLanguage(t *testing.T) {
-	services.IsTestMode = true
-	defer func() { services.IsTestMode = false }()
-
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
-	router.POST("/slack/action", HandleSlackAction(db))
+	router := newSlackTestRouter(t)
+	router.POST("/slack/action", HandleSlackAction(db, services.NewFakeSlackClient()))
 
 	// Create config with English language and 2 reviewers
 	config := models.ChannelConfig{
@@ -409,13 +397,10 @@ func TestHandleSlackAction_ChangeReviewer_EnglishLanguage(t *testing.T) {
 
 // Test that single-reviewer "cannot change" message uses correct language
 func TestHandleSlackAction_ChangeReviewer_SingleReviewer_English(t *testing.T) {
-	services.IsTestMode = true
-	defer func() { services.IsTestMode = false }()
-
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
-	router.POST("/slack/action", HandleSlackAction(db))
+	router := newSlackTestRouter(t)
+	router.POST("/slack/action", HandleSlackAction(db, services.NewFakeSlackClient()))
 
 	// Create config with only one reviewer, English language
 	config := models.ChannelConfig{
@@ -487,7 +472,7 @@ func TestHandleSlackAction_ChangeReviewer_SingleReviewer_English(t *testing.T) {
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	// Should succeed (message posted to thread in test mode)
+	// Should succeed (message posted to thread)
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	// Reviewer should NOT have changed (only one registered)
@@ -495,3 +480,71 @@ func TestHandleSlackAction_ChangeReviewer_SingleReviewer_English(t *testing.T) {
 	db.Where("id = ?", "test-task-en-2").First(&updatedTask)
 	assert.Equal(t, "U11111", updatedTask.Reviewer)
 }
+
+// With GitHub sync on, changing the reviewer swaps the review request on the
+// PR in the background.
+func TestHandleSlackAction_ChangeReviewer_SyncsGitHub(t *testing.T) {
+	db := setupTestDB(t)
+
+	var mu sync.Mutex
+	var requests []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/test/repo/pulls/5/requested_reviewers", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, r.Method+" "+strings.Join(strings.Fields(string(body)), ""))
+		mu.Unlock()
+		_, _ = io.WriteString(w, `{"number": 5}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	t.Setenv("GITHUB_API_BASE_URL", server.URL)
+	t.Setenv("GITHUB_TOKEN", "test-github-token")
+
+	db.Create(&models.ChannelConfig{
+		ID:                    "config-sync",
+		SlackChannelID:        "C12345",
+		LabelName:             "needs-review",
+		ReviewerList:          "U11111,U22222",
+		SyncReviewersToGitHub: true,
+		IsActive:              true,
+	})
+	db.Create(&models.UserMapping{ID: "map-old", GithubUsername: "old-reviewer", SlackUserID: "U11111"})
+	db.Create(&models.UserMapping{ID: "map-new", GithubUsername: "new-reviewer", SlackUserID: "U22222"})
+	db.Create(&models.ReviewTask{
+		ID:           "test-task-sync",
+		Repo:         "test/repo",
+		PRNumber:     5,
+		SlackTS:      "5555.5555",
+		SlackChannel: "C12345",
+		Status:       "in_review",
+		Reviewer:     "U11111",
+		LabelName:    "needs-review",
+	})
+
+	var payload SlackActionPayload
+	payload.Type = "block_actions"
+	payload.User.ID = "U99999"
+	payload.Actions = append(payload.Actions, struct {
+		ActionID       string `json:"action_id"`
+		Value          string `json:"value,omitempty"`
+		SelectedOption struct {
+			Value string `json:"value"`
+			Text  struct {
+				Text string `json:"text"`
+			} `json:"text"`
+		} `json:"selected_option,omitempty"`
+	}{ActionID: "change_reviewer", Value: "test-task-sync"})
+	payload.Container.ChannelID = "C12345"
+	payload.Message.Ts = "5555.5555"
+
+	processSlackAction(&socketModeResponse{}, db, services.NewFakeSlackClient(), payload)
+	assert.NoError(t, services.WaitForBackgroundTasks(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{
+		`DELETE {"reviewers":["old-reviewer"]}`,
+		`POST {"reviewers":["new-reviewer"]}`,
+	}, requests)
+}
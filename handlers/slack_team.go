@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"slack-review-notify/services"
+)
+
+// slackTeamContextKey holds the team of the Slack request being handled.
+const slackTeamContextKey = "slack_team_id"
+
+// setSlackTeam records the team a Slack request came from (see
+// services.SlackTeamKey) so the rows it reads and writes are scoped to it.
+func setSlackTeam(c slackResponder, teamID, enterpriseID string, isEnterpriseInstall bool) string {
+	teamKey := services.SlackTeamKey(teamID, enterpriseID, isEnterpriseInstall)
+	c.Set(slackTeamContextKey, teamKey)
+	return teamKey
+}
+
+// slackTeamID returns the team of the Slack request, or "" when the request
+// carried none.
+func slackTeamID(c slackResponder) string {
+	return c.GetString(slackTeamContextKey)
+}
@@ -1,11 +1,11 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
-	"os"
 	"slack-review-notify/models"
 	"slack-review-notify/services"
 	"strings"
@@ -13,7 +13,6 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/h2non/gock"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -21,8 +20,8 @@ func TestHandleSlackAction_PauseReminder_Today(t *testing.T) {
 	// Set up test DB and router
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
-	router.POST("/slack/action", HandleSlackAction(db))
+	router := newSlackTestRouter(t)
+	router.POST("/slack/action", HandleSlackAction(db, services.NewFakeSlackClient()))
 
 	// Create a test task
 	task := models.ReviewTask{
@@ -92,10 +91,6 @@ func TestHandleSlackAction_PauseReminder_Today(t *testing.T) {
 	req := httptest.NewRequest("POST", "/slack/action", strings.NewReader(form.Encode()))
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	// Mock signature verification (needs proper setup in actual tests)
-	services.IsTestMode = true
-	defer func() { services.IsTestMode = false }()
-
 	// Execute request
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -142,8 +137,8 @@ func TestHandleSlackAction_PauseReminder_Hours(t *testing.T) {
 			// Set up test DB and router
 			db := setupTestDB(t)
 			gin.SetMode(gin.TestMode)
-			router := gin.New()
-			router.POST("/slack/action", HandleSlackAction(db))
+			router := newSlackTestRouter(t)
+			router.POST("/slack/action", HandleSlackAction(db, services.NewFakeSlackClient()))
 
 			// Create a test task
 			task := models.ReviewTask{
@@ -208,10 +203,6 @@ func TestHandleSlackAction_PauseReminder_Hours(t *testing.T) {
 			req := httptest.NewRequest("POST", "/slack/action", strings.NewReader(form.Encode()))
 			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-			// Mock signature verification
-			services.IsTestMode = true
-			defer func() { services.IsTestMode = false }()
-
 			// Execute request
 			w := httptest.NewRecorder()
 			router.ServeHTTP(w, req)
@@ -240,22 +231,7 @@ func TestHandleSlackAction_PauseReminder_Hours(t *testing.T) {
 }
 
 func TestHandleSlackAction_PauseReminderInitial(t *testing.T) {
-	// Enable test mode (skip signature verification)
-	services.IsTestMode = true
-
-	// Stub the Slack API: the pause confirmation message (SendReminderPausedMessage)
-	// posts to chat.postMessage and does not honor test mode, so without this stub
-	// the test depends on real network reachability and flakes on a dial timeout.
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() { _ = os.Setenv("SLACK_BOT_TOKEN", originalToken) }()
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	defer gock.Off()
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Persist().
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
+	slack := services.NewFakeSlackClient()
 
 	// Create test DB
 	db := setupTestDB(t)
@@ -323,12 +299,14 @@ func TestHandleSlackAction_PauseReminderInitial(t *testing.T) {
 
 	req, _ := http.NewRequest("POST", "/slack/actions", strings.NewReader(form.Encode()))
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	t.Setenv("SLACK_SIGNING_SECRET", testSigningSecret)
+	signSlackRequest(t, req)
 
 	// Record response
 	w := httptest.NewRecorder()
 
 	// Execute handler
-	handler := HandleSlackAction(db)
+	handler := HandleSlackAction(db, slack)
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
 	handler(c)
@@ -346,4 +324,48 @@ func TestHandleSlackAction_PauseReminderInitial(t *testing.T) {
 	expected := time.Now().Add(4 * time.Hour)
 	actual := *updatedTask.ReminderPausedUntil
 	assert.WithinDuration(t, expected, actual, 10*time.Second)
+
+	// The pause is confirmed in the PR thread
+	msgs := slack.Messages()
+	if assert.Len(t, msgs, 1) {
+		assert.Equal(t, "1234.5678", msgs[0].ThreadTS)
+	}
+}
+
+// The App Home toggle turns the DM digest on and off for the clicking user.
+func TestHandleSlackAction_ToggleDMDigest(t *testing.T) {
+	db := setupTestDB(t)
+	slack := services.NewFakeSlackClient()
+	router := setupActionRouter(t, db, slack)
+
+	toggle := func(value string) {
+		payload := `{
+			"type": "block_actions",
+			"user": {"id": "UHOME"},
+			"team": {"id": "T1"},
+			"actions": [{"action_id": "` + services.ToggleDMDigestActionID + `", "value": "` + value + `"}],
+			"view": {"id": "V1", "type": "home"}
+		}`
+		w := postPayload(t, router, payload)
+		assert.Equal(t, http.StatusOK, w.Code, "body: %s", w.Body.String())
+	}
+
+	toggle("on")
+	sub, err := services.GetDMDigestSubscription(db, "T1", "UHOME")
+	assert.NoError(t, err)
+	if assert.NotNil(t, sub) {
+		assert.True(t, sub.Enabled)
+		assert.Equal(t, services.DefaultDMDigestTime, sub.DigestTime)
+	}
+
+	toggle("off")
+	sub, err = services.GetDMDigestSubscription(db, "T1", "UHOME")
+	assert.NoError(t, err)
+	if assert.NotNil(t, sub) {
+		assert.False(t, sub.Enabled)
+	}
+
+	// Each toggle re-rendered the Home tab in the background
+	assert.NoError(t, services.WaitForBackgroundTasks(context.Background()))
+	assert.Len(t, slack.Views(), 2)
 }
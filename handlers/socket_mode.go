@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slack-review-notify/services"
+
+	slackapi "github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+	"gorm.io/gorm"
+)
+
+// Slack transports selectable with SLACK_TRANSPORT.
+const (
+	SlackTransportHTTP   = "http"   // Slack calls the public /slack/* endpoints
+	SlackTransportSocket = "socket" // the server connects out to Slack with Socket Mode
+)
+
+// SlackTransport returns how Slack requests reach the server, from
+// SLACK_TRANSPORT. Socket Mode needs SLACK_APP_TOKEN, an app-level token
+// with the connections:write scope.
+func SlackTransport() (string, error) {
+	switch transport := os.Getenv("SLACK_TRANSPORT"); transport {
+	case "", SlackTransportHTTP:
+		return SlackTransportHTTP, nil
+	case SlackTransportSocket:
+		if os.Getenv("SLACK_APP_TOKEN") == "" {
+			return "", fmt.Errorf("SLACK_APP_TOKEN is required when SLACK_TRANSPORT=%s", SlackTransportSocket)
+		}
+		return SlackTransportSocket, nil
+	default:
+		return "", fmt.Errorf("unknown SLACK_TRANSPORT %q (want %s or %s)", transport, SlackTransportHTTP, SlackTransportSocket)
+	}
+}
+
+// RunSocketMode receives slash commands, interactions and events over a
+// Socket Mode connection and passes them to the same handlers as the HTTP
+// endpoints. Slack authenticates the connection, so there is no request
+// signature to verify. It returns when ctx is done.
+func RunSocketMode(ctx context.Context, db *gorm.DB, slack services.SlackClient, appToken string) error {
+	api := slackapi.New("", slackapi.OptionAppLevelToken(appToken), slackapi.OptionAPIURL(services.SlackAPIBaseURL()+"/"))
+	client := socketmode.New(api)
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt := <-client.Events:
+				// Handled concurrently like HTTP requests; each must be
+				// acknowledged within 3 seconds
+				services.Go(func() { handleSocketModeEvent(client, db, slack, evt) })
+			}
+		}
+	}()
+
+	if err := client.RunContext(ctx); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// socketModeAcker acknowledges a Socket Mode request, optionally with a
+// response payload. *socketmode.Client implements it.
+type socketModeAcker interface {
+	Ack(req socketmode.Request, payload ...interface{})
+}
+
+// handleSocketModeEvent handles one Socket Mode event and acknowledges it
+// with what the handler answered.
+func handleSocketModeEvent(acker socketModeAcker, db *gorm.DB, slack services.SlackClient, evt socketmode.Event) {
+	resp := &socketModeResponse{}
+
+	switch evt.Type {
+	case socketmode.EventTypeConnecting:
+		log.Println("connecting to slack with socket mode")
+		return
+	case socketmode.EventTypeConnected:
+		log.Println("connected to slack with socket mode")
+		return
+	case socketmode.EventTypeConnectionError, socketmode.EventTypeInvalidAuth:
+		log.Printf("slack socket mode connection error: %v", evt.Data)
+		return
+	case socketmode.EventTypeSlashCommand:
+		cmd, ok := evt.Data.(slackapi.SlashCommand)
+		if !ok {
+			log.Printf("unexpected slash command data: %T", evt.Data)
+			break
+		}
+		processSlackCommand(resp, db, slackCommand{
+			Command:             cmd.Command,
+			Text:                cmd.Text,
+			ChannelID:           cmd.ChannelID,
+			UserID:              cmd.UserID,
+			TeamID:              cmd.TeamID,
+			EnterpriseID:        cmd.EnterpriseID,
+			IsEnterpriseInstall: cmd.IsEnterpriseInstall,
+		})
+	case socketmode.EventTypeInteractive:
+		var payload SlackActionPayload
+		if err := json.Unmarshal(evt.Request.Payload, &payload); err != nil {
+			log.Printf("payload json parse error: %v", err)
+			break
+		}
+		processSlackAction(resp, db, slack, payload)
+	case socketmode.EventTypeEventsAPI:
+		log.Printf("slack event received: %s", string(evt.Request.Payload))
+		processSlackEvent(resp, db, slack, evt.Request.Payload)
+	default:
+		// hello, disconnect and the like are handled by the client
+		return
+	}
+
+	if evt.Request == nil {
+		return
+	}
+	if payload := resp.ackPayload(); payload != nil {
+		acker.Ack(*evt.Request, payload)
+	} else {
+		acker.Ack(*evt.Request)
+	}
+}
+
+// socketModeResponse collects a handler's answer to a Socket Mode request,
+// which is sent back as the payload of the request's acknowledgement.
+type socketModeResponse struct {
+	status  int
+	payload any
+	values  map[string]any
+}
+
+func (r *socketModeResponse) String(code int, format string, values ...any) {
+	text := format
+	if len(values) > 0 {
+		text = fmt.Sprintf(format, values...)
+	}
+	r.status, r.payload = code, map[string]string{"text": text}
+}
+
+func (r *socketModeResponse) JSON(code int, obj any) {
+	r.status, r.payload = code, obj
+}
+
+func (r *socketModeResponse) Status(code int) {
+	r.status = code
+}
+
+func (r *socketModeResponse) Set(key string, value any) {
+	if r.values == nil {
+		r.values = map[string]any{}
+	}
+	r.values[key] = value
+}
+
+func (r *socketModeResponse) GetString(key string) string {
+	s, _ := r.values[key].(string)
+	return s
+}
+
+// ackPayload returns the payload to acknowledge the request with. Error
+// answers are logged instead, since Slack shows nothing for them either.
+func (r *socketModeResponse) ackPayload() any {
+	if r.status >= http.StatusBadRequest {
+		log.Printf("slack socket mode request failed (status: %d): %v", r.status, r.payload)
+		return nil
+	}
+	return r.payload
+}
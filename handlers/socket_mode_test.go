@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"slack-review-notify/models"
+	"slack-review-notify/services"
+	"testing"
+
+	slackapi "github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingAcker records the acknowledgements sent for Socket Mode requests.
+type recordingAcker struct {
+	acks map[string][]interface{}
+}
+
+func (a *recordingAcker) Ack(req socketmode.Request, payload ...interface{}) {
+	if a.acks == nil {
+		a.acks = map[string][]interface{}{}
+	}
+	a.acks[req.EnvelopeID] = payload
+}
+
+func TestSlackTransport(t *testing.T) {
+	t.Setenv("SLACK_TRANSPORT", "")
+	transport, err := SlackTransport()
+	assert.NoError(t, err)
+	assert.Equal(t, SlackTransportHTTP, transport)
+
+	t.Setenv("SLACK_TRANSPORT", "socket")
+	t.Setenv("SLACK_APP_TOKEN", "")
+	_, err = SlackTransport()
+	assert.Error(t, err, "socket mode needs an app-level token")
+
+	t.Setenv("SLACK_APP_TOKEN", "xapp-1")
+	transport, err = SlackTransport()
+	assert.NoError(t, err)
+	assert.Equal(t, SlackTransportSocket, transport)
+
+	t.Setenv("SLACK_TRANSPORT", "carrier-pigeon")
+	_, err = SlackTransport()
+	assert.Error(t, err)
+}
+
+func TestHandleSocketModeEvent_SlashCommand(t *testing.T) {
+	db := setupTestDB(t)
+	acker := &recordingAcker{}
+
+	handleSocketModeEvent(acker, db, services.NewFakeSlackClient(), socketmode.Event{
+		Type: socketmode.EventTypeSlashCommand,
+		Data: slackapi.SlashCommand{
+			Command:   "/slack-review-notify",
+			Text:      "needs-review add-reviewer <@U1AAA>",
+			ChannelID: "C_SOCKET",
+			UserID:    "U12345",
+			TeamID:    "T1",
+		},
+		Request: &socketmode.Request{EnvelopeID: "env-1"},
+	})
+
+	// The command ran against the team's rows like over HTTP
+	var config models.ChannelConfig
+	if assert.NoError(t, db.Where("team_id = ? AND slack_channel_id = ?", "T1", "C_SOCKET").First(&config).Error) {
+		assert.Equal(t, "U1AAA", config.ReviewerList)
+	}
+
+	// and its answer is the payload of the acknowledgement
+	if assert.Len(t, acker.acks["env-1"], 1) {
+		payload, ok := acker.acks["env-1"][0].(map[string]string)
+		if assert.True(t, ok) {
+			assert.Contains(t, payload["text"], "needs-review")
+		}
+	}
+}
+
+func TestHandleSocketModeEvent_Interactive(t *testing.T) {
+	db := setupTestDB(t)
+	slack := services.NewFakeSlackClient()
+	acker := &recordingAcker{}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"type": "block_actions",
+		"user": map[string]string{"id": "U12345"},
+		"team": map[string]string{"id": "T1"},
+		"view": map[string]string{"id": "V1", "type": "home"},
+		"actions": []map[string]string{
+			{"action_id": services.ToggleDMDigestActionID, "value": "on"},
+		},
+	})
+	assert.NoError(t, err)
+
+	handleSocketModeEvent(acker, db, slack, socketmode.Event{
+		Type:    socketmode.EventTypeInteractive,
+		Request: &socketmode.Request{EnvelopeID: "env-2", Payload: payload},
+	})
+
+	assert.NoError(t, services.WaitForBackgroundTasks(context.Background()))
+	var sub models.DMDigestSubscription
+	assert.NoError(t, db.Where("team_id = ? AND slack_user_id = ?", "T1", "U12345").First(&sub).Error)
+	assert.Equal(t, []string{"T1"}, slack.Teams())
+	// Acknowledged without a payload
+	assert.Contains(t, acker.acks, "env-2")
+	assert.Empty(t, acker.acks["env-2"])
+}
+
+func TestHandleSocketModeEvent_EventsAPI(t *testing.T) {
+	db := setupTestDB(t)
+	slack := services.NewFakeSlackClient()
+	acker := &recordingAcker{}
+
+	handleSocketModeEvent(acker, db, slack, socketmode.Event{
+		Type: socketmode.EventTypeEventsAPI,
+		Request: &socketmode.Request{
+			EnvelopeID: "env-3",
+			Payload:    json.RawMessage(`{"type": "event_callback", "team_id": "T1", "event": {"type": "app_home_opened", "user": "U12345", "tab": "home"}}`),
+		},
+	})
+
+	assert.NoError(t, services.WaitForBackgroundTasks(context.Background()))
+	views := slack.Views()
+	if assert.Len(t, views, 1) {
+		assert.Equal(t, "U12345", views[0].Target)
+	}
+	assert.Contains(t, acker.acks, "env-3")
+}
@@ -18,15 +18,15 @@ import (
 // "👥 User mapping" help button. Existing mappings are loaded so the modal
 // can render them — important so the operator can spot legacy non-U-id rows
 // that still need re-registration.
-func handleOpenUserMapping(c *gin.Context, db *gorm.DB, payload SlackActionPayload) {
+func handleOpenUserMapping(c slackResponder, db *gorm.DB, slack services.SlackClient, payload SlackActionPayload) {
 	channelID := payload.Container.ChannelID
 	userID := payload.User.ID
 
-	configs := loadChannelConfigs(db, channelID)
+	configs := loadChannelConfigs(db, slackTeamID(c), channelID)
 	lang := pickModalLanguage(configs, "")
 
 	var mappings []models.UserMapping
-	if err := db.Order("github_username").Find(&mappings).Error; err != nil {
+	if err := db.Where("team_id = ?", slackTeamID(c)).Order("github_username").Find(&mappings).Error; err != nil {
 		log.Printf("user-mapping modal: failed to list mappings: %v", err)
 	}
 
@@ -38,11 +38,11 @@ func handleOpenUserMapping(c *gin.Context, db *gorm.DB, payload SlackActionPaylo
 	})
 	triggerID := payload.TriggerID
 
-	go func() {
-		if err := services.OpenView(triggerID, view); err != nil {
+	services.Go(func() {
+		if err := slack.OpenView(triggerID, view); err != nil {
 			log.Printf("user-mapping views.open failed: %v", err)
 		}
-	}()
+	})
 
 	c.Status(http.StatusOK)
 }
@@ -50,7 +50,7 @@ func handleOpenUserMapping(c *gin.Context, db *gorm.DB, payload SlackActionPaylo
 // handleUserMappingModalSubmission upserts or deletes a single row.
 // Deletes are Unscoped so a subsequent re-add of the same github_username
 // can't collide on the unique index.
-func handleUserMappingModalSubmission(c *gin.Context, db *gorm.DB, payload SlackActionPayload) {
+func handleUserMappingModalSubmission(c slackResponder, db *gorm.DB, slack services.SlackClient, payload SlackActionPayload) {
 	meta, err := services.DecodeUserMappingModalMetadata(payload.View.PrivateMetadata)
 	if err != nil {
 		log.Printf("user-mapping view_submission has invalid private_metadata: %q (err=%v)", payload.View.PrivateMetadata, err)
@@ -61,7 +61,7 @@ func handleUserMappingModalSubmission(c *gin.Context, db *gorm.DB, payload Slack
 		return
 	}
 
-	configs := loadChannelConfigs(db, meta.ChannelID)
+	configs := loadChannelConfigs(db, slackTeamID(c), meta.ChannelID)
 	lang := pickModalLanguage(configs, "")
 
 	form, err := services.ParseUserMappingModalSubmission(payload.View.State.Values, lang)
@@ -86,11 +86,11 @@ func handleUserMappingModalSubmission(c *gin.Context, db *gorm.DB, payload Slack
 
 	if form.Delete {
 		var existing models.UserMapping
-		res := db.Where("github_username = ?", form.GithubUsername).First(&existing)
+		res := db.Where("team_id = ? AND github_username = ?", slackTeamID(c), form.GithubUsername).First(&existing)
 		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
-			if !services.IsTestMode && meta.UserID != "" {
+			if meta.UserID != "" {
 				msg := i18n.TWithLang(lang, "modal.user_mapping.delete_not_found", form.GithubUsername)
-				if err := services.PostEphemeral(meta.ChannelID, meta.UserID, msg); err != nil {
+				if err := slack.PostEphemeral(meta.ChannelID, meta.UserID, msg); err != nil {
 					log.Printf("user-mapping delete-notfound notice failed: %v", err)
 				}
 			}
@@ -115,9 +115,9 @@ func handleUserMappingModalSubmission(c *gin.Context, db *gorm.DB, payload Slack
 			})
 			return
 		}
-		if !services.IsTestMode && meta.UserID != "" {
+		if meta.UserID != "" {
 			msg := i18n.TWithLang(lang, "modal.user_mapping.deleted", form.GithubUsername)
-			if err := services.PostEphemeral(meta.ChannelID, meta.UserID, msg); err != nil {
+			if err := slack.PostEphemeral(meta.ChannelID, meta.UserID, msg); err != nil {
 				log.Printf("user-mapping deleted notice failed: %v", err)
 			}
 		}
@@ -127,7 +127,7 @@ func handleUserMappingModalSubmission(c *gin.Context, db *gorm.DB, payload Slack
 
 	// Upsert
 	var existing models.UserMapping
-	res := db.Where("github_username = ?", form.GithubUsername).First(&existing)
+	res := db.Where("team_id = ? AND github_username = ?", slackTeamID(c), form.GithubUsername).First(&existing)
 	switch {
 	case res.Error == nil:
 		existing.SlackUserID = form.SlackUserID
@@ -143,6 +143,7 @@ func handleUserMappingModalSubmission(c *gin.Context, db *gorm.DB, payload Slack
 	case errors.Is(res.Error, gorm.ErrRecordNotFound):
 		record := models.UserMapping{
 			ID:             uuid.NewString(),
+			TeamID:         slackTeamID(c),
 			GithubUsername: form.GithubUsername,
 			SlackUserID:    form.SlackUserID,
 			CreatedAt:      now,
@@ -165,9 +166,9 @@ func handleUserMappingModalSubmission(c *gin.Context, db *gorm.DB, payload Slack
 		return
 	}
 
-	if !services.IsTestMode && meta.UserID != "" {
+	if meta.UserID != "" {
 		msg := i18n.TWithLang(lang, "modal.user_mapping.saved", form.GithubUsername)
-		if err := services.PostEphemeral(meta.ChannelID, meta.UserID, msg); err != nil {
+		if err := slack.PostEphemeral(meta.ChannelID, meta.UserID, msg); err != nil {
 			log.Printf("user-mapping saved notice failed: %v", err)
 		}
 	}
@@ -22,10 +22,8 @@ import (
 // non-U-id rows that caused the original bug.
 func TestHelpRendersUserMappingButton(t *testing.T) {
 	db := setupTestDB(t)
-	services.IsTestMode = true
-	defer func() { services.IsTestMode = false }()
 
-	router := setupTestRouter(db)
+	router := setupTestRouter(t, db)
 
 	form := url.Values{}
 	form.Add("command", "/slack-review-notify")
@@ -51,12 +49,10 @@ func TestHelpRendersUserMappingButton(t *testing.T) {
 // path end-to-end: a fresh DB receives an upsert and a follow-up modify.
 func TestUserMappingModal_Submission_Upsert(t *testing.T) {
 	db := setupTestDB(t)
-	services.IsTestMode = true
-	defer func() { services.IsTestMode = false }()
 
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
-	router.POST("/slack/actions", HandleSlackAction(db))
+	router := newSlackTestRouter(t)
+	router.POST("/slack/actions", HandleSlackAction(db, services.NewFakeSlackClient()))
 
 	payload := buildUserMappingSubmissionPayload(t, "octocat", "U01ABCDE234", false)
 	body := url.Values{}
@@ -91,8 +87,6 @@ func TestUserMappingModal_Submission_Upsert(t *testing.T) {
 // removes the row instead of upserting it.
 func TestUserMappingModal_Submission_Delete(t *testing.T) {
 	db := setupTestDB(t)
-	services.IsTestMode = true
-	defer func() { services.IsTestMode = false }()
 
 	// Seed an existing legacy row (slack_user_id is a plain handle, not a U-id).
 	db.Create(&models.UserMapping{
@@ -104,8 +98,8 @@ func TestUserMappingModal_Submission_Delete(t *testing.T) {
 	})
 
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
-	router.POST("/slack/actions", HandleSlackAction(db))
+	router := newSlackTestRouter(t)
+	router.POST("/slack/actions", HandleSlackAction(db, services.NewFakeSlackClient()))
 
 	payload := buildUserMappingSubmissionPayload(t, "octocat", "", true)
 	body := url.Values{}
@@ -126,12 +120,10 @@ func TestUserMappingModal_Submission_Delete(t *testing.T) {
 // renders the field-level error rather than silently closing the modal.
 func TestUserMappingModal_Submission_ValidationError(t *testing.T) {
 	db := setupTestDB(t)
-	services.IsTestMode = true
-	defer func() { services.IsTestMode = false }()
 
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
-	router.POST("/slack/actions", HandleSlackAction(db))
+	router := newSlackTestRouter(t)
+	router.POST("/slack/actions", HandleSlackAction(db, services.NewFakeSlackClient()))
 
 	// Empty github_username + no slack user → validation errors.
 	payload := buildUserMappingSubmissionPayload(t, "", "", false)
@@ -1,11 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"strings"
 	"time"
 
 	"slack-review-notify/i18n"
@@ -18,10 +18,14 @@ import (
 	"gorm.io/gorm"
 )
 
-func HandleGitHubWebhook(db *gorm.DB) gin.HandlerFunc {
+// HandleGitHubWebhook acknowledges GitHub webhook events right away and
+// processes them on the queue, one at a time per PR. A delivery whose
+// X-GitHub-Delivery ID was already received is skipped.
+func HandleGitHubWebhook(db *gorm.DB, slack services.SlackClient, queue *services.WebhookQueue) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		eventType := c.GetHeader("X-GitHub-Event")
-		log.Printf("GitHub Webhook received: event_type=%s", eventType)
+		deliveryID := c.GetHeader("X-GitHub-Delivery")
+		log.Printf("GitHub Webhook received: event_type=%s, delivery=%s", eventType, deliveryID)
 
 		payload, err := github.ValidatePayload(c.Request, []byte(os.Getenv("GITHUB_WEBHOOK_SECRET")))
 		if err != nil {
@@ -37,39 +41,193 @@ func HandleGitHubWebhook(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		switch e := event.(type) {
-		case *github.PullRequestEvent:
-			log.Printf("PullRequestEvent received: action=%s", e.GetAction())
-			if e.Action != nil {
-				switch *e.Action {
-				case "labeled":
-					if e.Label != nil {
-						handleLabeledEvent(c, db, e)
-					}
-				case "unlabeled":
-					if e.Label != nil {
-						handleUnlabeledEvent(c, db, e)
-					}
-				case "closed":
-					handleClosedEvent(c, db, e)
-				case "review_requested":
-					handleReviewRequestedEvent(c, db, e)
+		action := webhookAction(event)
+		services.RecordWebhookReceived(eventType, action)
+
+		if deliveryID != "" {
+			if err := services.BeginWebhookDelivery(db, deliveryID, eventType, action, payload); err != nil {
+				if errors.Is(err, services.ErrDuplicateWebhookDelivery) {
+					log.Printf("duplicate webhook delivery skipped: %s (event_type=%s, action=%s)", deliveryID, eventType, action)
+					services.RecordWebhookDuplicate(eventType, action)
+					c.JSON(http.StatusOK, gin.H{"message": "duplicate delivery"})
+					return
 				}
+				// Processing it unrecorded is better than losing the event
+				log.Printf("webhook delivery save error (delivery: %s): %v", deliveryID, err)
+				deliveryID = ""
 			}
-		case *github.PullRequestReviewEvent:
-			log.Printf("PullRequestReviewEvent received: action=%s", e.GetAction())
-			if e.Action != nil && (*e.Action == "submitted" || *e.Action == "dismissed") {
-				handleReviewSubmittedEvent(c, db, e)
-			}
-		default:
-			log.Printf("Unknown event type received: %T", e)
 		}
 
+		if err := queue.Enqueue(webhookJob(db, slack, deliveryID, eventType, event, nil)); err != nil {
+			log.Printf("webhook enqueue error (delivery: %s): %v", deliveryID, err)
+			if deliveryID != "" {
+				services.FinishWebhookDelivery(db, deliveryID, services.WebhookDeliveryFailed, err.Error())
+			}
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "shutting down"})
+			return
+		}
 		c.Status(http.StatusOK)
 	}
 }
 
-func handleLabeledEvent(c *gin.Context, db *gorm.DB, e *github.PullRequestEvent) {
+// ResumeWebhookDeliveries queues the deliveries that were still being
+// processed when a server stopped, so acknowledged events are not lost. Each
+// one is claimed first, so servers resuming at the same time queue it once.
+func ResumeWebhookDeliveries(db *gorm.DB, slack services.SlackClient, queue *services.WebhookQueue) {
+	deliveries, err := services.InterruptedWebhookDeliveries(db)
+	if err != nil {
+		log.Printf("interrupted webhook delivery search error: %v", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		claimed, err := services.ClaimInterruptedWebhookDelivery(db, delivery)
+		if err != nil {
+			log.Printf("interrupted webhook delivery claim error (delivery: %s): %v", delivery.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		event, err := github.ParseWebHook(delivery.EventType, []byte(delivery.Payload))
+		if err != nil {
+			services.FinishWebhookDelivery(db, delivery.ID, services.WebhookDeliveryFailed, err.Error())
+			continue
+		}
+		log.Printf("resuming interrupted webhook delivery %s (event_type=%s, action=%s)", delivery.ID, delivery.EventType, delivery.Action)
+		if err := queue.Enqueue(webhookJob(db, slack, delivery.ID, delivery.EventType, event, nil)); err != nil {
+			log.Printf("webhook enqueue error (delivery: %s): %v", delivery.ID, err)
+			return
+		}
+	}
+}
+
+// GitHubPollHandler passes the events synthesized by the GitHub poller to the
+// webhook handlers through the queue, so they are processed in order with
+// any webhook deliveries for the same PR, and waits for each to finish.
+func GitHubPollHandler(db *gorm.DB, slack services.SlackClient, queue *services.WebhookQueue) services.GitHubEventHandler {
+	return func(eventType string, event interface{}) error {
+		return queue.Do(webhookJob(db, slack, "", eventType, event, nil))
+	}
+}
+
+// webhookAction returns the action of a webhook event, or "" if it has none.
+func webhookAction(event interface{}) string {
+	if a, ok := event.(interface{ GetAction() string }); ok {
+		return a.GetAction()
+	}
+	return ""
+}
+
+// webhookJob processes the event and, when deliveryID is set, records the
+// outcome on the delivery and passes it to onDone (if not nil).
+func webhookJob(db *gorm.DB, slack services.SlackClient, deliveryID, eventType string, event interface{}, onDone func(status string)) services.WebhookJob {
+	var handled bool
+	return services.WebhookJob{
+		Key: webhookJobKey(eventType, event),
+		Run: func() error {
+			var err error
+			handled, err = processWebhookEvent(db, slack, eventType, event)
+			return err
+		},
+		Done: func(err error) {
+			if deliveryID == "" {
+				return
+			}
+			status, errMsg := services.WebhookDeliveryIgnored, ""
+			switch {
+			case err != nil:
+				status, errMsg = services.WebhookDeliveryFailed, err.Error()
+			case handled:
+				status = services.WebhookDeliveryProcessed
+			}
+			services.FinishWebhookDelivery(db, deliveryID, status, errMsg)
+			if onDone != nil {
+				onDone(status)
+			}
+		},
+	}
+}
+
+// webhookJobKey returns the queue key of the event: its PR, so events for a
+// PR are processed in order, its GitHub App installation for installation
+// events, or the event type for other events.
+func webhookJobKey(eventType string, event interface{}) string {
+	var repo *github.Repository
+	var number int
+	switch e := event.(type) {
+	case *github.PullRequestEvent:
+		repo, number = e.Repo, e.GetPullRequest().GetNumber()
+	case *github.PullRequestReviewEvent:
+		repo, number = e.Repo, e.GetPullRequest().GetNumber()
+	case *github.InstallationEvent:
+		return fmt.Sprintf("installation/%d", e.GetInstallation().GetID())
+	case *github.InstallationRepositoriesEvent:
+		return fmt.Sprintf("installation/%d", e.GetInstallation().GetID())
+	default:
+		return eventType
+	}
+	return fmt.Sprintf("%s/%s#%d", repo.GetOwner().GetLogin(), repo.GetName(), number)
+}
+
+// processWebhookEvent passes the event to its handler, and reports whether
+// there was one. Errors worth retrying, such as database locks, are returned.
+func processWebhookEvent(db *gorm.DB, slack services.SlackClient, eventType string, event interface{}) (bool, error) {
+	action := webhookAction(event)
+
+	switch e := event.(type) {
+	case *github.PullRequestEvent:
+		log.Printf("PullRequestEvent received: action=%s", e.GetAction())
+		if e.Action != nil {
+			switch *e.Action {
+			case "labeled":
+				if e.Label != nil {
+					services.RecordWebhookHandled(eventType, action)
+					return true, handleLabeledEvent(db, slack, e)
+				}
+			case "unlabeled":
+				if e.Label != nil {
+					services.RecordWebhookHandled(eventType, action)
+					handleUnlabeledEvent(db, slack, e)
+					return true, nil
+				}
+			case "closed":
+				services.RecordWebhookHandled(eventType, action)
+				handleClosedEvent(db, slack, e)
+				return true, nil
+			case "review_requested":
+				services.RecordWebhookHandled(eventType, action)
+				handleReviewRequestedEvent(db, slack, e)
+				return true, nil
+			}
+		}
+	case *github.PullRequestReviewEvent:
+		log.Printf("PullRequestReviewEvent received: action=%s", e.GetAction())
+		if e.Action != nil && (*e.Action == "submitted" || *e.Action == "dismissed") {
+			services.RecordWebhookHandled(eventType, action)
+			handleReviewSubmittedEvent(db, slack, e)
+			return true, nil
+		}
+	case *github.InstallationEvent:
+		log.Printf("InstallationEvent received: action=%s", e.GetAction())
+		services.RecordWebhookHandled(eventType, action)
+		return true, handleInstallationEvent(db, e)
+	case *github.InstallationRepositoriesEvent:
+		log.Printf("InstallationRepositoriesEvent received: action=%s", e.GetAction())
+		services.RecordWebhookHandled(eventType, action)
+		return true, handleInstallationRepositoriesEvent(db, e)
+	default:
+		log.Printf("Unknown event type received: %T", e)
+	}
+	return false, nil
+}
+
+// handleLabeledEvent creates a review task and posts the notification for
+// each channel watching the added label. It returns a database lock error
+// after trying every channel, so the queue retries the event; channels that
+// already got their task are skipped then.
+func handleLabeledEvent(db *gorm.DB, slack services.SlackClient, e *github.PullRequestEvent) error {
 	pr := e.PullRequest
 	repo := e.Repo
 	addedLabel := e.Label
@@ -77,7 +235,7 @@ func handleLabeledEvent(c *gin.Context, db *gorm.DB, e *github.PullRequestEvent)
 
 	if addedLabel == nil || addedLabel.Name == nil {
 		log.Printf("added label is nil or has no name")
-		return
+		return nil
 	}
 
 	addedLabelName := *addedLabel.Name
@@ -89,15 +247,18 @@ func handleLabeledEvent(c *gin.Context, db *gorm.DB, e *github.PullRequestEvent)
 
 	if len(configs) == 0 {
 		log.Println("no active channel config found")
-		c.JSON(http.StatusOK, gin.H{"message": "no active channel config"})
-		return
+		return nil
 	}
 
 	notified := false
+	var lockErr error
 
 	for _, config := range configs {
+		// Call Slack as the workspace that owns the config
+		slack := slack.ForTeam(config.TeamID)
+
 		// Check if channel is archived
-		isArchived, checkErr := services.IsChannelArchived(config.SlackChannelID)
+		isArchived, checkErr := services.IsChannelArchived(slack, config.SlackChannelID)
 		if checkErr != nil {
 			log.Printf("channel status check error (channel: %s): %v", config.SlackChannelID, checkErr)
 		}
@@ -137,209 +298,83 @@ func handleLabeledEvent(c *gin.Context, db *gorm.DB, e *github.PullRequestEvent)
 			continue
 		}
 
-		// Transaction with retry handling
-		const maxRetries = 3
-		const baseDelay = 100 * time.Millisecond
-
 		var taskCreated bool
-		var txErr error
 		var processTask func()
 
-		for retry := 0; retry < maxRetries; retry++ {
-			txErr = db.Transaction(func(tx *gorm.DB) error {
-				// Check for existing active tasks (only create one per channel and PR)
-				var existingTask models.ReviewTask
-				existingErr := tx.Where("repo = ? AND pr_number = ? AND slack_channel = ? AND status IN (?)",
-					repoFullName, pr.GetNumber(), config.SlackChannelID,
-					[]string{"pending", "in_review", "snoozed", "waiting_business_hours"}).
-					First(&existingTask).Error
-
-				if existingErr == nil {
-					// Skip if an active task already exists (regardless of label name)
-					log.Printf("active task already exists for PR %d in channel %s (existing label: %s, current config: %s), skipping",
-						pr.GetNumber(), config.SlackChannelID, existingTask.LabelName, config.LabelName)
-					taskCreated = false
-					return nil // End transaction normally and skip
-				}
-
-				// First create a temporary task record (before sending Slack message)
-				tempTask := models.ReviewTask{
-					ID:           uuid.NewString(),
-					PRURL:        pr.GetHTMLURL(),
-					Repo:         repoFullName,
-					PRNumber:     pr.GetNumber(),
-					Title:        pr.GetTitle(),
-					SlackTS:      "", // Updated later
-					SlackChannel: config.SlackChannelID,
-					Reviewer:     "",        // Updated later
-					Status:       "pending", // Temporary state
-					LabelName:    config.LabelName,
-					Language:     config.Language,
-					CreatedAt:    time.Now(),
-					UpdatedAt:    time.Now(),
-				}
-
-				if err := tx.Create(&tempTask).Error; err != nil {
-					log.Printf("temp task insert failed (channel: %s): %v", config.SlackChannelID, err)
-					return err
-				}
-
-				taskCreated = true
-
-				// Send Slack message and update task outside the transaction
-				processTask = func() {
-					var slackTs, slackChannelID string
-					var taskStatus string
-					var reviewerID string
-					var reviewersStr string
-
-					// Check if outside business hours
-					creatorGithubUsername := pr.GetUser().GetLogin()
-					creatorSlackID := services.GetSlackUserIDFromGitHub(db, creatorGithubUsername)
-					if creatorSlackID != "" {
-						log.Printf("PR creator slack ID found: github=%s, slack=%s", creatorGithubUsername, creatorSlackID)
-					}
-
-					if !services.IsWithinBusinessHours(&config, time.Now()) {
-						// Outside business hours: send message without mention
-						var err error
-						slackTs, slackChannelID, err = services.SendSlackMessageOffHours(
-							pr.GetHTMLURL(),
-							pr.GetTitle(),
-							config.SlackChannelID,
-							creatorSlackID,
-							config.Language,
-						)
-						taskStatus = "waiting_business_hours"
-						// Reviewer will be set on the next business day morning
-						reviewerID = ""
-						if err != nil {
-							log.Printf("off-hours slack message failed (channel: %s): %v", config.SlackChannelID, err)
-							// Delete task on error
-							db.Delete(&tempTask)
-							return
-						}
-						log.Printf("off-hours message sent: ts=%s, channel=%s", slackTs, slackChannelID)
-					} else {
-						// During business hours: send message with mention
-						var err error
-						slackTs, slackChannelID, err = services.SendSlackMessage(
-							pr.GetHTMLURL(),
-							pr.GetTitle(),
-							config.SlackChannelID,
-							config.DefaultMentionID,
-							creatorSlackID,
-							config.Language,
-						)
-						taskStatus = "in_review"
-						if err != nil {
-							log.Printf("business hours slack message failed (channel: %s): %v", config.SlackChannelID, err)
-							db.Delete(&tempTask)
-							return
-						}
-						log.Printf("business hours message sent: ts=%s, channel=%s", slackTs, slackChannelID)
-
-						// Add PR author to exclusion ID list
-						excludeIDs := []string{}
-						if creatorSlackID != "" {
-							excludeIDs = append(excludeIDs, creatorSlackID)
-						}
-
-						// Get required number of approvals
-						requiredApprovals := config.RequiredApprovals
-						if requiredApprovals <= 0 {
-							requiredApprovals = 1
-						}
-
-						// Select reviewers
-						reviewerIDs := services.SelectRandomReviewers(db, config.SlackChannelID, config.LabelName, requiredApprovals, excludeIDs)
-						if len(reviewerIDs) > 0 {
-							reviewerID = reviewerIDs[0]
-						}
-						reviewersStr = strings.Join(reviewerIDs, ",")
-					}
-
-					// Update task to its final state
-					updates := map[string]interface{}{
-						"slack_ts":          slackTs,
-						"reviewer":          reviewerID,
-						"reviewers":         reviewersStr,
-						"pr_author_slack_id": creatorSlackID,
-						"status":            taskStatus,
-						"updated_at":        time.Now(),
-					}
-
-					if err := db.Model(&models.ReviewTask{}).Where("id = ?", tempTask.ID).Updates(updates).Error; err != nil {
-						log.Printf("task update failed (channel: %s): %v", config.SlackChannelID, err)
-						return
-					}
-
-					// Also update local object
-					tempTask.SlackTS = slackTs
-					tempTask.Reviewer = reviewerID
-					tempTask.Reviewers = reviewersStr
-					tempTask.PRAuthorSlackID = creatorSlackID
-					tempTask.Status = taskStatus
-					tempTask.UpdatedAt = time.Now()
+		txErr := db.Transaction(func(tx *gorm.DB) error {
+			// Check for existing active tasks (only create one per channel and PR)
+			var existingTask models.ReviewTask
+			existingErr := tx.Where("repo = ? AND pr_number = ? AND slack_channel = ? AND status IN (?)",
+				repoFullName, pr.GetNumber(), config.SlackChannelID,
+				[]string{"pending", "in_review", "snoozed", "waiting_business_hours"}).
+				First(&existingTask).Error
+
+			if existingErr == nil {
+				// Skip if an active task already exists (regardless of label name)
+				log.Printf("active task already exists for PR %d in channel %s (existing label: %s, current config: %s), skipping",
+					pr.GetNumber(), config.SlackChannelID, existingTask.LabelName, config.LabelName)
+				taskCreated = false
+				return nil // End transaction normally and skip
+			}
 
-					log.Printf("pr registered (channel: %s): %s", config.SlackChannelID, tempTask.PRURL)
+			// First create a temporary task record (before sending Slack message)
+			tempTask := models.ReviewTask{
+				ID:           uuid.NewString(),
+				TeamID:       config.TeamID,
+				PRURL:        pr.GetHTMLURL(),
+				Repo:         repoFullName,
+				PRNumber:     pr.GetNumber(),
+				Title:        pr.GetTitle(),
+				SlackTS:      "", // Updated later
+				SlackChannel: config.SlackChannelID,
+				Reviewer:     "",        // Updated later
+				Status:       "pending", // Temporary state
+				LabelName:    config.LabelName,
+				Language:     config.Language,
+				CreatedAt:    time.Now(),
+				UpdatedAt:    time.Now(),
+			}
 
-					// Only notify in thread during business hours when a reviewer is assigned
-					if taskStatus == "in_review" && reviewerID != "" {
-						if err := services.PostReviewerAssignedMessageWithChangeButton(tempTask); err != nil {
-							log.Printf("reviewer assigned notification error: %v", err)
-						}
-					}
-				}
+			if err := tx.Create(&tempTask).Error; err != nil {
+				log.Printf("temp task insert failed (channel: %s): %v", config.SlackChannelID, err)
+				return err
+			}
 
-				return nil
-			})
+			taskCreated = true
 
-			// Break out of loop on success
-			if txErr == nil {
-				// Execute task update after successful transaction
-				if taskCreated {
-					// Run synchronously in test mode, asynchronously via goroutine in production
-					if services.IsTestMode {
-						processTask()
-					} else {
-						go processTask()
-					}
+			// Send Slack message and update task outside the transaction
+			processTask = func() {
+				if err := services.NotifyPendingTask(db, slack, tempTask, config, pr); err != nil {
+					log.Printf("%v (channel: %s)", err, config.SlackChannelID)
+					// Delete the task unless its message was posted; the
+					// recovery sweep finishes that one without reposting
+					db.Where("slack_ts = ?", "").Delete(&tempTask)
 				}
-				break
 			}
 
-			// Retry on database lock error
-			if strings.Contains(txErr.Error(), "database is locked") && retry < maxRetries-1 {
-				delay := baseDelay * time.Duration(1<<retry) // Exponential backoff
-				log.Printf("database lock detected for channel %s, retrying in %v (attempt %d/%d)",
-					config.SlackChannelID, delay, retry+1, maxRetries)
-				time.Sleep(delay)
-				continue
-			}
-
-			// Other errors or max retries reached
-			break
-		}
-
+			return nil
+		})
 		if txErr != nil {
-			log.Printf("transaction failed for channel %s after %d retries: %v", config.SlackChannelID, maxRetries, txErr)
+			log.Printf("transaction failed for channel %s: %v", config.SlackChannelID, txErr)
+			if services.IsDBLockError(txErr) && lockErr == nil {
+				lockErr = txErr
+			}
 			continue
 		}
 
 		if taskCreated {
+			processTask()
 			notified = true
 		}
 	}
 
-	if !notified {
+	if !notified && lockErr == nil {
 		log.Println("no matching channel")
-		c.JSON(http.StatusOK, gin.H{"message": "no matching channel"})
-		return
 	}
+	return lockErr
 }
 
-func handleUnlabeledEvent(c *gin.Context, db *gorm.DB, e *github.PullRequestEvent) {
+func handleUnlabeledEvent(db *gorm.DB, slack services.SlackClient, e *github.PullRequestEvent) {
 	pr := e.PullRequest
 	repo := e.Repo
 	repoFullName := fmt.Sprintf("%s/%s", repo.GetOwner().GetLogin(), repo.GetName())
@@ -364,7 +399,7 @@ func handleUnlabeledEvent(c *gin.Context, db *gorm.DB, e *github.PullRequestEven
 		// Find the config corresponding to this task
 		var matchingConfig *models.ChannelConfig
 		for _, config := range configs {
-			if config.SlackChannelID == task.SlackChannel && config.LabelName == task.LabelName {
+			if config.TeamID == task.TeamID && config.SlackChannelID == task.SlackChannel && config.LabelName == task.LabelName {
 				matchingConfig = &config
 				break
 			}
@@ -383,13 +418,13 @@ func handleUnlabeledEvent(c *gin.Context, db *gorm.DB, e *github.PullRequestEven
 			missingLabels := services.GetMissingLabels(matchingConfig, pr.Labels)
 
 			// Update Slack message to notify task completion
-			if err := services.UpdateSlackMessageForCompletedTask(task); err != nil {
+			if err := services.UpdateSlackMessageForCompletedTask(slack, task); err != nil {
 				log.Printf("failed to update slack message for completed task: %v", err)
 				continue
 			}
 
 			// Notify in thread about completion due to label removal
-			if err := services.PostLabelRemovedNotification(task, missingLabels); err != nil {
+			if err := services.PostLabelRemovedNotification(slack, task, missingLabels); err != nil {
 				log.Printf("failed to post label removed notification: %v", err)
 				// Still complete the task even if notification fails
 			}
@@ -403,6 +438,7 @@ func handleUnlabeledEvent(c *gin.Context, db *gorm.DB, e *github.PullRequestEven
 			}
 
 			log.Printf("task completed due to unlabeled event: id=%s, repo=%s, pr=%d", task.ID, repoFullName, pr.GetNumber())
+			services.RecordReviewEvent(db, task, services.ReviewEventCompleted, "", "label_removed")
 		} else {
 			log.Printf("label conditions still met for task: %s, continuing", task.ID)
 		}
@@ -410,7 +446,7 @@ func handleUnlabeledEvent(c *gin.Context, db *gorm.DB, e *github.PullRequestEven
 }
 
 // handleClosedEvent handles the event when a PR is closed
-func handleClosedEvent(c *gin.Context, db *gorm.DB, e *github.PullRequestEvent) {
+func handleClosedEvent(db *gorm.DB, slack services.SlackClient, e *github.PullRequestEvent) {
 	pr := e.PullRequest
 	repo := e.Repo
 	repoFullName := fmt.Sprintf("%s/%s", repo.GetOwner().GetLogin(), repo.GetName())
@@ -430,7 +466,7 @@ func handleClosedEvent(c *gin.Context, db *gorm.DB, e *github.PullRequestEvent)
 	// Execute completion processing for each task
 	for _, task := range tasks {
 		// Send close notification to Slack
-		if err := services.PostPRClosedNotification(task, pr.GetMerged()); err != nil {
+		if err := services.PostPRClosedNotification(slack, task, pr.GetMerged()); err != nil {
 			log.Printf("failed to post PR closed notification: %v", err)
 			// Still complete the task even if notification fails
 		}
@@ -445,24 +481,43 @@ func handleClosedEvent(c *gin.Context, db *gorm.DB, e *github.PullRequestEvent)
 
 		log.Printf("task completed due to PR closed: id=%s, repo=%s, pr=%d, merged=%v",
 			task.ID, repoFullName, pr.GetNumber(), pr.GetMerged())
+
+		reason := "closed"
+		if pr.GetMerged() {
+			reason = "merged"
+		}
+		services.RecordReviewEvent(db, task, services.ReviewEventCompleted, "", reason)
+	}
+
+	if pr.GetMerged() {
+		// Tasks completed earlier (e.g. by approval) are merged now too
+		var mergedTasks []models.ReviewTask
+		db.Where("repo = ? AND pr_number = ?", repoFullName, pr.GetNumber()).Find(&mergedTasks)
+		for _, task := range mergedTasks {
+			services.RecordReviewEventOnce(db, task, services.ReviewEventMerged, "")
+		}
 	}
 }
 
 // handleReviewRequestedEvent handles the event when GitHub's re-request review button is pressed
-func handleReviewRequestedEvent(c *gin.Context, db *gorm.DB, e *github.PullRequestEvent) {
+func handleReviewRequestedEvent(db *gorm.DB, slack services.SlackClient, e *github.PullRequestEvent) {
 	pr := e.PullRequest
 	repo := e.Repo
 	repoFullName := fmt.Sprintf("%s/%s", repo.GetOwner().GetLogin(), repo.GetName())
 
 	requestedReviewer := e.GetRequestedReviewer()
-	if requestedReviewer == nil {
-		log.Printf("review_requested event has no requested reviewer (may be a team request), skipping: repo=%s, pr=%d",
+	requestedTeam := e.GetRequestedTeam()
+	if requestedReviewer == nil && requestedTeam == nil {
+		log.Printf("review_requested event has no requested reviewer or team, skipping: repo=%s, pr=%d",
 			repoFullName, pr.GetNumber())
 		return
 	}
 
 	senderLogin := e.GetSender().GetLogin()
 	reviewerLogin := requestedReviewer.GetLogin()
+	if requestedReviewer == nil {
+		reviewerLogin = fmt.Sprintf("%s/%s", repo.GetOwner().GetLogin(), requestedTeam.GetSlug())
+	}
 
 	log.Printf("handling review_requested event: repo=%s, pr=%d, sender=%s, requested_reviewer=%s",
 		repoFullName, pr.GetNumber(), senderLogin, reviewerLogin)
@@ -490,25 +545,30 @@ func handleReviewRequestedEvent(c *gin.Context, db *gorm.DB, e *github.PullReque
 		}
 	}
 
-	senderSlackID := services.GetSlackUserIDFromGitHub(db, senderLogin)
-	reviewerSlackID := services.GetSlackUserIDFromGitHub(db, reviewerLogin)
-
-	// Build mention strings
-	var senderMention string
-	if senderSlackID != "" {
-		senderMention = fmt.Sprintf("<@%s>", senderSlackID)
-	} else {
-		senderMention = senderLogin
-	}
-
-	var reviewerMention string
-	if reviewerSlackID != "" {
-		reviewerMention = fmt.Sprintf("<@%s>", reviewerSlackID)
-	} else {
-		reviewerMention = reviewerLogin
-	}
-
 	for _, latestTask := range channelLatestTasks {
+		// Build mention strings from the user mappings of the task's Slack team
+		senderSlackID := services.GetSlackUserIDFromGitHub(db, latestTask.TeamID, senderLogin)
+		senderMention := senderLogin
+		if senderSlackID != "" {
+			senderMention = fmt.Sprintf("<@%s>", senderSlackID)
+		}
+		reviewerMention := reviewerLogin
+		// Team requests mention the user group or members the task's Slack
+		// team mapped, falling back to the team name like unmapped users
+		var teamMapping *models.TeamMapping
+		if requestedReviewer != nil {
+			if reviewerSlackID := services.GetSlackUserIDFromGitHub(db, latestTask.TeamID, reviewerLogin); reviewerSlackID != "" {
+				reviewerMention = fmt.Sprintf("<@%s>", reviewerSlackID)
+			}
+		} else if mapping, err := services.GetTeamMapping(db, latestTask.TeamID, reviewerLogin); err == nil {
+			teamMapping = mapping
+			if mention := services.TeamMention(mapping); mention != "" {
+				reviewerMention = mention
+			}
+		} else {
+			log.Printf("team mapping not found for github team: %s (slack team: %s)", reviewerLogin, latestTask.TeamID)
+		}
+
 		// Revert completed tasks to in_review
 		if latestTask.Status == "completed" {
 			result := db.Model(&models.ReviewTask{}).
@@ -528,13 +588,21 @@ func handleReviewRequestedEvent(c *gin.Context, db *gorm.DB, e *github.PullReque
 			log.Printf("task reactivated for re-review: id=%s, repo=%s, pr=%d", latestTask.ID, repoFullName, pr.GetNumber())
 		}
 
+		// Optionally hand a team request to one member of the team
+		taskReviewerMention := reviewerMention
+		if teamMapping != nil {
+			if picked := services.AssignTeamMember(db, teamMapping, &latestTask, []string{senderSlackID, latestTask.PRAuthorSlackID}); picked != "" {
+				taskReviewerMention = fmt.Sprintf("<@%s>", picked)
+			}
+		}
+
 		// Check business hours before sending re-review notification
 		var config models.ChannelConfig
 		labelName := latestTask.LabelName
 		if labelName == "" {
 			labelName = "needs-review"
 		}
-		if err := db.Where("slack_channel_id = ? AND label_name = ?", latestTask.SlackChannel, labelName).First(&config).Error; err == nil {
+		if err := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", latestTask.TeamID, latestTask.SlackChannel, labelName).First(&config).Error; err == nil {
 			now := time.Now()
 			if !services.IsWithinBusinessHours(&config, now) {
 				// Outside business hours: append to pending sender/reviewer (support multiple re-reviews)
@@ -544,10 +612,10 @@ func handleReviewRequestedEvent(c *gin.Context, db *gorm.DB, e *github.PullReque
 					continue
 				}
 				newSender := senderMention
-				newReviewer := reviewerMention
+				newReviewer := taskReviewerMention
 				if current.PendingReReviewNotify && current.PendingReReviewSender != "" {
 					newSender = current.PendingReReviewSender + "," + senderMention
-					newReviewer = current.PendingReReviewReviewer + "," + reviewerMention
+					newReviewer = current.PendingReReviewReviewer + "," + taskReviewerMention
 				}
 				// CAS: include updated_at in WHERE to detect concurrent modifications
 				result := db.Model(&models.ReviewTask{}).
@@ -570,7 +638,7 @@ func handleReviewRequestedEvent(c *gin.Context, db *gorm.DB, e *github.PullReque
 				// Send immediate feedback without mention so sender knows the request was received
 				t := i18n.L(latestTask.Language)
 				deferMsg := t("notify.re_review_deferred", senderLogin)
-				if err := services.PostToThread(latestTask.SlackChannel, latestTask.SlackTS, deferMsg); err != nil {
+				if err := services.PostToThread(slack, latestTask.SlackChannel, latestTask.SlackTS, deferMsg); err != nil {
 					log.Printf("deferred re-review feedback message error: %v", err)
 				}
 				continue
@@ -579,15 +647,15 @@ func handleReviewRequestedEvent(c *gin.Context, db *gorm.DB, e *github.PullReque
 
 		// Post re-review request notification to thread
 		t := i18n.L(latestTask.Language)
-		message := t("notify.re_review_requested", senderMention, reviewerMention)
-		if err := services.PostToThread(latestTask.SlackChannel, latestTask.SlackTS, message); err != nil {
+		message := t("notify.re_review_requested", senderMention, taskReviewerMention)
+		if err := services.PostToThread(slack, latestTask.SlackChannel, latestTask.SlackTS, message); err != nil {
 			log.Printf("re-review notification error: %v", err)
 		}
 	}
 }
 
 // handleReviewSubmittedEvent handles the event when a review is submitted
-func handleReviewSubmittedEvent(c *gin.Context, db *gorm.DB, e *github.PullRequestReviewEvent) {
+func handleReviewSubmittedEvent(db *gorm.DB, slack services.SlackClient, e *github.PullRequestReviewEvent) {
 	pr := e.PullRequest
 	repo := e.Repo
 	review := e.Review
@@ -636,16 +704,16 @@ func handleReviewSubmittedEvent(c *gin.Context, db *gorm.DB, e *github.PullReque
 		}
 	}
 
-	// Get Slack ID from reviewer's GitHub username
-	reviewerSlackID := services.GetSlackUserIDFromGitHub(db, review.GetUser().GetLogin())
-
 	// Send notifications for the latest task in each channel
 	for channel, latestTask := range channelLatestTasks {
+		// Get Slack ID from reviewer's GitHub username
+		reviewerSlackID := services.GetSlackUserIDFromGitHub(db, latestTask.TeamID, review.GetUser().GetLogin())
+
 		// Get RequiredApprovals
 		requiredApprovals := 1
 		if latestTask.LabelName != "" {
 			var config models.ChannelConfig
-			if err := db.Where("slack_channel_id = ? AND label_name = ?", latestTask.SlackChannel, latestTask.LabelName).First(&config).Error; err == nil {
+			if err := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", latestTask.TeamID, latestTask.SlackChannel, latestTask.LabelName).First(&config).Error; err == nil {
 				if config.RequiredApprovals > 0 {
 					requiredApprovals = config.RequiredApprovals
 				}
@@ -657,6 +725,10 @@ func handleReviewSubmittedEvent(c *gin.Context, db *gorm.DB, e *github.PullReque
 			approvalID = review.GetUser().GetLogin()
 		}
 
+		if reviewState != "dismissed" {
+			services.RecordReviewEventOnce(db, latestTask, services.ReviewEventFirstReview, approvalID)
+		}
+
 		switch reviewState {
 		case "dismissed":
 			// Only remove the dismissed reviewer from approved_by
@@ -681,7 +753,7 @@ func handleReviewSubmittedEvent(c *gin.Context, db *gorm.DB, e *github.PullReque
 
 		case "approved":
 			// Post review completion notification to thread
-			if err := services.SendReviewCompletedAutoNotification(latestTask, review.GetUser().GetLogin(), reviewState); err != nil {
+			if err := services.SendReviewCompletedAutoNotification(slack, latestTask, review.GetUser().GetLogin(), reviewState); err != nil {
 				log.Printf("failed to send review completed notification: %v", err)
 				if !services.IsChannelRelatedError(err) {
 					continue
@@ -690,7 +762,7 @@ func handleReviewSubmittedEvent(c *gin.Context, db *gorm.DB, e *github.PullReque
 
 			// Approval tracking: use CAS-like WHERE clause to prevent concurrent approval conflicts
 			oldApprovedBy := latestTask.ApprovedBy
-			services.AddApproval(&latestTask, approvalID)
+			newApproval := services.AddApproval(&latestTask, approvalID)
 
 			// Determine if all required approvals are met
 			fullyApproved := services.IsReviewFullyApproved(latestTask, requiredApprovals)
@@ -700,10 +772,15 @@ func handleReviewSubmittedEvent(c *gin.Context, db *gorm.DB, e *github.PullReque
 				approvedCount := services.CountApprovals(latestTask)
 				t := i18n.L(latestTask.Language)
 				completeMsg := t("notify.fully_approved", approvedCount, requiredApprovals)
-				if err := services.PostToThread(latestTask.SlackChannel, latestTask.SlackTS, completeMsg); err != nil {
+				if err := services.PostToThread(slack, latestTask.SlackChannel, latestTask.SlackTS, completeMsg); err != nil {
 					log.Printf("failed to post review complete message: %v", err)
 				}
 
+				if newApproval {
+					services.RecordReviewEvent(db, latestTask, services.ReviewEventApproved, approvalID, "")
+				}
+				services.RecordReviewEventOnce(db, latestTask, services.ReviewEventFullyApproved, approvalID)
+
 				// All approvals met -> mark all tasks as completed
 				var channelTasks []models.ReviewTask
 				db.Where("repo = ? AND pr_number = ? AND slack_channel = ? AND status IN ?",
@@ -719,6 +796,7 @@ func handleReviewSubmittedEvent(c *gin.Context, db *gorm.DB, e *github.PullReque
 							log.Printf("failed to update task status to completed: %v", err)
 							continue
 						}
+						services.RecordReviewEvent(db, task, services.ReviewEventCompleted, "", "approved")
 						if task.ID == latestTask.ID {
 							log.Printf("task auto-completed due to review: id=%s, repo=%s, pr=%d, reviewer=%s",
 								task.ID, repoFullName, pr.GetNumber(), review.GetUser().GetLogin())
@@ -747,11 +825,14 @@ func handleReviewSubmittedEvent(c *gin.Context, db *gorm.DB, e *github.PullReque
 					log.Printf("CAS conflict on approval update, skipping: id=%s", latestTask.ID)
 					continue
 				}
+				if newApproval {
+					services.RecordReviewEvent(db, latestTask, services.ReviewEventApproved, approvalID, "")
+				}
 
 				// Post progress message to thread
 				approvedCount := services.CountApprovals(latestTask)
 				progressMsg := fmt.Sprintf("✅ %d/%d approved", approvedCount, requiredApprovals)
-				if err := services.PostToThread(latestTask.SlackChannel, latestTask.SlackTS, progressMsg); err != nil {
+				if err := services.PostToThread(slack, latestTask.SlackChannel, latestTask.SlackTS, progressMsg); err != nil {
 					log.Printf("failed to post approval progress: %v", err)
 				}
 
@@ -761,7 +842,7 @@ func handleReviewSubmittedEvent(c *gin.Context, db *gorm.DB, e *github.PullReque
 
 		default:
 			// changes_requested, commented, etc.
-			if err := services.SendReviewCompletedAutoNotification(latestTask, review.GetUser().GetLogin(), reviewState); err != nil {
+			if err := services.SendReviewCompletedAutoNotification(slack, latestTask, review.GetUser().GetLogin(), reviewState); err != nil {
 				log.Printf("failed to send review completed notification: %v", err)
 				if !services.IsChannelRelatedError(err) {
 					continue
@@ -783,7 +864,44 @@ func handleReviewSubmittedEvent(c *gin.Context, db *gorm.DB, e *github.PullReque
 				}
 				log.Printf("task completed due to review (%s): id=%s, repo=%s, pr=%d, reviewer=%s",
 					reviewState, task.ID, repoFullName, pr.GetNumber(), review.GetUser().GetLogin())
+				services.RecordReviewEvent(db, task, services.ReviewEventCompleted, "", reviewState)
 			}
 		}
 	}
 }
+
+// handleInstallationEvent records GitHub App installations so API calls on
+// their repositories use their tokens.
+func handleInstallationEvent(db *gorm.DB, e *github.InstallationEvent) error {
+	installation := e.GetInstallation()
+	if installation == nil {
+		return nil
+	}
+	switch e.GetAction() {
+	case "created":
+		log.Printf("github app installed on %s (installation: %d, repositories: %d)",
+			installation.GetAccount().GetLogin(), installation.GetID(), len(e.Repositories))
+		return services.SaveGitHubInstallation(db, installation, e.Repositories)
+	case "deleted":
+		log.Printf("github app uninstalled from %s (installation: %d)", installation.GetAccount().GetLogin(), installation.GetID())
+		return services.DeleteGitHubInstallation(db, installation.GetID())
+	default:
+		// suspend, unsuspend and new_permissions_accepted
+		return services.UpdateGitHubInstallation(db, installation)
+	}
+}
+
+// handleInstallationRepositoriesEvent applies repositories added to or
+// removed from a GitHub App installation.
+func handleInstallationRepositoriesEvent(db *gorm.DB, e *github.InstallationRepositoriesEvent) error {
+	installation := e.GetInstallation()
+	if installation == nil {
+		return nil
+	}
+	log.Printf("github installation %d repositories changed: added=%d, removed=%d",
+		installation.GetID(), len(e.RepositoriesAdded), len(e.RepositoriesRemoved))
+	if e.RepositorySelection != nil {
+		installation.RepositorySelection = e.RepositorySelection
+	}
+	return services.UpdateGitHubInstallationRepositories(db, installation, e.RepositoriesAdded, e.RepositoriesRemoved)
+}
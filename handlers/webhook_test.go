@@ -3,9 +3,9 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"strings"
 	"testing"
 	"time"
@@ -15,7 +15,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/go-github/v71/github"
-	"github.com/h2non/gock"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -23,7 +22,7 @@ func TestUnlabeledEventWithExistingTask(t *testing.T) {
 	// Setup
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
+	slack := services.NewFakeSlackClient()
 
 	// Create channel config
 	config := models.ChannelConfig{
@@ -81,7 +80,7 @@ func TestUnlabeledEventWithExistingTask(t *testing.T) {
 
 	// Test the handler
 	router := gin.New()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 
 	jsonPayload, _ := json.Marshal(payload)
 	req, _ := http.NewRequest("POST", "/webhook", bytes.NewBuffer(jsonPayload))
@@ -98,13 +97,14 @@ func TestUnlabeledEventWithExistingTask(t *testing.T) {
 	var updatedTask models.ReviewTask
 	db.First(&updatedTask, "id = ?", "test-task-id")
 	assert.Equal(t, "completed", updatedTask.Status)
+	assert.Len(t, slack.Updates(), 1, "The original message should be marked as completed")
 }
 
 func TestUnlabeledEventWithoutExistingTask(t *testing.T) {
 	// Setup
 	db := setupTestDB(t)
+	slack := services.NewFakeSlackClient()
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
 
 	// Create unlabeled event payload (no corresponding task)
 	prNumber := 456
@@ -136,7 +136,7 @@ func TestUnlabeledEventWithoutExistingTask(t *testing.T) {
 
 	// Test the handler
 	router := gin.New()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 
 	jsonPayload, _ := json.Marshal(payload)
 	req, _ := http.NewRequest("POST", "/webhook", bytes.NewBuffer(jsonPayload))
@@ -158,8 +158,8 @@ func TestUnlabeledEventWithoutExistingTask(t *testing.T) {
 func TestUnlabeledEventWithWaitingBusinessHoursTask(t *testing.T) {
 	// Setup
 	db := setupTestDB(t)
+	slack := services.NewFakeSlackClient()
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
 
 	// Create channel config
 	config := models.ChannelConfig{
@@ -226,7 +226,7 @@ func TestUnlabeledEventWithWaitingBusinessHoursTask(t *testing.T) {
 
 	// Create Gin router and execute request
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	// Verify HTTP status is 200
@@ -244,26 +244,7 @@ func TestHandleReviewSubmittedEvent(t *testing.T) {
 	// Test DB
 	db := setupTestDB(t)
 
-	// Save environment variables and restore after test
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-
-	// Set test environment variables
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	// Mock setup
-	defer gock.Off() // Clean up mocks at test end
-
-	// Mock Slack API success response
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		MatchHeader("Authorization", "Bearer test-token").
-		Reply(200).
-		JSON(map[string]interface{}{
-			"ok": true,
-		})
+	slack := services.NewFakeSlackClient()
 
 	// Create test task
 	task := models.ReviewTask{
@@ -309,7 +290,7 @@ func TestHandleReviewSubmittedEvent(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	r := gin.Default()
-	r.POST("/webhook", HandleGitHubWebhook(db))
+	r.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	r.ServeHTTP(w, req)
 
 	// Verify status code
@@ -320,38 +301,16 @@ func TestHandleReviewSubmittedEvent(t *testing.T) {
 	db.Where("id = ?", "test-task-review").First(&updatedTask)
 	assert.Equal(t, "completed", updatedTask.Status)
 
-	// Verify mocks were used
-	assert.True(t, gock.IsDone(), "Not all mocks were consumed")
+	// The approval and the completion are both posted to the thread
+	assert.Len(t, slack.Messages(), 2)
 }
 
 func TestHandleReviewSubmittedEventWithWaitingBusinessHoursTask(t *testing.T) {
 	// Test DB
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
-
-	// Save environment variables and restore after test
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-
-	// Set test environment variables
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	// Mock setup
-	defer gock.Off() // Clean up mocks at test end
-
-	// Mock Slack API success response
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		MatchHeader("Authorization", "Bearer test-token").
-		Reply(200).
-		JSON(map[string]interface{}{
-			"ok":      true,
-			"ts":      "1234567890.123456",
-			"channel": "C1234567890",
-		})
+
+	slack := services.NewFakeSlackClient()
 
 	// Create a review task waiting for business hours
 	task := models.ReviewTask{
@@ -408,7 +367,7 @@ func TestHandleReviewSubmittedEventWithWaitingBusinessHoursTask(t *testing.T) {
 
 	// Create Gin router and execute request
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	// Verify HTTP status is 200
@@ -421,8 +380,8 @@ func TestHandleReviewSubmittedEventWithWaitingBusinessHoursTask(t *testing.T) {
 	assert.Equal(t, "completed", updatedTask.Status)
 	assert.True(t, updatedTask.UpdatedAt.After(task.UpdatedAt))
 
-	// Verify mocks were used (Slack API was called)
-	assert.True(t, gock.IsDone(), "Not all mocks were consumed")
+	// The approval and the completion are both posted to the thread
+	assert.Len(t, slack.Messages(), 2)
 }
 
 func TestMultipleLabelMatching(t *testing.T) {
@@ -478,28 +437,7 @@ func TestMultipleLabelMatching(t *testing.T) {
 			// Setup
 			db := setupTestDB(t)
 			gin.SetMode(gin.TestMode)
-			services.IsTestMode = true
-
-			// Mock Slack API calls
-			// Mock for channel status check
-			gock.New("https://slack.com").
-				Get("/api/conversations.info").
-				Reply(200).
-				JSON(map[string]interface{}{
-					"ok":      true,
-					"channel": map[string]interface{}{"is_archived": false},
-				})
-
-			if tt.shouldNotify {
-				gock.New("https://slack.com").
-					Post("/api/chat.postMessage").
-					Reply(200).
-					JSON(map[string]interface{}{
-						"ok":      true,
-						"channel": "C1234567890",
-						"ts":      "1234567890.123456",
-					})
-			}
+			slack := services.NewFakeSlackClient()
 
 			// Create channel config
 			config := models.ChannelConfig{
@@ -547,7 +485,7 @@ func TestMultipleLabelMatching(t *testing.T) {
 
 			// Create Gin router and execute request
 			router := gin.Default()
-			router.POST("/webhook", HandleGitHubWebhook(db))
+			router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 			router.ServeHTTP(w, req)
 
 			// Verify HTTP status is 200
@@ -559,17 +497,11 @@ func TestMultipleLabelMatching(t *testing.T) {
 
 			if tt.shouldNotify {
 				assert.Equal(t, int64(1), taskCount, "Task should be created when notification is expected")
-
-				// Verify mocks were used
-				if gock.HasUnmatchedRequest() {
-					t.Log("Unmatched requests:", gock.GetUnmatchedRequests())
-				}
+				assert.Len(t, slack.Messages(), 1)
 			} else {
 				assert.Equal(t, int64(0), taskCount, "Task should not be created when notification is not expected")
+				assert.Empty(t, slack.Messages())
 			}
-
-			// Cleanup
-			gock.Off()
 		})
 	}
 }
@@ -628,7 +560,7 @@ func TestMultipleLabelUnlabeling(t *testing.T) {
 			// Setup
 			db := setupTestDB(t)
 			gin.SetMode(gin.TestMode)
-			services.IsTestMode = true
+			slack := services.NewFakeSlackClient()
 
 			// Create channel config
 			config := models.ChannelConfig{
@@ -656,26 +588,6 @@ func TestMultipleLabelUnlabeling(t *testing.T) {
 			}
 			db.Create(&task)
 
-			// Mock Slack API calls
-			if tt.shouldComplete {
-				// Update message on task completion
-				gock.New("https://slack.com").
-					Post("/api/chat.update").
-					Reply(200).
-					JSON(map[string]interface{}{
-						"ok": true,
-					})
-
-				// Notify completion due to label removal in thread
-				gock.New("https://slack.com").
-					Post("/api/chat.postMessage").
-					Reply(200).
-					JSON(map[string]interface{}{
-						"ok": true,
-						"ts": "1234567890.123457",
-					})
-			}
-
 			// Create unlabeled event payload
 			action := "unlabeled"
 			prNumber := 123
@@ -712,7 +624,7 @@ func TestMultipleLabelUnlabeling(t *testing.T) {
 
 			// Create Gin router and execute request
 			router := gin.Default()
-			router.POST("/webhook", HandleGitHubWebhook(db))
+			router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 			router.ServeHTTP(w, req)
 
 			// Verify HTTP status is 200
@@ -725,12 +637,11 @@ func TestMultipleLabelUnlabeling(t *testing.T) {
 
 			if tt.shouldComplete {
 				assert.Equal(t, "completed", updatedTask.Status, "Task should be in completed state")
+				assert.Len(t, slack.Updates(), 1)
 			} else {
 				assert.Equal(t, "in_review", updatedTask.Status, "Task should remain in in_review state")
+				assert.Empty(t, slack.Updates())
 			}
-
-			// Cleanup
-			gock.Off()
 		})
 	}
 }
@@ -740,30 +651,8 @@ func TestHandleReviewSubmittedEvent_SecondReviewAfterCompletion(t *testing.T) {
 	// Test DB
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
-
-	// Save environment variables and restore after test
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-
-	// Set test environment variables
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	// Mock setup
-	defer gock.Off() // Clean up mocks at test end
-
-	// Mock Slack API success response (for second review notification)
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		MatchHeader("Authorization", "Bearer test-token").
-		Reply(200).
-		JSON(map[string]interface{}{
-			"ok":      true,
-			"ts":      "1234567890.999999",
-			"channel": "C1234567890",
-		})
+
+	slack := services.NewFakeSlackClient()
 
 	// Create an already reviewed (completed) task
 	task := models.ReviewTask{
@@ -821,7 +710,7 @@ func TestHandleReviewSubmittedEvent_SecondReviewAfterCompletion(t *testing.T) {
 
 	// Create Gin router and execute request
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	// Verify HTTP status is 200
@@ -834,7 +723,7 @@ func TestHandleReviewSubmittedEvent_SecondReviewAfterCompletion(t *testing.T) {
 	assert.Equal(t, "completed", updatedTask.Status, "Task status should remain completed")
 
 	// Verify mocks were used (Slack API was called = notification was sent)
-	assert.True(t, gock.IsDone(), "Slack notification should be sent even for second review")
+	assert.Len(t, slack.Messages(), 1, "Slack notification should be sent even for second review")
 }
 
 // Test that when a PR has multiple tasks, notifications are only sent to the latest thread
@@ -842,26 +731,8 @@ func TestHandleReviewSubmittedEvent_OnlyLatestTaskReceivesNotification(t *testin
 	// Test DB
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
-
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	defer gock.Off() // Clean up mocks at test end
-
-	// Mock Slack API success response
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		MatchHeader("Authorization", "Bearer test-token").
-		Reply(200).
-		JSON(map[string]interface{}{
-			"ok":      true,
-			"ts":      "1234567890.999999",
-			"channel": "C1234567890",
-		})
+
+	slack := services.NewFakeSlackClient()
 
 	// Create an old task for the same PR (created 2 hours ago)
 	oldTask := models.ReviewTask{
@@ -936,18 +807,18 @@ func TestHandleReviewSubmittedEvent_OnlyLatestTaskReceivesNotification(t *testin
 
 	// Create Gin router and execute request
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	// Verify HTTP status is 200
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	// Verify mock was used exactly once (notification sent only to the latest task)
-	assert.True(t, gock.IsDone(), "Slack notification should be sent only to the latest task")
-
-	// Verify no unconsumed mocks (confirming it was not called twice)
-	pendingMocks := gock.Pending()
-	assert.Equal(t, 0, len(pendingMocks), "Slack API should be called only once (no notification for old tasks)")
+	// Verify the notifications were sent only to the latest task's thread
+	msgs := slack.Messages()
+	assert.NotEmpty(t, msgs)
+	for _, msg := range msgs {
+		assert.Equal(t, newTask.SlackTS, msg.ThreadTS, "Slack notification should be sent only to the latest task")
+	}
 }
 
 // Test that notifications are sent to tasks in different channels
@@ -955,37 +826,8 @@ func TestHandleReviewSubmittedEvent_DifferentChannelsReceiveNotifications(t *tes
 	// Test DB
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
-
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	defer gock.Off() // Clean up mocks at test end
-
-	// Mock for channel 1 notification
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		MatchHeader("Authorization", "Bearer test-token").
-		Reply(200).
-		JSON(map[string]interface{}{
-			"ok":      true,
-			"ts":      "1234567890.111111",
-			"channel": "C1111111111",
-		})
 
-	// Mock for channel 2 notification
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		MatchHeader("Authorization", "Bearer test-token").
-		Reply(200).
-		JSON(map[string]interface{}{
-			"ok":      true,
-			"ts":      "1234567890.222222",
-			"channel": "C2222222222",
-		})
+	slack := services.NewFakeSlackClient()
 
 	// Channel 1 task (needs-review label)
 	task1 := models.ReviewTask{
@@ -1060,18 +902,18 @@ func TestHandleReviewSubmittedEvent_DifferentChannelsReceiveNotifications(t *tes
 
 	// Create Gin router and execute request
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	// Verify HTTP status is 200
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	// Verify mocks were used twice (notifications sent to both channels)
-	assert.True(t, gock.IsDone(), "Slack notifications should be sent to both channels")
-
-	// Verify no unconsumed mocks
-	pendingMocks := gock.Pending()
-	assert.Equal(t, 0, len(pendingMocks), "Slack API should be called twice (once per channel)")
+	// Verify notifications were sent to both channels
+	channels := make(map[string]bool)
+	for _, msg := range slack.Messages() {
+		channels[msg.Channel] = true
+	}
+	assert.Equal(t, map[string]bool{"C1111111111": true, "C2222222222": true}, channels, "Slack notifications should be sent to both channels")
 }
 
 // Test that old tasks for the same channel and PR also get completed
@@ -1079,30 +921,8 @@ func TestHandleReviewSubmittedEvent_OldTasksAlsoCompleted(t *testing.T) {
 	// Test DB
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
-
-	// Save environment variables and restore after test
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-
-	// Set test environment variables
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	// Mock setup
-	defer gock.Off() // Clean up mocks at test end
-
-	// Mock Slack API success response (called only once - latest task only)
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		MatchHeader("Authorization", "Bearer test-token").
-		Reply(200).
-		JSON(map[string]interface{}{
-			"ok":      true,
-			"ts":      "1234567890.999999",
-			"channel": "C1234567890",
-		})
+
+	slack := services.NewFakeSlackClient()
 
 	// Create old task (in_review) for the same PR and channel
 	oldTask := models.ReviewTask{
@@ -1177,7 +997,7 @@ func TestHandleReviewSubmittedEvent_OldTasksAlsoCompleted(t *testing.T) {
 
 	// Create Gin router and execute request
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	// Verify HTTP status is 200
@@ -1193,8 +1013,12 @@ func TestHandleReviewSubmittedEvent_OldTasksAlsoCompleted(t *testing.T) {
 	db.Where("id = ?", "new-in-review-task").First(&updatedNewTask)
 	assert.Equal(t, "completed", updatedNewTask.Status, "New task should also be completed")
 
-	// Verify mocks were used
-	assert.True(t, gock.IsDone(), "Slack notification should be sent only to the latest task")
+	// Verify notifications were sent only to the latest task's thread
+	msgs := slack.Messages()
+	assert.NotEmpty(t, msgs)
+	for _, msg := range msgs {
+		assert.Equal(t, updatedNewTask.SlackTS, msg.ThreadTS, "Slack notification should be sent only to the latest task")
+	}
 }
 
 // --- Tests for completing tasks and stopping reminders on review comment/changes requested ---
@@ -1202,22 +1026,8 @@ func TestHandleReviewSubmittedEvent_OldTasksAlsoCompleted(t *testing.T) {
 func TestHandleReviewSubmittedEvent_CommentedCompletesTask(t *testing.T) {
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
 
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	defer gock.Off()
-
-	// Mock Slack API notification
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		MatchHeader("Authorization", "Bearer test-token").
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
+	slack := services.NewFakeSlackClient()
 
 	task := models.ReviewTask{
 		ID:           "commented-task",
@@ -1248,7 +1058,7 @@ func TestHandleReviewSubmittedEvent_CommentedCompletesTask(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
@@ -1261,22 +1071,8 @@ func TestHandleReviewSubmittedEvent_CommentedCompletesTask(t *testing.T) {
 func TestHandleReviewSubmittedEvent_ChangesRequestedCompletesTask(t *testing.T) {
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
-
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	defer gock.Off()
 
-	// Mock Slack API notification
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		MatchHeader("Authorization", "Bearer test-token").
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
+	slack := services.NewFakeSlackClient()
 
 	task := models.ReviewTask{
 		ID:           "changes-requested-task",
@@ -1307,7 +1103,7 @@ func TestHandleReviewSubmittedEvent_ChangesRequestedCompletesTask(t *testing.T)
 
 	w := httptest.NewRecorder()
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
@@ -1320,20 +1116,8 @@ func TestHandleReviewSubmittedEvent_ChangesRequestedCompletesTask(t *testing.T)
 func TestHandleReviewSubmittedEvent_CommentedCompletesOldTasksToo(t *testing.T) {
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
 
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	defer gock.Off()
-
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
+	slack := services.NewFakeSlackClient()
 
 	// Old task (in_review) for same PR and channel
 	oldTask := models.ReviewTask{
@@ -1381,7 +1165,7 @@ func TestHandleReviewSubmittedEvent_CommentedCompletesOldTasksToo(t *testing.T)
 
 	w := httptest.NewRecorder()
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
@@ -1399,20 +1183,8 @@ func TestHandleReviewSubmittedEvent_CommentedCompletesOldTasksToo(t *testing.T)
 func TestHandleReviewSubmittedEvent_SnoozedTaskCompletedOnComment(t *testing.T) {
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
-
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	defer gock.Off()
 
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
+	slack := services.NewFakeSlackClient()
 
 	// Task in snoozed state
 	task := models.ReviewTask{
@@ -1444,7 +1216,7 @@ func TestHandleReviewSubmittedEvent_SnoozedTaskCompletedOnComment(t *testing.T)
 
 	w := httptest.NewRecorder()
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
@@ -1457,20 +1229,8 @@ func TestHandleReviewSubmittedEvent_SnoozedTaskCompletedOnComment(t *testing.T)
 func TestHandleReviewSubmittedEvent_SnoozedTaskCompletedOnChangesRequested(t *testing.T) {
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
 
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	defer gock.Off()
-
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
+	slack := services.NewFakeSlackClient()
 
 	// Task in snoozed state
 	task := models.ReviewTask{
@@ -1502,7 +1262,7 @@ func TestHandleReviewSubmittedEvent_SnoozedTaskCompletedOnChangesRequested(t *te
 
 	w := httptest.NewRecorder()
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
@@ -1517,25 +1277,8 @@ func TestHandleReviewSubmittedEvent_SnoozedTaskCompletedOnChangesRequested(t *te
 func TestHandleReviewSubmittedEvent_SnoozedTaskCompletedOnFullApproval(t *testing.T) {
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
-
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	defer gock.Off()
-
-	// Review notification + completion message + approve notification
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
+
+	slack := services.NewFakeSlackClient()
 
 	// Channel config with RequiredApprovals=1
 	config := models.ChannelConfig{
@@ -1586,7 +1329,7 @@ func TestHandleReviewSubmittedEvent_SnoozedTaskCompletedOnFullApproval(t *testin
 
 	w := httptest.NewRecorder()
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
@@ -1602,27 +1345,8 @@ func TestHandleReviewSubmittedEvent_PartialApproval(t *testing.T) {
 	// Test DB
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
-
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	defer gock.Off()
 
-	// Mock for review notification
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
-
-	// Mock for progress message on partial approval
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
+	slack := services.NewFakeSlackClient()
 
 	// Channel config with RequiredApprovals=2
 	config := models.ChannelConfig{
@@ -1673,7 +1397,7 @@ func TestHandleReviewSubmittedEvent_PartialApproval(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
@@ -1689,21 +1413,8 @@ func TestHandleReviewSubmittedEvent_FullApproval(t *testing.T) {
 	// Test DB
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
-
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
 
-	defer gock.Off()
-
-	// Mock for review notification
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
+	slack := services.NewFakeSlackClient()
 
 	// Channel config with RequiredApprovals=2
 	config := models.ChannelConfig{
@@ -1755,7 +1466,7 @@ func TestHandleReviewSubmittedEvent_FullApproval(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
@@ -1772,21 +1483,8 @@ func TestHandleReviewSubmittedEvent_BackwardCompat(t *testing.T) {
 	// Test DB - existing behavior with RequiredApprovals=1 (default)
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
-
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
 
-	defer gock.Off()
-
-	// Mock for review notification
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
+	slack := services.NewFakeSlackClient()
 
 	// Channel config without RequiredApprovals set (default=1)
 	config := models.ChannelConfig{
@@ -1827,7 +1525,7 @@ func TestHandleReviewSubmittedEvent_BackwardCompat(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
@@ -1841,15 +1539,8 @@ func TestHandleReviewSubmittedEvent_BackwardCompat(t *testing.T) {
 func TestHandleReviewRequestedEvent_CompletedTask(t *testing.T) {
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
-
-	// Mock Slack API with gock
-	defer gock.Off()
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
 
+	slack := services.NewFakeSlackClient()
 	// Create completed task
 	task := models.ReviewTask{
 		ID:           "completed-task",
@@ -1880,7 +1571,7 @@ func TestHandleReviewRequestedEvent_CompletedTask(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
@@ -1890,22 +1581,15 @@ func TestHandleReviewRequestedEvent_CompletedTask(t *testing.T) {
 	db.Where("id = ?", "completed-task").First(&updatedTask)
 	assert.Equal(t, "in_review", updatedTask.Status)
 
-	// Notification should have been sent (gock mocks consumed)
-	assert.True(t, gock.IsDone(), "Re-request notification should be sent to Slack")
+	// Notification should have been sent
+	assert.Len(t, slack.Messages(), 1, "Re-request notification should be sent to Slack")
 }
 
 func TestHandleReviewRequestedEvent_InReviewTask(t *testing.T) {
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
-
-	// Mock Slack API with gock
-	defer gock.Off()
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
 
+	slack := services.NewFakeSlackClient()
 	// Create in_review task
 	task := models.ReviewTask{
 		ID:           "inreview-task",
@@ -1936,7 +1620,7 @@ func TestHandleReviewRequestedEvent_InReviewTask(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
@@ -1946,14 +1630,14 @@ func TestHandleReviewRequestedEvent_InReviewTask(t *testing.T) {
 	db.Where("id = ?", "inreview-task").First(&updatedTask)
 	assert.Equal(t, "in_review", updatedTask.Status)
 
-	// Notification should have been sent (gock mocks consumed)
-	assert.True(t, gock.IsDone(), "Re-request notification should be sent to Slack")
+	// Notification should have been sent
+	assert.Len(t, slack.Messages(), 1, "Re-request notification should be sent to Slack")
 }
 
 func TestHandleReviewSubmittedEvent_DismissedOnlyUpdatesApprovedBy(t *testing.T) {
 	db := setupTestDB(t)
+	slack := services.NewFakeSlackClient()
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
 
 	// Create channel config (requires 2 approvals)
 	config := models.ChannelConfig{
@@ -2003,7 +1687,7 @@ func TestHandleReviewSubmittedEvent_DismissedOnlyUpdatesApprovedBy(t *testing.T)
 
 	w := httptest.NewRecorder()
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
@@ -2019,15 +1703,8 @@ func TestHandleReviewSubmittedEvent_DismissedOnlyUpdatesApprovedBy(t *testing.T)
 func TestHandleReviewRequestedEvent_OutsideBusinessHours_DefersNotification(t *testing.T) {
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
-
-	defer gock.Off()
-	// Feedback message (without mention) should be sent even outside business hours
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
 
+	slack := services.NewFakeSlackClient()
 	// Set business hours to 03:00-03:01 so we're almost always outside
 	loc, _ := time.LoadLocation("Asia/Tokyo")
 	now := time.Now().In(loc)
@@ -2078,7 +1755,7 @@ func TestHandleReviewRequestedEvent_OutsideBusinessHours_DefersNotification(t *t
 
 	w := httptest.NewRecorder()
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
@@ -2088,20 +1765,14 @@ func TestHandleReviewRequestedEvent_OutsideBusinessHours_DefersNotification(t *t
 	assert.True(t, updatedTask.PendingReReviewNotify, "Re-review notification should be deferred outside business hours")
 	assert.NotEmpty(t, updatedTask.PendingReReviewSender, "Sender should be stored")
 	assert.NotEmpty(t, updatedTask.PendingReReviewReviewer, "Reviewer should be stored")
-	assert.True(t, gock.IsDone(), "Deferred feedback message should have been sent to Slack")
+	assert.Len(t, slack.Messages(), 1, "Deferred feedback message should have been sent to Slack")
 }
 
 func TestHandleReviewRequestedEvent_WithinBusinessHours_SendsImmediately(t *testing.T) {
 	db := setupTestDB(t)
 	gin.SetMode(gin.TestMode)
-	services.IsTestMode = true
-
-	defer gock.Off()
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
 
+	slack := services.NewFakeSlackClient()
 	// No channel config created — without config, business hours check is skipped
 	// and notification should be sent immediately
 
@@ -2134,7 +1805,7 @@ func TestHandleReviewRequestedEvent_WithinBusinessHours_SendsImmediately(t *test
 
 	w := httptest.NewRecorder()
 	router := gin.Default()
-	router.POST("/webhook", HandleGitHubWebhook(db))
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
@@ -2142,5 +1813,515 @@ func TestHandleReviewRequestedEvent_WithinBusinessHours_SendsImmediately(t *test
 	var updatedTask models.ReviewTask
 	db.Where("id = ?", "rereview-bh-task").First(&updatedTask)
 	assert.False(t, updatedTask.PendingReReviewNotify, "Notification should be sent immediately without business hours config")
-	assert.True(t, gock.IsDone(), "Slack notification should have been sent")
+	assert.Len(t, slack.Messages(), 1, "Slack notification should have been sent")
+}
+
+// A team review request mentions the mapped Slack user group in the thread.
+func TestHandleReviewRequestedEvent_TeamRequestMentionsUserGroup(t *testing.T) {
+	db := setupTestDB(t)
+	gin.SetMode(gin.TestMode)
+
+	slack := services.NewFakeSlackClient()
+	db.Create(&models.TeamMapping{ID: "team-1", TeamID: "T1", GithubTeamSlug: "owner/backend", SlackUserGroupID: "S0BACKEND"})
+	// Another workspace mapped its own team of the same name, and so did
+	// another organization
+	db.Create(&models.TeamMapping{ID: "team-1-other", TeamID: "T2", GithubTeamSlug: "owner/backend", SlackUserGroupID: "S0OTHER"})
+	db.Create(&models.TeamMapping{ID: "team-1-org", TeamID: "T1", GithubTeamSlug: "other-org/backend", SlackUserGroupID: "S0ORG"})
+	db.Create(&models.ReviewTask{
+		ID:           "rereview-team-task",
+		TeamID:       "T1",
+		PRURL:        "https://github.com/owner/repo/pull/502",
+		Repo:         "owner/repo",
+		PRNumber:     502,
+		Title:        "Test PR",
+		SlackTS:      "1234.9999",
+		SlackChannel: "C_NO_CONFIG",
+		Status:       "completed",
+		LabelName:    "needs-review",
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	})
+
+	payload := `{
+		"action": "review_requested",
+		"pull_request": {"number": 502, "html_url": "https://github.com/owner/repo/pull/502"},
+		"repository": {"full_name": "owner/repo", "owner": {"login": "owner"}, "name": "repo"},
+		"sender": {"login": "author"},
+		"requested_team": {"slug": "backend", "name": "Backend"}
+	}`
+
+	req, _ := http.NewRequest("POST", "/webhook", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", "pull_request")
+
+	w := httptest.NewRecorder()
+	router := gin.Default()
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var updatedTask models.ReviewTask
+	db.Where("id = ?", "rereview-team-task").First(&updatedTask)
+	assert.Equal(t, "in_review", updatedTask.Status, "Team re-review should reactivate the task")
+	msgs := slack.Messages()
+	if assert.Len(t, msgs, 1, "Team should have been mentioned in the thread") {
+		assert.Contains(t, msgs[0].Text, "<!subteam^S0BACKEND>")
+		assert.NotContains(t, msgs[0].Text, "S0OTHER")
+		assert.NotContains(t, msgs[0].Text, "S0ORG")
+	}
+}
+
+// With PickReviewer set, one available member other than the PR author is
+// assigned to the task and the off-hours notification is deferred to them.
+func TestHandleReviewRequestedEvent_TeamRequestPicksMember(t *testing.T) {
+	db := setupTestDB(t)
+	gin.SetMode(gin.TestMode)
+
+	slack := services.NewFakeSlackClient()
+	loc, _ := time.LoadLocation("Asia/Tokyo")
+	now := time.Now().In(loc)
+	if now.Hour() == 3 && now.Minute() == 0 {
+		t.Skip("Skipping: current time falls within the narrow test business hours window")
+	}
+
+	db.Create(&models.ChannelConfig{
+		ID:                 "config-team-pick",
+		SlackChannelID:     "C_TEAM_PICK",
+		LabelName:          "needs-review",
+		IsActive:           true,
+		BusinessHoursStart: "03:00",
+		BusinessHoursEnd:   "03:01",
+		Timezone:           "Asia/Tokyo",
+	})
+	db.Create(&models.TeamMapping{ID: "team-2", GithubTeamSlug: "owner/frontend", MemberIDs: "UAUTHOR,UMEMBER", PickReviewer: true})
+	db.Create(&models.ReviewTask{
+		ID:              "rereview-team-pick-task",
+		PRURL:           "https://github.com/owner/repo/pull/503",
+		Repo:            "owner/repo",
+		PRNumber:        503,
+		Title:           "Test PR",
+		SlackTS:         "1234.1010",
+		SlackChannel:    "C_TEAM_PICK",
+		Reviewer:        "UOLD",
+		Reviewers:       "UOLD",
+		PRAuthorSlackID: "UAUTHOR",
+		Status:          "in_review",
+		LabelName:       "needs-review",
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	})
+
+	payload := `{
+		"action": "review_requested",
+		"pull_request": {"number": 503, "html_url": "https://github.com/owner/repo/pull/503"},
+		"repository": {"full_name": "owner/repo", "owner": {"login": "owner"}, "name": "repo"},
+		"sender": {"login": "author"},
+		"requested_team": {"slug": "frontend", "name": "Frontend"}
+	}`
+
+	req, _ := http.NewRequest("POST", "/webhook", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", "pull_request")
+
+	w := httptest.NewRecorder()
+	router := gin.Default()
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var updatedTask models.ReviewTask
+	db.Where("id = ?", "rereview-team-pick-task").First(&updatedTask)
+	assert.Equal(t, "UOLD,UMEMBER", updatedTask.Reviewers)
+	assert.Equal(t, "UOLD", updatedTask.Reviewer)
+	if updatedTask.PendingReReviewNotify {
+		assert.Equal(t, "<@UMEMBER>", updatedTask.PendingReReviewReviewer)
+	}
+}
+
+// Reviewers already requested on GitHub are mapped through UserMapping and
+// assigned as-is instead of picking from the reviewer list. The task records
+// them whether it is assigned immediately or waits for business hours.
+func TestLabeledEvent_AssignsRequestedReviewers(t *testing.T) {
+	db := setupTestDB(t)
+	gin.SetMode(gin.TestMode)
+	slack := services.NewFakeSlackClient()
+
+	db.Create(&models.ChannelConfig{
+		ID:                "requested-reviewers-config",
+		SlackChannelID:    "C1234567890",
+		LabelName:         "needs-review",
+		DefaultMentionID:  "@here",
+		ReviewerList:      "ULIST1,ULIST2",
+		RepositoryList:    "test/repo",
+		RequiredApprovals: 1,
+		IsActive:          true,
+	})
+	db.Create(&models.UserMapping{ID: "map-requested", GithubUsername: "requested-user", SlackUserID: "UREQUESTED"})
+
+	action := "labeled"
+	prNumber := 321
+	payload := github.PullRequestEvent{
+		Action: &action,
+		Number: &prNumber,
+		Label:  &github.Label{Name: github.Ptr("needs-review")},
+		PullRequest: &github.PullRequest{
+			Number:  &prNumber,
+			HTMLURL: github.Ptr("https://github.com/test/repo/pull/321"),
+			Title:   github.Ptr("Requested reviewers PR"),
+			Labels:  []*github.Label{{Name: github.Ptr("needs-review")}},
+			User:    &github.User{Login: github.Ptr("author")},
+			RequestedReviewers: []*github.User{
+				{Login: github.Ptr("requested-user")},
+				{Login: github.Ptr("unmapped-user")},
+			},
+		},
+		Repo: &github.Repository{
+			FullName: github.Ptr("test/repo"),
+			Owner:    &github.User{Login: github.Ptr("test")},
+			Name:     github.Ptr("repo"),
+		},
+	}
+	payloadJSON, _ := json.Marshal(payload)
+
+	req, _ := http.NewRequest("POST", "/webhook", bytes.NewBuffer(payloadJSON))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", "pull_request")
+	w := httptest.NewRecorder()
+
+	router := gin.New()
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var task models.ReviewTask
+	err := db.Where("repo = ? AND pr_number = ?", "test/repo", 321).First(&task).Error
+	assert.NoError(t, err)
+	assert.Equal(t, "UREQUESTED", task.Reviewers)
+	if task.Status == "in_review" {
+		assert.Equal(t, "UREQUESTED", task.Reviewer)
+	}
+}
+
+// Reviews record the lifecycle events used by the stats command: the first
+// review once, every approval, and reaching full approval with completion.
+func TestHandleReviewSubmittedEvent_RecordsLifecycleEvents(t *testing.T) {
+	db := setupTestDB(t)
+	gin.SetMode(gin.TestMode)
+
+	slack := services.NewFakeSlackClient()
+	db.Create(&models.ChannelConfig{
+		ID:                "config-events",
+		SlackChannelID:    "C_EVENTS",
+		LabelName:         "needs-review",
+		RequiredApprovals: 2,
+		IsActive:          true,
+	})
+	db.Create(&models.UserMapping{ID: "mapping-e1", GithubUsername: "reviewer1", SlackUserID: "UREVIEWER1"})
+	db.Create(&models.UserMapping{ID: "mapping-e2", GithubUsername: "reviewer2", SlackUserID: "UREVIEWER2"})
+	db.Create(&models.ReviewTask{
+		ID:           "events-task",
+		PRURL:        "https://github.com/owner/repo/pull/300",
+		Repo:         "owner/repo",
+		PRNumber:     300,
+		SlackTS:      "1234.5678",
+		SlackChannel: "C_EVENTS",
+		Reviewer:     "UREVIEWER1",
+		Reviewers:    "UREVIEWER1,UREVIEWER2",
+		Status:       "in_review",
+		LabelName:    "needs-review",
+	})
+
+	router := gin.New()
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
+	for _, login := range []string{"reviewer1", "reviewer2"} {
+		payload := `{
+			"action": "submitted",
+			"pull_request": {"number": 300, "html_url": "https://github.com/owner/repo/pull/300"},
+			"repository": {"full_name": "owner/repo", "owner": {"login": "owner"}, "name": "repo"},
+			"review": {"state": "approved", "user": {"login": "` + login + `"}}
+		}`
+		req, _ := http.NewRequest("POST", "/webhook", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Event", "pull_request_review")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	var events []models.ReviewEvent
+	db.Where("task_id = ?", "events-task").Order("created_at").Find(&events)
+	var got []string
+	for _, e := range events {
+		got = append(got, e.EventType+":"+e.ActorID+":"+e.Detail)
+	}
+	assert.Equal(t, []string{
+		"first_review:UREVIEWER1:",
+		"approved:UREVIEWER1:",
+		"approved:UREVIEWER2:",
+		"fully_approved:UREVIEWER2:",
+		"completed::approved",
+	}, got)
+}
+
+// A delivery retried by GitHub or replayed by a proxy is processed only once.
+func TestHandleGitHubWebhook_SkipsDuplicateDelivery(t *testing.T) {
+	db := setupTestDB(t)
+	gin.SetMode(gin.TestMode)
+
+	slack := services.NewFakeSlackClient()
+	db.Create(&models.ReviewTask{
+		ID:           "dup-task",
+		PRURL:        "https://github.com/owner/repo/pull/321",
+		Repo:         "owner/repo",
+		PRNumber:     321,
+		Title:        "Test PR",
+		SlackTS:      "1234.5678",
+		SlackChannel: "C12345",
+		Status:       "in_review",
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	})
+
+	payload := `{
+		"action": "submitted",
+		"pull_request": {"number": 321, "html_url": "https://github.com/owner/repo/pull/321"},
+		"repository": {"full_name": "owner/repo", "owner": {"login": "owner"}, "name": "repo"},
+		"review": {"state": "approved", "user": {"login": "reviewer123"}}
+	}`
+
+	router := gin.New()
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
+	deliver := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/webhook", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Event", "pull_request_review")
+		req.Header.Set("X-GitHub-Delivery", "delivery-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, deliver().Code)
+	posted := len(slack.Messages())
+	assert.NotZero(t, posted)
+
+	w := deliver()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "duplicate delivery")
+	assert.Len(t, slack.Messages(), posted, "A duplicate delivery must not post again")
+
+	var delivery models.WebhookDelivery
+	assert.NoError(t, db.First(&delivery, "id = ?", "delivery-1").Error)
+	assert.Equal(t, services.WebhookDeliveryProcessed, delivery.Status)
+	assert.Equal(t, "pull_request_review", delivery.EventType)
+	assert.Equal(t, "submitted", delivery.Action)
+	assert.Equal(t, 1, delivery.Attempts)
+}
+
+// Deliveries acknowledged before a restart are processed when the server
+// starts again.
+func TestResumeWebhookDeliveries(t *testing.T) {
+	db := setupTestDB(t)
+
+	slack := services.NewFakeSlackClient()
+	db.Create(&models.ReviewTask{
+		ID:           "resume-task",
+		PRURL:        "https://github.com/owner/repo/pull/77",
+		Repo:         "owner/repo",
+		PRNumber:     77,
+		Title:        "Test PR",
+		SlackTS:      "1234.5678",
+		SlackChannel: "C12345",
+		Status:       "in_review",
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	})
+	db.Create(&models.WebhookDelivery{
+		ID:        "interrupted",
+		EventType: "pull_request",
+		Action:    "closed",
+		Payload: `{"action": "closed",
+			"pull_request": {"number": 77, "merged": true, "html_url": "https://github.com/owner/repo/pull/77"},
+			"repository": {"full_name": "owner/repo", "owner": {"login": "owner"}, "name": "repo"}}`,
+		Status:    services.WebhookDeliveryProcessing,
+		Attempts:  1,
+		CreatedAt: time.Now().Add(-time.Hour),
+		UpdatedAt: time.Now().Add(-time.Hour),
+	})
+	db.Create(&models.WebhookDelivery{ID: "done", EventType: "pull_request", Action: "closed", Payload: `{}`, Status: services.WebhookDeliveryProcessed, CreatedAt: time.Now()})
+	// Still within its lease: being processed by another server
+	db.Create(&models.WebhookDelivery{ID: "in-progress", EventType: "pull_request", Action: "closed", Payload: `{}`, Status: services.WebhookDeliveryProcessing, Attempts: 1, CreatedAt: time.Now()})
+
+	ResumeWebhookDeliveries(db, slack, services.NewWebhookQueue(0))
+
+	var task models.ReviewTask
+	db.First(&task, "id = ?", "resume-task")
+	assert.Equal(t, "completed", task.Status)
+
+	var delivery models.WebhookDelivery
+	db.First(&delivery, "id = ?", "interrupted")
+	assert.Equal(t, services.WebhookDeliveryProcessed, delivery.Status)
+	assert.Equal(t, 2, delivery.Attempts)
+
+	var inProgress models.WebhookDelivery
+	db.First(&inProgress, "id = ?", "in-progress")
+	assert.Equal(t, services.WebhookDeliveryProcessing, inProgress.Status)
+	assert.Equal(t, 1, inProgress.Attempts)
+}
+
+func TestLabeledEvent_UsesTeamOfChannelConfig(t *testing.T) {
+	db := setupTestDB(t)
+	gin.SetMode(gin.TestMode)
+	slack := services.NewFakeSlackClient()
+
+	db.Create(&models.ChannelConfig{
+		ID:               "config-t1",
+		TeamID:           "T1",
+		SlackChannelID:   "C1234567890",
+		LabelName:        "needs-review",
+		DefaultMentionID: "@here",
+		RepositoryList:   "test/repo",
+		IsActive:         true,
+	})
+	// The same GitHub user is mapped differently in another workspace
+	db.Create(&models.UserMapping{ID: "m-t1", TeamID: "T1", GithubUsername: "author", SlackUserID: "U_T1_AUTHOR"})
+	db.Create(&models.UserMapping{ID: "m-t2", TeamID: "T2", GithubUsername: "author", SlackUserID: "U_T2_AUTHOR"})
+
+	action := "labeled"
+	prNumber := 123
+	payload := github.PullRequestEvent{
+		Action: &action,
+		Number: &prNumber,
+		Label:  &github.Label{Name: github.Ptr("needs-review")},
+		PullRequest: &github.PullRequest{
+			Number:  &prNumber,
+			HTMLURL: github.Ptr("https://github.com/test/repo/pull/123"),
+			Title:   github.Ptr("Test PR"),
+			User:    &github.User{Login: github.Ptr("author")},
+			Labels:  []*github.Label{{Name: github.Ptr("needs-review")}},
+		},
+		Repo: &github.Repository{
+			FullName: github.Ptr("test/repo"),
+			Owner:    &github.User{Login: github.Ptr("test")},
+			Name:     github.Ptr("repo"),
+		},
+	}
+	payloadJSON, _ := json.Marshal(payload)
+
+	req, _ := http.NewRequest("POST", "/webhook", bytes.NewBuffer(payloadJSON))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", "pull_request")
+	w := httptest.NewRecorder()
+	router := gin.New()
+	router.POST("/webhook", HandleGitHubWebhook(db, slack, services.NewWebhookQueue(0)))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var task models.ReviewTask
+	if assert.NoError(t, db.Where("repo = ? AND pr_number = ?", "test/repo", 123).First(&task).Error) {
+		assert.Equal(t, "T1", task.TeamID)
+		assert.Equal(t, "U_T1_AUTHOR", task.PRAuthorSlackID)
+	}
+	assert.Contains(t, slack.Teams(), "T1")
+}
+
+func TestHandleGitHubWebhook_InstallationEvents(t *testing.T) {
+	db := setupTestDB(t)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.POST("/webhook", HandleGitHubWebhook(db, services.NewFakeSlackClient(), services.NewWebhookQueue(0)))
+	deliver := func(eventType, payload string) {
+		req, _ := http.NewRequest("POST", "/webhook", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Event", eventType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	installedRepos := func() []string {
+		repos, err := services.GitHubInstallationRepositories(db, "")
+		assert.NoError(t, err)
+		return repos
+	}
+
+	deliver("installation", `{
+		"action": "created",
+		"installation": {"id": 55, "account": {"login": "acme", "type": "Organization"}, "repository_selection": "selected"},
+		"repositories": [{"full_name": "acme/api"}, {"full_name": "acme/web"}]
+	}`)
+	assert.Equal(t, []string{"acme/api", "acme/web"}, installedRepos())
+
+	deliver("installation_repositories", `{
+		"action": "added",
+		"installation": {"id": 55, "account": {"login": "acme", "type": "Organization"}},
+		"repository_selection": "selected",
+		"repositories_added": [{"full_name": "acme/infra"}],
+		"repositories_removed": [{"full_name": "acme/web"}]
+	}`)
+	assert.Equal(t, []string{"acme/api", "acme/infra"}, installedRepos())
+	id, ok := services.GitHubInstallationIDForRepo(db, "acme/infra")
+	assert.True(t, ok)
+	assert.EqualValues(t, 55, id)
+
+	deliver("installation", `{
+		"action": "deleted",
+		"installation": {"id": 55, "account": {"login": "acme", "type": "Organization"}}
+	}`)
+	assert.Empty(t, installedRepos())
+}
+
+func TestGitHubPollHandler_ReconcileTasks(t *testing.T) {
+	repo := `"base": {"repo": {"name": "repo", "full_name": "test/repo", "owner": {"login": "test"}}}`
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/test/repo/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"number": 1, "state": "closed", "merged": true, `+repo+`}`)
+	})
+	mux.HandleFunc("GET /repos/test/repo/pulls/2", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"number": 2, "state": "open", "labels": [{"name": "needs-review"}], `+repo+`}`)
+	})
+	mux.HandleFunc("GET /repos/test/repo/pulls/2/reviews", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `[{"id": 9, "state": "APPROVED", "user": {"login": "alice"}}]`)
+	})
+	mux.HandleFunc("GET /repos/test/repo/pulls/3", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"number": 3, "state": "open", "labels": [{"name": "bug"}], `+repo+`}`)
+	})
+	mux.HandleFunc("GET /repos/test/repo/pulls/3/reviews", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `[]`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	t.Setenv("GITHUB_API_BASE_URL", server.URL)
+	t.Setenv("GITHUB_TOKEN", "test-github-token")
+
+	db := setupTestDB(t)
+	slack := services.NewFakeSlackClient()
+
+	db.Create(&models.ChannelConfig{ID: "cfg-1", SlackChannelID: "C1", LabelName: "needs-review", IsActive: true, RequiredApprovals: 1})
+	db.Create(&models.UserMapping{ID: "map-1", GithubUsername: "alice", SlackUserID: "U_ALICE"})
+	db.Create(&models.ReviewTask{ID: "task-merged", Repo: "test/repo", PRNumber: 1, SlackTS: "1.1", SlackChannel: "C1", LabelName: "needs-review", Status: "in_review"})
+	db.Create(&models.ReviewTask{ID: "task-approved", Repo: "test/repo", PRNumber: 2, SlackTS: "2.1", SlackChannel: "C1", LabelName: "needs-review", Status: "in_review", Reviewers: "U_ALICE"})
+	db.Create(&models.ReviewTask{ID: "task-unlabeled", Repo: "test/repo", PRNumber: 3, SlackTS: "3.1", SlackChannel: "C1", LabelName: "needs-review", Status: "in_review"})
+
+	result := services.ReconcileTasks(db, GitHubPollHandler(db, slack, services.NewWebhookQueue(0)), "", "")
+	assert.Equal(t, 1, result.Closed)
+	assert.Equal(t, 1, result.Approvals)
+	assert.Equal(t, 1, result.Unlabeled)
+
+	// Completed as if the webhooks had arrived
+	var merged, approved, unlabeled models.ReviewTask
+	db.First(&merged, "id = ?", "task-merged")
+	db.First(&approved, "id = ?", "task-approved")
+	db.First(&unlabeled, "id = ?", "task-unlabeled")
+	assert.Equal(t, "completed", merged.Status)
+	assert.Equal(t, "completed", approved.Status)
+	assert.Equal(t, "completed", unlabeled.Status)
+	assert.Equal(t, "U_ALICE", approved.ApprovedBy)
+
+	// Nothing is left to resync
+	result = services.ReconcileTasks(db, GitHubPollHandler(db, slack, services.NewWebhookQueue(0)), "", "")
+	assert.Zero(t, result.PullRequests)
 }
@@ -43,6 +43,7 @@ Specify multiple labels separated by commas to notify only when all labels are p
 *Basic Operations:*
 • /slack-review-notify show - Show all label settings for this channel
 • /slack-review-notify [label-name] show - Show detailed settings for specified label
+• /slack-review-notify resync - Recheck this channel's open review tasks against GitHub (closed PRs, removed labels, missed approvals)
 
 *Required Settings:*
 • /slack-review-notify [label-name] add-repo owner/repo1,owner/repo2 - Add target repositories (required)
@@ -63,12 +64,19 @@ Specify multiple labels separated by commas to notify only when all labels are p
 • /slack-review-notify [label-name] set-business-hours-end 18:00 - Set business hours end
 • /slack-review-notify [label-name] set-timezone Asia/Tokyo - Set timezone
 • /slack-review-notify [label-name] set-required-approvals N - Set required approvals (1-10)
+• /slack-review-notify [label-name] set-strategy random|least-loaded|round-robin - Set reviewer selection strategy
+• /slack-review-notify [label-name] set-github-sync on|off - Also request assigned reviewers on the GitHub PR
+• /slack-review-notify [label-name] set-digest-time HH:MM|off - Post a daily digest of open reviews (business days only)
 • /slack-review-notify [label-name] set-language ja|en - Set message language
+• /slack-review-notify [label-name] stats [7d|30d] - Show review latency and reviewer stats (all labels when [label-name] is omitted)
 • /slack-review-notify [label-name] activate - Enable notifications
 • /slack-review-notify [label-name] deactivate - Disable notifications
 
 *User Mapping (for PR author notifications):*
 • /slack-review-notify map-user <github-username> @slack-user - Link GitHub user to Slack user
+• /slack-review-notify map-team <github-team> @user-group|@member... [pick] - Link GitHub team to a Slack user group or members
+• /slack-review-notify show-team-mappings - Show team mappings
+• /slack-review-notify remove-team-mapping <github-team> - Remove team mapping
 • /slack-review-notify show-user-mappings - Show registered user mappings
 • /slack-review-notify remove-user-mapping <github-username> - Remove user mapping
 
@@ -78,6 +86,11 @@ Specify multiple labels separated by commas to notify only when all labels are p
 • /slack-review-notify unset-away @user - Remove user's away status
 • /slack-review-notify show-availability - Show users on leave or scheduled
 
+*Personal DM Digest:*
+• /slack-review-notify dm-digest on [HH:MM] [timezone] - Get a daily DM of the reviews waiting on you (business days only)
+• /slack-review-notify dm-digest off - Stop the daily DM
+• /slack-review-notify dm-digest - Show your DM digest setting
+
 Omitting [label-name] uses the default label "needs-review"`,
 
 	// ==================== Command: set-language ====================
@@ -106,11 +119,14 @@ Omitting [label-name] uses the default label "needs-review"`,
 	"cmd.clear_reviewers.success":   "Cleared reviewer list for label \"%s\".",
 
 	// ==================== Command: add-repo ====================
-	"cmd.add_repo.usage":          "Please specify repository names separated by commas. Example: /slack-review-notify %s add-repo owner/repo1,owner/repo2",
-	"cmd.add_repo.created":        "Added `%[2]s` to notification target repositories for label \"%[1]s\".",
-	"cmd.add_repo.added":          "Added the following to notification target repositories for label \"%s\":\n`%s`",
-	"cmd.add_repo.already_exists": "The following repositories were already notification targets:\n`%s`",
-	"cmd.add_repo.no_valid":       "No valid repository names were specified.",
+	"cmd.add_repo.usage":                 "Please specify repository names separated by commas. Example: /slack-review-notify %s add-repo owner/repo1,owner/repo2",
+	"cmd.add_repo.created":               "Added `%[2]s` to notification target repositories for label \"%[1]s\".",
+	"cmd.add_repo.added":                 "Added the following to notification target repositories for label \"%s\":\n`%s`",
+	"cmd.add_repo.already_exists":        "The following repositories were already notification targets:\n`%s`",
+	"cmd.add_repo.no_valid":              "No valid repository names were specified.",
+	"cmd.add_repo.not_installed":         "The GitHub App cannot access the following repositories, so they were not added:",
+	"cmd.add_repo.not_installed_repo":    "• `%s`",
+	"cmd.add_repo.not_installed_suggest": "• `%s` (did you mean `%s`?)",
 
 	// ==================== Command: remove-repo ====================
 	"cmd.remove_repo.usage":     "Please specify a repository name. Example: /slack-review-notify %s remove-repo owner/repo",
@@ -158,6 +174,55 @@ Omitting [label-name] uses the default label "needs-review"`,
 	"cmd.set_required_approvals.set":     "Set required approvals for label \"%s\" to %d.",
 	"cmd.set_required_approvals.updated": "Updated required approvals for label \"%s\" to %d.",
 
+	// ==================== Command: set-digest-time ====================
+	"cmd.set_digest_time.usage":   "Please specify the daily digest time (HH:MM) or off.\nExample: /slack-review-notify %s set-digest-time 10:00",
+	"cmd.set_digest_time.set":     "Set daily digest for label \"%s\" to %s. It is posted on business days within business hours.",
+	"cmd.set_digest_time.updated": "Updated daily digest for label \"%s\" to %s. It is posted on business days within business hours.",
+
+	// ==================== Command: stats ====================
+	"cmd.stats.usage":            "Usage: /slack-review-notify stats [label-name] [7d|30d]",
+	"cmd.stats.invalid_period":   "The period must be between 1d and %dd.",
+	"cmd.stats.error":            "Failed to load review stats.",
+	"cmd.stats.all_labels":       "all labels",
+	"cmd.stats.header":           "*Review stats for %s* (last %d days)",
+	"cmd.stats.summary":          "PRs labeled: %d · Completed: %d · Merged: %d",
+	"cmd.stats.first_review":     "Time to first review: %s",
+	"cmd.stats.full_approval":    "Time to full approval: %s",
+	"cmd.stats.latency":          "median %s · p90 %s (%d PRs)",
+	"cmd.stats.no_data":          "no data",
+	"cmd.stats.reassignments":    "Reviewer reassignments: %d",
+	"cmd.stats.reviewers_header": "*Per reviewer*",
+	"cmd.stats.reviewer":         "• %s: assigned %d · approved %d · reassigned away %d",
+	"cmd.stats.no_reviewers":     "No reviewer activity in this period.",
+
+	// ==================== Command: resync ====================
+	"cmd.resync.started":         "Rechecking this channel's open review tasks against GitHub. You will be told the result here.",
+	"cmd.resync.busy":            "A resync is already waiting to run. Please try again in a moment.",
+	"cmd.resync.github_disabled": "Resync needs GitHub credentials (GITHUB_TOKEN or a GitHub App), which are not configured.",
+	"reconcile.done":             "Resync done: checked %d PR(s) · closed %d · labels removed %d · approvals recorded %d",
+	"reconcile.failed":           "⚠️ %d PR(s) could not be checked with GitHub.",
+
+	// ==================== Command: dm-digest ====================
+	"cmd.dm_digest.usage":      "Usage: /slack-review-notify dm-digest on [HH:MM] [timezone] | off\nExample: /slack-review-notify dm-digest on 09:30 Asia/Tokyo",
+	"cmd.dm_digest.enabled":    "You will get a daily DM of the reviews waiting on you at %s (%s) on business days.",
+	"cmd.dm_digest.disabled":   "Turned off your daily DM digest.",
+	"cmd.dm_digest.status_on":  "Your daily DM digest is on: %s (%s) on business days.",
+	"cmd.dm_digest.status_off": "Your daily DM digest is off. Turn it on with /slack-review-notify dm-digest on",
+	"cmd.dm_digest.error":      "Failed to update your DM digest setting.",
+
+	// ==================== Command: set-github-sync ====================
+	"cmd.set_github_sync.usage":          "Please specify on or off.\nExample: /slack-review-notify %s set-github-sync on",
+	"cmd.set_github_sync.invalid":        "Please specify on or off.",
+	"cmd.set_github_sync.set":            "Set GitHub reviewer sync for label \"%s\" to %s.",
+	"cmd.set_github_sync.updated":        "Updated GitHub reviewer sync for label \"%s\" to %s.",
+	"cmd.set_github_sync.no_credentials": "⚠️ No GitHub credentials are configured (GITHUB_TOKEN or GitHub App), so reviewers will not be requested on GitHub until they are set.",
+
+	// ==================== Command: set-strategy ====================
+	"cmd.set_strategy.usage":   "Please specify a reviewer selection strategy. Supported: %s\nExample: /slack-review-notify %s set-strategy least-loaded",
+	"cmd.set_strategy.invalid": "Unsupported strategy. Supported: %s",
+	"cmd.set_strategy.set":     "Set reviewer selection strategy for label \"%s\" to %s.",
+	"cmd.set_strategy.updated": "Updated reviewer selection strategy for label \"%s\" to %s.",
+
 	// ==================== Command: show ====================
 	"cmd.show.error":     "An error occurred while retrieving settings.",
 	"cmd.show.no_config": "No configuration found for this channel. Use /slack-review-notify [label-name] set-mention to get started.",
@@ -174,6 +239,9 @@ Omitting [label-name] uses the default label "needs-review"`,
 - Post-assignment reminder interval: %d min
 - Business hours: %s - %s (%s)
 - Required approvals: %d
+- Reviewer selection: %s
+- GitHub reviewer sync: %s
+- Daily digest: %s
 - Language: %s`,
 
 	// ==================== Command: map-user ====================
@@ -217,6 +285,28 @@ Omitting [label-name] uses the default label "needs-review"`,
 	"cmd.remove_user_mapping.error":     "Failed to delete user mapping.",
 	"cmd.remove_user_mapping.success":   "Deleted mapping for GitHub user `%s`.",
 
+	// ==================== Command: map-team ====================
+	"cmd.map_team.usage":                 "Usage: /slack-review-notify map-team <org/team> @user-group|@member... [pick]\nExamples:\n  /slack-review-notify map-team my-org/backend @backend-team\n  /slack-review-notify map-team my-org/backend @alice @bob pick (assign one member as reviewer)",
+	"cmd.map_team.org_required":         "Give the GitHub team with its organization (e.g. `my-org/%s`).",
+	"cmd.map_team.invalid_target":        "Could not resolve a Slack user group or user ID (`%s`). Click the autocomplete suggestion in the slash command and send.",
+	"cmd.map_team.pick_requires_members": "`pick` needs the team members. List them as @users.",
+	"cmd.map_team.updated":               "Updated mapping for GitHub team `%s`.",
+	"cmd.map_team.create_error":          "Failed to create team mapping.",
+	"cmd.map_team.created":               "Mapped GitHub team `%s`.",
+
+	// ==================== Command: show-team-mappings ====================
+	"cmd.show_team_mappings.error":  "Failed to retrieve team mappings.",
+	"cmd.show_team_mappings.empty":  "No team mappings registered yet.\nUse /slack-review-notify map-team to register.",
+	"cmd.show_team_mappings.header": "*Registered Team Mappings*\n",
+	"cmd.show_team_mappings.pick":   "[assigns one member]",
+	"cmd.show_team_mappings.no_org": "[unused: map it again as org/team]",
+
+	// ==================== Command: remove-team-mapping ====================
+	"cmd.remove_team_mapping.usage":     "Usage: /slack-review-notify remove-team-mapping <org/team>\nExample: /slack-review-notify remove-team-mapping my-org/backend",
+	"cmd.remove_team_mapping.not_found": "No mapping found for GitHub team `%s`.",
+	"cmd.remove_team_mapping.error":     "Failed to delete team mapping.",
+	"cmd.remove_team_mapping.success":   "Deleted mapping for GitHub team `%s`.",
+
 	// ==================== Command: set-away ====================
 	"cmd.set_away.usage":        "Please specify a user to set as away.\nExamples:\n  set-away @user\n  set-away @user until 2025-06-01\n  set-away @user from 2025-05-28 until 2025-06-01\n  set-away @user on 2025-06-01\n  set-away @user on 2025-06-01 reason Day off",
 	"cmd.set_away.from_after_until":  "The start date (from) must be before the end date (until).",
@@ -240,6 +330,40 @@ Omitting [label-name] uses the default label "needs-review"`,
 	"cmd.show_availability.status_away":     "Away",
 	"cmd.show_availability.status_scheduled": "Scheduled",
 
+	// ==================== Daily digest ====================
+	"digest.header":       "📋 *Daily review digest*: %d open review(s)",
+	"digest.task":         "• %s `%s#%d`\n    Age: %s · Reviewers: %s · Waiting on: %s · Approvals: %d/%d",
+	"digest.not_assigned": "not assigned yet",
+	"digest.more":         "…and %d more",
+
+	// ==================== DM digest ====================
+	"dm_digest.header": "📬 *Reviews waiting on you*: %d",
+	"dm_digest.task":   "• %s `%s#%d`\n    Waiting: %s · %s",
+	"dm_digest.thread": "Slack thread",
+
+	// ==================== App Home ====================
+	"home.title":             "📋 Review dashboard",
+	"home.assigned_header":   "*Reviews assigned to you* (%d)",
+	"home.assigned_empty":    "Nothing is waiting for your review. 🎉",
+	"home.authored_header":   "*Your PRs awaiting review* (%d)",
+	"home.authored_empty":    "None of your PRs is waiting for review.",
+	"home.away_header":       "*Your away periods*",
+	"home.away_empty":        "No away periods scheduled.",
+	"home.more":              "…and %d more",
+	"home.waiting":           "waiting %s",
+	"home.paused":            "⏸ reminders paused",
+	"home.reviewers":         "Reviewers: %s",
+	"home.not_assigned":      "not assigned yet",
+	"home.pause_placeholder": "Pause reminders",
+	"home.duration.minutes":  "%d min",
+	"home.duration.hours":    "%dh",
+	"home.duration.days":     "%dd %dh",
+
+	"home.dm_digest_on":       "*Daily DM digest*: on at %s (%s) on business days",
+	"home.dm_digest_off":      "*Daily DM digest*: off. Turn it on to get a daily DM of the reviews waiting on you.",
+	"home.dm_digest_turn_on":  "Turn on",
+	"home.dm_digest_turn_off": "Turn off",
+
 	// ==================== Common ====================
 	"common.active":             "Active",
 	"common.inactive":           "Inactive",
@@ -267,7 +391,7 @@ Omitting [label-name] uses the default label "needs-review"`,
 	"modal.default_mention_text":         "Mention target (other)",
 	"modal.default_mention_text.hint":    "Subteam ID (S…), @team-handle, or decorative free text. Used when the user picker above is empty. To actually ping a subteam, paste its S… ID here.",
 	"modal.reviewer_list":                "Reviewer pool (picker)",
-	"modal.reviewer_list.hint":           "Pick individual reviewers from Slack's picker. Reviewers are drawn randomly from this pool.",
+	"modal.reviewer_list.hint":           "Pick individual reviewers from Slack's picker. Reviewers are drawn from this pool using the reviewer selection below.",
 	"modal.reviewer_list_text":           "Reviewer pool (other, comma-separated)",
 	"modal.reviewer_list_text.hint":      "Non-ID reviewers (legacy bare names, decorative entries). Merged with the picker selection at save time.",
 	"modal.reminder_interval":            "Reminder interval before reviewer assignment (minutes)",
@@ -284,6 +408,10 @@ Omitting [label-name] uses the default label "needs-review"`,
 	"modal.timezone.hint":                "e.g. Asia/Tokyo, UTC, America/New_York",
 	"modal.required_approvals":           "Required approvals",
 	"modal.required_approvals.hint":      "Integer between 1 and 10",
+	"modal.reviewer_strategy":              "Reviewer selection",
+	"modal.reviewer_strategy.random":       "Random",
+	"modal.reviewer_strategy.least-loaded": "Fewest open reviews first",
+	"modal.reviewer_strategy.round-robin":  "Round-robin in list order",
 	"modal.language":                     "Language",
 	"modal.is_active":                    "Notifications active",
 	"modal.lang.ja":                      "日本語",
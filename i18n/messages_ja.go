@@ -43,6 +43,7 @@ var messagesJa = map[string]string{
 *基本操作:*
 • /slack-review-notify show - このチャンネルの全ラベル設定を表示
 • /slack-review-notify [ラベル名] show - 指定ラベルの詳細設定を表示
+• /slack-review-notify resync - このチャンネルの未完了のレビュータスクを GitHub と照合し直す（クローズ済みの PR・外されたラベル・取りこぼした承認）
 
 *必須設定:*
 • /slack-review-notify [ラベル名] add-repo owner/repo1,owner/repo2 - 対象リポジトリを追加（必須）
@@ -63,12 +64,19 @@ var messagesJa = map[string]string{
 • /slack-review-notify [ラベル名] set-business-hours-end 18:00 - 営業終了時間を設定
 • /slack-review-notify [ラベル名] set-timezone Asia/Tokyo - タイムゾーンを設定
 • /slack-review-notify [ラベル名] set-required-approvals N - 必要なapprove数を設定（1〜10）
+• /slack-review-notify [ラベル名] set-strategy random|least-loaded|round-robin - レビュワーの選び方を設定
+• /slack-review-notify [ラベル名] set-github-sync on|off - 割り当てたレビュワーを GitHub の PR にもリクエスト
+• /slack-review-notify [ラベル名] set-digest-time HH:MM|off - 未完了レビューのまとめを毎日投稿（営業日のみ）
 • /slack-review-notify [ラベル名] set-language ja|en - メッセージの言語を設定
+• /slack-review-notify [ラベル名] stats [7d|30d] - レビュー所要時間とレビュワーごとの集計を表示（[ラベル名]省略時は全ラベル）
 • /slack-review-notify [ラベル名] activate - 通知を有効化
 • /slack-review-notify [ラベル名] deactivate - 通知を無効化
 
 *ユーザーマッピング（PR作成者の通知用）:*
 • /slack-review-notify map-user <github-username> @slack-user - GitHubユーザーとSlackユーザーを紐付け
+• /slack-review-notify map-team <github-team> @user-group|@member... [pick] - GitHubチームとSlackユーザーグループまたはメンバーを紐付け
+• /slack-review-notify show-team-mappings - チームマッピングを表示
+• /slack-review-notify remove-team-mapping <github-team> - チームマッピングを削除
 • /slack-review-notify show-user-mappings - 登録済みのユーザーマッピング一覧を表示
 • /slack-review-notify remove-user-mapping <github-username> - ユーザーマッピングを削除
 
@@ -78,6 +86,11 @@ var messagesJa = map[string]string{
 • /slack-review-notify unset-away @user - ユーザーの休暇を解除
 • /slack-review-notify show-availability - 休暇中・予約中のユーザー一覧を表示
 
+*個人向け DM まとめ:*
+• /slack-review-notify dm-digest on [HH:MM] [タイムゾーン] - あなた宛てのレビュー待ちを毎日 DM で受け取る（営業日のみ）
+• /slack-review-notify dm-digest off - 毎日の DM を停止
+• /slack-review-notify dm-digest - DM まとめの設定を表示
+
 [ラベル名]を省略すると「needs-review」というデフォルトのラベルを使用します`,
 
 	// ==================== Command: set-language ====================
@@ -106,11 +119,14 @@ var messagesJa = map[string]string{
 	"cmd.clear_reviewers.success":   "ラベル「%s」のレビュワーリストをクリアしました。",
 
 	// ==================== Command: add-repo ====================
-	"cmd.add_repo.usage":          "リポジトリ名をカンマ区切りで指定してください。例: /slack-review-notify %s add-repo owner/repo1,owner/repo2",
-	"cmd.add_repo.created":        "ラベル「%s」の通知対象リポジトリに `%s` を追加しました。",
-	"cmd.add_repo.added":          "ラベル「%s」の通知対象リポジトリに以下を追加しました:\n`%s`",
-	"cmd.add_repo.already_exists": "以下のリポジトリは既に通知対象でした:\n`%s`",
-	"cmd.add_repo.no_valid":       "有効なリポジトリ名が指定されませんでした。",
+	"cmd.add_repo.usage":                 "リポジトリ名をカンマ区切りで指定してください。例: /slack-review-notify %s add-repo owner/repo1,owner/repo2",
+	"cmd.add_repo.created":               "ラベル「%s」の通知対象リポジトリに `%s` を追加しました。",
+	"cmd.add_repo.added":                 "ラベル「%s」の通知対象リポジトリに以下を追加しました:\n`%s`",
+	"cmd.add_repo.already_exists":        "以下のリポジトリは既に通知対象でした:\n`%s`",
+	"cmd.add_repo.no_valid":              "有効なリポジトリ名が指定されませんでした。",
+	"cmd.add_repo.not_installed":         "以下のリポジトリは GitHub App からアクセスできないため追加しませんでした:",
+	"cmd.add_repo.not_installed_repo":    "• `%s`",
+	"cmd.add_repo.not_installed_suggest": "• `%s`（もしかして: `%s`）",
 
 	// ==================== Command: remove-repo ====================
 	"cmd.remove_repo.usage":     "リポジトリ名を指定してください。例: /slack-review-notify %s remove-repo owner/repo",
@@ -158,6 +174,55 @@ var messagesJa = map[string]string{
 	"cmd.set_required_approvals.set":     "ラベル「%s」の必要なapprove数を %d に設定しました。",
 	"cmd.set_required_approvals.updated": "ラベル「%s」の必要なapprove数を %d に更新しました。",
 
+	// ==================== Command: set-digest-time ====================
+	"cmd.set_digest_time.usage":   "まとめを投稿する時刻（HH:MM）または off を指定してください。\n例: /slack-review-notify %s set-digest-time 10:00",
+	"cmd.set_digest_time.set":     "ラベル「%s」の毎日のまとめを %s に設定しました。営業日の営業時間内に投稿されます。",
+	"cmd.set_digest_time.updated": "ラベル「%s」の毎日のまとめを %s に更新しました。営業日の営業時間内に投稿されます。",
+
+	// ==================== Command: stats ====================
+	"cmd.stats.usage":            "使い方: /slack-review-notify stats [ラベル名] [7d|30d]",
+	"cmd.stats.invalid_period":   "期間は 1d から %dd の範囲で指定してください。",
+	"cmd.stats.error":            "レビュー統計の取得に失敗しました。",
+	"cmd.stats.all_labels":       "全ラベル",
+	"cmd.stats.header":           "*%s のレビュー統計*（過去 %d 日間）",
+	"cmd.stats.summary":          "ラベル付与: %d 件 · 完了: %d 件 · マージ: %d 件",
+	"cmd.stats.first_review":     "最初のレビューまで: %s",
+	"cmd.stats.full_approval":    "必要な承認が揃うまで: %s",
+	"cmd.stats.latency":          "中央値 %s · p90 %s（%d 件）",
+	"cmd.stats.no_data":          "データなし",
+	"cmd.stats.reassignments":    "レビュワー変更: %d 回",
+	"cmd.stats.reviewers_header": "*レビュワーごと*",
+	"cmd.stats.reviewer":         "• %s: 割り当て %d · 承認 %d · 変更で外れた %d",
+	"cmd.stats.no_reviewers":     "この期間のレビュワーの活動はありません。",
+
+	// ==================== Command: resync ====================
+	"cmd.resync.started":         "このチャンネルの未完了のレビュータスクを GitHub と照合しています。結果はここでお知らせします。",
+	"cmd.resync.busy":            "実行待ちの照合がすでにあります。しばらくしてからもう一度お試しください。",
+	"cmd.resync.github_disabled": "照合には GitHub の認証情報（GITHUB_TOKEN または GitHub App）が必要ですが、設定されていません。",
+	"reconcile.done":             "照合が完了しました: PR %d 件を確認 · クローズ %d 件 · ラベル削除 %d 件 · 承認の記録 %d 件",
+	"reconcile.failed":           "⚠️ PR %d 件は GitHub で確認できませんでした。",
+
+	// ==================== Command: dm-digest ====================
+	"cmd.dm_digest.usage":      "使い方: /slack-review-notify dm-digest on [HH:MM] [タイムゾーン] | off\n例: /slack-review-notify dm-digest on 09:30 Asia/Tokyo",
+	"cmd.dm_digest.enabled":    "営業日の %s (%s) に、あなた宛てのレビュー待ちを毎日 DM でお知らせします。",
+	"cmd.dm_digest.disabled":   "毎日の DM まとめを停止しました。",
+	"cmd.dm_digest.status_on":  "毎日の DM まとめはオンです: 営業日の %s (%s)",
+	"cmd.dm_digest.status_off": "毎日の DM まとめはオフです。/slack-review-notify dm-digest on でオンにできます",
+	"cmd.dm_digest.error":      "DM まとめの設定の更新に失敗しました。",
+
+	// ==================== Command: set-github-sync ====================
+	"cmd.set_github_sync.usage":          "on または off を指定してください。\n例: /slack-review-notify %s set-github-sync on",
+	"cmd.set_github_sync.invalid":        "on または off を指定してください。",
+	"cmd.set_github_sync.set":            "ラベル「%s」の GitHub へのレビュワー連携を %s に設定しました。",
+	"cmd.set_github_sync.updated":        "ラベル「%s」の GitHub へのレビュワー連携を %s に更新しました。",
+	"cmd.set_github_sync.no_credentials": "⚠️ GitHub の認証情報（GITHUB_TOKEN または GitHub App）が未設定のため、設定されるまで GitHub へのリクエストは行われません。",
+
+	// ==================== Command: set-strategy ====================
+	"cmd.set_strategy.usage":   "レビュワーの選び方を指定してください。対応: %s\n例: /slack-review-notify %s set-strategy least-loaded",
+	"cmd.set_strategy.invalid": "対応していない選び方です。対応: %s",
+	"cmd.set_strategy.set":     "ラベル「%s」のレビュワーの選び方を %s に設定しました。",
+	"cmd.set_strategy.updated": "ラベル「%s」のレビュワーの選び方を %s に更新しました。",
+
 	// ==================== Command: show ====================
 	"cmd.show.error":     "設定の取得中にエラーが発生しました。",
 	"cmd.show.no_config": "このチャンネルにはまだ設定がありません。/slack-review-notify [ラベル名] set-mention コマンドで設定を開始してください。",
@@ -174,6 +239,9 @@ var messagesJa = map[string]string{
 - レビュワー割り当て後のリマインド頻度: %d分
 - 営業時間: %s - %s (%s)
 - 必要なapprove数: %d
+- レビュワーの選び方: %s
+- GitHub へのレビュワー連携: %s
+- 毎日のまとめ: %s
 - 言語: %s`,
 
 	// ==================== Command: map-user ====================
@@ -217,6 +285,28 @@ var messagesJa = map[string]string{
 	"cmd.remove_user_mapping.error":     "ユーザーマッピングの削除に失敗しました。",
 	"cmd.remove_user_mapping.success":   "GitHubユーザー `%s` のマッピングを削除しました。",
 
+	// ==================== Command: map-team ====================
+	"cmd.map_team.usage":                 "使用方法: /slack-review-notify map-team <org/team> @user-group|@member... [pick]\n例:\n  /slack-review-notify map-team my-org/backend @backend-team\n  /slack-review-notify map-team my-org/backend @alice @bob pick （メンバーから1人をレビュワーに割り当て）",
+	"cmd.map_team.org_required":         "GitHubチームは組織名付きで指定してください（例: `my-org/%s`）。",
+	"cmd.map_team.invalid_target":        "SlackユーザーグループまたはユーザーIDを解決できませんでした（`%s`）。スラッシュコマンドの補完候補をクリックしてから送信してください。",
+	"cmd.map_team.pick_requires_members": "`pick` を使うにはチームのメンバーを @ユーザー で指定してください。",
+	"cmd.map_team.updated":               "GitHubチーム `%s` のマッピングを更新しました。",
+	"cmd.map_team.create_error":          "チームマッピングの作成に失敗しました。",
+	"cmd.map_team.created":               "GitHubチーム `%s` のマッピングを登録しました。",
+
+	// ==================== Command: show-team-mappings ====================
+	"cmd.show_team_mappings.error":  "チームマッピングの取得に失敗しました。",
+	"cmd.show_team_mappings.empty":  "まだチームマッピングが登録されていません。\n/slack-review-notify map-team コマンドで登録してください。",
+	"cmd.show_team_mappings.header": "*登録済みのチームマッピング*\n",
+	"cmd.show_team_mappings.pick":   "[メンバーから1人を割り当て]",
+	"cmd.show_team_mappings.no_org": "[未使用: org/team の形式で登録し直してください]",
+
+	// ==================== Command: remove-team-mapping ====================
+	"cmd.remove_team_mapping.usage":     "使用方法: /slack-review-notify remove-team-mapping <org/team>\n例: /slack-review-notify remove-team-mapping my-org/backend",
+	"cmd.remove_team_mapping.not_found": "GitHubチーム `%s` のマッピングは存在しません。",
+	"cmd.remove_team_mapping.error":     "チームマッピングの削除に失敗しました。",
+	"cmd.remove_team_mapping.success":   "GitHubチーム `%s` のマッピングを削除しました。",
+
 	// ==================== Command: set-away ====================
 	"cmd.set_away.usage":        "休暇に設定するユーザーを指定してください。\n例:\n  set-away @user\n  set-away @user until 2025-06-01\n  set-away @user from 2025-05-28 until 2025-06-01\n  set-away @user on 2025-06-01\n  set-away @user on 2025-06-01 reason 有給休暇",
 	"cmd.set_away.from_after_until":  "開始日（from）は終了日（until）より前に指定してください。",
@@ -240,6 +330,40 @@ var messagesJa = map[string]string{
 	"cmd.show_availability.status_away":     "休暇中",
 	"cmd.show_availability.status_scheduled": "予約中",
 
+	// ==================== Daily digest ====================
+	"digest.header":       "📋 *本日のレビューまとめ*: 未完了のレビュー %d 件",
+	"digest.task":         "• %s `%s#%d`\n    経過: %s · レビュワー: %s · 承認待ち: %s · 承認: %d/%d",
+	"digest.not_assigned": "未割り当て",
+	"digest.more":         "…ほか %d 件",
+
+	// ==================== DM digest ====================
+	"dm_digest.header": "📬 *あなたのレビュー待ち*: %d 件",
+	"dm_digest.task":   "• %s `%s#%d`\n    経過: %s · %s",
+	"dm_digest.thread": "Slack スレッド",
+
+	// ==================== App Home ====================
+	"home.title":             "📋 レビューダッシュボード",
+	"home.assigned_header":   "*あなたが担当中のレビュー* (%d)",
+	"home.assigned_empty":    "担当中のレビューはありません。🎉",
+	"home.authored_header":   "*レビュー待ちのあなたのPR* (%d)",
+	"home.authored_empty":    "レビュー待ちのPRはありません。",
+	"home.away_header":       "*あなたの休暇*",
+	"home.away_empty":        "休暇の予定はありません。",
+	"home.more":              "…ほか %d 件",
+	"home.waiting":           "%s 待機中",
+	"home.paused":            "⏸ リマインド停止中",
+	"home.reviewers":         "レビュワー: %s",
+	"home.not_assigned":      "未割り当て",
+	"home.pause_placeholder": "リマインドを停止",
+	"home.duration.minutes":  "%d分",
+	"home.duration.hours":    "%d時間",
+	"home.duration.days":     "%d日%d時間",
+
+	"home.dm_digest_on":       "*毎日の DM まとめ*: 営業日の %s (%s) に送信",
+	"home.dm_digest_off":      "*毎日の DM まとめ*: オフ。オンにするとあなた宛てのレビュー待ちを毎日 DM で受け取れます。",
+	"home.dm_digest_turn_on":  "オンにする",
+	"home.dm_digest_turn_off": "オフにする",
+
 	// ==================== Common ====================
 	"common.active":             "有効",
 	"common.inactive":           "無効",
@@ -267,7 +391,7 @@ var messagesJa = map[string]string{
 	"modal.default_mention_text":         "メンション先 (その他)",
 	"modal.default_mention_text.hint":    "サブチーム ID (S…)、@チーム名、装飾テキストなど自由入力可。上の個人選択が空欄の場合に使われます。実メンションにしたいサブチームは S… ID を貼ってください。",
 	"modal.reviewer_list":                "レビュワー候補 (ピッカー)",
-	"modal.reviewer_list.hint":           "Slack のユーザーピッカーから個人レビュワーを複数選択。割当時はこの候補からランダムに選ばれます。",
+	"modal.reviewer_list.hint":           "Slack のユーザーピッカーから個人レビュワーを複数選択。割当時は下の「レビュワーの選び方」に従ってこの候補から選ばれます。",
 	"modal.reviewer_list_text":           "レビュワー候補 (その他, カンマ区切り)",
 	"modal.reviewer_list_text.hint":      "ID 形式じゃないレビュワー（旧データ等）はこちらに。ピッカーの選択と合わせて保存されます。",
 	"modal.reminder_interval":            "レビュワー募集中のリマインド頻度 (分)",
@@ -284,6 +408,10 @@ var messagesJa = map[string]string{
 	"modal.timezone.hint":                "例: Asia/Tokyo, UTC, America/New_York",
 	"modal.required_approvals":           "必要なapprove数",
 	"modal.required_approvals.hint":      "1〜10の整数",
+	"modal.reviewer_strategy":              "レビュワーの選び方",
+	"modal.reviewer_strategy.random":       "ランダム",
+	"modal.reviewer_strategy.least-loaded": "担当中のレビューが少ない人を優先",
+	"modal.reviewer_strategy.round-robin":  "リスト順に順番で割り当て",
 	"modal.language":                     "言語",
 	"modal.is_active":                    "通知を有効化",
 	"modal.lang.ja":                      "日本語",
@@ -1,50 +1,52 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
+	"slack-review-notify/database"
 	"slack-review-notify/handlers"
 	"slack-review-notify/models"
 	"slack-review-notify/services"
 )
 
+// shutdownTimeout bounds how long shutdown waits for in-flight requests and
+// background work such as Slack posts for a newly labeled PR.
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	err := godotenv.Load()
 	if err != nil {
 		log.Println("fail to load .env file")
 	}
 
-	dbPath := os.Getenv("DB_PATH")
-	if dbPath == "" {
-		dbPath = "review_tasks.db"
-	}
-	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
+	// DATABASE_URL selects PostgreSQL or MySQL; without it the SQLite file at
+	// DB_PATH is used.
+	db, err := database.Open(&gorm.Config{})
 
 	if err != nil {
 		log.Fatal("fail to connect db:", err)
 	}
 
-	if err := db.AutoMigrate(&models.ReviewTask{}, &models.ChannelConfig{}, &models.UserMapping{}, &models.ReviewerAvailability{}); err != nil {
-		log.Fatal("fail to migrate db:", err)
-	}
-
-	// Relax the legacy UNIQUE index on slack_user_id so a reviewer can hold
-	// multiple away periods. AutoMigrate cannot change index uniqueness.
-	if err := models.MigrateReviewerAvailabilityIndex(db); err != nil {
-		log.Fatal("fail to migrate reviewer availability index:", err)
+	// "slack-review-notify migrate up|status" manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(db, os.Args[2:], os.Stdout))
 	}
 
-	// Normalize legacy slack_user_id values stored as "ID|displayname" by
-	// an older version of cleanUserID that did not strip the pipe suffix.
-	if err := models.MigrateNormalizeSlackUserIDs(db); err != nil {
-		log.Fatal("fail to normalize slack_user_id values:", err)
+	// Apply pending schema migrations. This refuses to start against a schema
+	// migrated by a newer release rather than run with a mismatched schema.
+	if err := models.Migrate(db); err != nil {
+		log.Fatal("fail to migrate db:", err)
 	}
 
 	// Surface user_mappings rows whose slack_user_id is not a resolved U-id.
@@ -53,51 +55,204 @@ func main() {
 	// each entry from the user-mapping modal.
 	services.LogLegacyUserMappings(db)
 
+	if err := services.RegisterTaskMetrics(db); err != nil {
+		log.Fatal("failed to register task metrics:", err)
+	}
+
+	// Rows created before the app could serve several Slack workspaces belong
+	// to the workspace of SLACK_BOT_TOKEN (or SLACK_TEAM_ID)
+	if err := services.AdoptSlackTeam(db, os.Getenv("SLACK_BOT_TOKEN"), os.Getenv("SLACK_TEAM_ID")); err != nil {
+		log.Printf("slack team of existing rows not assigned: %v", err)
+	}
+
+	// Installations of the GitHub App made while its webhooks did not reach
+	// this server are picked up from the GitHub API. Shutdown waits for the
+	// sync like the other background work.
+	services.Go(func() {
+		if err := services.SyncGitHubInstallations(db); err != nil {
+			log.Printf("github app installations not synced: %v", err)
+		}
+	})
+
+	// Slack Web API client using the bot token of the workspace that owns
+	// each call, falling back to SLACK_BOT_TOKEN. Posts that need no response
+	// go through the persistent outbox, which the outbox worker delivers with
+	// slackAPI.
+	slackAPI := services.NewSlackWorkspaces(db, os.Getenv("SLACK_BOT_TOKEN"))
+	slackClient := services.NewSlackOutbox(db, slackAPI)
+
+	// GitHub webhook events are acknowledged right away and processed by
+	// these workers, one at a time per PR
+	webhookQueue := services.NewWebhookQueue(services.WebhookWorkers())
+	handlers.ResumeWebhookDeliveries(db, slackClient, webhookQueue)
+
+	// Stop on SIGINT / SIGTERM, or when a component fails for good and
+	// reports it on failed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	failed := make(chan error, 1)
+
 	// Background periodic task to check watching tasks
-	go runTaskChecker(db)
+	services.Go(func() { runTaskChecker(ctx, db, slackClient, webhookQueue) })
 
 	// Background check for channel status
-	go runChannelChecker(db)
+	services.Go(func() { runChannelChecker(ctx, db, slackClient) })
 
-	r := gin.Default()
+	// Background delivery of queued Slack messages
+	services.Go(func() { runSlackOutboxWorker(ctx, db, slackAPI) })
 
-	// Slack button click events
-	r.POST("/slack/actions", handlers.HandleSlackAction(db))
+	// Repositories that cannot send webhooks are polled instead, when
+	// GITHUB_POLL_INTERVAL is set
+	githubEvents := handlers.GitHubPollHandler(db, slackClient, webhookQueue)
+	if interval := services.GitHubPollInterval(); interval > 0 {
+		if services.IsGitHubAPIEnabled() {
+			services.Go(func() { runGitHubPoller(ctx, db, githubEvents, interval) })
+		} else {
+			log.Println("GITHUB_POLL_INTERVAL is set but no GitHub credentials are configured, github polling disabled")
+		}
+	}
+
+	// Tasks left active by webhooks that never arrived are resynced with
+	// GitHub periodically and by the resync command
+	if services.IsGitHubAPIEnabled() {
+		services.Go(func() { runTaskReconciler(ctx, db, slackClient, githubEvents, services.ReconcileInterval()) })
+	}
+
+	// Slack requests arrive on the /slack endpoints, or over Socket Mode so
+	// they need not be exposed
+	slackTransport, err := handlers.SlackTransport()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	r := gin.Default()
 
 	// Receive GitHub Webhooks
-	r.POST("/webhook", handlers.HandleGitHubWebhook(db))
+	r.POST("/webhook", handlers.HandleGitHubWebhook(db, slackClient, webhookQueue))
 
-	// Receive Slack commands
-	r.POST("/slack/command", handlers.HandleSlackCommand(db))
+	if slackTransport == handlers.SlackTransportSocket {
+		// The connection is re-established by the client; an error means it
+		// cannot be (e.g. a revoked app token), so the server shuts down
+		// gracefully and exits with an error
+		services.Go(func() {
+			if err := handlers.RunSocketMode(ctx, db, slackClient, os.Getenv("SLACK_APP_TOKEN")); err != nil {
+				log.Printf("slack socket mode stopped: %v", err)
+				failed <- err
+				stop()
+			}
+		})
+	} else {
+		// Slack button click events
+		r.POST("/slack/actions", handlers.HandleSlackAction(db, slackClient))
 
-	// Slack event receiving endpoint
-	r.POST("/slack/events", handlers.HandleSlackEvents(db))
+		// Receive Slack commands
+		r.POST("/slack/command", handlers.HandleSlackCommand(db))
 
-	if err := r.Run(":8080"); err != nil {
-		log.Fatal("failed to start server:", err)
+		// Slack event receiving endpoint
+		r.POST("/slack/events", handlers.HandleSlackEvents(db, slackClient))
+	}
+
+	// Slack OAuth install flow, enabled by SLACK_CLIENT_ID and SLACK_CLIENT_SECRET
+	r.GET("/slack/install", handlers.HandleSlackInstall())
+	r.GET("/slack/oauth/callback", handlers.HandleSlackOAuthCallback(db))
+
+	// Liveness and readiness probes
+	r.GET("/healthz", handlers.HandleHealthz())
+	r.GET("/readyz", handlers.HandleReadyz(db))
+
+	// Prometheus metrics
+	r.GET("/metrics", gin.WrapH(services.MetricsHandler()))
+
+	// Admin endpoints, enabled by ADMIN_TOKEN
+	admin := r.Group("/admin", handlers.RequireAdminToken())
+	admin.GET("/webhook-deliveries", handlers.HandleListWebhookDeliveries(db))
+	admin.POST("/webhook-deliveries/:id/replay", handlers.HandleReplayWebhookDelivery(db, slackClient, webhookQueue))
+	admin.PUT("/github-installations/:id", handlers.HandleLinkGitHubInstallation(db))
+
+	srv := &http.Server{Addr: ":8080", Handler: r}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("failed to start server:", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	log.Println("shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// Stop accepting requests and finish the in-flight ones first, since they
+	// may start more background work
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("server shutdown error: %v", err)
+	}
+	// Let the webhook workers finish the queued events
+	webhookQueue.Close()
+	if err := services.WaitForBackgroundTasks(shutdownCtx); err != nil {
+		log.Printf("background tasks did not finish before shutdown: %v", err)
+	}
+
+	if sqlDB, err := db.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			log.Printf("database close error: %v", err)
+		}
+	}
+	log.Println("shutdown complete")
+
+	select {
+	case err := <-failed:
+		log.Printf("exiting after failure: %v", err)
+		os.Exit(1)
+	default:
 	}
 }
 
-// Background process that periodically checks tasks
-func runTaskChecker(db *gorm.DB) {
+// Background process that periodically checks tasks until ctx is canceled.
+// A pass in progress is completed before returning.
+func runTaskChecker(ctx context.Context, db *gorm.DB, slack services.SlackClient, webhookQueue *services.WebhookQueue) {
 	taskTicker := time.NewTicker(60 * time.Second) // Check every 1 minute
 	cleanupTicker := time.NewTicker(1 * time.Hour) // Cleanup every 1 hour
 	defer taskTicker.Stop()
 	defer cleanupTicker.Stop()
 
+	services.RegisterLoop(services.LoopTaskChecker, 60*time.Second)
+	services.RegisterLoop(services.LoopTaskCleanup, 1*time.Hour)
+
 	for {
 		select {
+		case <-ctx.Done():
+			log.Println("task checker stopped")
+			return
+
 		case <-taskTicker.C:
 			log.Println("start task check")
+			start := time.Now()
+
+			// Finish pending tasks whose Slack post was interrupted by a crash
+			services.RecoverStalePendingTasks(db, slack)
+
+			// Process webhook deliveries a stopped server left unfinished
+			handlers.ResumeWebhookDeliveries(db, slack, webhookQueue)
 
 			// Check tasks waiting for business hours
-			services.CheckBusinessHoursTasks(db)
+			services.CheckBusinessHoursTasks(db, slack)
 
 			// Send deferred re-review notifications when business hours begin
-			services.CheckPendingReReviewNotifications(db)
+			services.CheckPendingReReviewNotifications(db, slack)
 
 			// Check in-review tasks (reviewer already assigned)
-			services.CheckInReviewTasks(db)
+			services.CheckInReviewTasks(db, slack)
+
+			// Post the daily review digest to channels whose digest time has come
+			services.CheckDailyDigests(db, slack)
+
+			// Send the personal DM digest to subscribed reviewers
+			services.CheckDMDigests(db, slack)
+
+			services.ObserveTaskCheckDuration(time.Since(start))
+			services.MarkLoopCompleted(services.LoopTaskChecker)
 
 		case <-cleanupTicker.C:
 			log.Println("start old task cleanup")
@@ -107,17 +262,105 @@ func runTaskChecker(db *gorm.DB) {
 
 			// Delete expired availability records
 			services.CleanupExpiredAvailability(db)
+
+			// Delete delivered and failed Slack outbox messages
+			services.CleanupSlackOutbox(db)
+
+			// Delete webhook deliveries kept for deduplication
+			services.CleanupWebhookDeliveries(db)
+
+			services.MarkLoopCompleted(services.LoopTaskCleanup)
 		}
 	}
 }
 
-// Background process that periodically checks channel status
-func runChannelChecker(db *gorm.DB) {
+// Background process that periodically checks channel status until ctx is
+// canceled
+func runChannelChecker(ctx context.Context, db *gorm.DB, slack services.SlackClient) {
 	ticker := time.NewTicker(1 * time.Hour) // Check every 1 hour
 	defer ticker.Stop()
 
-	for range ticker.C {
-		log.Println("start channel status check")
-		services.CleanupArchivedChannels(db) // Deactivate configs for archived channels
+	services.RegisterLoop(services.LoopChannelChecker, 1*time.Hour)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("channel checker stopped")
+			return
+
+		case <-ticker.C:
+			log.Println("start channel status check")
+			services.CleanupArchivedChannels(db, slack) // Deactivate configs for archived channels
+			services.MarkLoopCompleted(services.LoopChannelChecker)
+		}
+	}
+}
+
+// Background process that delivers queued Slack messages until ctx is canceled
+func runSlackOutboxWorker(ctx context.Context, db *gorm.DB, slack services.SlackClient) {
+	ticker := time.NewTicker(5 * time.Second) // Check every 5 seconds
+	defer ticker.Stop()
+
+	services.RegisterLoop(services.LoopSlackOutbox, 5*time.Second)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("slack outbox worker stopped")
+			return
+
+		case <-ticker.C:
+			services.ProcessSlackOutbox(db, slack)
+			services.MarkLoopCompleted(services.LoopSlackOutbox)
+		}
+	}
+}
+
+// Background process that polls GitHub for pull request changes every
+// interval until ctx is canceled
+func runGitHubPoller(ctx context.Context, db *gorm.DB, handle services.GitHubEventHandler, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	services.RegisterLoop(services.LoopGitHubPoller, interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("github poller stopped")
+			return
+
+		case <-ticker.C:
+			services.PollGitHub(db, handle)
+			services.MarkLoopCompleted(services.LoopGitHubPoller)
+		}
+	}
+}
+
+// Background process that resyncs active tasks with GitHub every interval
+// (never when 0) and on resync requests until ctx is canceled
+func runTaskReconciler(ctx context.Context, db *gorm.DB, slack services.SlackClient, handle services.GitHubEventHandler, interval time.Duration) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+		services.RegisterLoop(services.LoopTaskReconciler, interval)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("task reconciler stopped")
+			return
+
+		case <-tick:
+			log.Println("start task reconciliation")
+			services.ReconcileTasks(db, handle, "", "")
+			services.MarkLoopCompleted(services.LoopTaskReconciler)
+
+		case req := <-services.ReconcileRequests():
+			services.HandleReconcileRequest(db, slack, handle, req)
+		}
 	}
 }
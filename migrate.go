@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"text/tabwriter"
+	"time"
+
+	"gorm.io/gorm"
+
+	"slack-review-notify/models"
+)
+
+const migrateUsage = "usage: slack-review-notify migrate up|status"
+
+// runMigrate runs the migrate subcommand and returns the exit code.
+//
+//	migrate up      apply pending migrations
+//	migrate status  list migrations and whether they are applied
+func runMigrate(db *gorm.DB, args []string, out io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintln(out, migrateUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "up":
+		if err := models.Migrate(db); err != nil {
+			log.Printf("migrate up failed: %v", err)
+			return 1
+		}
+		fmt.Fprintf(out, "database schema is at version %d\n", models.LatestSchemaVersion())
+		return 0
+	case "status":
+		statuses, err := models.MigrationStatuses(db)
+		if err != nil && !errors.Is(err, models.ErrSchemaTooNew) {
+			log.Printf("migrate status failed: %v", err)
+			return 1
+		}
+		printMigrationStatuses(out, statuses)
+		if err != nil {
+			fmt.Fprintln(out, err)
+			return 1
+		}
+		return 0
+	default:
+		fmt.Fprintln(out, migrateUsage)
+		return 2
+	}
+}
+
+func printMigrationStatuses(out io.Writer, statuses []models.MigrationStatus) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+	for _, s := range statuses {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = s.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+	}
+	w.Flush()
+}
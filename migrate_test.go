@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"slack-review-notify/database/dbtest"
+	"slack-review-notify/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunMigrate(t *testing.T) {
+	db := dbtest.Open(t)
+	var out bytes.Buffer
+
+	assert.Equal(t, 0, runMigrate(db, []string{"status"}, &out))
+	assert.Contains(t, out.String(), "create_tables")
+	assert.Contains(t, out.String(), "pending")
+
+	out.Reset()
+	assert.Equal(t, 0, runMigrate(db, []string{"up"}, &out))
+	assert.Contains(t, out.String(), "database schema is at version")
+
+	out.Reset()
+	assert.Equal(t, 0, runMigrate(db, []string{"status"}, &out))
+	assert.NotContains(t, out.String(), "pending")
+
+	out.Reset()
+	require.NoError(t, db.Create(&models.SchemaMigration{Version: models.LatestSchemaVersion() + 1, Name: "newer", AppliedAt: time.Now()}).Error)
+	assert.Equal(t, 1, runMigrate(db, []string{"status"}, &out))
+	assert.Contains(t, out.String(), "newer than this binary")
+	assert.Equal(t, 1, runMigrate(db, []string{"up"}, &out))
+
+	out.Reset()
+	assert.Equal(t, 2, runMigrate(db, []string{"down"}, &out))
+	assert.Contains(t, out.String(), "usage:")
+	assert.Equal(t, 2, runMigrate(db, nil, &out))
+}
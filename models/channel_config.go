@@ -7,20 +7,25 @@ import (
 )
 
 type ChannelConfig struct {
-	ID                       string `gorm:"primaryKey"`
-	SlackChannelID           string `gorm:"index:idx_channel_label,unique:true"` // Composite unique index on channel ID and label name
-	LabelName                string `gorm:"index:idx_channel_label,unique:true"` // Label name to trigger notifications
-	DefaultMentionID         string // Default mention target (user ID)
-	ReviewerList             string // Reviewer list (comma-separated)
-	RepositoryList           string // List of repositories to notify for (comma-separated)
-	IsActive                 bool   // Active/inactive flag
-	ReminderInterval         int    // Reminder frequency (in minutes, default 30 minutes)
-	ReviewerReminderInterval int    // Reminder frequency after reviewer assignment (in minutes, default 30 minutes)
-	RequiredApprovals        int    `gorm:"default:1"` // Required number of approvals (default: 1)
-	BusinessHoursStart       string `gorm:"default:'09:00'"`      // Business hours start (HH:MM format)
-	BusinessHoursEnd         string `gorm:"default:'18:00'"`      // Business hours end (HH:MM format)
-	Timezone                 string `gorm:"default:'Asia/Tokyo'"` // Timezone (default: JST)
-	Language                 string `gorm:"default:'ja'"`         // Language for messages (ja, en)
+	ID                       string     `gorm:"primaryKey"`
+	TeamID                   string     `gorm:"index:idx_team_channel_label,unique:true"` // Slack installation the channel belongs to (see SlackInstallation)
+	SlackChannelID           string     `gorm:"index:idx_team_channel_label,unique:true"` // Composite unique index on team, channel ID and label name
+	LabelName                string     `gorm:"index:idx_team_channel_label,unique:true"` // Label name to trigger notifications
+	DefaultMentionID         string     // Default mention target (user ID)
+	ReviewerList             string     // Reviewer list (comma-separated)
+	RepositoryList           string     // List of repositories to notify for (comma-separated)
+	IsActive                 bool       // Active/inactive flag
+	ReminderInterval         int        // Reminder frequency (in minutes, default 30 minutes)
+	ReviewerReminderInterval int        // Reminder frequency after reviewer assignment (in minutes, default 30 minutes)
+	RequiredApprovals        int        `gorm:"default:1"`            // Required number of approvals (default: 1)
+	BusinessHoursStart       string     `gorm:"default:'09:00'"`      // Business hours start (HH:MM format)
+	BusinessHoursEnd         string     `gorm:"default:'18:00'"`      // Business hours end (HH:MM format)
+	Timezone                 string     `gorm:"default:'Asia/Tokyo'"` // Timezone (default: JST)
+	Language                 string     `gorm:"default:'ja'"`         // Language for messages (ja, en)
+	ReviewerStrategy         string     `gorm:"default:'random'"`     // Reviewer selection strategy (random, least-loaded, round-robin)
+	SyncReviewersToGitHub    bool       // Request the Slack-assigned reviewers on the GitHub PR as well
+	DigestTime               string     // Daily digest time (HH:MM in Timezone); empty disables the digest
+	DigestLastSentAt         *time.Time // When the daily digest was last posted
 	CreatedAt                time.Time
 	UpdatedAt                time.Time
 	DeletedAt                gorm.DeletedAt `gorm:"index"`
@@ -1,19 +1,16 @@
 package models
 
 import (
+	"slack-review-notify/database/dbtest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 func setupChannelConfigTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err != nil {
-		t.Fatalf("fail to open test db: %v", err)
-	}
+	db := dbtest.Open(t)
 
 	// Run migration
 	if err := db.AutoMigrate(&ChannelConfig{}); err != nil {
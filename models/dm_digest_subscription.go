@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// DMDigestSubscription holds a reviewer's opt-in to the daily direct message
+// listing the reviews waiting on them
+type DMDigestSubscription struct {
+	ID          string     `gorm:"primaryKey"`
+	TeamID      string     `gorm:"uniqueIndex:idx_team_slack_user;size:191"` // Slack installation the DM is sent through
+	SlackUserID string     `gorm:"uniqueIndex:idx_team_slack_user;size:191"`
+	Enabled     bool       // Opt-in flag
+	DigestTime  string     `gorm:"default:'10:00'"`      // Time of day to send (HH:MM format)
+	Timezone    string     `gorm:"default:'Asia/Tokyo'"` // Timezone of DigestTime
+	LastSentAt  *time.Time // When the digest was last sent
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// GithubInstallation is an installation of the GitHub App on a user or
+// organization account, recorded from installation webhooks. Installation
+// tokens for API calls on its repositories are minted under its ID.
+type GithubInstallation struct {
+	ID                  int64  `gorm:"primaryKey;autoIncrement:false"` // GitHub installation ID
+	TeamID              string `gorm:"index"`                          // Slack installation it is linked to; "" for every workspace
+	AccountLogin        string
+	AccountType         string // User or Organization
+	RepositorySelection string // all or selected
+	SuspendedAt         *time.Time
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// GithubInstallationRepository is a repository a GitHub App installation can
+// access. A repository belongs to a single account, so it is reachable
+// through at most one installation.
+type GithubInstallationRepository struct {
+	FullName       string `gorm:"primaryKey;size:191"` // owner/repo
+	InstallationID int64  `gorm:"index"`
+	CreatedAt      time.Time
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// GithubPollETag is the ETag of the last GitHub API response the poller
+// handled for a resource, sent back as If-None-Match so an unchanged
+// resource is answered with 304 Not Modified, which costs no rate limit.
+type GithubPollETag struct {
+	Resource  string `gorm:"primaryKey;size:191"` // e.g. pulls:owner/repo, pulls:owner/repo?page=2 or reviews:owner/repo#12
+	ETag      string
+	UpdatedAt time.Time
+}
+
+// GithubPolledPullRequest is an open pull request as the GitHub poller last
+// saw it, so the next poll can tell which labels and reviews are new.
+type GithubPolledPullRequest struct {
+	Repo         string    `gorm:"primaryKey;size:191"` // owner/repo
+	PRNumber     int       `gorm:"primaryKey;autoIncrement:false"`
+	Labels       string    // Comma-separated label names
+	PRUpdatedAt  time.Time // updated_at of the pull request; a review changes it
+	LastReviewID int64     // Highest review ID already handled
+	UpdatedAt    time.Time
+}
@@ -0,0 +1,360 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// The migrations create and alter tables from the copies of the models below,
+// frozen as they were when the migration was released, so a migration keeps
+// doing the same thing as the models change. A model change needs a new
+// migration that brings the tables from the last snapshot to it.
+
+// Tables as created by migration 1 (create_tables).
+
+type v1ReviewTask struct {
+	ID                      string `gorm:"primaryKey"`
+	PRURL                   string
+	Repo                    string
+	PRNumber                int
+	Title                   string
+	SlackTS                 string
+	SlackChannel            string
+	Reviewer                string
+	Reviewers               string
+	ApprovedBy              string
+	PRAuthorSlackID         string
+	Status                  string
+	LabelName               string
+	WatchingUntil           *time.Time
+	ReminderPausedUntil     *time.Time
+	OutOfHoursReminded      bool
+	PendingReReviewNotify   bool
+	PendingReReviewSender   string
+	PendingReReviewReviewer string
+	Language                string
+	CreatedAt               time.Time
+	UpdatedAt               time.Time
+	DeletedAt               gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1ReviewTask) TableName() string { return "review_tasks" }
+
+type v1ChannelConfig struct {
+	ID                       string `gorm:"primaryKey"`
+	SlackChannelID           string `gorm:"index:idx_channel_label,unique:true"`
+	LabelName                string `gorm:"index:idx_channel_label,unique:true"`
+	DefaultMentionID         string
+	ReviewerList             string
+	RepositoryList           string
+	IsActive                 bool
+	ReminderInterval         int
+	ReviewerReminderInterval int
+	RequiredApprovals        int    `gorm:"default:1"`
+	BusinessHoursStart       string `gorm:"default:'09:00'"`
+	BusinessHoursEnd         string `gorm:"default:'18:00'"`
+	Timezone                 string `gorm:"default:'Asia/Tokyo'"`
+	Language                 string `gorm:"default:'ja'"`
+	ReviewerStrategy         string `gorm:"default:'random'"`
+	SyncReviewersToGitHub    bool
+	DigestTime               string
+	DigestLastSentAt         *time.Time
+	CreatedAt                time.Time
+	UpdatedAt                time.Time
+	DeletedAt                gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1ChannelConfig) TableName() string { return "channel_configs" }
+
+type v1UserMapping struct {
+	ID             string `gorm:"primaryKey"`
+	GithubUsername string `gorm:"uniqueIndex;size:191"`
+	SlackUserID    string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1UserMapping) TableName() string { return "user_mappings" }
+
+type v1ReviewerAvailability struct {
+	ID          string `gorm:"primaryKey"`
+	SlackUserID string `gorm:"index"`
+	AwayFrom    *time.Time
+	AwayUntil   *time.Time
+	Reason      string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1ReviewerAvailability) TableName() string { return "reviewer_availabilities" }
+
+type v1ReviewerRotation struct {
+	ID             string `gorm:"primaryKey"`
+	SlackChannelID string `gorm:"index:idx_rotation_channel_label,unique:true"`
+	LabelName      string `gorm:"index:idx_rotation_channel_label,unique:true"`
+	LastReviewerID string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (v1ReviewerRotation) TableName() string { return "reviewer_rotations" }
+
+type v1TeamMapping struct {
+	ID               string `gorm:"primaryKey"`
+	GithubTeamSlug   string `gorm:"uniqueIndex;size:191"`
+	SlackUserGroupID string
+	MemberIDs        string
+	PickReviewer     bool
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+func (v1TeamMapping) TableName() string { return "team_mappings" }
+
+type v1DMDigestSubscription struct {
+	ID          string `gorm:"primaryKey"`
+	SlackUserID string `gorm:"uniqueIndex;size:191"`
+	Enabled     bool
+	DigestTime  string `gorm:"default:'10:00'"`
+	Timezone    string `gorm:"default:'Asia/Tokyo'"`
+	LastSentAt  *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (v1DMDigestSubscription) TableName() string { return "dm_digest_subscriptions" }
+
+type v1ReviewEvent struct {
+	ID           string `gorm:"primaryKey"`
+	TaskID       string `gorm:"index"`
+	Repo         string
+	PRNumber     int
+	SlackChannel string `gorm:"index:idx_review_events_scope"`
+	LabelName    string `gorm:"index:idx_review_events_scope"`
+	EventType    string `gorm:"index"`
+	ActorID      string
+	Detail       string
+	CreatedAt    time.Time `gorm:"index"`
+}
+
+func (v1ReviewEvent) TableName() string { return "review_events" }
+
+type v1SlackOutboxMessage struct {
+	ID            string `gorm:"primaryKey"`
+	Method        string
+	Channel       string `gorm:"index"`
+	Payload       string
+	Status        string `gorm:"index"`
+	Attempts      int
+	NextAttemptAt time.Time `gorm:"index"`
+	LastError     string
+	SentAt        *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (v1SlackOutboxMessage) TableName() string { return "slack_outbox_messages" }
+
+type v1WebhookDelivery struct {
+	ID          string `gorm:"primaryKey"`
+	EventType   string
+	Action      string
+	Payload     string
+	Status      string `gorm:"index"`
+	Error       string
+	Attempts    int
+	ProcessedAt *time.Time
+	CreatedAt   time.Time `gorm:"index"`
+	UpdatedAt   time.Time
+}
+
+func (v1WebhookDelivery) TableName() string { return "webhook_deliveries" }
+
+// Tables as changed by migration 4 (scope_by_slack_team).
+
+type v4SlackInstallation struct {
+	TeamID              string `gorm:"primaryKey"`
+	TeamName            string
+	EnterpriseID        string
+	IsEnterpriseInstall bool
+	BotUserID           string
+	BotToken            string
+	Scope               string
+	InstalledBy         string
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+func (v4SlackInstallation) TableName() string { return "slack_installations" }
+
+type v4ChannelConfig struct {
+	ID                       string `gorm:"primaryKey"`
+	TeamID                   string `gorm:"index:idx_team_channel_label,unique:true"`
+	SlackChannelID           string `gorm:"index:idx_team_channel_label,unique:true"`
+	LabelName                string `gorm:"index:idx_team_channel_label,unique:true"`
+	DefaultMentionID         string
+	ReviewerList             string
+	RepositoryList           string
+	IsActive                 bool
+	ReminderInterval         int
+	ReviewerReminderInterval int
+	RequiredApprovals        int    `gorm:"default:1"`
+	BusinessHoursStart       string `gorm:"default:'09:00'"`
+	BusinessHoursEnd         string `gorm:"default:'18:00'"`
+	Timezone                 string `gorm:"default:'Asia/Tokyo'"`
+	Language                 string `gorm:"default:'ja'"`
+	ReviewerStrategy         string `gorm:"default:'random'"`
+	SyncReviewersToGitHub    bool
+	DigestTime               string
+	DigestLastSentAt         *time.Time
+	CreatedAt                time.Time
+	UpdatedAt                time.Time
+	DeletedAt                gorm.DeletedAt `gorm:"index"`
+}
+
+func (v4ChannelConfig) TableName() string { return "channel_configs" }
+
+type v4ReviewTask struct {
+	v1ReviewTask
+	TeamID string `gorm:"index"`
+}
+
+func (v4ReviewTask) TableName() string { return "review_tasks" }
+
+type v4UserMapping struct {
+	ID             string `gorm:"primaryKey"`
+	TeamID         string `gorm:"uniqueIndex:idx_team_github_username;size:191"`
+	GithubUsername string `gorm:"uniqueIndex:idx_team_github_username;size:191"`
+	SlackUserID    string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+}
+
+func (v4UserMapping) TableName() string { return "user_mappings" }
+
+type v4DMDigestSubscription struct {
+	v1DMDigestSubscription
+	TeamID string
+}
+
+func (v4DMDigestSubscription) TableName() string { return "dm_digest_subscriptions" }
+
+type v4SlackOutboxMessage struct {
+	v1SlackOutboxMessage
+	TeamID string
+}
+
+func (v4SlackOutboxMessage) TableName() string { return "slack_outbox_messages" }
+
+// Tables as created by migration 5 (create_github_installations).
+
+type v5GithubInstallation struct {
+	ID                  int64 `gorm:"primaryKey;autoIncrement:false"`
+	AccountLogin        string
+	AccountType         string
+	RepositorySelection string
+	SuspendedAt         *time.Time
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+func (v5GithubInstallation) TableName() string { return "github_installations" }
+
+type v5GithubInstallationRepository struct {
+	FullName       string `gorm:"primaryKey;size:191"`
+	InstallationID int64  `gorm:"index"`
+	CreatedAt      time.Time
+}
+
+func (v5GithubInstallationRepository) TableName() string { return "github_installation_repositories" }
+
+// Tables as created by migration 6 (create_github_poll_state).
+
+type v6GithubPollETag struct {
+	Resource  string `gorm:"primaryKey;size:191"`
+	ETag      string
+	UpdatedAt time.Time
+}
+
+func (v6GithubPollETag) TableName() string { return "github_poll_e_tags" }
+
+type v6GithubPolledPullRequest struct {
+	Repo         string `gorm:"primaryKey;size:191"`
+	PRNumber     int    `gorm:"primaryKey;autoIncrement:false"`
+	Labels       string
+	PRUpdatedAt  time.Time
+	LastReviewID int64
+	UpdatedAt    time.Time
+}
+
+func (v6GithubPolledPullRequest) TableName() string { return "github_polled_pull_requests" }
+
+// Tables as changed by migration 7 (scope_team_mappings_and_github_installations).
+
+type v7TeamMapping struct {
+	ID               string `gorm:"primaryKey"`
+	TeamID           string `gorm:"uniqueIndex:idx_team_github_team_slug;size:191"`
+	GithubTeamSlug   string `gorm:"uniqueIndex:idx_team_github_team_slug;size:191"`
+	SlackUserGroupID string
+	MemberIDs        string
+	PickReviewer     bool
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+func (v7TeamMapping) TableName() string { return "team_mappings" }
+
+type v7GithubInstallation struct {
+	v5GithubInstallation
+	TeamID string `gorm:"index"`
+}
+
+func (v7GithubInstallation) TableName() string { return "github_installations" }
+
+// Tables as changed by migration 8 (scope_dm_digest_subscriptions).
+
+type v8DMDigestSubscription struct {
+	ID          string `gorm:"primaryKey"`
+	TeamID      string `gorm:"uniqueIndex:idx_team_slack_user;size:191"`
+	SlackUserID string `gorm:"uniqueIndex:idx_team_slack_user;size:191"`
+	Enabled     bool
+	DigestTime  string `gorm:"default:'10:00'"`
+	Timezone    string `gorm:"default:'Asia/Tokyo'"`
+	LastSentAt  *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (v8DMDigestSubscription) TableName() string { return "dm_digest_subscriptions" }
+
+type v9ReviewerRotation struct {
+	ID             string `gorm:"primaryKey"`
+	TeamID         string `gorm:"index:idx_team_rotation_channel_label,unique:true"`
+	SlackChannelID string `gorm:"index:idx_team_rotation_channel_label,unique:true"`
+	LabelName      string `gorm:"index:idx_team_rotation_channel_label,unique:true"`
+	LastReviewerID string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (v9ReviewerRotation) TableName() string { return "reviewer_rotations" }
+
+type v10ReviewEvent struct {
+	ID           string `gorm:"primaryKey"`
+	TaskID       string `gorm:"index"`
+	TeamID       string `gorm:"index:idx_review_events_team_scope"`
+	Repo         string
+	PRNumber     int
+	SlackChannel string `gorm:"index:idx_review_events_team_scope"`
+	LabelName    string `gorm:"index:idx_review_events_team_scope"`
+	EventType    string `gorm:"index"`
+	ActorID      string
+	Detail       string
+	CreatedAt    time.Time `gorm:"index"`
+}
+
+func (v10ReviewEvent) TableName() string { return "review_events" }
@@ -0,0 +1,331 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SchemaMigration records a migration applied to the database.
+type SchemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// Migration is one versioned schema change. Versions start at 1 and increase
+// by one; a migration is never edited once released, later changes get a new
+// version instead. Migrations work on the snapshots of the models in
+// migration_schemas.go, never on the models themselves.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+}
+
+// MigrationStatus is a migration and, once applied, when it was applied.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// ErrSchemaTooNew is returned when the database has migrations applied that
+// this binary does not know, i.e. it was migrated by a newer release.
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary")
+
+// Migrations lists every schema migration in version order.
+var Migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_tables",
+		Up: func(tx *gorm.DB) error {
+			// Idempotent, so databases created before versioned migrations
+			// existed are adopted as they are.
+			return tx.AutoMigrate(&v1ReviewTask{}, &v1ChannelConfig{}, &v1UserMapping{}, &v1ReviewerAvailability{}, &v1ReviewerRotation{}, &v1TeamMapping{}, &v1DMDigestSubscription{}, &v1ReviewEvent{}, &v1SlackOutboxMessage{}, &v1WebhookDelivery{})
+		},
+	},
+	{
+		Version: 2,
+		Name:    "relax_reviewer_availability_index",
+		Up:      MigrateReviewerAvailabilityIndex,
+	},
+	{
+		Version: 3,
+		Name:    "normalize_slack_user_ids",
+		Up:      MigrateNormalizeSlackUserIDs,
+	},
+	{
+		Version: 4,
+		Name:    "scope_by_slack_team",
+		Up:      migrateScopeBySlackTeam,
+	},
+	{
+		Version: 5,
+		Name:    "create_github_installations",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&v5GithubInstallation{}, &v5GithubInstallationRepository{})
+		},
+	},
+	{
+		Version: 6,
+		Name:    "create_github_poll_state",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&v6GithubPollETag{}, &v6GithubPolledPullRequest{})
+		},
+	},
+	{
+		Version: 7,
+		Name:    "scope_team_mappings_and_github_installations",
+		Up:      migrateScopeTeamMappings,
+	},
+	{
+		Version: 8,
+		Name:    "scope_dm_digest_subscriptions",
+		Up:      migrateScopeDMDigestSubscriptions,
+	},
+	{
+		Version: 9,
+		Name:    "scope_reviewer_rotations",
+		Up:      migrateScopeReviewerRotations,
+	},
+	{
+		Version: 10,
+		Name:    "scope_review_events",
+		Up:      migrateScopeReviewEvents,
+	},
+}
+
+// LatestSchemaVersion is the version the database has after Migrate.
+func LatestSchemaVersion() int {
+	return Migrations[len(Migrations)-1].Version
+}
+
+// migrationLockName names the lock Migrate holds; migrationLockKey is its
+// PostgreSQL advisory lock key.
+const (
+	migrationLockName = "slack_review_notify_migrate"
+	migrationLockKey  = int64(4207313411)
+)
+
+// Migrate applies the pending migrations in order, each in its own
+// transaction together with its schema_migrations row. It returns
+// ErrSchemaTooNew without changing anything when the database is ahead of
+// this binary. Processes migrating at the same time take turns, so the later
+// one finds the migrations already applied.
+func Migrate(db *gorm.DB) error {
+	return withMigrationLock(db, func(conn *gorm.DB) error {
+		applied, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+		if err := checkSchemaVersion(applied); err != nil {
+			return err
+		}
+
+		for _, m := range Migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := m.Up(tx); err != nil {
+					return err
+				}
+				return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+			}
+			log.Printf("applied migration %d (%s)", m.Version, m.Name)
+		}
+		return nil
+	})
+}
+
+// withMigrationLock runs fn on a single connection holding the database-wide
+// migration lock: an advisory lock on PostgreSQL, a named lock on MySQL. Both
+// belong to the session, so they are released with the connection should the
+// process die. SQLite has no such lock and runs fn as it is.
+func withMigrationLock(db *gorm.DB, fn func(conn *gorm.DB) error) error {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return db.Connection(func(conn *gorm.DB) error {
+			if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockKey).Error; err != nil {
+				return fmt.Errorf("failed to take the migration lock: %w", err)
+			}
+			defer func() {
+				if err := conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockKey).Error; err != nil {
+					log.Printf("failed to release the migration lock: %v", err)
+				}
+			}()
+			return fn(conn)
+		})
+	case "mysql":
+		return db.Connection(func(conn *gorm.DB) error {
+			var locked *int
+			if err := conn.Raw("SELECT GET_LOCK(?, -1)", migrationLockName).Scan(&locked).Error; err != nil {
+				return fmt.Errorf("failed to take the migration lock: %w", err)
+			}
+			if locked == nil || *locked != 1 {
+				return errors.New("failed to take the migration lock")
+			}
+			defer func() {
+				if err := conn.Exec("SELECT RELEASE_LOCK(?)", migrationLockName).Error; err != nil {
+					log.Printf("failed to release the migration lock: %v", err)
+				}
+			}()
+			return fn(conn)
+		})
+	default:
+		return fn(db)
+	}
+}
+
+// MigrationStatuses returns every known migration with the time it was
+// applied, or nil while pending. Like Migrate, it returns ErrSchemaTooNew when
+// the database is ahead of this binary.
+func MigrationStatuses(db *gorm.DB) ([]MigrationStatus, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(Migrations))
+	for _, m := range Migrations {
+		status := MigrationStatus{Migration: m}
+		if a, ok := applied[m.Version]; ok {
+			appliedAt := a.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, checkSchemaVersion(applied)
+}
+
+// appliedMigrations loads the schema_migrations table, creating it first on a
+// database that has never been migrated.
+func appliedMigrations(db *gorm.DB) (map[int]SchemaMigration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+	var rows []SchemaMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+func checkSchemaVersion(applied map[int]SchemaMigration) error {
+	latest, newest := LatestSchemaVersion(), 0
+	for version := range applied {
+		newest = max(newest, version)
+	}
+	if newest > latest {
+		return fmt.Errorf("%w: database is at version %d, this binary knows up to %d", ErrSchemaTooNew, newest, latest)
+	}
+	return nil
+}
+
+// migrateScopeBySlackTeam adds the slack_installations table and the team_id
+// columns, and replaces the channel/label and GitHub username unique indexes
+// with ones that include team_id. Existing rows get an empty team_id until
+// they are adopted by a team at startup.
+func migrateScopeBySlackTeam(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&v4SlackInstallation{}, &v4ChannelConfig{}, &v4ReviewTask{}, &v4UserMapping{}, &v4DMDigestSubscription{}, &v4SlackOutboxMessage{}); err != nil {
+		return err
+	}
+	for _, table := range []string{"channel_configs", "review_tasks", "user_mappings", "dm_digest_subscriptions", "slack_outbox_messages"} {
+		if err := tx.Table(table).Where("team_id IS NULL").Update("team_id", "").Error; err != nil {
+			return err
+		}
+	}
+	if tx.Migrator().HasIndex(&v4ChannelConfig{}, "idx_channel_label") {
+		if err := tx.Migrator().DropIndex(&v4ChannelConfig{}, "idx_channel_label"); err != nil {
+			return err
+		}
+	}
+	if tx.Migrator().HasIndex(&v4UserMapping{}, "idx_user_mappings_github_username") {
+		if err := tx.Migrator().DropIndex(&v4UserMapping{}, "idx_user_mappings_github_username"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateScopeTeamMappings adds team_id to team mappings and GitHub App
+// installations, and replaces the GitHub team slug unique index with one that
+// includes team_id. Existing team mappings are adopted by a team at startup;
+// existing installations stay shared by every workspace.
+func migrateScopeTeamMappings(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&v7TeamMapping{}, &v7GithubInstallation{}); err != nil {
+		return err
+	}
+	for _, table := range []string{"team_mappings", "github_installations"} {
+		if err := tx.Table(table).Where("team_id IS NULL").Update("team_id", "").Error; err != nil {
+			return err
+		}
+	}
+	if tx.Migrator().HasIndex(&v7TeamMapping{}, "idx_team_mappings_github_team_slug") {
+		if err := tx.Migrator().DropIndex(&v7TeamMapping{}, "idx_team_mappings_github_team_slug"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateScopeDMDigestSubscriptions replaces the Slack user unique index of
+// DM digest subscriptions with one that includes team_id, so a user of two
+// workspaces can subscribe in each.
+func migrateScopeDMDigestSubscriptions(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&v8DMDigestSubscription{}); err != nil {
+		return err
+	}
+	if tx.Migrator().HasIndex(&v8DMDigestSubscription{}, "idx_dm_digest_subscriptions_slack_user_id") {
+		if err := tx.Migrator().DropIndex(&v8DMDigestSubscription{}, "idx_dm_digest_subscriptions_slack_user_id"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateScopeReviewerRotations adds team_id to the round-robin cursors and
+// replaces their channel/label unique index with one that includes team_id.
+// Existing cursors are adopted by a team at startup.
+func migrateScopeReviewerRotations(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&v9ReviewerRotation{}); err != nil {
+		return err
+	}
+	if err := tx.Table("reviewer_rotations").Where("team_id IS NULL").Update("team_id", "").Error; err != nil {
+		return err
+	}
+	if tx.Migrator().HasIndex(&v9ReviewerRotation{}, "idx_rotation_channel_label") {
+		if err := tx.Migrator().DropIndex(&v9ReviewerRotation{}, "idx_rotation_channel_label"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateScopeReviewEvents adds team_id to the review lifecycle events and
+// replaces their channel/label index with one that starts with team_id.
+// Existing events are adopted by a team at startup.
+func migrateScopeReviewEvents(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&v10ReviewEvent{}); err != nil {
+		return err
+	}
+	if err := tx.Table("review_events").Where("team_id IS NULL").Update("team_id", "").Error; err != nil {
+		return err
+	}
+	if tx.Migrator().HasIndex(&v10ReviewEvent{}, "idx_review_events_scope") {
+		if err := tx.Migrator().DropIndex(&v10ReviewEvent{}, "idx_review_events_scope"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
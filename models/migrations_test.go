@@ -0,0 +1,236 @@
+package models
+
+import (
+	"slack-review-notify/database/dbtest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestMigrate_FreshDB(t *testing.T) {
+	db := dbtest.Open(t)
+
+	require.NoError(t, Migrate(db))
+
+	statuses, err := MigrationStatuses(db)
+	require.NoError(t, err)
+	require.Len(t, statuses, len(Migrations))
+	for _, s := range statuses {
+		assert.NotNil(t, s.AppliedAt, "migration %d should be applied", s.Version)
+	}
+	assert.True(t, db.Migrator().HasTable(&ReviewTask{}))
+	assert.True(t, db.Migrator().HasTable(&WebhookDelivery{}))
+	assert.True(t, db.Migrator().HasTable(&GithubInstallationRepository{}))
+	assert.True(t, db.Migrator().HasTable(&GithubPolledPullRequest{}))
+
+	// Running again applies nothing
+	require.NoError(t, Migrate(db))
+	var count int64
+	db.Model(&SchemaMigration{}).Count(&count)
+	assert.EqualValues(t, len(Migrations), count)
+}
+
+// TestMigrate_MatchesModels checks that the migrations, which work on
+// snapshots of the models, leave every column and index the models expect.
+func TestMigrate_MatchesModels(t *testing.T) {
+	db := dbtest.Open(t)
+	require.NoError(t, Migrate(db))
+
+	models := []interface{}{
+		&ReviewTask{}, &ChannelConfig{}, &UserMapping{}, &ReviewerAvailability{}, &ReviewerRotation{},
+		&TeamMapping{}, &DMDigestSubscription{}, &ReviewEvent{}, &SlackOutboxMessage{}, &WebhookDelivery{},
+		&SlackInstallation{}, &GithubInstallation{}, &GithubInstallationRepository{},
+		&GithubPollETag{}, &GithubPolledPullRequest{},
+	}
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		require.NoError(t, stmt.Parse(model))
+		require.True(t, db.Migrator().HasTable(model), "table %s", stmt.Table)
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" {
+				assert.True(t, db.Migrator().HasColumn(model, field.DBName), "column %s.%s", stmt.Table, field.DBName)
+			}
+		}
+		for _, index := range stmt.Schema.ParseIndexes() {
+			assert.True(t, db.Migrator().HasIndex(model, index.Name), "index %s on %s", index.Name, stmt.Table)
+		}
+	}
+}
+
+// TestMigrate_AdoptsUnversionedDB covers a database created by AutoMigrate
+// before schema_migrations existed: the migrations apply on top of it and the
+// data fixers still run.
+func TestMigrate_AdoptsUnversionedDB(t *testing.T) {
+	db := dbtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&legacyReviewerAvailability{}))
+	legacy := ReviewerAvailability{ID: uuid.NewString(), SlackUserID: "UABC|alice"}
+	require.NoError(t, db.Create(&legacy).Error)
+
+	require.NoError(t, Migrate(db))
+
+	var got ReviewerAvailability
+	require.NoError(t, db.First(&got, "id = ?", legacy.ID).Error)
+	assert.Equal(t, "UABC", got.SlackUserID)
+
+	unique, err := isUniqueIndex(db, "reviewer_availabilities", reviewerAvailabilitySlackUserIndex)
+	require.NoError(t, err)
+	assert.False(t, unique)
+}
+
+// legacyChannelConfig and legacyUserMapping replicate the schema before rows
+// were scoped by Slack team, unique on channel/label and GitHub username alone.
+type legacyChannelConfig struct {
+	ID             string `gorm:"primaryKey"`
+	SlackChannelID string `gorm:"index:idx_channel_label,unique:true"`
+	LabelName      string `gorm:"index:idx_channel_label,unique:true"`
+}
+
+func (legacyChannelConfig) TableName() string { return "channel_configs" }
+
+type legacyUserMapping struct {
+	ID             string `gorm:"primaryKey"`
+	GithubUsername string `gorm:"uniqueIndex;size:191"`
+	SlackUserID    string
+}
+
+func (legacyUserMapping) TableName() string { return "user_mappings" }
+
+func TestMigrate_ScopesBySlackTeam(t *testing.T) {
+	db := dbtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&legacyChannelConfig{}, &legacyUserMapping{}))
+	require.NoError(t, db.Create(&legacyChannelConfig{ID: "c-legacy", SlackChannelID: "C1", LabelName: "needs-review"}).Error)
+	require.NoError(t, db.Create(&legacyUserMapping{ID: "m-legacy", GithubUsername: "octocat", SlackUserID: "U1"}).Error)
+
+	require.NoError(t, Migrate(db))
+
+	// Existing rows are left for the startup adoption with an empty team
+	var config ChannelConfig
+	require.NoError(t, db.First(&config, "id = ?", "c-legacy").Error)
+	assert.Equal(t, "", config.TeamID)
+	var count int64
+	db.Model(&UserMapping{}).Where("team_id = ''").Count(&count)
+	assert.EqualValues(t, 1, count)
+
+	// Another team may use the same channel/label and GitHub username...
+	assert.NoError(t, db.Create(&ChannelConfig{ID: "c-t2", TeamID: "T2", SlackChannelID: "C1", LabelName: "needs-review"}).Error)
+	assert.NoError(t, db.Create(&UserMapping{ID: "m-t2", TeamID: "T2", GithubUsername: "octocat", SlackUserID: "U2"}).Error)
+	// ...but a team still has one of each
+	assert.Error(t, db.Create(&ChannelConfig{ID: "c-t2-dup", TeamID: "T2", SlackChannelID: "C1", LabelName: "needs-review"}).Error)
+	assert.Error(t, db.Create(&UserMapping{ID: "m-t2-dup", TeamID: "T2", GithubUsername: "octocat", SlackUserID: "U3"}).Error)
+}
+
+func TestMigrate_ScopesTeamMappings(t *testing.T) {
+	db := dbtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&v1TeamMapping{}))
+	require.NoError(t, db.Create(&v1TeamMapping{ID: "tm-legacy", GithubTeamSlug: "backend"}).Error)
+
+	require.NoError(t, Migrate(db))
+
+	var mapping TeamMapping
+	require.NoError(t, db.First(&mapping, "id = ?", "tm-legacy").Error)
+	assert.Equal(t, "", mapping.TeamID)
+
+	assert.NoError(t, db.Create(&TeamMapping{ID: "tm-t2", TeamID: "T2", GithubTeamSlug: "backend"}).Error)
+	assert.Error(t, db.Create(&TeamMapping{ID: "tm-t2-dup", TeamID: "T2", GithubTeamSlug: "backend"}).Error)
+}
+
+func TestMigrate_ScopesDMDigestSubscriptions(t *testing.T) {
+	db := dbtest.Open(t)
+	require.NoError(t, Migrate(db))
+
+	require.NoError(t, db.Create(&DMDigestSubscription{ID: "d-t1", TeamID: "T1", SlackUserID: "U1"}).Error)
+	assert.NoError(t, db.Create(&DMDigestSubscription{ID: "d-t2", TeamID: "T2", SlackUserID: "U1"}).Error)
+	assert.Error(t, db.Create(&DMDigestSubscription{ID: "d-t2-dup", TeamID: "T2", SlackUserID: "U1"}).Error)
+}
+
+func TestMigrate_ScopesReviewerRotations(t *testing.T) {
+	db := dbtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&v1ReviewerRotation{}))
+	require.NoError(t, db.Create(&v1ReviewerRotation{ID: "r-legacy", SlackChannelID: "C1", LabelName: "needs-review"}).Error)
+
+	require.NoError(t, Migrate(db))
+
+	var rotation ReviewerRotation
+	require.NoError(t, db.First(&rotation, "id = ?", "r-legacy").Error)
+	assert.Equal(t, "", rotation.TeamID)
+
+	assert.NoError(t, db.Create(&ReviewerRotation{ID: "r-t2", TeamID: "T2", SlackChannelID: "C1", LabelName: "needs-review"}).Error)
+	assert.Error(t, db.Create(&ReviewerRotation{ID: "r-t2-dup", TeamID: "T2", SlackChannelID: "C1", LabelName: "needs-review"}).Error)
+}
+
+func TestMigrate_ScopesReviewEvents(t *testing.T) {
+	db := dbtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&v1ReviewEvent{}))
+	require.NoError(t, db.Create(&v1ReviewEvent{ID: "e-legacy", SlackChannel: "C1", LabelName: "needs-review", CreatedAt: time.Now()}).Error)
+
+	require.NoError(t, Migrate(db))
+
+	var event ReviewEvent
+	require.NoError(t, db.First(&event, "id = ?", "e-legacy").Error)
+	assert.Equal(t, "", event.TeamID)
+	assert.False(t, db.Migrator().HasIndex(&ReviewEvent{}, "idx_review_events_scope"))
+}
+
+func TestMigrate_PendingMigrations(t *testing.T) {
+	db := dbtest.Open(t)
+	require.NoError(t, Migrate(db))
+	require.NoError(t, db.Where("version > ?", 1).Delete(&SchemaMigration{}).Error)
+
+	statuses, err := MigrationStatuses(db)
+	require.NoError(t, err)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	for _, s := range statuses[1:] {
+		assert.Nil(t, s.AppliedAt, "migration %d should be pending", s.Version)
+	}
+
+	require.NoError(t, Migrate(db))
+	statuses, err = MigrationStatuses(db)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.NotNil(t, s.AppliedAt, "migration %d should be applied", s.Version)
+	}
+}
+
+func TestMigrate_Concurrently(t *testing.T) {
+	db := dbtest.Open(t)
+	if db.Dialector.Name() == "sqlite" {
+		t.Skip("SQLite has no migration lock; run with TEST_DATABASE_URL")
+	}
+
+	errs := make(chan error, 3)
+	for range cap(errs) {
+		go func() { errs <- Migrate(db) }()
+	}
+	for range cap(errs) {
+		assert.NoError(t, <-errs)
+	}
+
+	var count int64
+	db.Model(&SchemaMigration{}).Count(&count)
+	assert.EqualValues(t, len(Migrations), count)
+}
+
+func TestMigrate_RefusesNewerSchema(t *testing.T) {
+	db := dbtest.Open(t)
+	require.NoError(t, Migrate(db))
+	future := SchemaMigration{Version: LatestSchemaVersion() + 1, Name: "from_the_future", AppliedAt: time.Now()}
+	require.NoError(t, db.Create(&future).Error)
+
+	assert.ErrorIs(t, Migrate(db), ErrSchemaTooNew)
+
+	statuses, err := MigrationStatuses(db)
+	assert.ErrorIs(t, err, ErrSchemaTooNew)
+	assert.Len(t, statuses, len(Migrations))
+}
+
+func TestMigrations_VersionsAreSequential(t *testing.T) {
+	for i, m := range Migrations {
+		assert.Equal(t, i+1, m.Version, "migration %s", m.Name)
+		assert.NotEmpty(t, m.Name)
+		assert.NotNil(t, m.Up)
+	}
+}
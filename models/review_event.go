@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// ReviewEvent records one step of a review task's lifecycle. Events outlive
+// the task rows, which are cleaned up soon after completion, so review
+// latency can be reported over longer periods.
+type ReviewEvent struct {
+	ID           string `gorm:"primaryKey"`
+	TaskID       string `gorm:"index"`
+	TeamID       string `gorm:"index:idx_review_events_team_scope"` // Slack installation of the task's channel (see SlackInstallation)
+	Repo         string
+	PRNumber     int
+	SlackChannel string    `gorm:"index:idx_review_events_team_scope"`
+	LabelName    string    `gorm:"index:idx_review_events_team_scope"`
+	EventType    string    `gorm:"index"` // labeled, reviewer_assigned, reviewer_reassigned, first_review, approved, fully_approved, completed, merged
+	ActorID      string    // Slack ID (or GitHub login when unmapped) of the reviewer involved, or the PR author for labeled
+	Detail       string    // reviewer_reassigned: the replaced reviewer; completed: the reason
+	CreatedAt    time.Time `gorm:"index"`
+}
@@ -8,6 +8,7 @@ import (
 
 type ReviewTask struct {
 	ID                  string `gorm:"primaryKey"`
+	TeamID              string `gorm:"index"` // Slack installation of SlackChannel (copied from ChannelConfig)
 	PRURL               string
 	Repo                string
 	PRNumber            int
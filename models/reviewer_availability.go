@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"log"
 	"strings"
 	"time"
@@ -38,27 +39,58 @@ func MigrateReviewerAvailabilityIndex(db *gorm.DB) error {
 		return nil
 	}
 
-	var indexes []struct {
-		Name   string
-		Unique int
-	}
-	if err := db.Raw("PRAGMA index_list(reviewer_availabilities)").Scan(&indexes).Error; err != nil {
+	unique, err := isUniqueIndex(db, "reviewer_availabilities", reviewerAvailabilitySlackUserIndex)
+	if err != nil {
 		return err
 	}
+	if !unique {
+		return nil
+	}
 
-	for _, idx := range indexes {
-		if idx.Name == reviewerAvailabilitySlackUserIndex && idx.Unique == 1 {
-			// Drop and recreate in one transaction so a failure cannot leave
-			// the table without any slack_user_id index.
-			return db.Transaction(func(tx *gorm.DB) error {
-				if err := tx.Migrator().DropIndex(&ReviewerAvailability{}, "SlackUserID"); err != nil {
-					return err
-				}
-				return tx.Migrator().CreateIndex(&ReviewerAvailability{}, "SlackUserID")
-			})
+	// Drop and recreate in one transaction so a failure cannot leave the table
+	// without any slack_user_id index. MySQL commits DDL implicitly, so there
+	// the two steps are not atomic.
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropIndex(&ReviewerAvailability{}, "SlackUserID"); err != nil {
+			return err
 		}
+		return tx.Migrator().CreateIndex(&ReviewerAvailability{}, "SlackUserID")
+	})
+}
+
+// isUniqueIndex reports whether the named index on table exists and is
+// UNIQUE, reading the catalog of the connected dialect.
+func isUniqueIndex(db *gorm.DB, table, index string) (bool, error) {
+	var unique []bool
+	var err error
+	switch db.Dialector.Name() {
+	case "sqlite":
+		var indexes []struct {
+			Name   string
+			Unique bool
+		}
+		err = db.Raw("PRAGMA index_list(" + table + ")").Scan(&indexes).Error
+		for _, idx := range indexes {
+			if idx.Name == index {
+				unique = append(unique, idx.Unique)
+			}
+		}
+	case "postgres":
+		err = db.Raw(`SELECT ix.indisunique FROM pg_index ix
+			JOIN pg_class i ON i.oid = ix.indexrelid
+			JOIN pg_class t ON t.oid = ix.indrelid
+			WHERE t.relname = ? AND i.relname = ? AND pg_table_is_visible(t.oid)`, table, index).Scan(&unique).Error
+	case "mysql":
+		err = db.Raw(`SELECT NON_UNIQUE = 0 FROM information_schema.STATISTICS
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND INDEX_NAME = ?
+			LIMIT 1`, table, index).Scan(&unique).Error
+	default:
+		return false, fmt.Errorf("unsupported database dialect %q", db.Dialector.Name())
+	}
+	if err != nil {
+		return false, err
 	}
-	return nil
+	return len(unique) > 0 && unique[0], nil
 }
 
 // MigrateNormalizeSlackUserIDs normalizes legacy slack_user_id values that
@@ -1,13 +1,12 @@
 package models
 
 import (
+	"slack-review-notify/database/dbtest"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 // legacyReviewerAvailability replicates the pre-migration schema where
@@ -15,7 +14,7 @@ import (
 // reproduce a database created by an older version of the app.
 type legacyReviewerAvailability struct {
 	ID          string `gorm:"primaryKey"`
-	SlackUserID string `gorm:"uniqueIndex"`
+	SlackUserID string `gorm:"uniqueIndex;size:191"`
 	AwayFrom    *time.Time
 	AwayUntil   *time.Time
 	Reason      string
@@ -26,32 +25,18 @@ type legacyReviewerAvailability struct {
 
 func (legacyReviewerAvailability) TableName() string { return "reviewer_availabilities" }
 
-func openSilent(t *testing.T, path string) *gorm.DB {
-	t.Helper()
-	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
-	if err != nil {
-		t.Fatalf("open db: %v", err)
-	}
-	return db
-}
-
 // TestMigrateReviewerAvailabilityIndex_LegacyDB reproduces the original bug:
 // a database created with the old uniqueIndex schema rejects a second leave
 // period for the same user even after AutoMigrate. The migration must relax
 // the index so multiple periods can coexist.
 func TestMigrateReviewerAvailabilityIndex_LegacyDB(t *testing.T) {
-	dbPath := t.TempDir() + "/legacy.db"
+	db := dbtest.Open(t)
 
-	// Create the DB with the old uniqueIndex schema, then close it.
-	dbOld := openSilent(t, dbPath)
-	if err := dbOld.AutoMigrate(&legacyReviewerAvailability{}); err != nil {
+	// Create the table with the old uniqueIndex schema, then apply the
+	// current migrations.
+	if err := db.AutoMigrate(&legacyReviewerAvailability{}); err != nil {
 		t.Fatalf("migrate legacy schema: %v", err)
 	}
-	sqlOld, _ := dbOld.DB()
-	sqlOld.Close()
-
-	// Reopen and apply the current migrations.
-	db := openSilent(t, dbPath)
 	if err := db.AutoMigrate(&ReviewerAvailability{}); err != nil {
 		t.Fatalf("auto migrate: %v", err)
 	}
@@ -80,8 +65,7 @@ func TestMigrateReviewerAvailabilityIndex_LegacyDB(t *testing.T) {
 // TestMigrateNormalizeSlackUserIDs verifies that legacy "ID|displayname" values
 // are stripped to the bare ID, and that records with clean IDs are untouched.
 func TestMigrateNormalizeSlackUserIDs(t *testing.T) {
-	dbPath := t.TempDir() + "/normalize.db"
-	db := openSilent(t, dbPath)
+	db := dbtest.Open(t)
 	if err := db.AutoMigrate(&ReviewerAvailability{}); err != nil {
 		t.Fatalf("auto migrate: %v", err)
 	}
@@ -121,8 +105,7 @@ func TestMigrateNormalizeSlackUserIDs(t *testing.T) {
 // a clean-ID record for the same period, normalizing the legacy "ID|name" row
 // drops it instead of creating a duplicate (user, period) pair.
 func TestMigrateNormalizeSlackUserIDs_Dedup(t *testing.T) {
-	dbPath := t.TempDir() + "/dedup.db"
-	db := openSilent(t, dbPath)
+	db := dbtest.Open(t)
 	if err := db.AutoMigrate(&ReviewerAvailability{}); err != nil {
 		t.Fatalf("auto migrate: %v", err)
 	}
@@ -162,8 +145,7 @@ func TestMigrateNormalizeSlackUserIDs_Dedup(t *testing.T) {
 // no-op when re-run (it runs on every startup), matching the index migration's
 // idempotency guarantee.
 func TestMigrateNormalizeSlackUserIDs_Idempotent(t *testing.T) {
-	dbPath := t.TempDir() + "/normalize-idem.db"
-	db := openSilent(t, dbPath)
+	db := dbtest.Open(t)
 	if err := db.AutoMigrate(&ReviewerAvailability{}); err != nil {
 		t.Fatalf("auto migrate: %v", err)
 	}
@@ -191,8 +173,7 @@ func TestMigrateNormalizeSlackUserIDs_Idempotent(t *testing.T) {
 // legacy row in a single pass is normalized, and that a value with multiple
 // pipes is truncated at the first pipe.
 func TestMigrateNormalizeSlackUserIDs_MultipleAndMultiPipe(t *testing.T) {
-	dbPath := t.TempDir() + "/normalize-multi.db"
-	db := openSilent(t, dbPath)
+	db := dbtest.Open(t)
 	if err := db.AutoMigrate(&ReviewerAvailability{}); err != nil {
 		t.Fatalf("auto migrate: %v", err)
 	}
@@ -233,8 +214,7 @@ func TestMigrateNormalizeSlackUserIDs_MultipleAndMultiPipe(t *testing.T) {
 // TestMigrateReviewerAvailabilityIndex_Idempotent confirms the migration is a
 // no-op on a fresh DB (already non-unique) and safe to run repeatedly.
 func TestMigrateReviewerAvailabilityIndex_Idempotent(t *testing.T) {
-	dbPath := t.TempDir() + "/fresh.db"
-	db := openSilent(t, dbPath)
+	db := dbtest.Open(t)
 	if err := db.AutoMigrate(&ReviewerAvailability{}); err != nil {
 		t.Fatalf("auto migrate: %v", err)
 	}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// ReviewerRotation persists the round-robin cursor for a channel/label so the
+// rotation picks up where it left off after a restart.
+type ReviewerRotation struct {
+	ID             string `gorm:"primaryKey"`
+	TeamID         string `gorm:"index:idx_team_rotation_channel_label,unique:true"` // Slack installation the channel belongs to (see SlackInstallation)
+	SlackChannelID string `gorm:"index:idx_team_rotation_channel_label,unique:true"` // Composite unique index on team, channel ID and label name
+	LabelName      string `gorm:"index:idx_team_rotation_channel_label,unique:true"`
+	LastReviewerID string // Slack user ID of the most recently picked reviewer
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
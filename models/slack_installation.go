@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// SlackInstallation is the bot token of a workspace the app was installed to
+// through Slack OAuth. An Enterprise Grid org-wide install is stored once
+// under the enterprise ID, which then serves every workspace of the org.
+type SlackInstallation struct {
+	TeamID              string `gorm:"primaryKey"` // Workspace ID (T...), or enterprise ID (E...) for an org-wide install
+	TeamName            string
+	EnterpriseID        string
+	IsEnterpriseInstall bool
+	BotUserID           string
+	BotToken            string // xoxb- token used for every Slack call of the team
+	Scope               string // Granted bot scopes, comma-separated
+	InstalledBy         string // Slack user ID of the installer
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
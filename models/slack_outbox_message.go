@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// SlackOutboxMessage is a Slack Web API call stored before it is sent, so
+// posts that fail temporarily (rate limits, Slack outages, restarts) are
+// retried instead of lost
+type SlackOutboxMessage struct {
+	ID            string `gorm:"primaryKey"`
+	Method        string // Slack Web API method, e.g. chat.postMessage
+	TeamID        string // Slack installation whose token sends the message; empty routes by channel
+	Channel       string `gorm:"index"` // Messages to the same channel are delivered in order
+	Payload       string // JSON request body
+	Status        string `gorm:"index"` // pending, sent, failed
+	Attempts      int
+	NextAttemptAt time.Time `gorm:"index"`
+	LastError     string    // Slack error code of the last failed attempt
+	SentAt        *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
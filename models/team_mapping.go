@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// TeamMapping holds the mapping between a GitHub team and the Slack users to
+// notify when a review is requested from that team
+type TeamMapping struct {
+	ID               string `gorm:"primaryKey"`
+	TeamID           string `gorm:"uniqueIndex:idx_team_github_team_slug;size:191"` // Slack installation the members belong to
+	GithubTeamSlug   string `gorm:"uniqueIndex:idx_team_github_team_slug;size:191"` // GitHub organization and team slug (e.g., "my-org/backend")
+	SlackUserGroupID string // Slack user group (subteam) ID, e.g. S0123ABCD
+	MemberIDs        string // Comma-separated: Slack IDs of the team members
+	PickReviewer     bool   // Assign one member as reviewer instead of mentioning the whole team
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
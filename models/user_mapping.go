@@ -9,7 +9,8 @@ import (
 // UserMapping holds the mapping between GitHub username and Slack User ID
 type UserMapping struct {
 	ID             string `gorm:"primaryKey"`
-	GithubUsername string `gorm:"uniqueIndex"` // GitHub username
+	TeamID         string `gorm:"uniqueIndex:idx_team_github_username;size:191"` // Slack installation SlackUserID belongs to
+	GithubUsername string `gorm:"uniqueIndex:idx_team_github_username;size:191"` // GitHub username
 	SlackUserID    string // Slack user ID
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
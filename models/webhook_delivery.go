@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// WebhookDelivery is a GitHub webhook delivery, stored under its
+// X-GitHub-Delivery ID so a delivery retried by GitHub or replayed by a proxy
+// is processed only once, and so recent deliveries can be inspected and
+// replayed
+type WebhookDelivery struct {
+	ID          string `gorm:"primaryKey"` // X-GitHub-Delivery header
+	EventType   string // X-GitHub-Event header, e.g. pull_request
+	Action      string
+	Payload     string // JSON request body
+	Status      string `gorm:"index"` // processing, processed, ignored, failed
+	Error       string // why processing failed
+	Attempts    int    // times processed, replays included
+	ProcessedAt *time.Time
+	CreatedAt   time.Time `gorm:"index"`
+	UpdatedAt   time.Time
+}
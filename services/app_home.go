@@ -0,0 +1,239 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"slack-review-notify/i18n"
+	"slack-review-notify/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// homeMaxItems caps each App Home list so the view stays under Slack's
+// 100-block limit.
+const homeMaxItems = 15
+
+// homeAssignedStatuses are the statuses of tasks a reviewer still has to act on.
+var homeAssignedStatuses = []string{"in_review", "paused", "snoozed"}
+
+// homeAuthoredStatuses are the statuses of tasks whose PR still awaits review.
+var homeAuthoredStatuses = []string{"pending", "in_review", "paused", "snoozed", "waiting_business_hours"}
+
+// HomeDashboard is what a user's App Home tab shows.
+type HomeDashboard struct {
+	Assigned []models.ReviewTask           // open tasks the user has not approved yet, oldest first
+	Authored []models.ReviewTask           // open tasks for PRs the user authored, one per PR, oldest first
+	Away     []models.ReviewerAvailability // the user's current and upcoming away periods
+	DMDigest *models.DMDigestSubscription  // the user's DM digest opt-in, nil if never set
+	Lang     string
+}
+
+// LoadHomeDashboard collects the App Home data for a user of the Slack team.
+func LoadHomeDashboard(db *gorm.DB, teamID, userID string) HomeDashboard {
+	var d HomeDashboard
+
+	assigned, err := findAssignedOpenTasks(db, teamID, userID)
+	if err != nil {
+		log.Printf("failed to load assigned tasks for home (user: %s): %v", userID, err)
+	}
+	d.Assigned = assigned
+
+	var authored []models.ReviewTask
+	if err := db.Where("team_id = ? AND status IN ? AND pr_author_slack_id = ?", teamID, homeAuthoredStatuses, userID).
+		Order("created_at").
+		Find(&authored).Error; err != nil {
+		log.Printf("failed to load authored tasks for home (user: %s): %v", userID, err)
+	}
+	seenPRs := make(map[string]bool)
+	for _, task := range authored {
+		if seenPRs[task.PRURL] {
+			continue
+		}
+		seenPRs[task.PRURL] = true
+		d.Authored = append(d.Authored, task)
+	}
+
+	if err := db.Where("slack_user_id = ? AND (away_until IS NULL OR away_until > ?)", userID, time.Now()).
+		Order("away_from").
+		Find(&d.Away).Error; err != nil {
+		log.Printf("failed to load away periods for home (user: %s): %v", userID, err)
+	}
+
+	sub, err := GetDMDigestSubscription(db, teamID, userID)
+	if err != nil {
+		log.Printf("failed to load dm digest subscription for home (user: %s): %v", userID, err)
+	}
+	d.DMDigest = sub
+
+	// The tab follows the language of the channels the user's reviews come from
+	d.Lang = "ja"
+	for _, tasks := range [][]models.ReviewTask{d.Assigned, d.Authored} {
+		if len(tasks) > 0 && tasks[0].Language != "" {
+			d.Lang = tasks[0].Language
+			break
+		}
+	}
+
+	return d
+}
+
+// findAssignedOpenTasks returns the open tasks, oldest first, on which the
+// user is a reviewer who has not approved yet.
+func findAssignedOpenTasks(db *gorm.DB, teamID, userID string) ([]models.ReviewTask, error) {
+	var candidates []models.ReviewTask
+	if err := db.Where("team_id = ? AND status IN ? AND (reviewers LIKE ? OR reviewer = ?)", teamID, homeAssignedStatuses, "%"+userID+"%", userID).
+		Order("created_at").
+		Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	var tasks []models.ReviewTask
+	for _, task := range candidates {
+		for _, id := range GetPendingReviewers(task) {
+			if id == userID {
+				tasks = append(tasks, task)
+				break
+			}
+		}
+	}
+	return tasks, nil
+}
+
+// BuildHomeView returns the Block Kit view for a user's App Home tab. Each
+// assigned review gets the same pause and change-reviewer controls as the
+// channel message, so the existing action handlers serve both.
+func BuildHomeView(d HomeDashboard, userID string, now time.Time) map[string]interface{} {
+	t := i18n.L(d.Lang)
+
+	blocks := []map[string]interface{}{
+		{
+			"type": "header",
+			"text": map[string]interface{}{"type": "plain_text", "text": t("home.title")},
+		},
+	}
+	context := func(text string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":     "context",
+			"elements": []map[string]interface{}{{"type": "mrkdwn", "text": text}},
+		}
+	}
+	more := func(total int) {
+		if total > homeMaxItems {
+			blocks = append(blocks, context(t("home.more", total-homeMaxItems)))
+		}
+	}
+
+	blocks = append(blocks, NewSlackBlockBuilder().AddSection(t("home.assigned_header", len(d.Assigned))).Build()...)
+	if len(d.Assigned) == 0 {
+		blocks = append(blocks, context(t("home.assigned_empty")))
+	}
+	for i, task := range d.Assigned {
+		if i == homeMaxItems {
+			break
+		}
+		line := fmt.Sprintf("%s\n`%s#%d` · %s", taskLink(task), task.Repo, task.PRNumber, t("home.waiting", FormatWaitingDuration(now.Sub(task.CreatedAt), d.Lang)))
+		if task.Status == "paused" || (task.ReminderPausedUntil != nil && task.ReminderPausedUntil.After(now)) {
+			line += " · " + t("home.paused")
+		}
+		blocks = append(blocks, NewSlackBlockBuilder().
+			AddSection(line).
+			AddActions(
+				CreateAllOptionsPauseReminderSelect(task.ID, "pause_reminder", t("home.pause_placeholder"), d.Lang),
+				CreateButton(t("button.change_reviewer"), "change_reviewer", task.ID+":"+userID, "danger"),
+			).
+			Build()...)
+	}
+	more(len(d.Assigned))
+
+	blocks = append(blocks, map[string]interface{}{"type": "divider"})
+	blocks = append(blocks, NewSlackBlockBuilder().AddSection(t("home.authored_header", len(d.Authored))).Build()...)
+	if len(d.Authored) == 0 {
+		blocks = append(blocks, context(t("home.authored_empty")))
+	}
+	for i, task := range d.Authored {
+		if i == homeMaxItems {
+			break
+		}
+		reviewers := t("home.not_assigned")
+		if pending := GetPendingReviewers(task); len(pending) > 0 {
+			reviewers = formatReviewerCSVMentions(strings.Join(pending, ","), "")
+		}
+		line := fmt.Sprintf("%s\n`%s#%d` · %s · %s", taskLink(task), task.Repo, task.PRNumber,
+			t("home.waiting", FormatWaitingDuration(now.Sub(task.CreatedAt), d.Lang)), t("home.reviewers", reviewers))
+		blocks = append(blocks, NewSlackBlockBuilder().AddSection(line).Build()...)
+	}
+	more(len(d.Authored))
+
+	blocks = append(blocks, map[string]interface{}{"type": "divider"})
+	blocks = append(blocks, NewSlackBlockBuilder().AddSection(t("home.away_header")).Build()...)
+	if len(d.Away) == 0 {
+		blocks = append(blocks, context(t("home.away_empty")))
+	} else {
+		var lines []string
+		for _, r := range d.Away {
+			status := t("cmd.show_availability.status_away")
+			if r.AwayFrom != nil && r.AwayFrom.After(now) {
+				status = t("cmd.show_availability.status_scheduled")
+			}
+			line := fmt.Sprintf("• [%s] %s", status, FormatAwayDateRange(r.AwayFrom, r.AwayUntil, t))
+			if r.Reason != "" {
+				line += t("common.reason_paren", r.Reason)
+			}
+			lines = append(lines, line)
+		}
+		blocks = append(blocks, NewSlackBlockBuilder().AddSection(strings.Join(lines, "\n")).Build()...)
+	}
+
+	blocks = append(blocks, map[string]interface{}{"type": "divider"})
+	if d.DMDigest != nil && d.DMDigest.Enabled {
+		blocks = append(blocks, NewSlackBlockBuilder().
+			AddSection(t("home.dm_digest_on", d.DMDigest.DigestTime, d.DMDigest.Timezone)).
+			AddActions(CreateButton(t("home.dm_digest_turn_off"), ToggleDMDigestActionID, "off", "")).
+			Build()...)
+	} else {
+		blocks = append(blocks, NewSlackBlockBuilder().
+			AddSection(t("home.dm_digest_off")).
+			AddActions(CreateButton(t("home.dm_digest_turn_on"), ToggleDMDigestActionID, "on", "primary")).
+			Build()...)
+	}
+
+	return map[string]interface{}{
+		"type":   "home",
+		"blocks": blocks,
+	}
+}
+
+// PublishHomeView renders and publishes the App Home tab for a user of the
+// Slack team.
+func PublishHomeView(db *gorm.DB, slack SlackClient, teamID, userID string) error {
+	d := LoadHomeDashboard(db, teamID, userID)
+	return slack.PublishView(userID, BuildHomeView(d, userID, time.Now()))
+}
+
+// FormatWaitingDuration renders how long a review has been waiting, rounded
+// down to minutes below an hour, hours below a day, and days plus hours above.
+func FormatWaitingDuration(d time.Duration, lang string) string {
+	t := i18n.L(lang)
+	if d < 0 {
+		d = 0
+	}
+	switch {
+	case d < time.Hour:
+		return t("home.duration.minutes", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return t("home.duration.hours", int(d/time.Hour))
+	default:
+		return t("home.duration.days", int(d/(24*time.Hour)), int(d%(24*time.Hour)/time.Hour))
+	}
+}
+
+// taskLink renders the PR title linked to the PR.
+func taskLink(task models.ReviewTask) string {
+	title := task.Title
+	if title == "" {
+		title = task.PRURL
+	}
+	return fmt.Sprintf("<%s|%s>", task.PRURL, title)
+}
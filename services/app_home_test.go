@@ -0,0 +1,104 @@
+package services
+
+import (
+	"encoding/json"
+	"slack-review-notify/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadHomeDashboard(t *testing.T) {
+	db := setupTestDB(t)
+
+	now := time.Now()
+	past := now.Add(-48 * time.Hour)
+	future := now.Add(48 * time.Hour)
+
+	tasks := []models.ReviewTask{
+		{ID: "assigned-new", PRURL: "https://github.com/o/r/pull/2", Reviewers: "UME,U2", Status: "in_review", Language: "en", CreatedAt: now.Add(-1 * time.Hour)},
+		{ID: "assigned-old", PRURL: "https://github.com/o/r/pull/1", Reviewers: "U2,UME", Status: "paused", Language: "en", CreatedAt: now.Add(-5 * time.Hour)},
+		{ID: "legacy-single", PRURL: "https://github.com/o/r/pull/3", Reviewer: "UME", Status: "snoozed", CreatedAt: now},
+		// already approved by the user
+		{ID: "approved", PRURL: "https://github.com/o/r/pull/4", Reviewers: "UME", ApprovedBy: "UME", Status: "in_review", CreatedAt: now},
+		// a different user whose ID contains UME
+		{ID: "other-user", PRURL: "https://github.com/o/r/pull/5", Reviewers: "UMEX", Status: "in_review", CreatedAt: now},
+		{ID: "done", PRURL: "https://github.com/o/r/pull/6", Reviewers: "UME", Status: "done", CreatedAt: now},
+		// a review of another workspace
+		{ID: "other-team", TeamID: "T2", PRURL: "https://github.com/o/r/pull/9", Reviewers: "UME", Status: "in_review", CreatedAt: now},
+		// authored: the same PR in two channels is listed once
+		{ID: "authored-a", PRURL: "https://github.com/o/r/pull/7", PRAuthorSlackID: "UME", Status: "waiting_business_hours", CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: "authored-b", PRURL: "https://github.com/o/r/pull/7", PRAuthorSlackID: "UME", Status: "in_review", CreatedAt: now.Add(-1 * time.Hour)},
+		{ID: "authored-done", PRURL: "https://github.com/o/r/pull/8", PRAuthorSlackID: "UME", Status: "completed", CreatedAt: now},
+	}
+	for _, task := range tasks {
+		db.Create(&task)
+	}
+	db.Create(&models.ReviewerAvailability{ID: "away-current", SlackUserID: "UME", AwayUntil: &future, Reason: "vacation"})
+	db.Create(&models.ReviewerAvailability{ID: "away-expired", SlackUserID: "UME", AwayUntil: &past})
+	db.Create(&models.ReviewerAvailability{ID: "away-other", SlackUserID: "U2"})
+
+	d := LoadHomeDashboard(db, "", "UME")
+
+	var assigned []string
+	for _, task := range d.Assigned {
+		assigned = append(assigned, task.ID)
+	}
+	assert.Equal(t, []string{"assigned-old", "assigned-new", "legacy-single"}, assigned)
+
+	assert.Len(t, d.Authored, 1)
+	assert.Equal(t, "authored-a", d.Authored[0].ID)
+
+	assert.Len(t, d.Away, 1)
+	assert.Equal(t, "away-current", d.Away[0].ID)
+
+	assert.Equal(t, "en", d.Lang)
+}
+
+func TestBuildHomeView(t *testing.T) {
+	now := time.Now()
+	d := HomeDashboard{
+		Assigned: []models.ReviewTask{
+			{ID: "task-1", PRURL: "https://github.com/o/r/pull/1", Title: "Fix bug", Repo: "o/r", PRNumber: 1, Status: "paused", CreatedAt: now.Add(-26 * time.Hour)},
+		},
+		Authored: []models.ReviewTask{
+			{ID: "task-2", PRURL: "https://github.com/o/r/pull/2", Title: "Add feature", Repo: "o/r", PRNumber: 2, Reviewers: "U1,U2", ApprovedBy: "U1", Status: "in_review", CreatedAt: now.Add(-30 * time.Minute)},
+		},
+		Lang: "en",
+	}
+
+	view := BuildHomeView(d, "UME", now)
+	assert.Equal(t, "home", view["type"])
+
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	assert.NoError(t, enc.Encode(view))
+	body := buf.String()
+
+	assert.Contains(t, body, "<https://github.com/o/r/pull/1|Fix bug>")
+	assert.Contains(t, body, "waiting 1d 2h")
+	assert.Contains(t, body, "reminders paused")
+	// the pause and change-reviewer actions reuse the channel message's handlers
+	assert.Contains(t, body, `"action_id":"pause_reminder"`)
+	assert.Contains(t, body, `"value":"task-1:1h"`)
+	assert.Contains(t, body, `"action_id":"change_reviewer"`)
+	assert.Contains(t, body, `"value":"task-1:UME"`)
+
+	assert.Contains(t, body, "waiting 30 min")
+	assert.Contains(t, body, "Reviewers: <@U2>")
+	assert.Contains(t, body, "No away periods scheduled.")
+
+	// without a subscription the DM digest toggle offers to turn it on
+	assert.Contains(t, body, `"action_id":"`+ToggleDMDigestActionID+`"`)
+	assert.Contains(t, body, `"value":"on"`)
+}
+
+func TestFormatWaitingDuration(t *testing.T) {
+	assert.Equal(t, "0分", FormatWaitingDuration(-time.Minute, "ja"))
+	assert.Equal(t, "45 min", FormatWaitingDuration(45*time.Minute, "en"))
+	assert.Equal(t, "3時間", FormatWaitingDuration(3*time.Hour+59*time.Minute, "ja"))
+	assert.Equal(t, "2d 5h", FormatWaitingDuration(53*time.Hour, "en"))
+}
@@ -313,3 +313,22 @@ func ParseAwayModalSubmission(values map[string]map[string]ViewStateValue, loc *
 	}
 	return form, nil
 }
+
+// FormatAwayDateRange returns a human-readable date range string for away periods.
+func FormatAwayDateRange(awayFrom, awayUntil *time.Time, t func(string, ...interface{}) string) string {
+	isSameDay := awayFrom != nil && awayUntil != nil &&
+		awayFrom.Year() == awayUntil.Year() && awayFrom.YearDay() == awayUntil.YearDay()
+
+	switch {
+	case isSameDay:
+		return t("common.on_date", awayFrom.Format("2006-01-02"))
+	case awayFrom != nil && awayUntil != nil:
+		return t("common.from_until", awayFrom.Format("2006-01-02"), awayUntil.Format("2006-01-02"))
+	case awayFrom != nil:
+		return t("common.from_until", awayFrom.Format("2006-01-02"), t("common.indefinite"))
+	case awayUntil != nil:
+		return t("common.until", awayUntil.Format("2006-01-02"))
+	default:
+		return t("common.indefinite")
+	}
+}
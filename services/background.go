@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"sync"
+)
+
+// backgroundTasks tracks goroutines started with Go so shutdown can wait for
+// them instead of killing them halfway through.
+var backgroundTasks = newTaskTracker()
+
+// taskTracker counts running goroutines. Unlike a sync.WaitGroup, it may be
+// waited on while goroutines are still being started.
+type taskTracker struct {
+	mu      sync.Mutex
+	running int
+	idle    chan struct{} // closed while no goroutine is running
+}
+
+func newTaskTracker() *taskTracker {
+	idle := make(chan struct{})
+	close(idle)
+	return &taskTracker{idle: idle}
+}
+
+func (t *taskTracker) add() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.running == 0 {
+		t.idle = make(chan struct{})
+	}
+	t.running++
+}
+
+func (t *taskTracker) done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.running--
+	if t.running == 0 {
+		close(t.idle)
+	}
+}
+
+// idleChan returns a channel that is closed once no goroutine is running.
+func (t *taskTracker) idleChan() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.idle
+}
+
+// Go runs fn in a goroutine that shutdown waits for.
+func Go(fn func()) {
+	backgroundTasks.add()
+	go func() {
+		defer backgroundTasks.done()
+		fn()
+	}()
+}
+
+// WaitForBackgroundTasks blocks until every goroutine started with Go has
+// returned, or returns ctx's error once ctx is done.
+func WaitForBackgroundTasks(ctx context.Context) error {
+	for {
+		select {
+		case <-backgroundTasks.idleChan():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		// Go may have been called again after the last goroutine returned
+		backgroundTasks.mu.Lock()
+		running := backgroundTasks.running
+		backgroundTasks.mu.Unlock()
+		if running == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForBackgroundTasks(t *testing.T) {
+	finished := make(chan struct{})
+	Go(func() {
+		time.Sleep(20 * time.Millisecond)
+		close(finished)
+	})
+
+	assert.NoError(t, WaitForBackgroundTasks(context.Background()))
+	select {
+	case <-finished:
+	default:
+		t.Fatal("returned before the background task finished")
+	}
+}
+
+func TestWaitForBackgroundTasks_Deadline(t *testing.T) {
+	release := make(chan struct{})
+	Go(func() { <-release })
+	defer func() {
+		close(release)
+		assert.NoError(t, WaitForBackgroundTasks(context.Background()))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, WaitForBackgroundTasks(ctx), context.DeadlineExceeded)
+}
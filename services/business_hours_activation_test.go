@@ -1,12 +1,15 @@
 package services
 
 import (
-	"os"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"slack-review-notify/models"
 	"testing"
 	"time"
 
-	"github.com/h2non/gock"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,10 +19,6 @@ import (
 // "auto-assigned" message. The two are now merged, so activation must send exactly one
 // chat.postMessage that mentions the reviewer and carries the change/pause controls.
 func TestActivateBusinessHoursTask_SendsSingleMergedReviewerMessage(t *testing.T) {
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() { _ = os.Setenv("SLACK_BOT_TOKEN", originalToken) }()
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
 	db := setupTestDB(t)
 
 	config := models.ChannelConfig{
@@ -51,22 +50,18 @@ func TestActivateBusinessHoursTask_SendsSingleMergedReviewerMessage(t *testing.T
 	}
 	db.Create(&task)
 
-	defer gock.OffAll()
-	gock.CleanUnmatchedRequest()
+	slack := NewFakeSlackClient()
+
+	err := activateBusinessHoursTask(db, slack, task, config, "needs-review")
+	assert.NoError(t, err)
 
 	// The single morning message must mention the reviewer and carry the change
 	// reviewer button and the reminder pause select.
-	// json.Marshal escapes "<@UREV1>" to "<@UREV1>" in the body.
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		BodyString(`おはよう[\s\S]*\\u003c@UREV1\\u003e[\s\S]*change_reviewer[\s\S]*pause_reminder_initial`).
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
-
-	err := activateBusinessHoursTask(db, task, config, "needs-review")
-	assert.NoError(t, err)
-	assert.True(t, gock.IsDone(), "expected one merged morning message with reviewer mention and controls")
-	assert.False(t, gock.HasUnmatchedRequest(), "expected no second reviewer-assigned message")
+	msgs := slack.Messages()
+	if assert.Len(t, msgs, 1, "expected no second reviewer-assigned message") {
+		assert.Equal(t, "1234.5678", msgs[0].ThreadTS)
+		assert.Regexp(t, `おはよう[\s\S]*<@UREV1>[\s\S]*change_reviewer[\s\S]*pause_reminder_initial`, messageJSON(t, msgs[0]))
+	}
 
 	var updated models.ReviewTask
 	db.First(&updated, "id = ?", "task-activation")
@@ -77,21 +72,6 @@ func TestActivateBusinessHoursTask_SendsSingleMergedReviewerMessage(t *testing.T
 // The merged morning message mentions every assigned reviewer (not just the first) and
 // includes the change reviewer button and the reminder pause select.
 func TestPostBusinessHoursNotificationToThread_MentionsAllReviewersWithControls(t *testing.T) {
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() { _ = os.Setenv("SLACK_BOT_TOKEN", originalToken) }()
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	defer gock.OffAll()
-	gock.CleanUnmatchedRequest()
-
-	// Reviewers are serialized in list order, so the mentions and controls appear in a
-	// deterministic sequence in the request body.
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		BodyString(`\\u003c@UREV1\\u003e \\u003c@UREV2\\u003e[\s\S]*change_reviewer[\s\S]*pause_reminder_initial`).
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
-
 	task := models.ReviewTask{
 		ID:           "task-morning-multi",
 		SlackTS:      "1234.5678",
@@ -102,8 +82,110 @@ func TestPostBusinessHoursNotificationToThread_MentionsAllReviewersWithControls(
 		Language:     "ja",
 	}
 
-	err := PostBusinessHoursNotificationToThread(task, "UDEFAULT")
+	slack := NewFakeSlackClient()
+	err := PostBusinessHoursNotificationToThread(slack, task, "UDEFAULT")
 	assert.NoError(t, err)
-	assert.True(t, gock.IsDone(), "expected a single message mentioning all reviewers with controls")
-	assert.False(t, gock.HasUnmatchedRequest())
+
+	// Reviewers are rendered in list order, followed by the controls
+	msgs := slack.Messages()
+	if assert.Len(t, msgs, 1, "expected a single message mentioning all reviewers with controls") {
+		assert.Regexp(t, `<@UREV1> <@UREV2>[\s\S]*change_reviewer[\s\S]*pause_reminder_initial`, messageJSON(t, msgs[0]))
+	}
+}
+
+// Reviewers requested on GitHub while the task waited for business hours are kept
+// at activation, and only the remaining slots are filled from the reviewer list.
+func TestActivateBusinessHoursTask_TopsUpRequestedReviewers(t *testing.T) {
+	db := setupTestDB(t)
+
+	config := models.ChannelConfig{
+		ID:                "cfg-activation-requested",
+		SlackChannelID:    "C12345",
+		LabelName:         "needs-review",
+		DefaultMentionID:  "U_DEFAULT",
+		ReviewerList:      "UREQ,UREV1",
+		RequiredApprovals: 2,
+		IsActive:          true,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+	db.Create(&config)
+
+	task := models.ReviewTask{
+		ID:           "task-activation-requested",
+		PRURL:        "https://github.com/owner/repo/pull/2",
+		Repo:         "owner/repo",
+		PRNumber:     2,
+		Title:        "Test PR",
+		SlackTS:      "1234.5678",
+		SlackChannel: "C12345",
+		Reviewers:    "UREQ", // requested on GitHub when the PR was labeled
+		Status:       "waiting_business_hours",
+		LabelName:    "needs-review",
+		Language:     "ja",
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	db.Create(&task)
+
+	err := activateBusinessHoursTask(db, NewFakeSlackClient(), task, config, "needs-review")
+	assert.NoError(t, err)
+
+	var updated models.ReviewTask
+	db.First(&updated, "id = ?", "task-activation-requested")
+	assert.Equal(t, "in_review", updated.Status)
+	assert.Equal(t, "UREQ", updated.Reviewer)
+	assert.Equal(t, "UREQ,UREV1", updated.Reviewers)
+}
+
+// The CODEOWNERS preferred at activation are read from the PR's base branch.
+func TestActivateBusinessHoursTask_CodeownersFromBaseBranch(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.UserMapping{ID: "m-owner", GithubUsername: "alice", SlackUserID: "UALICE"})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/owner/repo/pulls/3", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"number": 3, "state": "open", "base": {"ref": "release"}}`)
+	})
+	mux.HandleFunc("GET /repos/owner/repo/pulls/3/files", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `[{"filename": "main.go"}]`)
+	})
+	mux.HandleFunc("GET /repos/owner/repo/contents/.github/CODEOWNERS", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "release", r.URL.Query().Get("ref"))
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"type":     "file",
+			"encoding": "base64",
+			"content":  base64.StdEncoding.EncodeToString([]byte("* @alice\n")),
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	t.Setenv("GITHUB_API_BASE_URL", server.URL)
+	t.Setenv("GITHUB_TOKEN", "test-github-token")
+
+	config := models.ChannelConfig{
+		ID:               "cfg-activation-codeowners",
+		SlackChannelID:   "C12345",
+		LabelName:        "needs-review",
+		DefaultMentionID: "U_DEFAULT",
+		ReviewerList:     "UREV1",
+		IsActive:         true,
+	}
+	db.Create(&config)
+	task := models.ReviewTask{
+		ID:           "task-activation-codeowners",
+		Repo:         "owner/repo",
+		PRNumber:     3,
+		SlackTS:      "1234.5678",
+		SlackChannel: "C12345",
+		Status:       "waiting_business_hours",
+		LabelName:    "needs-review",
+	}
+	db.Create(&task)
+
+	assert.NoError(t, activateBusinessHoursTask(db, NewFakeSlackClient(), task, config, "needs-review"))
+
+	var updated models.ReviewTask
+	db.First(&updated, "id = ?", task.ID)
+	assert.Equal(t, "UALICE", updated.Reviewers)
 }
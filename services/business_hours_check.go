@@ -30,14 +30,7 @@ func IsWithinBusinessHours(config *models.ChannelConfig, currentTime time.Time)
 
 	localTime := currentTime.In(loc)
 
-	// Check for weekends
-	weekday := localTime.Weekday()
-	if weekday == time.Saturday || weekday == time.Sunday {
-		return false
-	}
-
-	// Check for Japanese holidays if the timezone is Asia/Tokyo
-	if timezone == "Asia/Tokyo" && isJapaneseHoliday(localTime) {
+	if !isBusinessDay(localTime, timezone) {
 		return false
 	}
 
@@ -65,6 +58,16 @@ func IsWithinBusinessHours(config *models.ChannelConfig, currentTime time.Time)
 	return currentMinutes >= startMinutes || currentMinutes < endMinutes
 }
 
+// isBusinessDay reports whether localTime falls on a weekday that is not a
+// Japanese public holiday (holidays are only checked for Asia/Tokyo)
+func isBusinessDay(localTime time.Time, timezone string) bool {
+	weekday := localTime.Weekday()
+	if weekday == time.Saturday || weekday == time.Sunday {
+		return false
+	}
+	return timezone != "Asia/Tokyo" || !isJapaneseHoliday(localTime)
+}
+
 // parseBusinessHoursTime parses a time string (HH:MM) into hours and minutes
 func parseBusinessHoursTime(timeStr string) (int, int, error) {
 	if timeStr == "" {
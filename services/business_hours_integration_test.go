@@ -107,7 +107,7 @@ func TestBusinessHoursTaskFlow(t *testing.T) {
 	db.Create(&task)
 
 	// Test reviewer selection
-	selectedReviewers := SelectRandomReviewers(db, "C12345", "needs-review", 1, nil)
+	selectedReviewers := SelectRandomReviewers(db, "", "C12345", "needs-review", 1, nil)
 	assert.Len(t, selectedReviewers, 1, "One reviewer should be selected")
 	assert.Contains(t, []string{"U67890", "U11111", "U22222"}, selectedReviewers[0], "Reviewer should be correctly selected from the list")
 
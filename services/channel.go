@@ -9,12 +9,12 @@ import (
 )
 
 // CleanupArchivedChannels deactivates configurations for archived channels
-func CleanupArchivedChannels(db *gorm.DB) {
+func CleanupArchivedChannels(db *gorm.DB, slack SlackClient) {
 	var configs []models.ChannelConfig
 	db.Where("is_active = ?", true).Find(&configs)
 
 	for _, config := range configs {
-		isArchived, err := IsChannelArchived(config.SlackChannelID)
+		isArchived, err := IsChannelArchived(slack, config.SlackChannelID)
 		if err != nil {
 			log.Printf("channel status check error (channel: %s): %v", config.SlackChannelID, err)
 			continue
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"log"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// CodeownersRule is a single CODEOWNERS line: a path pattern and its owners
+// (@user, @org/team or an email address).
+type CodeownersRule struct {
+	Pattern string
+	Owners  []string
+	re      *regexp.Regexp
+}
+
+// ParseCodeowners parses the contents of a CODEOWNERS file. Blank lines,
+// comments and lines with an invalid pattern are skipped.
+func ParseCodeowners(content string) []CodeownersRule {
+	var rules []CodeownersRule
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		re, err := codeownersPatternRegexp(fields[0])
+		if err != nil {
+			log.Printf("skipping invalid CODEOWNERS pattern %q: %v", fields[0], err)
+			continue
+		}
+		rules = append(rules, CodeownersRule{Pattern: fields[0], Owners: fields[1:], re: re})
+	}
+	return rules
+}
+
+// CodeownersFor returns the owners of path. As on GitHub, the last matching
+// rule wins; a matching rule without owners means the path has no owner.
+func CodeownersFor(rules []CodeownersRule, path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].re.MatchString(path) {
+			return rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// codeownersPatternRegexp converts a gitignore-style CODEOWNERS pattern into
+// a regular expression matched against repository-relative paths.
+//
+// A pattern without a slash (other than a trailing one) matches at any depth;
+// a leading or inner slash anchors it to the repository root. A pattern
+// matching a directory also matches everything below it, except that a
+// trailing "/*" only matches the directory's direct children.
+func codeownersPatternRegexp(pattern string) (*regexp.Regexp, error) {
+	p := strings.TrimPrefix(pattern, "/")
+	anchored := p != pattern
+	dirOnly := strings.HasSuffix(p, "/")
+	p = strings.TrimSuffix(p, "/")
+	if strings.Contains(p, "/") {
+		anchored = true
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; c {
+		case '*':
+			if i+1 < len(p) && p[i+1] == '*' {
+				if i+2 < len(p) && p[i+2] == '/' {
+					// "**/" matches zero or more directories.
+					b.WriteString("(?:.*/)?")
+					i += 2
+				} else {
+					b.WriteString(".*")
+					i++
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	switch {
+	case dirOnly:
+		b.WriteString("/.*")
+	case strings.HasSuffix(p, "/*"):
+		// direct children only
+	default:
+		b.WriteString("(?:/.*)?")
+	}
+	b.WriteString("$")
+
+	return regexp.Compile(b.String())
+}
+
+// pullRequestBaseRef returns the branch the pull request merges into, whose
+// CODEOWNERS file applies to it, or "" (the default branch) when it cannot
+// be fetched.
+func pullRequestBaseRef(db *gorm.DB, repoFullName string, prNumber int) string {
+	if !IsGitHubAPIEnabled() {
+		return ""
+	}
+	pr, err := GetPullRequest(db, repoFullName, prNumber)
+	if err != nil {
+		log.Printf("failed to get base branch of %s#%d: %v", repoFullName, prNumber, err)
+		return ""
+	}
+	return pr.GetBase().GetRef()
+}
+
+// GetCodeownerSlackIDs returns the Slack user IDs of the CODEOWNERS of the
+// files changed by a pull request, resolved via the team's UserMapping. Owners without a
+// mapping, teams and email owners are skipped. It returns nil when the GitHub
+// API is not configured or any lookup fails, so callers fall back to the
+// channel's reviewer list.
+func GetCodeownerSlackIDs(db *gorm.DB, teamID, repoFullName string, prNumber int, ref string) []string {
+	if !IsGitHubAPIEnabled() {
+		return nil
+	}
+
+	files, err := GetPullRequestFiles(db, repoFullName, prNumber)
+	if err != nil {
+		log.Printf("failed to get changed files for %s#%d: %v", repoFullName, prNumber, err)
+		return nil
+	}
+	content, err := GetCodeownersFile(db, repoFullName, ref)
+	if err != nil {
+		log.Printf("failed to get CODEOWNERS for %s: %v", repoFullName, err)
+		return nil
+	}
+	rules := ParseCodeowners(content)
+	if len(rules) == 0 {
+		return nil
+	}
+
+	seenOwners := make(map[string]bool)
+	seenIDs := make(map[string]bool)
+	var slackIDs []string
+	for _, file := range files {
+		for _, owner := range CodeownersFor(rules, file) {
+			if seenOwners[owner] {
+				continue
+			}
+			seenOwners[owner] = true
+
+			login, ok := strings.CutPrefix(owner, "@")
+			if !ok || strings.Contains(login, "/") {
+				continue
+			}
+			if id := GetSlackUserIDFromGitHub(db, teamID, login); id != "" && !seenIDs[id] {
+				seenIDs[id] = true
+				slackIDs = append(slackIDs, id)
+			}
+		}
+	}
+
+	return slackIDs
+}
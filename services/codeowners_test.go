@@ -0,0 +1,150 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slack-review-notify/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeownersFor(t *testing.T) {
+	rules := ParseCodeowners(`
+# Default owners
+*                 @default-owner
+
+*.js              @js-owner   # inline comment
+/build/logs/      @build-owner
+/build/           @build-owner
+docs/*            @docs-owner
+apps/             @apps-owner
+**/logs           @logs-owner
+/scripts/         @scripts-owner
+/scripts/vendored
+`)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"README.md", []string{"@default-owner"}},
+		{"src/app.js", []string{"@js-owner"}},
+		// The later **/logs rule wins over /build/logs/.
+		{"build/logs/out.txt", []string{"@logs-owner"}},
+		{"build/app.bin", []string{"@build-owner"}},
+		{"docs/getting-started.md", []string{"@docs-owner"}},
+		// docs/* only covers direct children of docs/
+		{"docs/build-app/troubleshooting.md", []string{"@default-owner"}},
+		{"apps/web/main.go", []string{"@apps-owner"}},
+		{"pkg/apps/web/main.go", []string{"@apps-owner"}},
+		{"deep/nested/logs/today.txt", []string{"@logs-owner"}},
+		// A later rule without owners leaves the path unowned.
+		{"scripts/vendored/tool.sh", []string{}},
+		{"scripts/run.sh", []string{"@scripts-owner"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got := CodeownersFor(rules, tt.path)
+			if len(tt.want) == 0 {
+				assert.Empty(t, got)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCodeownersFor_NoRules(t *testing.T) {
+	assert.Nil(t, CodeownersFor(ParseCodeowners(""), "main.go"))
+}
+
+// GetCodeownerSlackIDs talks to the API at GITHUB_API_BASE_URL, so tests can
+// point it at a local stub.
+func TestGetCodeownerSlackIDs_UsesConfigurableBaseURL(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.UserMapping{ID: "m1", GithubUsername: "alice", SlackUserID: "UALICE"})
+	db.Create(&models.UserMapping{ID: "m2", GithubUsername: "bob", SlackUserID: "UBOB"})
+
+	codeowners := "*.go @alice @org/backend\n/docs/ @bob @carol\n"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/pulls/7/files", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-github-token", r.Header.Get("Authorization"))
+		_ = json.NewEncoder(w).Encode([]map[string]any{
+			{"filename": "main.go"},
+			{"filename": "docs/guide.md"},
+			{"filename": "Makefile"},
+		})
+	})
+	mux.HandleFunc("/repos/owner/repo/contents/.github/CODEOWNERS", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/repos/owner/repo/contents/CODEOWNERS", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "main", r.URL.Query().Get("ref"))
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"type":     "file",
+			"encoding": "base64",
+			"content":  base64.StdEncoding.EncodeToString([]byte(codeowners)),
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Setenv("GITHUB_API_BASE_URL", server.URL)
+	t.Setenv("GITHUB_TOKEN", "test-github-token")
+
+	// @org/backend is a team and @carol has no mapping, so both are skipped.
+	got := GetCodeownerSlackIDs(db, "", "owner/repo", 7, "main")
+	assert.Equal(t, []string{"UALICE", "UBOB"}, got)
+}
+
+func TestGetCodeownerSlackIDs_DisabledWithoutToken(t *testing.T) {
+	db := setupTestDB(t)
+	t.Setenv("GITHUB_TOKEN", "")
+	assert.Nil(t, GetCodeownerSlackIDs(db, "", "owner/repo", 1, ""))
+}
+
+// Code owners are picked before the channel's reviewer list, even when they
+// are not in it, and the list tops up the remaining slots.
+func TestSelectReviewers_PrefersCodeowners(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.ChannelConfig{
+		ID:               "codeowners-id",
+		SlackChannelID:   "C_OWNERS",
+		LabelName:        "needs-review",
+		DefaultMentionID: "UDEFAULT",
+		ReviewerList:     "U1,U2,U3",
+		IsActive:         true,
+	})
+
+	for i := 0; i < 20; i++ {
+		got := SelectReviewers(db, "", "C_OWNERS", "needs-review", 1, nil, []string{"UOWNER"})
+		assert.Equal(t, []string{"UOWNER"}, got)
+	}
+
+	got := SelectReviewers(db, "", "C_OWNERS", "needs-review", 2, nil, []string{"UOWNER"})
+	assert.Len(t, got, 2)
+	assert.Equal(t, "UOWNER", got[0])
+	assert.Contains(t, []string{"U1", "U2", "U3"}, got[1])
+}
+
+// The PR author is excluded from the code owners too; with no owner left the
+// channel's reviewer list is used as before.
+func TestSelectReviewers_FallsBackWhenNoCodeownerRemains(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.ChannelConfig{
+		ID:               "codeowners-fallback-id",
+		SlackChannelID:   "C_OWNERS_FB",
+		LabelName:        "needs-review",
+		DefaultMentionID: "UDEFAULT",
+		ReviewerList:     "U1",
+		IsActive:         true,
+	})
+
+	got := SelectReviewers(db, "", "C_OWNERS_FB", "needs-review", 1, []string{"UAUTHOR"}, []string{"UAUTHOR"})
+	assert.Equal(t, []string{"U1"}, got)
+}
@@ -9,11 +9,11 @@ import (
 	"gorm.io/gorm"
 )
 
-// GetChannelConfig retrieves the channel configuration
-func GetChannelConfig(db *gorm.DB, channelID string, labelName string) (*models.ChannelConfig, error) {
+// GetChannelConfig retrieves the team's channel configuration
+func GetChannelConfig(db *gorm.DB, teamID, channelID string, labelName string) (*models.ChannelConfig, error) {
 	var config models.ChannelConfig
 
-	err := db.Where("slack_channel_id = ? AND label_name = ? AND is_active = ?", channelID, labelName, true).First(&config).Error
+	err := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ? AND is_active = ?", teamID, channelID, labelName, true).First(&config).Error
 	if err != nil {
 		return nil, err
 	}
@@ -22,9 +22,9 @@ func GetChannelConfig(db *gorm.DB, channelID string, labelName string) (*models.
 }
 
 // HasChannelConfig checks whether a channel configuration exists
-func HasChannelConfig(db *gorm.DB, channelID string, labelName string) bool {
+func HasChannelConfig(db *gorm.DB, teamID, channelID string, labelName string) bool {
 	var count int64
-	db.Model(&models.ChannelConfig{}).Where("slack_channel_id = ? AND label_name = ? AND is_active = ?", channelID, labelName, true).Count(&count)
+	db.Model(&models.ChannelConfig{}).Where("team_id = ? AND slack_channel_id = ? AND label_name = ? AND is_active = ?", teamID, channelID, labelName, true).Count(&count)
 	return count > 0
 }
 
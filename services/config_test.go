@@ -1,23 +1,20 @@
 package services
 
 import (
+	"slack-review-notify/database/dbtest"
 	"slack-review-notify/models"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 func setupTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err != nil {
-		t.Fatalf("fail to open test db: %v", err)
-	}
+	db := dbtest.Open(t)
 
 	// Run migrations
-	if err := db.AutoMigrate(&models.ChannelConfig{}, &models.ReviewTask{}, &models.UserMapping{}, &models.ReviewerAvailability{}); err != nil {
+	if err := models.Migrate(db); err != nil {
 		t.Fatalf("fail to migrate test db: %v", err)
 	}
 
@@ -42,7 +39,7 @@ func TestGetChannelConfig(t *testing.T) {
 	db.Create(&testConfig)
 
 	// Execute test
-	config, err := GetChannelConfig(db, "C12345", "needs-review")
+	config, err := GetChannelConfig(db, "", "C12345", "needs-review")
 
 	// Assertions
 	assert.NoError(t, err)
@@ -53,7 +50,7 @@ func TestGetChannelConfig(t *testing.T) {
 	assert.True(t, config.IsActive)
 
 	// Test with a non-existent channel ID
-	_, err = GetChannelConfig(db, "nonexistent", "needs-review")
+	_, err = GetChannelConfig(db, "", "nonexistent", "needs-review")
 	assert.Error(t, err)
 }
 
@@ -75,9 +72,9 @@ func TestHasChannelConfig(t *testing.T) {
 	db.Create(&testConfig)
 
 	// Execute test and assertions
-	assert.True(t, HasChannelConfig(db, "C12345", "needs-review"))
-	assert.False(t, HasChannelConfig(db, "nonexistent", "needs-review"))
-	assert.False(t, HasChannelConfig(db, "C12345", "other-label"))
+	assert.True(t, HasChannelConfig(db, "", "C12345", "needs-review"))
+	assert.False(t, HasChannelConfig(db, "", "nonexistent", "needs-review"))
+	assert.False(t, HasChannelConfig(db, "", "C12345", "other-label"))
 }
 
 func TestIsRepositoryWatched(t *testing.T) {
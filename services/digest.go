@@ -0,0 +1,192 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"slack-review-notify/i18n"
+	"slack-review-notify/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// digestStatuses are the statuses of the open tasks listed in the daily digest.
+var digestStatuses = []string{"pending", "in_review", "snoozed", "paused"}
+
+// digestMaxTasks keeps the digest under Slack's 50-block message limit.
+const digestMaxTasks = 45
+
+// IsDigestDue reports whether the config's daily digest should be posted at
+// now: today's DigestTime has passed in the config's timezone, the digest has
+// not been posted since, and now is within business hours (so weekends,
+// holidays and digest times outside business hours are skipped).
+func IsDigestDue(config *models.ChannelConfig, now time.Time) bool {
+	if config == nil || config.DigestTime == "" {
+		return false
+	}
+	scheduled, err := scheduledTimeToday(now, config.DigestTime, config.Timezone)
+	if err != nil || now.Before(scheduled) {
+		return false
+	}
+	if config.DigestLastSentAt != nil && !config.DigestLastSentAt.Before(scheduled) {
+		return false
+	}
+
+	return IsWithinBusinessHours(config, now)
+}
+
+// scheduledTimeToday returns today's occurrence of the HH:MM time of day in
+// timezone (Asia/Tokyo when empty or unknown), "today" being now's date there.
+func scheduledTimeToday(now time.Time, timeOfDay, timezone string) (time.Time, error) {
+	hour, minute, err := parseBusinessHoursTime(timeOfDay)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if timezone == "" {
+		timezone = "Asia/Tokyo"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc, _ = time.LoadLocation("Asia/Tokyo")
+	}
+
+	localTime := now.In(loc)
+	return time.Date(localTime.Year(), localTime.Month(), localTime.Day(), hour, minute, 0, 0, loc), nil
+}
+
+// CheckDailyDigests posts the daily review digest to every channel with a
+// config whose digest is due. A channel with several due label configs gets
+// a single message covering all of them.
+func CheckDailyDigests(db *gorm.DB, slack SlackClient) {
+	var configs []models.ChannelConfig
+	if err := db.Where("is_active = ? AND digest_time != ?", true, "").Find(&configs).Error; err != nil {
+		log.Printf("daily digest config search error: %v", err)
+		return
+	}
+
+	now := time.Now()
+	dueByChannel := make(map[string][]models.ChannelConfig)
+	var channels []string
+	for _, config := range configs {
+		if !IsDigestDue(&config, now) {
+			continue
+		}
+		if _, ok := dueByChannel[config.SlackChannelID]; !ok {
+			channels = append(channels, config.SlackChannelID)
+		}
+		dueByChannel[config.SlackChannelID] = append(dueByChannel[config.SlackChannelID], config)
+	}
+
+	for _, channelID := range channels {
+		due := dueByChannel[channelID]
+		if err := sendChannelDigest(db, slack, channelID, due, now); err != nil {
+			log.Printf("daily digest send error (channel: %s): %v", channelID, err)
+			// Retry on the next tick unless the channel itself is unusable
+			if !IsChannelRelatedError(err) {
+				continue
+			}
+		}
+
+		ids := make([]string, 0, len(due))
+		for _, config := range due {
+			ids = append(ids, config.ID)
+		}
+		if err := db.Model(&models.ChannelConfig{}).Where("id IN ?", ids).Update("digest_last_sent_at", now).Error; err != nil {
+			log.Printf("failed to record daily digest (channel: %s): %v", channelID, err)
+		}
+	}
+}
+
+// sendChannelDigest posts the digest of the open tasks of the given label
+// configs to the channel. Nothing is posted when no task is open.
+func sendChannelDigest(db *gorm.DB, slack SlackClient, channelID string, configs []models.ChannelConfig, now time.Time) error {
+	configsByLabel := make(map[string]models.ChannelConfig)
+	for _, config := range configs {
+		configsByLabel[config.LabelName] = config
+	}
+
+	var candidates []models.ReviewTask
+	if err := db.Where("slack_channel = ? AND status IN ?", channelID, digestStatuses).
+		Order("created_at").
+		Find(&candidates).Error; err != nil {
+		return fmt.Errorf("failed to load open tasks: %w", err)
+	}
+
+	var tasks []models.ReviewTask
+	for _, task := range candidates {
+		labelName := task.LabelName
+		if labelName == "" {
+			labelName = "needs-review"
+		}
+		if _, ok := configsByLabel[labelName]; ok {
+			tasks = append(tasks, task)
+		}
+	}
+	if len(tasks) == 0 {
+		log.Printf("no open tasks for daily digest (channel: %s)", channelID)
+		return nil
+	}
+
+	text, blocks := BuildDigestMessage(tasks, configsByLabel, now)
+	if err := PostChannelMessage(slack, channelID, text, blocks); err != nil {
+		return err
+	}
+	log.Printf("daily digest sent (channel: %s, tasks: %d)", channelID, len(tasks))
+	return nil
+}
+
+// BuildDigestMessage renders the digest of tasks, each with its age, assigned
+// reviewers, reviewers yet to approve and approval progress. configsByLabel
+// supplies the required approvals; the first task's config sets the language.
+func BuildDigestMessage(tasks []models.ReviewTask, configsByLabel map[string]models.ChannelConfig, now time.Time) (string, []map[string]interface{}) {
+	configFor := func(task models.ReviewTask) models.ChannelConfig {
+		labelName := task.LabelName
+		if labelName == "" {
+			labelName = "needs-review"
+		}
+		return configsByLabel[labelName]
+	}
+
+	lang := "ja"
+	if len(tasks) > 0 && configFor(tasks[0]).Language != "" {
+		lang = configFor(tasks[0]).Language
+	}
+	t := i18n.L(lang)
+
+	header := t("digest.header", len(tasks))
+	builder := NewSlackBlockBuilder().AddSection(header)
+	for i, task := range tasks {
+		if i == digestMaxTasks {
+			builder.AddSection(t("digest.more", len(tasks)-digestMaxTasks))
+			break
+		}
+		config := configFor(task)
+
+		reviewers := formatReviewerCSVMentions(task.Reviewers, task.Reviewer)
+		if reviewers == "" {
+			reviewers = t("digest.not_assigned")
+		}
+		pending := formatReviewerCSVMentions(strings.Join(GetPendingReviewers(task), ","), "")
+		if pending == "" {
+			pending = "-"
+		}
+
+		link := taskLink(task)
+		if len(configsByLabel) > 1 {
+			link = fmt.Sprintf("`%s` %s", config.LabelName, link)
+		}
+		line := t("digest.task",
+			link, task.Repo, task.PRNumber,
+			FormatWaitingDuration(now.Sub(task.CreatedAt), lang),
+			reviewers, pending,
+			CountApprovals(task), EffectiveRequiredApprovals(task, config.RequiredApprovals))
+		if task.Status == "paused" {
+			line += " · " + t("home.paused")
+		}
+		builder.AddSection(line)
+	}
+
+	return header, builder.Build()
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"slack-review-notify/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsDigestDue(t *testing.T) {
+	jst, _ := time.LoadLocation("Asia/Tokyo")
+	// Wednesday 2024-05-15, a business day in Japan
+	wednesday := func(hour, minute int) time.Time {
+		return time.Date(2024, 5, 15, hour, minute, 0, 0, jst)
+	}
+	sentAt := func(tm time.Time) *time.Time { return &tm }
+
+	base := models.ChannelConfig{
+		BusinessHoursStart: "09:00",
+		BusinessHoursEnd:   "18:00",
+		Timezone:           "Asia/Tokyo",
+		DigestTime:         "10:00",
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *models.ChannelConfig)
+		now    time.Time
+		want   bool
+	}{
+		{"before digest time", nil, wednesday(9, 59), false},
+		{"at digest time", nil, wednesday(10, 0), true},
+		{"later the same day when not yet sent", nil, wednesday(15, 0), true},
+		{"already sent today", func(c *models.ChannelConfig) { c.DigestLastSentAt = sentAt(wednesday(10, 1)) }, wednesday(11, 0), false},
+		{"sent yesterday", func(c *models.ChannelConfig) { c.DigestLastSentAt = sentAt(wednesday(10, 1).AddDate(0, 0, -1)) }, wednesday(10, 0), true},
+		{"after business hours", nil, wednesday(18, 30), false},
+		{"disabled", func(c *models.ChannelConfig) { c.DigestTime = "" }, wednesday(10, 0), false},
+		{"weekend", nil, time.Date(2024, 5, 18, 10, 0, 0, 0, jst), false},
+		{"Japanese holiday", nil, time.Date(2024, 5, 3, 10, 0, 0, 0, jst), false},
+		{"other timezone", func(c *models.ChannelConfig) { c.Timezone = "America/New_York" }, time.Date(2024, 5, 15, 10, 0, 0, 0, mustLoadLocation(t, "America/New_York")), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := base
+			if tt.modify != nil {
+				tt.modify(&config)
+			}
+			assert.Equal(t, tt.want, IsDigestDue(&config, tt.now))
+		})
+	}
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("failed to load location %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestBuildDigestMessage(t *testing.T) {
+	now := time.Now()
+	configs := map[string]models.ChannelConfig{
+		"needs-review": {LabelName: "needs-review", RequiredApprovals: 2, Language: "en"},
+	}
+	tasks := []models.ReviewTask{
+		{PRURL: "https://github.com/o/r/pull/1", Title: "Fix bug", Repo: "o/r", PRNumber: 1, Reviewers: "U1,U2", ApprovedBy: "U1", Status: "in_review", CreatedAt: now.Add(-3 * time.Hour)},
+		{PRURL: "https://github.com/o/r/pull/2", Title: "Add feature", Repo: "o/r", PRNumber: 2, Status: "pending", CreatedAt: now.Add(-10 * time.Minute)},
+	}
+
+	text, blocks := BuildDigestMessage(tasks, configs, now)
+	assert.Contains(t, text, "2 open review(s)")
+	assert.Len(t, blocks, 3)
+
+	first := blocks[1]["text"].(map[string]interface{})["text"].(string)
+	assert.Contains(t, first, "<https://github.com/o/r/pull/1|Fix bug> `o/r#1`")
+	assert.Contains(t, first, "Age: 3h")
+	assert.Contains(t, first, "Reviewers: <@U1> <@U2>")
+	assert.Contains(t, first, "Waiting on: <@U2>")
+	assert.Contains(t, first, "Approvals: 1/2")
+
+	second := blocks[2]["text"].(map[string]interface{})["text"].(string)
+	assert.Contains(t, second, "Reviewers: not assigned yet")
+	assert.Contains(t, second, "Approvals: 0/2")
+}
+
+// One digest is posted per channel even with several due labels, and it is
+// not posted again the same day.
+func TestCheckDailyDigests(t *testing.T) {
+	now := time.Now().UTC()
+	if now.Weekday() == time.Saturday || now.Weekday() == time.Sunday {
+		t.Skip("Skipping: weekend (business hours always false)")
+	}
+	if now.Hour() == 23 && now.Minute() == 59 {
+		t.Skip("Skipping: too close to the end of the test business hours window")
+	}
+
+	db := setupTestDB(t)
+	for _, label := range []string{"needs-review", "backend"} {
+		db.Create(&models.ChannelConfig{
+			ID:                 "digest-" + label,
+			SlackChannelID:     "C_DIGEST",
+			LabelName:          label,
+			IsActive:           true,
+			BusinessHoursStart: "00:00",
+			BusinessHoursEnd:   "23:59",
+			Timezone:           "UTC",
+			DigestTime:         "00:00",
+			Language:           "en",
+		})
+	}
+	db.Create(&models.ReviewTask{ID: "digest-1", PRURL: "https://github.com/o/r/pull/1", Title: "One", Repo: "o/r", PRNumber: 1, SlackChannel: "C_DIGEST", LabelName: "needs-review", Reviewers: "U1", Status: "in_review", CreatedAt: now})
+	db.Create(&models.ReviewTask{ID: "digest-2", PRURL: "https://github.com/o/r/pull/2", Title: "Two", Repo: "o/r", PRNumber: 2, SlackChannel: "C_DIGEST", LabelName: "backend", Reviewers: "U2", Status: "paused", CreatedAt: now})
+	db.Create(&models.ReviewTask{ID: "digest-done", PRURL: "https://github.com/o/r/pull/3", Title: "Done", Repo: "o/r", PRNumber: 3, SlackChannel: "C_DIGEST", LabelName: "backend", Status: "completed", CreatedAt: now})
+
+	slack := NewFakeSlackClient()
+	CheckDailyDigests(db, slack)
+	CheckDailyDigests(db, slack)
+
+	var posted []string
+	for _, msg := range slack.Messages() {
+		posted = append(posted, messageJSON(t, msg))
+	}
+
+	if assert.Len(t, posted, 1) {
+		assert.Contains(t, posted[0], "2 open review(s)")
+		assert.Contains(t, posted[0], "`backend`")
+		assert.False(t, strings.Contains(posted[0], "Done"))
+	}
+
+	var configs []models.ChannelConfig
+	db.Where("slack_channel_id = ?", "C_DIGEST").Find(&configs)
+	for _, config := range configs {
+		assert.NotNil(t, config.DigestLastSentAt, "label %s should be marked as sent", config.LabelName)
+	}
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slack-review-notify/i18n"
+	"slack-review-notify/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ToggleDMDigestActionID is the action_id of the App Home button that turns
+// the user's DM digest on or off.
+const ToggleDMDigestActionID = "toggle_dm_digest"
+
+// DefaultDMDigestTime and DefaultDMDigestTimezone apply when a user opts in
+// without choosing a time.
+const (
+	DefaultDMDigestTime     = "10:00"
+	DefaultDMDigestTimezone = "Asia/Tokyo"
+)
+
+// GetDMDigestSubscription returns the DM digest subscription of the user in
+// the Slack team, or nil when the user has never opted in there.
+func GetDMDigestSubscription(db *gorm.DB, teamID, userID string) (*models.DMDigestSubscription, error) {
+	var sub models.DMDigestSubscription
+	if err := db.Where("team_id = ? AND slack_user_id = ?", teamID, userID).First(&sub).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// SetDMDigest turns the user's DM digest on or off. teamID is the workspace
+// the digest is sent in. Empty digestTime or timezone keep the current value
+// (or the default for a new subscription).
+func SetDMDigest(db *gorm.DB, teamID, userID string, enabled bool, digestTime, timezone string) (*models.DMDigestSubscription, error) {
+	sub, err := GetDMDigestSubscription(db, teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		sub = &models.DMDigestSubscription{
+			ID:          uuid.NewString(),
+			TeamID:      teamID,
+			SlackUserID: userID,
+			DigestTime:  DefaultDMDigestTime,
+			Timezone:    DefaultDMDigestTimezone,
+		}
+	}
+
+	sub.Enabled = enabled
+	if digestTime != "" {
+		sub.DigestTime = digestTime
+	}
+	if timezone != "" {
+		sub.Timezone = timezone
+	}
+	if err := db.Save(sub).Error; err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// IsDMDigestDue reports whether the subscription's digest should be sent at
+// now: today's DigestTime has passed in the subscription's timezone, the
+// digest has not been sent since, and today is a business day there.
+func IsDMDigestDue(sub *models.DMDigestSubscription, now time.Time) bool {
+	if sub == nil || !sub.Enabled {
+		return false
+	}
+	scheduled, err := scheduledTimeToday(now, sub.DigestTime, sub.Timezone)
+	if err != nil || now.Before(scheduled) {
+		return false
+	}
+	if sub.LastSentAt != nil && !sub.LastSentAt.Before(scheduled) {
+		return false
+	}
+	return isBusinessDay(now.In(scheduled.Location()), scheduled.Location().String())
+}
+
+// CheckDMDigests sends the DM digest to every subscribed user whose digest is
+// due. Users who are currently away are skipped for the day.
+func CheckDMDigests(db *gorm.DB, slack SlackClient) {
+	var subs []models.DMDigestSubscription
+	if err := db.Where("enabled = ?", true).Find(&subs).Error; err != nil {
+		log.Printf("dm digest subscription search error: %v", err)
+		return
+	}
+
+	now := time.Now()
+	away := make(map[string]bool)
+	for _, id := range GetAwayUserIDs(db) {
+		away[id] = true
+	}
+
+	for _, sub := range subs {
+		if !IsDMDigestDue(&sub, now) {
+			continue
+		}
+		if away[sub.SlackUserID] {
+			log.Printf("dm digest skipped for away user %s", sub.SlackUserID)
+		} else if err := sendDMDigest(db, slack.ForTeam(sub.TeamID), sub.TeamID, sub.SlackUserID, now); err != nil {
+			// Retry on the next tick
+			log.Printf("dm digest send error (user: %s): %v", sub.SlackUserID, err)
+			continue
+		}
+
+		if err := db.Model(&models.DMDigestSubscription{}).Where("id = ?", sub.ID).Update("last_sent_at", now).Error; err != nil {
+			log.Printf("failed to record dm digest (user: %s): %v", sub.SlackUserID, err)
+		}
+	}
+}
+
+// sendDMDigest sends the user a direct message listing the reviews of the
+// Slack team waiting on them. Nothing is sent when no review is waiting.
+func sendDMDigest(db *gorm.DB, slack SlackClient, teamID, userID string, now time.Time) error {
+	tasks, err := findAssignedOpenTasks(db, teamID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to load assigned tasks: %w", err)
+	}
+	if len(tasks) == 0 {
+		log.Printf("no assigned tasks for dm digest (user: %s)", userID)
+		return nil
+	}
+
+	threadLinks := make(map[string]string)
+	for i, task := range tasks {
+		if i == digestMaxTasks {
+			break
+		}
+		if task.SlackTS == "" {
+			continue
+		}
+		permalink, err := slack.GetPermalink(task.SlackChannel, task.SlackTS)
+		if err != nil {
+			log.Printf("failed to get thread permalink (task: %s): %v", task.ID, err)
+			continue
+		}
+		threadLinks[task.ID] = permalink
+	}
+
+	text, blocks := BuildDMDigestMessage(tasks, threadLinks, now)
+	// Posting to a user ID delivers the message to the DM with the bot
+	if err := PostChannelMessage(slack, userID, text, blocks); err != nil {
+		return err
+	}
+	log.Printf("dm digest sent (user: %s, tasks: %d)", userID, len(tasks))
+	return nil
+}
+
+// BuildDMDigestMessage renders the DM digest of tasks with links to each PR
+// and its Slack thread. threadLinks maps task IDs to thread permalinks; tasks
+// without one link to their channel instead. The first task sets the language.
+func BuildDMDigestMessage(tasks []models.ReviewTask, threadLinks map[string]string, now time.Time) (string, []map[string]interface{}) {
+	lang := "ja"
+	if len(tasks) > 0 && tasks[0].Language != "" {
+		lang = tasks[0].Language
+	}
+	t := i18n.L(lang)
+
+	header := t("dm_digest.header", len(tasks))
+	builder := NewSlackBlockBuilder().AddSection(header)
+	for i, task := range tasks {
+		if i == digestMaxTasks {
+			builder.AddSection(t("digest.more", len(tasks)-digestMaxTasks))
+			break
+		}
+
+		thread := fmt.Sprintf("<#%s>", task.SlackChannel)
+		if permalink, ok := threadLinks[task.ID]; ok {
+			thread = fmt.Sprintf("<%s|%s>", permalink, t("dm_digest.thread"))
+		}
+		line := t("dm_digest.task",
+			taskLink(task), task.Repo, task.PRNumber,
+			FormatWaitingDuration(now.Sub(task.CreatedAt), lang), thread)
+		if task.Status == "paused" {
+			line += " · " + t("home.paused")
+		}
+		builder.AddSection(line)
+	}
+
+	return header, builder.Build()
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"encoding/json"
+	"slack-review-notify/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsDMDigestDue(t *testing.T) {
+	jst, _ := time.LoadLocation("Asia/Tokyo")
+	// Wednesday 2024-05-15, a business day in Japan
+	wednesday := func(hour, minute int) time.Time {
+		return time.Date(2024, 5, 15, hour, minute, 0, 0, jst)
+	}
+	sentAt := func(tm time.Time) *time.Time { return &tm }
+
+	tests := []struct {
+		name string
+		sub  *models.DMDigestSubscription
+		now  time.Time
+		want bool
+	}{
+		{"nil subscription", nil, wednesday(11, 0), false},
+		{"disabled", &models.DMDigestSubscription{DigestTime: "10:00", Timezone: "Asia/Tokyo"}, wednesday(11, 0), false},
+		{"before digest time", &models.DMDigestSubscription{Enabled: true, DigestTime: "10:00", Timezone: "Asia/Tokyo"}, wednesday(9, 59), false},
+		{"due", &models.DMDigestSubscription{Enabled: true, DigestTime: "10:00", Timezone: "Asia/Tokyo"}, wednesday(10, 0), true},
+		{"already sent today", &models.DMDigestSubscription{Enabled: true, DigestTime: "10:00", Timezone: "Asia/Tokyo", LastSentAt: sentAt(wednesday(10, 1))}, wednesday(11, 0), false},
+		{"sent yesterday", &models.DMDigestSubscription{Enabled: true, DigestTime: "10:00", Timezone: "Asia/Tokyo", LastSentAt: sentAt(wednesday(10, 1).AddDate(0, 0, -1))}, wednesday(11, 0), true},
+		// 2024-05-18 is a Saturday
+		{"weekend", &models.DMDigestSubscription{Enabled: true, DigestTime: "10:00", Timezone: "Asia/Tokyo"}, wednesday(11, 0).AddDate(0, 0, 3), false},
+		// 2024-05-03 is Constitution Memorial Day
+		{"japanese holiday", &models.DMDigestSubscription{Enabled: true, DigestTime: "10:00", Timezone: "Asia/Tokyo"}, time.Date(2024, 5, 3, 11, 0, 0, 0, jst), false},
+		{"japanese holiday outside Japan", &models.DMDigestSubscription{Enabled: true, DigestTime: "10:00", Timezone: "UTC"}, time.Date(2024, 5, 3, 11, 0, 0, 0, time.UTC), true},
+		{"invalid time", &models.DMDigestSubscription{Enabled: true, DigestTime: "25:00", Timezone: "Asia/Tokyo"}, wednesday(11, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsDMDigestDue(tt.sub, tt.now))
+		})
+	}
+}
+
+func TestBuildDMDigestMessage(t *testing.T) {
+	now := time.Now()
+	tasks := []models.ReviewTask{
+		{ID: "dm-1", PRURL: "https://github.com/o/r/pull/1", Title: "Fix bug", Repo: "o/r", PRNumber: 1, SlackChannel: "C1", Status: "in_review", Language: "en", CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: "dm-2", PRURL: "https://github.com/o/r/pull/2", Title: "Add feature", Repo: "o/r", PRNumber: 2, SlackChannel: "C2", Status: "paused", Language: "en", CreatedAt: now},
+	}
+
+	text, blocks := BuildDMDigestMessage(tasks, map[string]string{"dm-1": "https://example.slack.com/archives/C1/p1"}, now)
+
+	assert.Contains(t, text, "Reviews waiting on you*: 2")
+	assert.Len(t, blocks, 3)
+
+	first := blocks[1]["text"].(map[string]interface{})["text"].(string)
+	assert.Contains(t, first, "<https://github.com/o/r/pull/1|Fix bug>")
+	assert.Contains(t, first, "<https://example.slack.com/archives/C1/p1|Slack thread>")
+	assert.Contains(t, first, "Waiting: 3h")
+
+	// Without a permalink the task links to its channel
+	second := blocks[2]["text"].(map[string]interface{})["text"].(string)
+	assert.Contains(t, second, "<#C2>")
+	assert.Contains(t, second, "reminders paused")
+}
+
+func TestCheckDMDigests(t *testing.T) {
+	now := time.Now().UTC()
+	if now.Weekday() == time.Saturday || now.Weekday() == time.Sunday {
+		t.Skip("Skipping: weekend (DM digest is not sent)")
+	}
+
+	db := setupTestDB(t)
+	for _, userID := range []string{"UREADER", "UAWAY", "UIDLE"} {
+		db.Create(&models.DMDigestSubscription{ID: "sub-" + userID, TeamID: "T1", SlackUserID: userID, Enabled: true, DigestTime: "00:00", Timezone: "UTC"})
+	}
+	db.Create(&models.DMDigestSubscription{ID: "sub-off", TeamID: "T1", SlackUserID: "UOFF", Enabled: false, DigestTime: "00:00", Timezone: "UTC"})
+	db.Create(&models.ReviewerAvailability{ID: "away-dm", SlackUserID: "UAWAY"})
+
+	tasks := []models.ReviewTask{
+		{ID: "dm-a", TeamID: "T1", PRURL: "https://github.com/o/r/pull/1", Title: "Across channel A", Repo: "o/r", PRNumber: 1, SlackChannel: "CA", SlackTS: "111.1", Reviewers: "UREADER,UAWAY,UOFF", Status: "in_review", Language: "en", CreatedAt: now.Add(-time.Hour)},
+		{ID: "dm-b", TeamID: "T1", PRURL: "https://github.com/o/r/pull/2", Title: "Across channel B", Repo: "o/r", PRNumber: 2, SlackChannel: "CB", SlackTS: "222.2", Reviewers: "UREADER", Status: "snoozed", Language: "en", CreatedAt: now},
+		// approved by the reader already
+		{ID: "dm-approved", TeamID: "T1", PRURL: "https://github.com/o/r/pull/3", Title: "Approved", Repo: "o/r", PRNumber: 3, SlackChannel: "CA", Reviewers: "UREADER", ApprovedBy: "UREADER", Status: "in_review", CreatedAt: now},
+		{ID: "dm-done", TeamID: "T1", PRURL: "https://github.com/o/r/pull/4", Title: "Done", Repo: "o/r", PRNumber: 4, SlackChannel: "CA", Reviewers: "UREADER", Status: "completed", CreatedAt: now},
+		// a review of another workspace the reader is also in
+		{ID: "dm-other-team", TeamID: "T2", PRURL: "https://github.com/o/r/pull/5", Title: "Other workspace", Repo: "o/r", PRNumber: 5, SlackChannel: "CX", Reviewers: "UREADER", Status: "in_review", CreatedAt: now},
+	}
+	for _, task := range tasks {
+		db.Create(&task)
+	}
+
+	slack := NewFakeSlackClient()
+	CheckDMDigests(db, slack)
+	CheckDMDigests(db, slack)
+
+	// Only the reader gets a DM: the away user is skipped, the idle user has
+	// nothing waiting and the opted-out user is not subscribed.
+	messages := slack.Messages()
+	if assert.Len(t, messages, 1) {
+		body, _ := json.Marshal(messages[0])
+		posted := string(body)
+		assert.Equal(t, "UREADER", messages[0].Channel)
+		assert.Contains(t, posted, "Across channel A")
+		assert.Contains(t, posted, "Across channel B")
+		assert.Contains(t, posted, "https://example.slack.com/archives/CA/p111.1")
+		assert.False(t, strings.Contains(posted, "Approved"))
+		assert.False(t, strings.Contains(posted, "Done"))
+		assert.False(t, strings.Contains(posted, "Other workspace"))
+	}
+
+	var subs []models.DMDigestSubscription
+	db.Where("enabled = ?", true).Find(&subs)
+	for _, sub := range subs {
+		assert.NotNil(t, sub.LastSentAt, "user %s should be marked as handled", sub.SlackUserID)
+	}
+}
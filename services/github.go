@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"slack-review-notify/models"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v71/github"
+	"gorm.io/gorm"
+)
+
+// githubAPITimeout bounds every GitHub API call made on behalf of a webhook or
+// the task checker so a slow GitHub never stalls reviewer assignment.
+const githubAPITimeout = 10 * time.Second
+
+// codeownersPaths are the locations GitHub looks for a CODEOWNERS file, in
+// the order GitHub itself checks them.
+var codeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// GitHubAPIBaseURL returns the base URL for the GitHub REST API.
+// If the environment variable GITHUB_API_BASE_URL is set, it uses that value;
+// otherwise, it returns https://api.github.com.
+// Set this for GitHub Enterprise Server or to point tests at a local stub.
+func GitHubAPIBaseURL() string {
+	if base := os.Getenv("GITHUB_API_BASE_URL"); base != "" {
+		return strings.TrimRight(base, "/")
+	}
+	return "https://api.github.com"
+}
+
+// IsGitHubAPIEnabled reports whether credentials for the GitHub API are
+// configured (see github_auth.go). Features that call the API are skipped
+// when it returns false.
+func IsGitHubAPIEnabled() bool {
+	return os.Getenv("GITHUB_TOKEN") != "" || isGitHubAppConfigured()
+}
+
+// newGitHubClient builds a GitHub API client for calls on the repository,
+// authenticated with GITHUB_TOKEN or a GitHub App installation token.
+func newGitHubClient(db *gorm.DB, repoFullName string) (*github.Client, error) {
+	token, err := githubToken(db, repoFullName)
+	if err != nil {
+		return nil, err
+	}
+	return newGitHubClientWithToken(token)
+}
+
+// newGitHubClientWithToken builds a GitHub API client authenticated with the
+// token and pointed at GitHubAPIBaseURL.
+func newGitHubClientWithToken(token string) (*github.Client, error) {
+	baseURL, err := url.Parse(GitHubAPIBaseURL() + "/")
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub API base URL: %w", err)
+	}
+
+	client := github.NewClient(&http.Client{}).WithAuthToken(token)
+	client.BaseURL = baseURL
+	return client, nil
+}
+
+// splitRepoFullName splits "owner/repo" into its owner and repository name.
+func splitRepoFullName(fullName string) (string, string, error) {
+	parts := strings.SplitN(fullName, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid repository name: %q", fullName)
+	}
+	return parts[0], parts[1], nil
+}
+
+// GetPullRequest fetches the pull request.
+func GetPullRequest(db *gorm.DB, repoFullName string, prNumber int) (*github.PullRequest, error) {
+	owner, repo, err := splitRepoFullName(repoFullName)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newGitHubClient(db, repoFullName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), githubAPITimeout)
+	defer cancel()
+
+	pr, _, err := client.PullRequests.Get(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pull request: %w", err)
+	}
+	return pr, nil
+}
+
+// GetPullRequestFiles returns the paths of every file changed by the pull request.
+func GetPullRequestFiles(db *gorm.DB, repoFullName string, prNumber int) ([]string, error) {
+	owner, repo, err := splitRepoFullName(repoFullName)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newGitHubClient(db, repoFullName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), githubAPITimeout)
+	defer cancel()
+
+	var paths []string
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		files, resp, err := client.PullRequests.ListFiles(ctx, owner, repo, prNumber, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pull request files: %w", err)
+		}
+		for _, f := range files {
+			paths = append(paths, f.GetFilename())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return paths, nil
+}
+
+// GetPullRequestReviews returns every review of the pull request in the order
+// they were submitted.
+func GetPullRequestReviews(db *gorm.DB, repoFullName string, prNumber int) ([]*github.PullRequestReview, error) {
+	owner, repo, err := splitRepoFullName(repoFullName)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newGitHubClient(db, repoFullName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), githubAPITimeout)
+	defer cancel()
+
+	var reviews []*github.PullRequestReview
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.PullRequests.ListReviews(ctx, owner, repo, prNumber, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pull request reviews: %w", err)
+		}
+		reviews = append(reviews, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return reviews, nil
+}
+
+// GetCodeownersFile returns the contents of the repository's CODEOWNERS file
+// at ref (the default branch when ref is empty). It returns an empty string
+// without error when the repository has no CODEOWNERS file.
+func GetCodeownersFile(db *gorm.DB, repoFullName, ref string) (string, error) {
+	owner, repo, err := splitRepoFullName(repoFullName)
+	if err != nil {
+		return "", err
+	}
+	client, err := newGitHubClient(db, repoFullName)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), githubAPITimeout)
+	defer cancel()
+
+	var opts *github.RepositoryContentGetOptions
+	if ref != "" {
+		opts = &github.RepositoryContentGetOptions{Ref: ref}
+	}
+
+	for _, path := range codeownersPaths {
+		file, _, resp, err := client.Repositories.GetContents(ctx, owner, repo, path, opts)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return "", fmt.Errorf("failed to get %s: %w", path, err)
+		}
+		if file == nil {
+			// path is a directory
+			continue
+		}
+		content, err := file.GetContent()
+		if err != nil {
+			return "", fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+		return content, nil
+	}
+
+	log.Printf("no CODEOWNERS file found in %s", repoFullName)
+	return "", nil
+}
+
+// GetRequestedReviewerSlackIDs maps the pull request's requested reviewers to
+// Slack user IDs via the team's UserMapping. Unmapped users and the PR author
+// are skipped.
+func GetRequestedReviewerSlackIDs(db *gorm.DB, teamID string, pr *github.PullRequest, authorSlackID string) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, user := range pr.RequestedReviewers {
+		id := GetSlackUserIDFromGitHub(db, teamID, user.GetLogin())
+		if id == "" || id == authorSlackID || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// RequestGitHubReviewers requests reviews from the given GitHub users on the pull request.
+func RequestGitHubReviewers(db *gorm.DB, repoFullName string, prNumber int, logins []string) error {
+	if len(logins) == 0 {
+		return nil
+	}
+	owner, repo, err := splitRepoFullName(repoFullName)
+	if err != nil {
+		return err
+	}
+	client, err := newGitHubClient(db, repoFullName)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), githubAPITimeout)
+	defer cancel()
+
+	if _, _, err := client.PullRequests.RequestReviewers(ctx, owner, repo, prNumber, github.ReviewersRequest{Reviewers: logins}); err != nil {
+		return fmt.Errorf("failed to request reviewers: %w", err)
+	}
+	return nil
+}
+
+// RemoveGitHubReviewers withdraws the review requests of the given GitHub users on the pull request.
+func RemoveGitHubReviewers(db *gorm.DB, repoFullName string, prNumber int, logins []string) error {
+	if len(logins) == 0 {
+		return nil
+	}
+	owner, repo, err := splitRepoFullName(repoFullName)
+	if err != nil {
+		return err
+	}
+	client, err := newGitHubClient(db, repoFullName)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), githubAPITimeout)
+	defer cancel()
+
+	if _, err := client.PullRequests.RemoveReviewers(ctx, owner, repo, prNumber, github.ReviewersRequest{Reviewers: logins}); err != nil {
+		return fmt.Errorf("failed to remove reviewers: %w", err)
+	}
+	return nil
+}
+
+// PushReviewersToGitHub mirrors a Slack-side reviewer change onto the pull
+// request: review requests of removedIDs are withdrawn and addedIDs are
+// requested. Slack IDs are mapped to GitHub logins via UserMapping; unmapped
+// users are skipped. Nothing happens unless the task's channel config enables
+// SyncReviewersToGitHub and GitHub API credentials are configured. Failures
+// are logged, never returned, so they cannot block the Slack flow.
+func PushReviewersToGitHub(db *gorm.DB, task models.ReviewTask, addedIDs, removedIDs []string) {
+	if !IsGitHubAPIEnabled() || task.Repo == "" || task.PRNumber == 0 {
+		return
+	}
+
+	labelName := task.LabelName
+	if labelName == "" {
+		labelName = "needs-review"
+	}
+	config, err := GetChannelConfig(db, task.TeamID, task.SlackChannel, labelName)
+	if err != nil || !config.SyncReviewersToGitHub {
+		return
+	}
+
+	if logins := GetGitHubUsernamesFromSlack(db, task.TeamID, removedIDs); len(logins) > 0 {
+		if err := RemoveGitHubReviewers(db, task.Repo, task.PRNumber, logins); err != nil {
+			log.Printf("failed to remove github reviewers %v from %s#%d: %v", logins, task.Repo, task.PRNumber, err)
+		}
+	}
+	if logins := GetGitHubUsernamesFromSlack(db, task.TeamID, addedIDs); len(logins) > 0 {
+		if err := RequestGitHubReviewers(db, task.Repo, task.PRNumber, logins); err != nil {
+			log.Printf("failed to request github reviewers %v on %s#%d: %v", logins, task.Repo, task.PRNumber, err)
+		} else {
+			log.Printf("github reviewers requested on %s#%d: %v", task.Repo, task.PRNumber, logins)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v71/github"
+	"gorm.io/gorm"
+)
+
+// GitHub API credentials come from one of two sources:
+//
+//   - GITHUB_TOKEN: a personal access token or any other pre-issued token.
+//   - GITHUB_APP_ID and GITHUB_APP_PRIVATE_KEY (PEM contents) or
+//     GITHUB_APP_PRIVATE_KEY_PATH: a GitHub App. Calls for a repository use a
+//     token of the installation that can see it (see
+//     github_installations.go), or of GITHUB_APP_INSTALLATION_ID when no
+//     installation is known for it. Installation tokens are minted on demand
+//     and cached until shortly before they expire.
+//
+// GITHUB_TOKEN wins when both are configured.
+
+// installationTokenRefreshMargin is how long before expiry a cached
+// installation token is replaced.
+const installationTokenRefreshMargin = time.Minute
+
+type cachedInstallationToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+var installationTokenCache = struct {
+	sync.Mutex
+	tokens map[string]cachedInstallationToken // keyed by app ID and installation ID
+}{tokens: map[string]cachedInstallationToken{}}
+
+// isGitHubAppConfigured reports whether the GitHub App ID and private key are set.
+func isGitHubAppConfigured() bool {
+	return os.Getenv("GITHUB_APP_ID") != "" &&
+		(os.Getenv("GITHUB_APP_PRIVATE_KEY") != "" || os.Getenv("GITHUB_APP_PRIVATE_KEY_PATH") != "")
+}
+
+// githubToken returns the token used to authenticate GitHub API calls on the
+// repository.
+func githubToken(db *gorm.DB, repoFullName string) (string, error) {
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token, nil
+	}
+	if !isGitHubAppConfigured() {
+		return "", fmt.Errorf("neither GITHUB_TOKEN nor GitHub App credentials are set")
+	}
+
+	installationID, ok := GitHubInstallationIDForRepo(db, repoFullName)
+	if !ok {
+		fallback := os.Getenv("GITHUB_APP_INSTALLATION_ID")
+		if fallback == "" {
+			return "", fmt.Errorf("github app is not installed on %s", repoFullName)
+		}
+		var err error
+		installationID, err = strconv.ParseInt(fallback, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid GITHUB_APP_INSTALLATION_ID: %w", err)
+		}
+	}
+	return installationToken(installationID)
+}
+
+// installationToken returns a token of the GitHub App installation, minting
+// one when none is cached.
+func installationToken(installationID int64) (string, error) {
+	appID := os.Getenv("GITHUB_APP_ID")
+	cacheKey := appID + "/" + strconv.FormatInt(installationID, 10)
+
+	installationTokenCache.Lock()
+	defer installationTokenCache.Unlock()
+
+	if cached, ok := installationTokenCache.tokens[cacheKey]; ok && time.Now().Add(installationTokenRefreshMargin).Before(cached.expiresAt) {
+		return cached.token, nil
+	}
+
+	token, expiresAt, err := createInstallationToken(appID, installationID)
+	if err != nil {
+		return "", err
+	}
+	installationTokenCache.tokens[cacheKey] = cachedInstallationToken{token: token, expiresAt: expiresAt}
+	return token, nil
+}
+
+// newGitHubAppClient builds a client authenticated as the App itself, for
+// the endpoints under /app.
+func newGitHubAppClient(appID string) (*github.Client, error) {
+	key, err := loadGitHubAppPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	jwt, err := signGitHubAppJWT(appID, key, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return newGitHubClientWithToken(jwt)
+}
+
+// createInstallationToken exchanges a GitHub App JWT for an installation token.
+func createInstallationToken(appID string, installationID int64) (string, time.Time, error) {
+	client, err := newGitHubAppClient(appID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), githubAPITimeout)
+	defer cancel()
+
+	token, _, err := client.Apps.CreateInstallationToken(ctx, installationID, nil)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create installation token: %w", err)
+	}
+	return token.GetToken(), token.GetExpiresAt().Time, nil
+}
+
+// loadGitHubAppPrivateKey reads the App's PEM-encoded RSA private key from
+// GITHUB_APP_PRIVATE_KEY or the file at GITHUB_APP_PRIVATE_KEY_PATH.
+func loadGitHubAppPrivateKey() (*rsa.PrivateKey, error) {
+	data := []byte(os.Getenv("GITHUB_APP_PRIVATE_KEY"))
+	if len(data) == 0 {
+		var err error
+		data, err = os.ReadFile(os.Getenv("GITHUB_APP_PRIVATE_KEY_PATH"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GitHub App private key: %w", err)
+		}
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("GitHub App private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// signGitHubAppJWT builds the RS256 JWT GitHub expects from an App. The issue
+// time is backdated to tolerate clock drift and the token lives 9 minutes,
+// under GitHub's 10 minute limit.
+func signGitHubAppJWT(appID string, key *rsa.PrivateKey, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": appID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign GitHub App JWT: %w", err)
+	}
+	return signingInput + "." + enc.EncodeToString(signature), nil
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"slack-review-notify/models"
+	"strings"
+
+	"github.com/google/go-github/v71/github"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxRepositorySuggestions caps the repositories suggested for a name that
+// matches none of the App's repositories.
+const maxRepositorySuggestions = 5
+
+// githubInstallationRecord converts an installation of a webhook or API
+// response to its stored form.
+func githubInstallationRecord(installation *github.Installation) models.GithubInstallation {
+	record := models.GithubInstallation{
+		ID:                  installation.GetID(),
+		AccountLogin:        installation.GetAccount().GetLogin(),
+		AccountType:         installation.GetAccount().GetType(),
+		RepositorySelection: installation.GetRepositorySelection(),
+	}
+	if installation.SuspendedAt != nil {
+		suspendedAt := installation.GetSuspendedAt().Time
+		record.SuspendedAt = &suspendedAt
+	}
+	return record
+}
+
+func upsertGitHubInstallation(tx *gorm.DB, installation *github.Installation) error {
+	record := githubInstallationRecord(installation)
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"account_login", "account_type", "repository_selection", "suspended_at", "updated_at"}),
+	}).Create(&record).Error
+}
+
+func addGitHubInstallationRepositories(tx *gorm.DB, installationID int64, repos []*github.Repository) error {
+	for _, repo := range repos {
+		if repo.GetFullName() == "" {
+			continue
+		}
+		// A repository transferred to another account moves to its installation
+		record := models.GithubInstallationRepository{FullName: repo.GetFullName(), InstallationID: installationID}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "full_name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"installation_id"}),
+		}).Create(&record).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveGitHubInstallation records the installation and replaces the
+// repositories it can access with repos.
+func SaveGitHubInstallation(db *gorm.DB, installation *github.Installation, repos []*github.Repository) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := upsertGitHubInstallation(tx, installation); err != nil {
+			return err
+		}
+		if err := tx.Where("installation_id = ?", installation.GetID()).Delete(&models.GithubInstallationRepository{}).Error; err != nil {
+			return err
+		}
+		return addGitHubInstallationRepositories(tx, installation.GetID(), repos)
+	})
+}
+
+// UpdateGitHubInstallation records a change of the installation, such as a
+// suspension, leaving its repositories as they are.
+func UpdateGitHubInstallation(db *gorm.DB, installation *github.Installation) error {
+	return upsertGitHubInstallation(db, installation)
+}
+
+// UpdateGitHubInstallationRepositories applies the repositories added to and
+// removed from the installation.
+func UpdateGitHubInstallationRepositories(db *gorm.DB, installation *github.Installation, added, removed []*github.Repository) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := upsertGitHubInstallation(tx, installation); err != nil {
+			return err
+		}
+		if err := addGitHubInstallationRepositories(tx, installation.GetID(), added); err != nil {
+			return err
+		}
+		names := make([]string, 0, len(removed))
+		for _, repo := range removed {
+			names = append(names, repo.GetFullName())
+		}
+		if len(names) == 0 {
+			return nil
+		}
+		return tx.Where("installation_id = ? AND full_name IN ?", installation.GetID(), names).
+			Delete(&models.GithubInstallationRepository{}).Error
+	})
+}
+
+// DeleteGitHubInstallation forgets an uninstalled installation and its
+// repositories.
+func DeleteGitHubInstallation(db *gorm.DB, installationID int64) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("installation_id = ?", installationID).Delete(&models.GithubInstallationRepository{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.GithubInstallation{}, installationID).Error
+	})
+}
+
+// LinkGitHubInstallation links the installation to a Slack team, so add-repo
+// in other workspaces no longer accepts its repositories. An empty teamID
+// shares it with every workspace again. It returns gorm.ErrRecordNotFound
+// when the installation is unknown.
+func LinkGitHubInstallation(db *gorm.DB, installationID int64, teamID string) error {
+	result := db.Model(&models.GithubInstallation{}).Where("id = ?", installationID).Update("team_id", teamID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GitHubInstallationIDForRepo returns the installation through which the App
+// can access the repository. ok is false when no active installation is
+// known for it.
+func GitHubInstallationIDForRepo(db *gorm.DB, repoFullName string) (installationID int64, ok bool) {
+	var repos []models.GithubInstallationRepository
+	db.Joins("JOIN github_installations ON github_installations.id = github_installation_repositories.installation_id").
+		Where("github_installation_repositories.full_name = ? AND github_installations.suspended_at IS NULL", repoFullName).
+		Limit(1).Find(&repos)
+	if len(repos) == 0 {
+		return 0, false
+	}
+	return repos[0].InstallationID, true
+}
+
+// GitHubInstallationRepositories returns the full names of the repositories
+// the App can access through the active installations linked to the Slack
+// team or to no team, sorted. It is empty when no such installation has been
+// recorded.
+func GitHubInstallationRepositories(db *gorm.DB, teamID string) ([]string, error) {
+	var names []string
+	err := db.Model(&models.GithubInstallationRepository{}).
+		Joins("JOIN github_installations ON github_installations.id = github_installation_repositories.installation_id").
+		Where("github_installations.suspended_at IS NULL AND github_installations.team_id IN ?", []string{teamID, ""}).
+		Order("github_installation_repositories.full_name").
+		Pluck("github_installation_repositories.full_name", &names).Error
+	return names, err
+}
+
+// MatchGitHubRepository resolves a repository name given by a user against
+// the repositories the App can access. A full name matches regardless of
+// case, and a bare repository name matches when a single repository has it;
+// the match is returned in GitHub's spelling. Without a match, up to
+// maxRepositorySuggestions repositories containing name are suggested.
+func MatchGitHubRepository(repos []string, name string) (match string, suggestions []string) {
+	var sameName []string
+	for _, repo := range repos {
+		if strings.EqualFold(repo, name) {
+			return repo, nil
+		}
+		if _, repoName, err := splitRepoFullName(repo); err == nil && strings.EqualFold(repoName, name) {
+			sameName = append(sameName, repo)
+		}
+	}
+	if !strings.Contains(name, "/") && len(sameName) == 1 {
+		return sameName[0], nil
+	}
+
+	needle := strings.ToLower(name)
+	if _, repoName, err := splitRepoFullName(name); err == nil {
+		needle = strings.ToLower(repoName)
+	}
+	for _, repo := range repos {
+		if len(suggestions) == maxRepositorySuggestions {
+			break
+		}
+		if strings.Contains(strings.ToLower(repo), needle) {
+			suggestions = append(suggestions, repo)
+		}
+	}
+	return "", suggestions
+}
+
+// SyncGitHubInstallations replaces the recorded installations and their
+// repositories with what the GitHub API reports, so installations made while
+// webhooks were not delivered are known too. It does nothing unless a GitHub
+// App is configured.
+func SyncGitHubInstallations(db *gorm.DB) error {
+	if !isGitHubAppConfigured() {
+		return nil
+	}
+	client, err := newGitHubAppClient(os.Getenv("GITHUB_APP_ID"))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), githubAPITimeout)
+	defer cancel()
+
+	var installations []*github.Installation
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.Apps.ListInstallations(ctx, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list github app installations: %w", err)
+		}
+		installations = append(installations, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	ids := make([]int64, 0, len(installations))
+	for _, installation := range installations {
+		ids = append(ids, installation.GetID())
+		// A suspended installation cannot mint tokens; its repositories are
+		// kept for when it is unsuspended
+		if installation.SuspendedAt != nil {
+			if err := UpdateGitHubInstallation(db, installation); err != nil {
+				return err
+			}
+			continue
+		}
+		repos, err := listInstallationRepositories(installation.GetID())
+		if err != nil {
+			log.Printf("failed to list repositories of github installation %d: %v", installation.GetID(), err)
+			continue
+		}
+		if err := SaveGitHubInstallation(db, installation, repos); err != nil {
+			return err
+		}
+	}
+
+	// Installations removed while the server was not receiving webhooks
+	var stale []int64
+	query := db.Model(&models.GithubInstallation{})
+	if len(ids) > 0 {
+		query = query.Where("id NOT IN ?", ids)
+	}
+	if err := query.Pluck("id", &stale).Error; err != nil {
+		return err
+	}
+	for _, id := range stale {
+		if err := DeleteGitHubInstallation(db, id); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("synced %d github app installation(s)", len(installations))
+	return nil
+}
+
+// listInstallationRepositories returns every repository the installation can access.
+func listInstallationRepositories(installationID int64) ([]*github.Repository, error) {
+	token, err := installationToken(installationID)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newGitHubClientWithToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), githubAPITimeout)
+	defer cancel()
+
+	var repos []*github.Repository
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.Apps.ListRepos(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list installation repositories: %w", err)
+		}
+		repos = append(repos, page.Repositories...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return repos, nil
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slack-review-notify/models"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v71/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func testRepos(names ...string) []*github.Repository {
+	repos := make([]*github.Repository, 0, len(names))
+	for _, name := range names {
+		repos = append(repos, &github.Repository{FullName: github.Ptr(name)})
+	}
+	return repos
+}
+
+func TestGitHubInstallationLifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	installation := &github.Installation{
+		ID:                  github.Ptr(int64(7)),
+		Account:             &github.User{Login: github.Ptr("acme"), Type: github.Ptr("Organization")},
+		RepositorySelection: github.Ptr("selected"),
+	}
+
+	require.NoError(t, SaveGitHubInstallation(db, installation, testRepos("acme/api", "acme/web")))
+	repos, err := GitHubInstallationRepositories(db, "T1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme/api", "acme/web"}, repos)
+	id, ok := GitHubInstallationIDForRepo(db, "acme/web")
+	assert.True(t, ok)
+	assert.EqualValues(t, 7, id)
+
+	require.NoError(t, UpdateGitHubInstallationRepositories(db, installation, testRepos("acme/infra"), testRepos("acme/web")))
+	repos, err = GitHubInstallationRepositories(db, "T1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme/api", "acme/infra"}, repos)
+	_, ok = GitHubInstallationIDForRepo(db, "acme/web")
+	assert.False(t, ok)
+
+	// A suspended installation keeps its repositories but is not used
+	installation.SuspendedAt = &github.Timestamp{Time: time.Now()}
+	require.NoError(t, UpdateGitHubInstallation(db, installation))
+	_, ok = GitHubInstallationIDForRepo(db, "acme/api")
+	assert.False(t, ok)
+	repos, err = GitHubInstallationRepositories(db, "T1")
+	require.NoError(t, err)
+	assert.Empty(t, repos)
+
+	installation.SuspendedAt = nil
+	require.NoError(t, UpdateGitHubInstallation(db, installation))
+	_, ok = GitHubInstallationIDForRepo(db, "acme/api")
+	assert.True(t, ok)
+
+	// Linked to another workspace, its repositories are not offered to T1
+	require.NoError(t, LinkGitHubInstallation(db, 7, "T2"))
+	repos, err = GitHubInstallationRepositories(db, "T1")
+	require.NoError(t, err)
+	assert.Empty(t, repos)
+	repos, err = GitHubInstallationRepositories(db, "T2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme/api", "acme/infra"}, repos)
+	// and the link survives the installation being recorded again
+	require.NoError(t, UpdateGitHubInstallation(db, installation))
+	repos, err = GitHubInstallationRepositories(db, "T2")
+	require.NoError(t, err)
+	assert.Len(t, repos, 2)
+	assert.ErrorIs(t, LinkGitHubInstallation(db, 8, "T2"), gorm.ErrRecordNotFound)
+
+	require.NoError(t, DeleteGitHubInstallation(db, 7))
+	var installations, rows int64
+	db.Model(&models.GithubInstallation{}).Count(&installations)
+	db.Model(&models.GithubInstallationRepository{}).Count(&rows)
+	assert.Zero(t, installations)
+	assert.Zero(t, rows)
+}
+
+func TestMatchGitHubRepository(t *testing.T) {
+	repos := []string{"acme/api", "acme/api-gateway", "acme/Web", "other/web", "octo/docs"}
+
+	tests := []struct {
+		name            string
+		input           string
+		wantMatch       string
+		wantSuggestions []string
+	}{
+		{"full name", "acme/api", "acme/api", nil},
+		{"full name in other case", "ACME/web", "acme/Web", nil},
+		{"unique bare name", "docs", "octo/docs", nil},
+		{"ambiguous bare name", "web", "", []string{"acme/Web", "other/web"}},
+		{"unknown full name", "acme/gateway", "", []string{"acme/api-gateway"}},
+		{"nothing close", "acme/mobile", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, suggestions := MatchGitHubRepository(repos, tt.input)
+			assert.Equal(t, tt.wantMatch, match)
+			assert.Equal(t, tt.wantSuggestions, suggestions)
+		})
+	}
+}
+
+func TestSyncGitHubInstallations(t *testing.T) {
+	resetInstallationTokenCache(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /app/installations", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `[
+			{"id": 101, "account": {"login": "acme", "type": "Organization"}, "repository_selection": "all"},
+			{"id": 102, "account": {"login": "octo", "type": "User"}, "suspended_at": "2026-01-01T00:00:00Z"}
+		]`)
+	})
+	mux.HandleFunc("POST /app/installations/101/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"token":"token-101","expires_at":"`+
+			time.Now().Add(time.Hour).UTC().Format(time.RFC3339)+`"}`)
+	})
+	mux.HandleFunc("GET /installation/repositories", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token-101", r.Header.Get("Authorization"))
+		_, _ = io.WriteString(w, `{"total_count": 2, "repositories": [{"full_name": "acme/api"}, {"full_name": "acme/web"}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Setenv("GITHUB_API_BASE_URL", server.URL)
+	t.Setenv("GITHUB_APP_ID", "12345")
+	t.Setenv("GITHUB_APP_PRIVATE_KEY", string(keyPEM))
+
+	db := setupTestDB(t)
+	// Uninstalled while no webhooks were received
+	require.NoError(t, SaveGitHubInstallation(db, &github.Installation{ID: github.Ptr(int64(100))}, testRepos("gone/repo")))
+	// Suspended: its repositories are kept
+	require.NoError(t, SaveGitHubInstallation(db, &github.Installation{ID: github.Ptr(int64(102))}, testRepos("octo/docs")))
+
+	require.NoError(t, SyncGitHubInstallations(db))
+
+	var ids []int64
+	db.Model(&models.GithubInstallation{}).Order("id").Pluck("id", &ids)
+	assert.Equal(t, []int64{101, 102}, ids)
+	repos, err := GitHubInstallationRepositories(db, "T1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme/api", "acme/web"}, repos)
+	var suspended models.GithubInstallationRepository
+	assert.NoError(t, db.First(&suspended, "full_name = ?", "octo/docs").Error)
+}
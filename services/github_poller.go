@@ -0,0 +1,443 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slack-review-notify/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v71/github"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// The GitHub poller is an alternative to webhooks for repositories where
+// none can be installed. Every GITHUB_POLL_INTERVAL it lists the open pull
+// requests of the watched repositories (all repositories of active channel
+// configs, or those in GITHUB_POLL_REPOS), compares their labels, reviews
+// and state with what it saw last time and passes the differences on as the
+// webhook events GitHub would have sent. Requests carry the ETag of the last
+// response, so unchanged repositories and pull requests cost no rate limit.
+
+// GitHubEventHandler processes a webhook event synthesized by the poller,
+// such as a *github.PullRequestEvent of type "pull_request".
+type GitHubEventHandler func(eventType string, event interface{}) error
+
+// githubPollPageSize is the page size of the lists the poller fetches.
+const githubPollPageSize = 100
+
+// activePollTaskStatuses are the task statuses a closed pull request ends.
+var activePollTaskStatuses = []string{"pending", "in_review", "snoozed", "waiting_business_hours"}
+
+// GitHubPollInterval returns GITHUB_POLL_INTERVAL (e.g. "5m"), or 0 when
+// polling is disabled. Intervals under a minute are raised to a minute.
+func GitHubPollInterval() time.Duration {
+	value := os.Getenv("GITHUB_POLL_INTERVAL")
+	if value == "" {
+		return 0
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		log.Printf("invalid GITHUB_POLL_INTERVAL %q, github polling disabled", value)
+		return 0
+	}
+	return max(interval, time.Minute)
+}
+
+// GitHubPollRepositories returns the repositories to poll: GITHUB_POLL_REPOS
+// when set, otherwise every repository of an active channel config. A config
+// without a repository list matches every repository but names none, so it
+// adds nothing to poll.
+func GitHubPollRepositories(db *gorm.DB) []string {
+	seen := map[string]bool{}
+	var repos []string
+	add := func(list string) {
+		for _, repo := range strings.Split(list, ",") {
+			repo = strings.TrimSpace(repo)
+			if repo != "" && !seen[repo] {
+				seen[repo] = true
+				repos = append(repos, repo)
+			}
+		}
+	}
+
+	if list := os.Getenv("GITHUB_POLL_REPOS"); list != "" {
+		add(list)
+		return repos
+	}
+
+	var configs []models.ChannelConfig
+	db.Where("is_active = ?", true).Find(&configs)
+	for _, config := range configs {
+		add(config.RepositoryList)
+	}
+	sort.Strings(repos)
+	return repos
+}
+
+// PollGitHub polls every repository once, passing the changes found to handle.
+func PollGitHub(db *gorm.DB, handle GitHubEventHandler) {
+	for _, repo := range GitHubPollRepositories(db) {
+		if err := pollGitHubRepository(db, repo, handle); err != nil {
+			log.Printf("github poll error (repo: %s): %v", repo, err)
+		}
+	}
+}
+
+// pollGitHubRepository handles the changes of one repository's pull
+// requests. The ETags of the pull request list are only stored once every
+// change was handled, so a failed change is retried on the next poll.
+func pollGitHubRepository(db *gorm.DB, repoFullName string, handle GitHubEventHandler) error {
+	owner, repo, err := splitRepoFullName(repoFullName)
+	if err != nil {
+		return err
+	}
+	client, err := newGitHubClient(db, repoFullName)
+	if err != nil {
+		return err
+	}
+
+	// Bounds the whole poll of the repository, which makes a request per
+	// updated pull request
+	ctx, cancel := context.WithTimeout(context.Background(), 10*githubAPITimeout)
+	defer cancel()
+
+	listResource := "pulls:" + repoFullName
+	prs, etags, modified, err := listWithETags[*github.PullRequest](ctx, db, client, listResource,
+		fmt.Sprintf("repos/%s/%s/pulls?state=open&per_page=%d", owner, repo, githubPollPageSize))
+	if err != nil {
+		return fmt.Errorf("failed to list pull requests: %w", err)
+	}
+	if !modified {
+		return nil
+	}
+
+	var errs []error
+	open := map[int]bool{}
+	for _, pr := range prs {
+		open[pr.GetNumber()] = true
+		if err := pollGitHubPullRequest(ctx, db, client, repoFullName, pr, handle); err != nil {
+			errs = append(errs, fmt.Errorf("#%d: %w", pr.GetNumber(), err))
+		}
+	}
+
+	// Pull requests that left the open list were closed or merged
+	for _, number := range closedCandidates(db, repoFullName, open) {
+		if err := pollClosedPullRequest(db, repoFullName, number, handle); err != nil {
+			errs = append(errs, fmt.Errorf("#%d: %w", number, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return saveETags(db, listResource, etags)
+}
+
+// pollGitHubPullRequest passes on the labels added to and removed from an
+// open pull request and its new reviews. Labels already on a pull request
+// seen for the first time count as added, since creating a task for a label
+// is skipped when the task exists; its existing reviews do not count as new.
+func pollGitHubPullRequest(ctx context.Context, db *gorm.DB, client *github.Client, repoFullName string, pr *github.PullRequest, handle GitHubEventHandler) error {
+	var states []models.GithubPolledPullRequest
+	db.Where("repo = ? AND pr_number = ?", repoFullName, pr.GetNumber()).Limit(1).Find(&states)
+	firstSeen := len(states) == 0
+	state := models.GithubPolledPullRequest{Repo: repoFullName, PRNumber: pr.GetNumber()}
+	if !firstSeen {
+		state = states[0]
+	}
+
+	repo := pr.GetBase().GetRepo()
+	current := make([]string, 0, len(pr.Labels))
+	for _, label := range pr.Labels {
+		current = append(current, label.GetName())
+	}
+	previous := splitLabels(state.Labels)
+
+	for _, name := range current {
+		if !containsString(previous, name) {
+			if err := handle("pull_request", &github.PullRequestEvent{
+				Action: github.Ptr("labeled"), Label: &github.Label{Name: github.Ptr(name)}, PullRequest: pr, Repo: repo,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range previous {
+		if !containsString(current, name) {
+			if err := handle("pull_request", &github.PullRequestEvent{
+				Action: github.Ptr("unlabeled"), Label: &github.Label{Name: github.Ptr(name)}, PullRequest: pr, Repo: repo,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	// A review updates the pull request, so reviews are only listed then
+	if firstSeen || !pr.GetUpdatedAt().Time.Equal(state.PRUpdatedAt) {
+		reviews, etags, modified, err := listReviewsWithETags(ctx, db, client, repoFullName, pr.GetNumber())
+		if err != nil {
+			return err
+		}
+		if modified {
+			for _, review := range reviews {
+				if review.GetID() <= state.LastReviewID {
+					continue
+				}
+				state.LastReviewID = review.GetID()
+				// Pending reviews are not submitted yet
+				if firstSeen || review.GetState() == "PENDING" {
+					continue
+				}
+				// The API spells review states in upper case, webhooks in lower case
+				submitted := *review
+				submitted.State = github.Ptr(strings.ToLower(review.GetState()))
+				if err := handle("pull_request_review", &github.PullRequestReviewEvent{
+					Action: github.Ptr("submitted"), Review: &submitted, PullRequest: pr, Repo: repo,
+				}); err != nil {
+					return err
+				}
+			}
+			if err := saveETags(db, reviewsResource(repoFullName, pr.GetNumber()), etags); err != nil {
+				return err
+			}
+		}
+	}
+
+	state.Labels = strings.Join(current, ",")
+	state.PRUpdatedAt = pr.GetUpdatedAt().Time
+	return db.Save(&state).Error
+}
+
+// listReviewsWithETags returns the reviews of a pull request in submission
+// order, or modified false when they did not change since the stored ETags.
+func listReviewsWithETags(ctx context.Context, db *gorm.DB, client *github.Client, repoFullName string, number int) ([]*github.PullRequestReview, []string, bool, error) {
+	owner, repo, err := splitRepoFullName(repoFullName)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	reviews, etags, modified, err := listWithETags[*github.PullRequestReview](ctx, db, client, reviewsResource(repoFullName, number),
+		fmt.Sprintf("repos/%s/%s/pulls/%d/reviews?per_page=%d", owner, repo, number, githubPollPageSize))
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to list reviews: %w", err)
+	}
+	return reviews, etags, modified, nil
+}
+
+// listWithETags lists every page of the API path, sending each page the ETag
+// stored for it. When GitHub answers 304 Not Modified for every page,
+// modified is false and nothing is returned; otherwise the unchanged pages
+// are fetched again, and all items are returned with the ETag of each page,
+// to be stored with saveETags once they have been handled.
+//
+// A 304 carries no Link header, so the walk goes past an unchanged page only
+// while an ETag is stored for the next one. A full last page is followed by
+// the empty page after it, so items appended to a list that ended on a page
+// boundary still show up as a change.
+func listWithETags[T any](ctx context.Context, db *gorm.DB, client *github.Client, resource, path string) ([]T, []string, bool, error) {
+	var pages [][]T
+	var etags []string
+	var unchanged []int
+	for page := 1; ; page++ {
+		var items []T
+		etag, modified, resp, err := getWithETag(ctx, client, pagePath(path, page), storedETag(db, pageResource(resource, page)), &items)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		pages = append(pages, items)
+		etags = append(etags, etag)
+		if !modified {
+			unchanged = append(unchanged, page)
+			if storedETag(db, pageResource(resource, page+1)) == "" {
+				break
+			}
+			continue
+		}
+		if resp.NextPage == 0 && len(items) < githubPollPageSize {
+			break
+		}
+	}
+	if len(unchanged) == len(pages) {
+		return nil, nil, false, nil
+	}
+
+	for _, page := range unchanged {
+		var items []T
+		etag, _, _, err := getWithETag(ctx, client, pagePath(path, page), "", &items)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		pages[page-1], etags[page-1] = items, etag
+	}
+	var all []T
+	for _, items := range pages {
+		all = append(all, items...)
+	}
+	return all, etags, true, nil
+}
+
+// closedCandidates returns the pull requests of the repository that the
+// poller or an active task knows of but that are no longer open.
+func closedCandidates(db *gorm.DB, repoFullName string, open map[int]bool) []int {
+	var numbers []int
+	db.Model(&models.GithubPolledPullRequest{}).Where("repo = ?", repoFullName).Pluck("pr_number", &numbers)
+	var taskNumbers []int
+	db.Model(&models.ReviewTask{}).Where("repo = ? AND status IN ?", repoFullName, activePollTaskStatuses).
+		Distinct().Pluck("pr_number", &taskNumbers)
+	numbers = append(numbers, taskNumbers...)
+
+	seen := map[int]bool{}
+	var closed []int
+	for _, number := range numbers {
+		if !open[number] && !seen[number] {
+			seen[number] = true
+			closed = append(closed, number)
+		}
+	}
+	sort.Ints(closed)
+	return closed
+}
+
+// pollClosedPullRequest passes on the closing of a pull request that left
+// the open list, and forgets it.
+func pollClosedPullRequest(db *gorm.DB, repoFullName string, number int, handle GitHubEventHandler) error {
+	pr, err := GetPullRequest(db, repoFullName, number)
+	if err != nil {
+		return err
+	}
+	if pr.GetState() != "closed" {
+		// Opened again since the list was fetched; the next poll sees it
+		return nil
+	}
+	if err := handle("pull_request", &github.PullRequestEvent{
+		Action: github.Ptr("closed"), PullRequest: pr, Repo: pr.GetBase().GetRepo(),
+	}); err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repo = ? AND pr_number = ?", repoFullName, number).Delete(&models.GithubPolledPullRequest{}).Error; err != nil {
+			return err
+		}
+		resource := reviewsResource(repoFullName, number)
+		return tx.Where("resource = ? OR resource LIKE ?", resource, pageResource(resource, 0)+"%").Delete(&models.GithubPollETag{}).Error
+	})
+}
+
+// getWithETag GETs the API path into v, sending etag as If-None-Match
+// unless it is empty. modified is false when GitHub answered 304 Not
+// Modified; otherwise the response's ETag is returned.
+func getWithETag(ctx context.Context, client *github.Client, path, etag string, v interface{}) (string, bool, *github.Response, error) {
+	req, err := client.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return "", false, nil, err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := client.Do(ctx, req, v)
+	if resp != nil && resp.StatusCode == http.StatusNotModified {
+		return "", false, resp, nil
+	}
+	if err != nil {
+		return "", false, resp, err
+	}
+	return resp.Header.Get("ETag"), true, resp, nil
+}
+
+// storedETag returns the ETag stored for resource, or "".
+func storedETag(db *gorm.DB, resource string) string {
+	var stored []models.GithubPollETag
+	db.Where("resource = ?", resource).Limit(1).Find(&stored)
+	if len(stored) == 0 {
+		return ""
+	}
+	return stored[0].ETag
+}
+
+// saveETags stores the ETags of the handled pages of resource, and forgets
+// those of pages the list no longer has.
+func saveETags(db *gorm.DB, resource string, etags []string) error {
+	for i, etag := range etags {
+		if err := saveETag(db, pageResource(resource, i+1), etag); err != nil {
+			return err
+		}
+	}
+
+	var stored []string
+	if err := db.Model(&models.GithubPollETag{}).Where("resource LIKE ?", pageResource(resource, 0)+"%").
+		Pluck("resource", &stored).Error; err != nil {
+		return err
+	}
+	var stale []string
+	for _, name := range stored {
+		page, err := strconv.Atoi(strings.TrimPrefix(name, pageResource(resource, 0)))
+		if err == nil && strings.HasPrefix(name, pageResource(resource, 0)) && page > len(etags) {
+			stale = append(stale, name)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	return db.Where("resource IN ?", stale).Delete(&models.GithubPollETag{}).Error
+}
+
+// saveETag stores the ETag of the handled response for resource.
+func saveETag(db *gorm.DB, resource, etag string) error {
+	if etag == "" {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "resource"}},
+		DoUpdates: clause.AssignmentColumns([]string{"e_tag", "updated_at"}),
+	}).Create(&models.GithubPollETag{Resource: resource, ETag: etag}).Error
+}
+
+// pageResource names a page of a list in GithubPollETag. The first page
+// keeps the name of the list; page 0 gives the prefix of the others.
+func pageResource(resource string, page int) string {
+	switch page {
+	case 0:
+		return resource + "?page="
+	case 1:
+		return resource
+	default:
+		return fmt.Sprintf("%s?page=%d", resource, page)
+	}
+}
+
+// pagePath adds the page to an API path that already has a query.
+func pagePath(path string, page int) string {
+	if page == 1 {
+		return path
+	}
+	return fmt.Sprintf("%s&page=%d", path, page)
+}
+
+// reviewsResource names the reviews of a pull request in GithubPollETag.
+func reviewsResource(repoFullName string, number int) string {
+	return fmt.Sprintf("reviews:%s#%d", repoFullName, number)
+}
+
+func splitLabels(labels string) []string {
+	if labels == "" {
+		return nil
+	}
+	return strings.Split(labels, ",")
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
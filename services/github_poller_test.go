@@ -0,0 +1,267 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slack-review-notify/models"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v71/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// polledEvent is an event passed on by the GitHub poller, in short.
+type polledEvent struct {
+	Action string
+	Label  string
+	State  string
+	PR     int
+}
+
+func recordPolledEvents(events *[]polledEvent) GitHubEventHandler {
+	return func(eventType string, event interface{}) error {
+		switch e := event.(type) {
+		case *github.PullRequestEvent:
+			*events = append(*events, polledEvent{Action: e.GetAction(), Label: e.GetLabel().GetName(), PR: e.GetPullRequest().GetNumber()})
+		case *github.PullRequestReviewEvent:
+			*events = append(*events, polledEvent{Action: e.GetAction(), State: e.GetReview().GetState(), PR: e.GetPullRequest().GetNumber()})
+		}
+		return nil
+	}
+}
+
+// fakeGitHubPulls serves the open pull requests and reviews of a repository,
+// answering 304 Not Modified when the client sends the current ETag.
+type fakeGitHubPulls struct {
+	mu          sync.Mutex
+	pulls       string
+	pullsETag   string
+	reviews     string
+	reviewsETag string
+	closed      string
+	requests    int
+}
+
+func (f *fakeGitHubPulls) set(pulls, pullsETag, reviews, reviewsETag string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pulls, f.pullsETag, f.reviews, f.reviewsETag = pulls, pullsETag, reviews, reviewsETag
+}
+
+func (f *fakeGitHubPulls) serve(body, etag func() string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.requests++
+		if r.Header.Get("If-None-Match") == etag() {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag())
+		_, _ = io.WriteString(w, body())
+	}
+}
+
+func (f *fakeGitHubPulls) server(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/acme/api/pulls", f.serve(func() string { return f.pulls }, func() string { return f.pullsETag }))
+	mux.HandleFunc("GET /repos/acme/api/pulls/1/reviews", f.serve(func() string { return f.reviews }, func() string { return f.reviewsETag }))
+	mux.HandleFunc("GET /repos/acme/api/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		_, _ = io.WriteString(w, f.closed)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+const polledRepo = `"base": {"repo": {"name": "api", "full_name": "acme/api", "owner": {"login": "acme"}}}`
+
+func TestPollGitHub(t *testing.T) {
+	fake := &fakeGitHubPulls{}
+	server := fake.server(t)
+	t.Setenv("GITHUB_API_BASE_URL", server.URL)
+	t.Setenv("GITHUB_TOKEN", "test-github-token")
+	t.Setenv("GITHUB_POLL_REPOS", "")
+
+	db := setupTestDB(t)
+	require.NoError(t, db.Create(&models.ChannelConfig{
+		ID: "cfg-poll", SlackChannelID: "C1", LabelName: "needs-review", RepositoryList: "acme/api", IsActive: true,
+	}).Error)
+
+	// First sight: its labels count as added, its existing reviews do not
+	fake.set(`[{"number": 1, "state": "open", "updated_at": "2026-01-01T00:00:00Z", "labels": [{"name": "needs-review"}], `+polledRepo+`}]`, `"p1"`,
+		`[{"id": 5, "state": "APPROVED", "user": {"login": "alice"}}]`, `"r1"`)
+	var events []polledEvent
+	PollGitHub(db, recordPolledEvents(&events))
+	assert.Equal(t, []polledEvent{{Action: "labeled", Label: "needs-review", PR: 1}}, events)
+
+	// Nothing changed: GitHub answers 304 and nothing is passed on
+	events = nil
+	PollGitHub(db, recordPolledEvents(&events))
+	assert.Empty(t, events)
+	assert.Equal(t, 3, fake.requests)
+
+	// Label removed and reviews submitted
+	fake.set(`[{"number": 1, "state": "open", "updated_at": "2026-01-01T01:00:00Z", "labels": [], `+polledRepo+`}]`, `"p2"`,
+		`[{"id": 5, "state": "APPROVED"}, {"id": 6, "state": "CHANGES_REQUESTED"}, {"id": 7, "state": "PENDING"}]`, `"r2"`)
+	events = nil
+	PollGitHub(db, recordPolledEvents(&events))
+	assert.Equal(t, []polledEvent{
+		{Action: "unlabeled", Label: "needs-review", PR: 1},
+		{Action: "submitted", State: "changes_requested", PR: 1},
+	}, events)
+
+	// Closed: it left the open list
+	fake.set(`[]`, `"p3"`, `[]`, `"r3"`)
+	fake.closed = `{"number": 1, "state": "closed", "merged": true, ` + polledRepo + `}`
+	events = nil
+	PollGitHub(db, recordPolledEvents(&events))
+	assert.Equal(t, []polledEvent{{Action: "closed", PR: 1}}, events)
+
+	var states, etags int64
+	db.Model(&models.GithubPolledPullRequest{}).Count(&states)
+	db.Model(&models.GithubPollETag{}).Where("resource LIKE ?", "reviews:%").Count(&etags)
+	assert.Zero(t, states)
+	assert.Zero(t, etags)
+}
+
+func TestPollGitHub_RetriesFailedChanges(t *testing.T) {
+	fake := &fakeGitHubPulls{}
+	server := fake.server(t)
+	t.Setenv("GITHUB_API_BASE_URL", server.URL)
+	t.Setenv("GITHUB_TOKEN", "test-github-token")
+	t.Setenv("GITHUB_POLL_REPOS", "acme/api")
+
+	db := setupTestDB(t)
+	fake.set(`[{"number": 1, "state": "open", "updated_at": "2026-01-01T00:00:00Z", "labels": [{"name": "needs-review"}], `+polledRepo+`}]`, `"p1"`,
+		`[]`, `"r1"`)
+
+	PollGitHub(db, func(string, interface{}) error { return errors.New("database is locked") })
+
+	// Neither the ETag nor the state was stored, so the label is passed on again
+	var events []polledEvent
+	PollGitHub(db, recordPolledEvents(&events))
+	assert.Equal(t, []polledEvent{{Action: "labeled", Label: "needs-review", PR: 1}}, events)
+}
+
+// fakeGitHubPages serves a list in pages linked by the Link header, each
+// page with its own ETag, the way GitHub paginates.
+type fakeGitHubPages struct {
+	mu    sync.Mutex
+	pages map[string][][2]string // path -> body and ETag of each page
+}
+
+func (f *fakeGitHubPages) set(path string, pages ...[2]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pages[path] = pages
+}
+
+func (f *fakeGitHubPages) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pages, ok := f.pages[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	page := 1
+	if p := r.URL.Query().Get("page"); p != "" {
+		page, _ = strconv.Atoi(p)
+	}
+	body, etag := `[]`, `"empty"`
+	if page <= len(pages) {
+		body, etag = pages[page-1][0], pages[page-1][1]
+	}
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if page < len(pages) {
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?page=%d>; rel="next"`, r.Host, r.URL.Path, page+1))
+	}
+	w.Header().Set("ETag", etag)
+	_, _ = io.WriteString(w, body)
+}
+
+func TestPollGitHub_ChangeOnLaterPage(t *testing.T) {
+	fake := &fakeGitHubPages{pages: map[string][][2]string{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	t.Setenv("GITHUB_API_BASE_URL", server.URL)
+	t.Setenv("GITHUB_TOKEN", "test-github-token")
+	t.Setenv("GITHUB_POLL_REPOS", "acme/api")
+
+	db := setupTestDB(t)
+	pr := func(number int, updatedAt, labels string) string {
+		return fmt.Sprintf(`{"number": %d, "state": "open", "updated_at": %q, "labels": [%s], %s}`, number, updatedAt, labels, polledRepo)
+	}
+	fake.set("/repos/acme/api/pulls",
+		[2]string{`[` + pr(1, "2026-01-01T00:00:00Z", "") + `]`, `"p1"`},
+		[2]string{`[` + pr(2, "2026-01-01T00:00:00Z", "") + `]`, `"p2"`})
+	fake.set("/repos/acme/api/pulls/1/reviews", [2]string{`[]`, `"r1"`})
+	fake.set("/repos/acme/api/pulls/2/reviews",
+		[2]string{`[{"id": 1, "state": "COMMENTED"}]`, `"r2-1"`},
+		[2]string{`[{"id": 2, "state": "COMMENTED"}]`, `"r2-2"`})
+	var events []polledEvent
+	PollGitHub(db, recordPolledEvents(&events))
+	assert.Empty(t, events)
+
+	// Only the second pages change: the first ones answer 304
+	fake.set("/repos/acme/api/pulls",
+		[2]string{`[` + pr(1, "2026-01-01T00:00:00Z", "") + `]`, `"p1"`},
+		[2]string{`[` + pr(2, "2026-01-01T01:00:00Z", `{"name": "needs-review"}`) + `]`, `"p2b"`})
+	fake.set("/repos/acme/api/pulls/2/reviews",
+		[2]string{`[{"id": 1, "state": "COMMENTED"}]`, `"r2-1"`},
+		[2]string{`[{"id": 2, "state": "COMMENTED"}, {"id": 3, "state": "APPROVED"}]`, `"r2-2b"`})
+	events = nil
+	PollGitHub(db, recordPolledEvents(&events))
+	assert.Equal(t, []polledEvent{
+		{Action: "labeled", Label: "needs-review", PR: 2},
+		{Action: "submitted", State: "approved", PR: 2},
+	}, events)
+
+	// PR #1 on the unchanged first page is still known, not closed
+	var states int64
+	db.Model(&models.GithubPolledPullRequest{}).Count(&states)
+	assert.Equal(t, int64(2), states)
+
+	var etag models.GithubPollETag
+	require.NoError(t, db.Where("resource = ?", "pulls:acme/api?page=2").First(&etag).Error)
+	assert.Equal(t, `"p2b"`, etag.ETag)
+}
+
+func TestGitHubPollRepositories(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.Create(&[]models.ChannelConfig{
+		{ID: "cfg-1", SlackChannelID: "C1", LabelName: "a", RepositoryList: "acme/web, acme/api", IsActive: true},
+		{ID: "cfg-2", SlackChannelID: "C2", LabelName: "b", RepositoryList: "acme/api", IsActive: true},
+		{ID: "cfg-3", SlackChannelID: "C3", LabelName: "c", RepositoryList: "acme/old"},
+	}).Error)
+	require.NoError(t, db.Model(&models.ChannelConfig{}).Where("id = ?", "cfg-3").Update("is_active", false).Error)
+
+	t.Setenv("GITHUB_POLL_REPOS", "")
+	assert.Equal(t, []string{"acme/api", "acme/web"}, GitHubPollRepositories(db))
+
+	t.Setenv("GITHUB_POLL_REPOS", "octo/docs,acme/api")
+	assert.Equal(t, []string{"octo/docs", "acme/api"}, GitHubPollRepositories(db))
+}
+
+func TestGitHubPollInterval(t *testing.T) {
+	t.Setenv("GITHUB_POLL_INTERVAL", "")
+	assert.Zero(t, GitHubPollInterval())
+	t.Setenv("GITHUB_POLL_INTERVAL", "5m")
+	assert.Equal(t, "5m0s", GitHubPollInterval().String())
+	t.Setenv("GITHUB_POLL_INTERVAL", "10s")
+	assert.Equal(t, "1m0s", GitHubPollInterval().String())
+	t.Setenv("GITHUB_POLL_INTERVAL", "often")
+	assert.Zero(t, GitHubPollInterval())
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slack-review-notify/models"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v71/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// reviewerRequestStub records the requested_reviewers calls made against it.
+type reviewerRequestStub struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (s *reviewerRequestStub) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Reviewers []string `json:"reviewers"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+strings.Join(body.Reviewers, ","))
+		s.mu.Unlock()
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"number": 5})
+	}
+}
+
+func setupGitHubSyncTest(t *testing.T, syncEnabled bool) (*gorm.DB, *reviewerRequestStub, models.ReviewTask) {
+	db := setupTestDB(t)
+	db.Create(&models.ChannelConfig{
+		ID:                    "github-sync-id",
+		SlackChannelID:        "C_SYNC",
+		LabelName:             "needs-review",
+		SyncReviewersToGitHub: syncEnabled,
+		IsActive:              true,
+	})
+	db.Create(&models.UserMapping{ID: "m1", GithubUsername: "alice", SlackUserID: "UALICE"})
+	db.Create(&models.UserMapping{ID: "m2", GithubUsername: "bob", SlackUserID: "UBOB"})
+
+	stub := &reviewerRequestStub{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/pulls/5/requested_reviewers", stub.handler(t))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	t.Setenv("GITHUB_API_BASE_URL", server.URL)
+	t.Setenv("GITHUB_TOKEN", "test-github-token")
+
+	task := models.ReviewTask{
+		ID:           "sync-task",
+		Repo:         "owner/repo",
+		PRNumber:     5,
+		SlackChannel: "C_SYNC",
+		LabelName:    "needs-review",
+	}
+	return db, stub, task
+}
+
+func TestPushReviewersToGitHub(t *testing.T) {
+	db, stub, task := setupGitHubSyncTest(t, true)
+
+	// UNOMAP has no GitHub account and is skipped.
+	PushReviewersToGitHub(db, task, []string{"UALICE", "UNOMAP"}, nil)
+	// A reviewer change withdraws the old request before adding the new one.
+	PushReviewersToGitHub(db, task, []string{"UBOB"}, []string{"UALICE"})
+
+	assert.Equal(t, []string{"POST alice", "DELETE alice", "POST bob"}, stub.requests)
+}
+
+func TestPushReviewersToGitHub_DisabledByChannelConfig(t *testing.T) {
+	db, stub, task := setupGitHubSyncTest(t, false)
+
+	PushReviewersToGitHub(db, task, []string{"UALICE"}, nil)
+
+	assert.Empty(t, stub.requests)
+}
+
+// resetInstallationTokenCache empties the installation token cache for the
+// test and again when it ends, so no token outlives the server that minted it.
+func resetInstallationTokenCache(t *testing.T) {
+	reset := func() {
+		installationTokenCache.Lock()
+		installationTokenCache.tokens = map[string]cachedInstallationToken{}
+		installationTokenCache.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestGitHubToken_GitHubAppInstallation(t *testing.T) {
+	resetInstallationTokenCache(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	mux := http.NewServeMux()
+	// Runs on the server's goroutines, so failures are recorded with assert
+	mux.HandleFunc("/app/installations/{id}/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls[r.PathValue("id")]++
+		mu.Unlock()
+		assert.Equal(t, http.MethodPost, r.Method)
+
+		jwt, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		assert.True(t, ok)
+		parts := strings.Split(jwt, ".")
+		if !assert.Len(t, parts, 3) {
+			http.Error(w, "malformed jwt", http.StatusUnauthorized)
+			return
+		}
+		claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+		assert.NoError(t, err)
+		assert.Contains(t, string(claims), `"iss":"12345"`)
+
+		_, _ = io.WriteString(w, `{"token":"installation-token-`+r.PathValue("id")+`","expires_at":"`+
+			time.Now().Add(time.Hour).UTC().Format(time.RFC3339)+`"}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Setenv("GITHUB_API_BASE_URL", server.URL)
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_APP_ID", "12345")
+	t.Setenv("GITHUB_APP_INSTALLATION_ID", "42")
+	t.Setenv("GITHUB_APP_PRIVATE_KEY", string(keyPEM))
+
+	db := setupTestDB(t)
+	require.NoError(t, SaveGitHubInstallation(db, &github.Installation{ID: github.Ptr(int64(43))},
+		[]*github.Repository{{FullName: github.Ptr("acme/api")}}))
+
+	assert.True(t, IsGitHubAPIEnabled())
+
+	// A repository of a recorded installation uses that installation's token
+	token, err := githubToken(db, "acme/api")
+	require.NoError(t, err)
+	assert.Equal(t, "installation-token-43", token)
+
+	// Other repositories fall back to GITHUB_APP_INSTALLATION_ID
+	token, err = githubToken(db, "other/repo")
+	require.NoError(t, err)
+	assert.Equal(t, "installation-token-42", token)
+
+	// The cached token is reused until shortly before it expires.
+	token, err = githubToken(db, "other/repo")
+	require.NoError(t, err)
+	assert.Equal(t, "installation-token-42", token)
+	mu.Lock()
+	assert.Equal(t, map[string]int{"42": 1, "43": 1}, calls)
+	mu.Unlock()
+
+	// Without the fallback, repositories of no installation have no token
+	t.Setenv("GITHUB_APP_INSTALLATION_ID", "")
+	_, err = githubToken(db, "other/repo")
+	assert.Error(t, err)
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Names of the background loops whose liveness is reported by /healthz and
+// /readyz.
+const (
+	LoopTaskChecker    = "task_checker"
+	LoopTaskCleanup    = "task_cleanup"
+	LoopChannelChecker = "channel_checker"
+	LoopSlackOutbox    = "slack_outbox"
+	LoopGitHubPoller   = "github_poller"
+	LoopTaskReconciler = "task_reconciler"
+)
+
+// defaultLoopStaleIntervals is how many intervals a loop may go without
+// completing before it counts as stalled, unless LOOP_STALE_INTERVALS is set.
+const defaultLoopStaleIntervals = 3
+
+// LoopStatus reports the liveness of a background loop.
+type LoopStatus struct {
+	Name          string
+	Interval      time.Duration
+	LastCompleted *time.Time // nil until the first pass completes
+	Stalled       bool
+}
+
+type loopState struct {
+	interval      time.Duration
+	registeredAt  time.Time
+	lastCompleted *time.Time
+}
+
+// loopRegistry tracks when each background loop last completed a pass.
+type loopRegistry struct {
+	mu    sync.Mutex
+	loops map[string]*loopState
+}
+
+var backgroundLoops = &loopRegistry{loops: make(map[string]*loopState)}
+
+// RegisterLoop starts tracking a background loop that completes a pass every
+// interval. Until its first pass, the loop is measured from registration.
+func RegisterLoop(name string, interval time.Duration) {
+	backgroundLoops.register(name, interval, time.Now())
+}
+
+// MarkLoopCompleted records that the loop has just completed a pass.
+func MarkLoopCompleted(name string) {
+	backgroundLoops.markCompleted(name, time.Now())
+}
+
+// LoopStatuses returns the liveness of every registered loop, sorted by name.
+func LoopStatuses() []LoopStatus {
+	return backgroundLoops.statuses(time.Now(), LoopStaleIntervals())
+}
+
+// LoopStaleIntervals returns how many missed intervals make a loop stalled,
+// from LOOP_STALE_INTERVALS (default 3).
+func LoopStaleIntervals() int {
+	if n, err := strconv.Atoi(os.Getenv("LOOP_STALE_INTERVALS")); err == nil && n > 0 {
+		return n
+	}
+	return defaultLoopStaleIntervals
+}
+
+func (r *loopRegistry) register(name string, interval time.Duration, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loops[name] = &loopState{interval: interval, registeredAt: now}
+}
+
+func (r *loopRegistry) markCompleted(name string, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if state, ok := r.loops[name]; ok {
+		state.lastCompleted = &now
+	}
+}
+
+func (r *loopRegistry) statuses(now time.Time, staleIntervals int) []LoopStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statuses := make([]LoopStatus, 0, len(r.loops))
+	for name, state := range r.loops {
+		since := state.registeredAt
+		var lastCompleted *time.Time
+		if state.lastCompleted != nil {
+			completed := *state.lastCompleted
+			since = completed
+			lastCompleted = &completed
+		}
+		statuses = append(statuses, LoopStatus{
+			Name:          name,
+			Interval:      state.interval,
+			LastCompleted: lastCompleted,
+			Stalled:       now.Sub(since) > time.Duration(staleIntervals)*state.interval,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
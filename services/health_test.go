@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoopRegistryStatuses(t *testing.T) {
+	registry := &loopRegistry{loops: make(map[string]*loopState)}
+	start := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+
+	registry.register("task_checker", time.Minute, start)
+	registry.register("channel_checker", time.Hour, start)
+	// Unknown loops are ignored
+	registry.markCompleted("unknown", start)
+
+	// Before the first pass a loop is measured from registration
+	statuses := registry.statuses(start.Add(3*time.Minute), 3)
+	if assert.Len(t, statuses, 2) {
+		assert.Equal(t, "channel_checker", statuses[0].Name)
+		assert.Equal(t, "task_checker", statuses[1].Name)
+		assert.Nil(t, statuses[1].LastCompleted)
+		assert.False(t, statuses[1].Stalled)
+	}
+	assert.True(t, registry.statuses(start.Add(3*time.Minute+time.Second), 3)[1].Stalled)
+
+	completed := start.Add(10 * time.Minute)
+	registry.markCompleted("task_checker", completed)
+	statuses = registry.statuses(completed.Add(2*time.Minute), 3)
+	assert.Equal(t, &completed, statuses[1].LastCompleted)
+	assert.False(t, statuses[1].Stalled)
+	// The hourly loop has not run for 12 minutes, well within its intervals
+	assert.False(t, statuses[0].Stalled)
+
+	assert.True(t, registry.statuses(completed.Add(2*time.Minute), 1)[1].Stalled)
+}
+
+func TestLoopStaleIntervals(t *testing.T) {
+	t.Setenv("LOOP_STALE_INTERVALS", "")
+	assert.Equal(t, 3, LoopStaleIntervals())
+
+	t.Setenv("LOOP_STALE_INTERVALS", "5")
+	assert.Equal(t, 5, LoopStaleIntervals())
+
+	t.Setenv("LOOP_STALE_INTERVALS", "0")
+	assert.Equal(t, 3, LoopStaleIntervals())
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"slack-review-notify/models"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+)
+
+// metricsNamespace prefixes every metric exposed on /metrics.
+const metricsNamespace = "slack_review_notify"
+
+// taskStatuses are the ReviewTask statuses always reported by the task
+// gauge, so a status with no tasks shows 0 instead of disappearing.
+var taskStatuses = []string{"pending", "in_review", "snoozed", "paused", "waiting_business_hours", "completed", "done", "archived"}
+
+var (
+	webhookEventsReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "webhook_events_received_total",
+		Help:      "GitHub webhook events received, by event type and action.",
+	}, []string{"event", "action"})
+
+	webhookEventsHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "webhook_events_handled_total",
+		Help:      "GitHub webhook events passed to a handler, by event type and action.",
+	}, []string{"event", "action"})
+
+	webhookDuplicates = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "webhook_duplicate_deliveries_total",
+		Help:      "GitHub webhook deliveries skipped as already processed, by event type and action.",
+	}, []string{"event", "action"})
+
+	slackAPIRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "slack_api_requests_total",
+		Help:      "Slack Web API calls, by API method.",
+	}, []string{"method"})
+
+	slackAPIFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "slack_api_failures_total",
+		Help:      "Slack Web API calls that failed or returned ok=false, by API method.",
+	}, []string{"method"})
+
+	outboxDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "slack_outbox_deliveries_total",
+		Help:      "Slack outbox delivery attempts, by result (sent, retried or failed).",
+	}, []string{"result"})
+
+	remindersSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "reminders_sent_total",
+		Help:      "Reviewer reminders sent, by kind (reviewer or out_of_hours).",
+	}, []string{"kind"})
+
+	taskCheckDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "task_check_duration_seconds",
+		Help:      "Duration of each pass of the periodic task checker.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 14),
+	})
+
+	pendingTaskRecoveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "pending_task_recoveries_total",
+		Help:      "Stale pending tasks handled by the recovery sweep, by result (reposted, finished, deleted or failed).",
+	}, []string{"result"})
+
+	dbLockRetries = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "db_lock_retries_total",
+		Help:      "Webhook event retries after a database lock error.",
+	})
+)
+
+// MetricsHandler serves the Prometheus metrics.
+func MetricsHandler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RecordWebhookReceived counts a received GitHub webhook event.
+func RecordWebhookReceived(event, action string) {
+	webhookEventsReceived.WithLabelValues(event, action).Inc()
+}
+
+// RecordWebhookHandled counts a GitHub webhook event passed to a handler.
+func RecordWebhookHandled(event, action string) {
+	webhookEventsHandled.WithLabelValues(event, action).Inc()
+}
+
+// RecordWebhookDuplicate counts a GitHub webhook delivery skipped as a
+// duplicate.
+func RecordWebhookDuplicate(event, action string) {
+	webhookDuplicates.WithLabelValues(event, action).Inc()
+}
+
+// RecordOutboxDelivery counts a Slack outbox delivery attempt by its result.
+func RecordOutboxDelivery(result string) {
+	outboxDeliveries.WithLabelValues(result).Inc()
+}
+
+// RecordReminderSent counts a sent reminder of the given kind.
+func RecordReminderSent(kind string) {
+	remindersSent.WithLabelValues(kind).Inc()
+}
+
+// ObserveTaskCheckDuration records how long a task checker pass took.
+func ObserveTaskCheckDuration(d time.Duration) {
+	taskCheckDuration.Observe(d.Seconds())
+}
+
+// RecordPendingTaskRecovery counts a stale pending task handled by the
+// recovery sweep.
+func RecordPendingTaskRecovery(result string) {
+	pendingTaskRecoveries.WithLabelValues(result).Inc()
+}
+
+// RecordDBLockRetry counts a retry after a database lock error.
+func RecordDBLockRetry() {
+	dbLockRetries.Inc()
+}
+
+// doSlackAPIRequest sends a Slack Web API request and counts it, and its
+// failure (transport error, HTTP error or ok=false), under the API method
+// named by the last path segment. The returned body can still be read.
+func doSlackAPIRequest(req *http.Request) (*http.Response, error) {
+	method := path.Base(req.URL.Path)
+	slackAPIRequests.WithLabelValues(method).Inc()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		slackAPIFailures.WithLabelValues(method).Inc()
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		slackAPIFailures.WithLabelValues(method).Inc()
+		return resp, nil
+	}
+
+	var result struct {
+		OK bool `json:"ok"`
+	}
+	if resp.StatusCode >= http.StatusBadRequest || json.Unmarshal(body, &result) != nil || !result.OK {
+		slackAPIFailures.WithLabelValues(method).Inc()
+	}
+	return resp, nil
+}
+
+// taskStatusCollector reports the number of ReviewTask rows per status,
+// queried when metrics are scraped.
+type taskStatusCollector struct {
+	db   *gorm.DB
+	desc *prometheus.Desc
+}
+
+// RegisterTaskMetrics adds the per-status task gauge to the metrics.
+func RegisterTaskMetrics(db *gorm.DB) error {
+	return prometheus.Register(newTaskStatusCollector(db))
+}
+
+func newTaskStatusCollector(db *gorm.DB) *taskStatusCollector {
+	return &taskStatusCollector{
+		db: db,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "tasks"),
+			"Review tasks in the database, by status.",
+			[]string{"status"}, nil,
+		),
+	}
+}
+
+func (c *taskStatusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *taskStatusCollector) Collect(ch chan<- prometheus.Metric) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := c.db.Model(&models.ReviewTask{}).Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error; err != nil {
+		log.Printf("failed to count tasks for metrics: %v", err)
+		return
+	}
+
+	counts := make(map[string]int64)
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	for _, status := range taskStatuses {
+		if _, ok := counts[status]; !ok {
+			counts[status] = 0
+		}
+	}
+	for status, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), status)
+	}
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slack-review-notify/models"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoSlackAPIRequest_CountsFailures(t *testing.T) {
+	replies := []string{`{"ok": true}`, `{"ok": false, "error": "channel_not_found"}`}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, replies[0])
+		replies = replies[1:]
+	}))
+	defer server.Close()
+
+	requests := testutil.ToFloat64(slackAPIRequests.WithLabelValues("chat.postMessage"))
+	failures := testutil.ToFloat64(slackAPIFailures.WithLabelValues("chat.postMessage"))
+
+	for range 2 {
+		req, err := http.NewRequest("POST", server.URL+"/api/chat.postMessage", nil)
+		require.NoError(t, err)
+		resp, err := doSlackAPIRequest(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+
+	assert.Equal(t, requests+2, testutil.ToFloat64(slackAPIRequests.WithLabelValues("chat.postMessage")))
+	assert.Equal(t, failures+1, testutil.ToFloat64(slackAPIFailures.WithLabelValues("chat.postMessage")))
+	assert.Empty(t, replies)
+}
+
+func TestTaskStatusCollector(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.ReviewTask{ID: "m1", Status: "in_review"})
+	db.Create(&models.ReviewTask{ID: "m2", Status: "in_review"})
+	db.Create(&models.ReviewTask{ID: "m3", Status: "pending"})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(newTaskStatusCollector(db))
+
+	expected := `
+# HELP slack_review_notify_tasks Review tasks in the database, by status.
+# TYPE slack_review_notify_tasks gauge
+slack_review_notify_tasks{status="archived"} 0
+slack_review_notify_tasks{status="completed"} 0
+slack_review_notify_tasks{status="done"} 0
+slack_review_notify_tasks{status="in_review"} 2
+slack_review_notify_tasks{status="paused"} 0
+slack_review_notify_tasks{status="pending"} 1
+slack_review_notify_tasks{status="snoozed"} 0
+slack_review_notify_tasks{status="waiting_business_hours"} 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected)))
+}
@@ -1,24 +1,21 @@
 package services
 
 import (
-	"os"
+	"slack-review-notify/database/dbtest"
 	"slack-review-notify/models"
 	"testing"
 	"time"
 
-	"github.com/h2non/gock"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 // Create a database for testing
 func setupOutOfHoursTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	assert.NoError(t, err)
+	db := dbtest.Open(t)
 
 	// Run migrations
-	err = db.AutoMigrate(&models.ReviewTask{}, &models.ChannelConfig{})
+	err := db.AutoMigrate(&models.ReviewTask{}, &models.ChannelConfig{})
 	assert.NoError(t, err)
 
 	return db
@@ -60,29 +57,12 @@ func TestCheckInReviewTasks_OutOfHoursReminder(t *testing.T) {
 	}
 	db.Create(&task)
 
-	// Stub the Slack API so the reminder send does not depend on real network
-	// reachability. Both the in-hours and out-of-hours reminder paths post to
-	// chat.postMessage; without this the test flakes (or hangs on a dial timeout)
-	// when the CI runner cannot reach slack.com.
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() { _ = os.Setenv("SLACK_BOT_TOKEN", originalToken) }()
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	defer gock.Off()
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Persist().
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
-	// The in-hours reminder path also checks whether the channel is archived.
-	gock.New("https://slack.com").
-		Get("/api/conversations.info").
-		Persist().
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true, "channel": map[string]interface{}{"is_archived": false}})
+	// Record the reminder instead of calling Slack. Both the in-hours and
+	// out-of-hours reminder paths post to the task's thread.
+	slack := NewFakeSlackClient()
 
 	// Execute CheckInReviewTasks
-	CheckInReviewTasks(db)
+	CheckInReviewTasks(db, slack)
 
 	// Verify task state
 	var updatedTask models.ReviewTask
@@ -91,6 +71,7 @@ func TestCheckInReviewTasks_OutOfHoursReminder(t *testing.T) {
 	// Verify that UpdatedAt has been updated (some kind of reminder was sent)
 	assert.True(t, updatedTask.UpdatedAt.After(oldTime),
 		"UpdatedAt was not updated (no reminder was sent)")
+	assert.Len(t, slack.Messages(), 1)
 
 	// Check out-of-hours determination at the current time and log the output
 	now := time.Now()
@@ -1,12 +1,10 @@
 package services
 
 import (
-	"os"
 	"slack-review-notify/models"
 	"testing"
 	"time"
 
-	"github.com/h2non/gock"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,17 +17,7 @@ func TestCheckPendingReReviewNotifications_SendsDuringBusinessHours(t *testing.T
 	}
 
 	db := setupTestDB(t)
-	IsTestMode = true
-
-	// Mock Slack API
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() { _ = os.Setenv("SLACK_BOT_TOKEN", originalToken) }()
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-	defer gock.Off()
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
+	slack := NewFakeSlackClient()
 
 	// Create channel config with 24h business hours (always within business hours on weekdays)
 	config := models.ChannelConfig{
@@ -62,7 +50,7 @@ func TestCheckPendingReReviewNotifications_SendsDuringBusinessHours(t *testing.T
 	}
 	db.Create(&task)
 
-	CheckPendingReReviewNotifications(db)
+	CheckPendingReReviewNotifications(db, slack)
 
 	var updatedTask models.ReviewTask
 	db.Where("id = ?", "pending-rr-task").First(&updatedTask)
@@ -73,8 +61,6 @@ func TestCheckPendingReReviewNotifications_SendsDuringBusinessHours(t *testing.T
 
 func TestCheckPendingReReviewNotifications_SkipsOutsideBusinessHours(t *testing.T) {
 	db := setupTestDB(t)
-	IsTestMode = true
-
 	loc, _ := time.LoadLocation("Asia/Tokyo")
 	now := time.Now().In(loc)
 	// Use a narrow window (03:00-03:01) to ensure we're almost always outside
@@ -116,7 +102,7 @@ func TestCheckPendingReReviewNotifications_SkipsOutsideBusinessHours(t *testing.
 	}
 	db.Create(&task)
 
-	CheckPendingReReviewNotifications(db)
+	CheckPendingReReviewNotifications(db, NewFakeSlackClient())
 
 	var updatedTask models.ReviewTask
 	db.Where("id = ?", "pending-rr-bh-task").First(&updatedTask)
@@ -127,8 +113,6 @@ func TestCheckPendingReReviewNotifications_SkipsOutsideBusinessHours(t *testing.
 
 func TestCheckPendingReReviewNotifications_NoPendingTasks(t *testing.T) {
 	db := setupTestDB(t)
-	IsTestMode = true
-
 	task := models.ReviewTask{
 		ID:                    "no-pending-task",
 		PRURL:                 "https://github.com/owner/repo/pull/602",
@@ -145,7 +129,7 @@ func TestCheckPendingReReviewNotifications_NoPendingTasks(t *testing.T) {
 	}
 	db.Create(&task)
 
-	CheckPendingReReviewNotifications(db)
+	CheckPendingReReviewNotifications(db, NewFakeSlackClient())
 
 	var updatedTask models.ReviewTask
 	db.Where("id = ?", "no-pending-task").First(&updatedTask)
@@ -154,8 +138,6 @@ func TestCheckPendingReReviewNotifications_NoPendingTasks(t *testing.T) {
 
 func TestCheckPendingReReviewNotifications_SkipsCompletedTasks(t *testing.T) {
 	db := setupTestDB(t)
-	IsTestMode = true
-
 	// Task with pending flag but already completed — should NOT be processed
 	task := models.ReviewTask{
 		ID:                      "completed-pending-task",
@@ -175,7 +157,7 @@ func TestCheckPendingReReviewNotifications_SkipsCompletedTasks(t *testing.T) {
 	}
 	db.Create(&task)
 
-	CheckPendingReReviewNotifications(db)
+	CheckPendingReReviewNotifications(db, NewFakeSlackClient())
 
 	// Pending flag should remain (task was filtered out by status)
 	var updatedTask models.ReviewTask
@@ -185,8 +167,6 @@ func TestCheckPendingReReviewNotifications_SkipsCompletedTasks(t *testing.T) {
 
 func TestCheckPendingReReviewNotifications_ClearsOnMissingConfig(t *testing.T) {
 	db := setupTestDB(t)
-	IsTestMode = true
-
 	// No config created — config lookup will fail
 	task := models.ReviewTask{
 		ID:                      "orphan-pending-task",
@@ -206,7 +186,7 @@ func TestCheckPendingReReviewNotifications_ClearsOnMissingConfig(t *testing.T) {
 	}
 	db.Create(&task)
 
-	CheckPendingReReviewNotifications(db)
+	CheckPendingReReviewNotifications(db, NewFakeSlackClient())
 
 	// Pending flag should be cleared to avoid infinite retry
 	var updatedTask models.ReviewTask
@@ -223,21 +203,7 @@ func TestCheckPendingReReviewNotifications_MultipleNotifications(t *testing.T) {
 	}
 
 	db := setupTestDB(t)
-	IsTestMode = true
-
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() { _ = os.Setenv("SLACK_BOT_TOKEN", originalToken) }()
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-	defer gock.Off()
-	// Expect 2 Slack API calls (one per sender/reviewer pair)
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
+	slack := NewFakeSlackClient()
 
 	config := models.ChannelConfig{
 		ID:                 "config-multi-rr",
@@ -270,18 +236,17 @@ func TestCheckPendingReReviewNotifications_MultipleNotifications(t *testing.T) {
 	}
 	db.Create(&task)
 
-	CheckPendingReReviewNotifications(db)
+	CheckPendingReReviewNotifications(db, slack)
 
 	var updatedTask models.ReviewTask
 	db.Where("id = ?", "multi-rr-task").First(&updatedTask)
 	assert.False(t, updatedTask.PendingReReviewNotify, "Pending flag should be cleared")
-	assert.True(t, gock.IsDone(), "Both Slack notifications should have been sent")
+	// One notification per sender/reviewer pair
+	assert.Len(t, slack.Messages(), 2, "Both Slack notifications should have been sent")
 }
 
 func TestClearPendingReReviewFlags_CASMiss(t *testing.T) {
 	db := setupTestDB(t)
-	IsTestMode = true
-
 	now := time.Now()
 	task := models.ReviewTask{
 		ID:                      "cas-miss-task",
@@ -315,8 +280,6 @@ func TestClearPendingReReviewFlags_CASMiss(t *testing.T) {
 
 func TestClearPendingReReviewFlags_CASHit(t *testing.T) {
 	db := setupTestDB(t)
-	IsTestMode = true
-
 	now := time.Now()
 	task := models.ReviewTask{
 		ID:                      "cas-hit-task",
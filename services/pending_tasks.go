@@ -0,0 +1,275 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slack-review-notify/models"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v71/github"
+	"gorm.io/gorm"
+)
+
+// pendingTaskRecoveryAge is how long a task may stay pending without a Slack
+// message before the recovery sweep takes it over. Normal processing finishes
+// within seconds, so a row this old was left by a crash.
+const pendingTaskRecoveryAge = 10 * time.Minute
+
+// pendingTaskMaxAge is how long the sweep keeps retrying a pending task before
+// giving up and deleting it.
+const pendingTaskMaxAge = 24 * time.Hour
+
+// Results of recovering a stale pending task, reported in logs and metrics.
+const (
+	PendingRecoveryReposted = "reposted"
+	PendingRecoveryFinished = "finished"
+	PendingRecoveryDeleted  = "deleted"
+	PendingRecoveryFailed   = "failed"
+)
+
+// NotifyPendingTask posts the Slack notification for a newly created pending
+// task, assigns reviewers during business hours and moves the task to its
+// final status. The message's timestamp is saved as soon as it is posted, so
+// a task whose message was posted is finished without posting it again. It
+// returns an error when the message could not be posted or the task could
+// not be saved; the task is then left pending for the caller to delete or
+// retry.
+func NotifyPendingTask(db *gorm.DB, slack SlackClient, task models.ReviewTask, config models.ChannelConfig, pr *github.PullRequest) error {
+	var taskStatus string
+	var reviewerID string
+	var reviewersStr string
+
+	creatorGithubUsername := pr.GetUser().GetLogin()
+	creatorSlackID := GetSlackUserIDFromGitHub(db, task.TeamID, creatorGithubUsername)
+	if creatorSlackID != "" {
+		log.Printf("PR creator slack ID found: github=%s, slack=%s", creatorGithubUsername, creatorSlackID)
+	}
+
+	// Reviewers the author already requested on GitHub are assigned as-is
+	requestedIDs := GetRequestedReviewerSlackIDs(db, task.TeamID, pr, creatorSlackID)
+
+	withinBusinessHours := IsWithinBusinessHours(&config, time.Now())
+	slackTs := task.SlackTS
+	if slackTs == "" {
+		var slackChannelID string
+		var err error
+		if !withinBusinessHours {
+			// Outside business hours: send message without mention
+			slackTs, slackChannelID, err = SendSlackMessageOffHours(
+				slack,
+				task.PRURL,
+				task.Title,
+				config.SlackChannelID,
+				creatorSlackID,
+				config.Language,
+			)
+			if err != nil {
+				return fmt.Errorf("off-hours slack message failed: %w", err)
+			}
+			log.Printf("off-hours message sent: ts=%s, channel=%s", slackTs, slackChannelID)
+		} else {
+			// During business hours: send message with mention
+			slackTs, slackChannelID, err = SendSlackMessage(
+				slack,
+				task.PRURL,
+				task.Title,
+				config.SlackChannelID,
+				config.DefaultMentionID,
+				creatorSlackID,
+				config.Language,
+			)
+			if err != nil {
+				return fmt.Errorf("business hours slack message failed: %w", err)
+			}
+			log.Printf("business hours message sent: ts=%s, channel=%s", slackTs, slackChannelID)
+		}
+
+		// Save the message before anything else can fail, so that a retry
+		// finishes the task instead of posting it again
+		if err := db.Model(&models.ReviewTask{}).Where("id = ?", task.ID).Update("slack_ts", slackTs).Error; err != nil {
+			return fmt.Errorf("slack message of task %s could not be saved: %w", task.ID, err)
+		}
+	}
+
+	if !withinBusinessHours {
+		taskStatus = "waiting_business_hours"
+		// Reviewer will be set on the next business day morning; requested
+		// reviewers are kept so activation only tops them up
+		reviewersStr = strings.Join(requestedIDs, ",")
+	} else {
+		taskStatus = "in_review"
+
+		// Add PR author to exclusion ID list
+		excludeIDs := []string{}
+		if creatorSlackID != "" {
+			excludeIDs = append(excludeIDs, creatorSlackID)
+		}
+
+		// Get required number of approvals
+		requiredApprovals := config.RequiredApprovals
+		if requiredApprovals <= 0 {
+			requiredApprovals = 1
+		}
+
+		// Top up the requested reviewers, preferring the CODEOWNERS of the changed files
+		var codeownerIDs []string
+		if len(requestedIDs) < requiredApprovals {
+			codeownerIDs = GetCodeownerSlackIDs(db, task.TeamID, task.Repo, task.PRNumber, pr.GetBase().GetRef())
+		}
+		reviewerIDs := TopUpReviewers(db, task.TeamID, config.SlackChannelID, config.LabelName, requiredApprovals, requestedIDs, excludeIDs, codeownerIDs)
+		if len(reviewerIDs) > 0 {
+			reviewerID = reviewerIDs[0]
+		}
+		reviewersStr = strings.Join(reviewerIDs, ",")
+	}
+
+	// Update task to its final state
+	updates := map[string]interface{}{
+		"slack_ts":           slackTs,
+		"reviewer":           reviewerID,
+		"reviewers":          reviewersStr,
+		"pr_author_slack_id": creatorSlackID,
+		"status":             taskStatus,
+		"updated_at":         time.Now(),
+	}
+
+	if err := db.Model(&models.ReviewTask{}).Where("id = ?", task.ID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("task update failed (task: %s): %w", task.ID, err)
+	}
+
+	// Also update local object
+	task.SlackTS = slackTs
+	task.Reviewer = reviewerID
+	task.Reviewers = reviewersStr
+	task.PRAuthorSlackID = creatorSlackID
+	task.Status = taskStatus
+	task.UpdatedAt = time.Now()
+
+	log.Printf("pr registered (channel: %s): %s", config.SlackChannelID, task.PRURL)
+
+	RecordReviewEvent(db, task, ReviewEventLabeled, creatorSlackID, "")
+	if taskStatus == "in_review" && reviewersStr != "" {
+		RecordReviewerAssignments(db, task, strings.Split(reviewersStr, ","))
+	}
+
+	// Only notify in thread during business hours when a reviewer is assigned
+	if taskStatus == "in_review" && reviewerID != "" {
+		if err := PostReviewerAssignedMessageWithChangeButton(slack, task); err != nil {
+			log.Printf("reviewer assigned notification error: %v", err)
+		}
+
+		// Request the assigned reviewers on GitHub (when enabled for the config)
+		PushReviewersToGitHub(db, task, strings.Split(reviewersStr, ","), nil)
+	}
+
+	return nil
+}
+
+// RecoverStalePendingTasks finishes pending tasks left behind when the process
+// dies, or the database fails, between creating the task and moving it to its
+// final status. Such rows would otherwise block new notifications for the PR.
+// A task whose Slack message was posted is finished without posting it again.
+// Each task is retried until pendingTaskMaxAge and deleted when its PR is
+// closed, its channel config is gone or it is older than that.
+func RecoverStalePendingTasks(db *gorm.DB, slack SlackClient) {
+	now := time.Now()
+	var tasks []models.ReviewTask
+	if err := db.Where("status = ? AND updated_at < ?", "pending", now.Add(-pendingTaskRecoveryAge)).
+		Find(&tasks).Error; err != nil {
+		log.Printf("stale pending task search error: %v", err)
+		return
+	}
+
+	for _, task := range tasks {
+		// Claim the task by bumping updated_at, so a retry that is still
+		// running elsewhere is not repeated and a failed one waits for the
+		// next recovery period
+		result := db.Model(&models.ReviewTask{}).
+			Where("id = ? AND status = ? AND updated_at = ?", task.ID, "pending", task.UpdatedAt).
+			Update("updated_at", now)
+		if result.Error != nil {
+			log.Printf("stale pending task claim error (task: %s): %v", task.ID, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		outcome, err := recoverPendingTask(db, slack, task, now)
+		RecordPendingTaskRecovery(outcome)
+		if err != nil {
+			log.Printf("stale pending task %s (task: %s, pr: %s): %v", outcome, task.ID, task.PRURL, err)
+		} else {
+			log.Printf("stale pending task %s (task: %s, pr: %s)", outcome, task.ID, task.PRURL)
+		}
+	}
+}
+
+// recoverPendingTask reposts, finishes or deletes a claimed stale pending task
+// and returns what it did with the reason.
+func recoverPendingTask(db *gorm.DB, slack SlackClient, task models.ReviewTask, now time.Time) (string, error) {
+	if now.Sub(task.CreatedAt) > pendingTaskMaxAge {
+		return deletePendingTask(db, task, errors.New("gave up retrying"))
+	}
+
+	labelName := task.LabelName
+	if labelName == "" {
+		labelName = "needs-review"
+	}
+	var config models.ChannelConfig
+	if err := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ? AND is_active = ?", task.TeamID, task.SlackChannel, labelName, true).
+		First(&config).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return deletePendingTask(db, task, errors.New("no active channel config"))
+		}
+		return PendingRecoveryFailed, err
+	}
+
+	// Another task for the PR took over in the meantime
+	var count int64
+	if err := db.Model(&models.ReviewTask{}).
+		Where("id <> ? AND repo = ? AND pr_number = ? AND slack_channel = ? AND status IN (?)",
+			task.ID, task.Repo, task.PRNumber, task.SlackChannel,
+			[]string{"in_review", "snoozed", "waiting_business_hours"}).
+		Count(&count).Error; err != nil {
+		return PendingRecoveryFailed, err
+	}
+	if count > 0 {
+		return deletePendingTask(db, task, errors.New("an active task already exists"))
+	}
+
+	// Reload the PR for its author and requested reviewers; without the
+	// GitHub API the notification is posted from the stored task alone
+	pr := &github.PullRequest{
+		HTMLURL: github.Ptr(task.PRURL),
+		Title:   github.Ptr(task.Title),
+		Number:  github.Ptr(task.PRNumber),
+	}
+	if IsGitHubAPIEnabled() {
+		fetched, err := GetPullRequest(db, task.Repo, task.PRNumber)
+		if err != nil {
+			return PendingRecoveryFailed, err
+		}
+		if fetched.GetState() == "closed" {
+			return deletePendingTask(db, task, errors.New("pull request is closed"))
+		}
+		pr = fetched
+	}
+
+	if err := NotifyPendingTask(db, slack, task, config, pr); err != nil {
+		return PendingRecoveryFailed, err
+	}
+	if task.SlackTS != "" {
+		return PendingRecoveryFinished, nil
+	}
+	return PendingRecoveryReposted, nil
+}
+
+func deletePendingTask(db *gorm.DB, task models.ReviewTask, reason error) (string, error) {
+	if err := db.Delete(&task).Error; err != nil {
+		return PendingRecoveryFailed, fmt.Errorf("delete failed: %w", err)
+	}
+	return PendingRecoveryDeleted, reason
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"errors"
+	"slack-review-notify/models"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v71/github"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestRecoverStalePendingTasks(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	db := setupTestDB(t)
+
+	now := time.Now()
+	db.Create(&models.ChannelConfig{
+		ID: "cfg-pending", SlackChannelID: "C_PENDING", LabelName: "needs-review",
+		IsActive: true, Language: "en", Timezone: "UTC",
+	})
+
+	pending := func(id, channel string, createdAt, updatedAt time.Time) models.ReviewTask {
+		return models.ReviewTask{
+			ID: id, PRURL: "https://github.com/o/r/pull/" + id, Repo: "o/r", Title: "PR " + id,
+			SlackChannel: channel, LabelName: "needs-review", Status: "pending", Language: "en",
+			CreatedAt: createdAt, UpdatedAt: updatedAt,
+		}
+	}
+	tasks := []models.ReviewTask{
+		pending("stale", "C_PENDING", now.Add(-time.Hour), now.Add(-time.Hour)),
+		// still being processed by the webhook handler
+		pending("fresh", "C_PENDING", now.Add(-time.Minute), now.Add(-time.Minute)),
+		pending("no-config", "C_GONE", now.Add(-time.Hour), now.Add(-time.Hour)),
+		pending("expired", "C_PENDING", now.Add(-48*time.Hour), now.Add(-time.Hour)),
+	}
+	tasks[0].PRNumber, tasks[1].PRNumber, tasks[2].PRNumber, tasks[3].PRNumber = 1, 2, 3, 4
+	for _, task := range tasks {
+		db.Create(&task)
+	}
+
+	slack := NewFakeSlackClient()
+
+	reposted := testutil.ToFloat64(pendingTaskRecoveries.WithLabelValues(PendingRecoveryReposted))
+	deleted := testutil.ToFloat64(pendingTaskRecoveries.WithLabelValues(PendingRecoveryDeleted))
+
+	RecoverStalePendingTasks(db, slack)
+
+	var stale models.ReviewTask
+	assert.NoError(t, db.First(&stale, "id = ?", "stale").Error)
+	assert.Equal(t, "1700000000.000001", stale.SlackTS)
+	assert.Contains(t, []string{"in_review", "waiting_business_hours"}, stale.Status)
+
+	var fresh models.ReviewTask
+	assert.NoError(t, db.First(&fresh, "id = ?", "fresh").Error)
+	assert.Equal(t, "pending", fresh.Status)
+
+	var count int64
+	db.Model(&models.ReviewTask{}).Where("id IN ?", []string{"no-config", "expired"}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	assert.Equal(t, reposted+1, testutil.ToFloat64(pendingTaskRecoveries.WithLabelValues(PendingRecoveryReposted)))
+	assert.Equal(t, deleted+2, testutil.ToFloat64(pendingTaskRecoveries.WithLabelValues(PendingRecoveryDeleted)))
+
+	// A second sweep leaves the recovered task alone
+	RecoverStalePendingTasks(db, slack)
+	assert.Equal(t, reposted+1, testutil.ToFloat64(pendingTaskRecoveries.WithLabelValues(PendingRecoveryReposted)))
+}
+
+func TestRecoverStalePendingTasks_PostFailureIsRetriedLater(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	db := setupTestDB(t)
+
+	now := time.Now()
+	db.Create(&models.ChannelConfig{
+		ID: "cfg-retry", SlackChannelID: "C_RETRY", LabelName: "needs-review",
+		IsActive: true, Language: "en", Timezone: "UTC",
+	})
+	db.Create(&models.ReviewTask{
+		ID: "retry", PRURL: "https://github.com/o/r/pull/1", Repo: "o/r", PRNumber: 1, Title: "PR",
+		SlackChannel: "C_RETRY", LabelName: "needs-review", Status: "pending",
+		CreatedAt: now.Add(-time.Hour), UpdatedAt: now.Add(-time.Hour),
+	})
+
+	slack := NewFakeSlackClient()
+	slack.Errors["chat.postMessage"] = &SlackAPIError{Method: "chat.postMessage", Code: "ratelimited"}
+
+	failed := testutil.ToFloat64(pendingTaskRecoveries.WithLabelValues(PendingRecoveryFailed))
+	RecoverStalePendingTasks(db, slack)
+	assert.Equal(t, failed+1, testutil.ToFloat64(pendingTaskRecoveries.WithLabelValues(PendingRecoveryFailed)))
+
+	var task models.ReviewTask
+	assert.NoError(t, db.First(&task, "id = ?", "retry").Error)
+	assert.Equal(t, "pending", task.Status)
+	assert.Empty(t, task.SlackTS)
+	// The claim pushes the next attempt back by a recovery period
+	assert.WithinDuration(t, now, task.UpdatedAt, 5*time.Second)
+
+	// Not retried until the recovery period passes again
+	RecoverStalePendingTasks(db, slack)
+	assert.Equal(t, failed+1, testutil.ToFloat64(pendingTaskRecoveries.WithLabelValues(PendingRecoveryFailed)))
+}
+
+func TestNotifyPendingTask_SavesMessageBeforeFinishing(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	db := setupTestDB(t)
+
+	config := models.ChannelConfig{
+		ID: "cfg-notify", SlackChannelID: "C_NOTIFY", LabelName: "needs-review",
+		IsActive: true, Language: "en", Timezone: "UTC",
+	}
+	db.Create(&config)
+	task := models.ReviewTask{
+		ID: "notify", PRURL: "https://github.com/o/r/pull/1", Repo: "o/r", PRNumber: 1, Title: "PR",
+		SlackChannel: "C_NOTIFY", LabelName: "needs-review", Status: "pending", Language: "en",
+	}
+	db.Create(&task)
+	pr := &github.PullRequest{HTMLURL: github.Ptr(task.PRURL), Title: github.Ptr(task.Title), Number: github.Ptr(1)}
+
+	// Moving the task to its final status fails after the message was posted
+	require.NoError(t, db.Callback().Update().Before("gorm:update").Register("fail_status_update", func(tx *gorm.DB) {
+		if updates, ok := tx.Statement.Dest.(map[string]interface{}); ok && updates["status"] != nil {
+			_ = tx.AddError(errors.New("database is locked"))
+		}
+	}))
+	slack := NewFakeSlackClient()
+	assert.Error(t, NotifyPendingTask(db, slack, task, config, pr))
+
+	var saved models.ReviewTask
+	require.NoError(t, db.First(&saved, "id = ?", "notify").Error)
+	assert.Equal(t, "pending", saved.Status)
+	assert.Equal(t, "1700000000.000001", saved.SlackTS)
+
+	// Once the database recovers, the sweep finishes the task without posting it again
+	require.NoError(t, db.Callback().Update().Remove("fail_status_update"))
+	db.Model(&models.ReviewTask{}).Where("id = ?", "notify").Update("updated_at", time.Now().Add(-time.Hour))
+
+	finished := testutil.ToFloat64(pendingTaskRecoveries.WithLabelValues(PendingRecoveryFinished))
+	RecoverStalePendingTasks(db, slack)
+	assert.Equal(t, finished+1, testutil.ToFloat64(pendingTaskRecoveries.WithLabelValues(PendingRecoveryFinished)))
+
+	require.NoError(t, db.First(&saved, "id = ?", "notify").Error)
+	assert.Contains(t, []string{"in_review", "waiting_business_hours"}, saved.Status)
+	assert.Equal(t, "1700000000.000001", saved.SlackTS)
+	var posted int
+	for _, msg := range slack.Messages() {
+		if msg.ThreadTS == "" {
+			posted++
+		}
+	}
+	assert.Equal(t, 1, posted)
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"log"
+	"math"
+	"slack-review-notify/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Review lifecycle event types recorded in ReviewEvent.
+const (
+	ReviewEventLabeled            = "labeled"
+	ReviewEventReviewerAssigned   = "reviewer_assigned"
+	ReviewEventReviewerReassigned = "reviewer_reassigned"
+	ReviewEventFirstReview        = "first_review"
+	ReviewEventApproved           = "approved"
+	ReviewEventFullyApproved      = "fully_approved"
+	ReviewEventCompleted          = "completed"
+	ReviewEventMerged             = "merged"
+)
+
+// reviewEventRetention is how long lifecycle events are kept for reporting.
+const reviewEventRetention = 180 * 24 * time.Hour
+
+// MaxReviewStatsDays is the longest period the stats command reports on.
+const MaxReviewStatsDays = 180
+
+// RecordReviewEvent stores a lifecycle event of the task. Failures are logged,
+// never returned, so metrics can never block the review flow.
+func RecordReviewEvent(db *gorm.DB, task models.ReviewTask, eventType, actorID, detail string) {
+	labelName := task.LabelName
+	if labelName == "" {
+		labelName = "needs-review"
+	}
+	event := models.ReviewEvent{
+		ID:           uuid.NewString(),
+		TaskID:       task.ID,
+		TeamID:       task.TeamID,
+		Repo:         task.Repo,
+		PRNumber:     task.PRNumber,
+		SlackChannel: task.SlackChannel,
+		LabelName:    labelName,
+		EventType:    eventType,
+		ActorID:      actorID,
+		Detail:       detail,
+		CreatedAt:    time.Now(),
+	}
+	if err := db.Create(&event).Error; err != nil {
+		log.Printf("failed to record review event %s (task: %s): %v", eventType, task.ID, err)
+	}
+}
+
+// RecordReviewEventOnce stores the event unless the task already has one of
+// the same type, for milestones such as the first review.
+func RecordReviewEventOnce(db *gorm.DB, task models.ReviewTask, eventType, actorID string) {
+	var count int64
+	if err := db.Model(&models.ReviewEvent{}).
+		Where("task_id = ? AND event_type = ?", task.ID, eventType).
+		Count(&count).Error; err != nil {
+		log.Printf("failed to look up review event %s (task: %s): %v", eventType, task.ID, err)
+		return
+	}
+	if count == 0 {
+		RecordReviewEvent(db, task, eventType, actorID, "")
+	}
+}
+
+// RecordReviewerAssignments stores a reviewer_assigned event per reviewer.
+func RecordReviewerAssignments(db *gorm.DB, task models.ReviewTask, reviewerIDs []string) {
+	for _, id := range reviewerIDs {
+		if id != "" {
+			RecordReviewEvent(db, task, ReviewEventReviewerAssigned, id, "")
+		}
+	}
+}
+
+// CleanupOldReviewEvents deletes lifecycle events past the retention period.
+func CleanupOldReviewEvents(db *gorm.DB) {
+	result := db.Where("created_at < ?", time.Now().Add(-reviewEventRetention)).Delete(&models.ReviewEvent{})
+	if result.Error != nil {
+		log.Printf("review event delete error: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("old review events deleted: %d", result.RowsAffected)
+	}
+}
+
+// DurationStats summarizes a set of durations.
+type DurationStats struct {
+	Count  int
+	Median time.Duration
+	P90    time.Duration
+}
+
+// ReviewerStats counts one reviewer's activity.
+type ReviewerStats struct {
+	ID        string
+	Assigned  int // reviews assigned, including reassignments to the reviewer
+	Approved  int
+	HandedOff int // reviews reassigned away from the reviewer
+}
+
+// ReviewStats is the review latency report for a channel.
+type ReviewStats struct {
+	Labeled       int
+	Completed     int
+	Merged        int
+	FirstReview   DurationStats // from labeling to the first submitted review
+	FullApproval  DurationStats // from labeling to reaching the required approvals
+	Reassignments int
+	Reviewers     []ReviewerStats // most assigned first
+}
+
+// LoadReviewStats builds the report from the events recorded in the team's
+// channel since the given time. An empty labelName covers every label. Latencies are
+// counted for tasks whose milestone falls in the period, measured from when
+// the task was labeled (which may be before the period).
+func LoadReviewStats(db *gorm.DB, teamID, channelID, labelName string, since time.Time) (ReviewStats, error) {
+	var stats ReviewStats
+
+	query := db.Where("team_id = ? AND slack_channel = ? AND created_at >= ?", teamID, channelID, since)
+	if labelName != "" {
+		query = query.Where("label_name = ?", labelName)
+	}
+	var events []models.ReviewEvent
+	if err := query.Order("created_at").Find(&events).Error; err != nil {
+		return stats, err
+	}
+
+	reviewers := make(map[string]*ReviewerStats)
+	reviewer := func(id string) *ReviewerStats {
+		if reviewers[id] == nil {
+			reviewers[id] = &ReviewerStats{ID: id}
+		}
+		return reviewers[id]
+	}
+
+	var milestoneTaskIDs []string
+	for _, e := range events {
+		switch e.EventType {
+		case ReviewEventLabeled:
+			stats.Labeled++
+		case ReviewEventCompleted:
+			stats.Completed++
+		case ReviewEventMerged:
+			stats.Merged++
+		case ReviewEventReviewerAssigned:
+			reviewer(e.ActorID).Assigned++
+		case ReviewEventReviewerReassigned:
+			stats.Reassignments++
+			reviewer(e.ActorID).Assigned++
+			if e.Detail != "" {
+				reviewer(e.Detail).HandedOff++
+			}
+		case ReviewEventApproved:
+			reviewer(e.ActorID).Approved++
+		case ReviewEventFirstReview, ReviewEventFullyApproved:
+			milestoneTaskIDs = append(milestoneTaskIDs, e.TaskID)
+		}
+	}
+
+	labeledAt := make(map[string]time.Time)
+	if len(milestoneTaskIDs) > 0 {
+		var labeledEvents []models.ReviewEvent
+		if err := db.Where("task_id IN ? AND event_type = ?", milestoneTaskIDs, ReviewEventLabeled).
+			Find(&labeledEvents).Error; err != nil {
+			return stats, err
+		}
+		for _, e := range labeledEvents {
+			labeledAt[e.TaskID] = e.CreatedAt
+		}
+	}
+
+	var firstReview, fullApproval []time.Duration
+	for _, e := range events {
+		start, ok := labeledAt[e.TaskID]
+		if !ok {
+			continue
+		}
+		switch e.EventType {
+		case ReviewEventFirstReview:
+			firstReview = append(firstReview, e.CreatedAt.Sub(start))
+		case ReviewEventFullyApproved:
+			fullApproval = append(fullApproval, e.CreatedAt.Sub(start))
+		}
+	}
+	stats.FirstReview = summarizeDurations(firstReview)
+	stats.FullApproval = summarizeDurations(fullApproval)
+
+	for _, r := range reviewers {
+		stats.Reviewers = append(stats.Reviewers, *r)
+	}
+	sort.Slice(stats.Reviewers, func(i, j int) bool {
+		a, b := stats.Reviewers[i], stats.Reviewers[j]
+		if a.Assigned != b.Assigned {
+			return a.Assigned > b.Assigned
+		}
+		return a.ID < b.ID
+	})
+
+	return stats, nil
+}
+
+// summarizeDurations returns the count, median and 90th percentile
+// (nearest-rank) of the durations.
+func summarizeDurations(durations []time.Duration) DurationStats {
+	if len(durations) == 0 {
+		return DurationStats{}
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return DurationStats{
+		Count:  len(sorted),
+		Median: percentile(sorted, 50),
+		P90:    percentile(sorted, 90),
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"slack-review-notify/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeDurations(t *testing.T) {
+	assert.Equal(t, DurationStats{}, summarizeDurations(nil))
+
+	var durations []time.Duration
+	for i := 10; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Hour)
+	}
+	assert.Equal(t, DurationStats{Count: 10, Median: 5 * time.Hour, P90: 9 * time.Hour}, summarizeDurations(durations))
+
+	assert.Equal(t, DurationStats{Count: 1, Median: time.Hour, P90: time.Hour}, summarizeDurations([]time.Duration{time.Hour}))
+}
+
+func TestLoadReviewStats(t *testing.T) {
+	db := setupTestDB(t)
+
+	now := time.Now()
+	event := func(id, taskID, label, eventType, actor, detail string, at time.Time) {
+		db.Create(&models.ReviewEvent{
+			ID: id, TaskID: taskID, TeamID: "T1", SlackChannel: "C_STATS", LabelName: label,
+			EventType: eventType, ActorID: actor, Detail: detail, CreatedAt: at,
+		})
+	}
+
+	// t1: labeled 10h ago, first review after 2h, fully approved after 6h
+	event("e1", "t1", "needs-review", ReviewEventLabeled, "UAUTHOR", "", now.Add(-10*time.Hour))
+	event("e2", "t1", "needs-review", ReviewEventReviewerAssigned, "U1", "", now.Add(-10*time.Hour))
+	event("e3", "t1", "needs-review", ReviewEventReviewerReassigned, "U2", "U1", now.Add(-9*time.Hour))
+	event("e4", "t1", "needs-review", ReviewEventFirstReview, "U2", "", now.Add(-8*time.Hour))
+	event("e5", "t1", "needs-review", ReviewEventApproved, "U2", "", now.Add(-8*time.Hour))
+	event("e6", "t1", "needs-review", ReviewEventFullyApproved, "U2", "", now.Add(-4*time.Hour))
+	event("e7", "t1", "needs-review", ReviewEventCompleted, "", "approved", now.Add(-4*time.Hour))
+	event("e8", "t1", "needs-review", ReviewEventMerged, "", "", now.Add(-3*time.Hour))
+
+	// t2: labeled before the period, first reviewed within it after 4 days
+	event("e9", "t2", "needs-review", ReviewEventLabeled, "UAUTHOR", "", now.AddDate(0, 0, -10))
+	event("e10", "t2", "needs-review", ReviewEventFirstReview, "U1", "", now.AddDate(0, 0, -6))
+
+	// another label
+	event("e11", "t3", "backend", ReviewEventLabeled, "UAUTHOR", "", now.Add(-time.Hour))
+	event("e12", "t3", "backend", ReviewEventReviewerAssigned, "U3", "", now.Add(-time.Hour))
+
+	stats, err := LoadReviewStats(db, "T1", "C_STATS", "needs-review", now.AddDate(0, 0, -7))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.Labeled)
+	assert.Equal(t, 1, stats.Completed)
+	assert.Equal(t, 1, stats.Merged)
+	assert.Equal(t, 1, stats.Reassignments)
+	assert.Equal(t, DurationStats{Count: 2, Median: 2 * time.Hour, P90: 4 * 24 * time.Hour}, stats.FirstReview)
+	assert.Equal(t, DurationStats{Count: 1, Median: 6 * time.Hour, P90: 6 * time.Hour}, stats.FullApproval)
+	assert.Equal(t, []ReviewerStats{
+		{ID: "U1", Assigned: 1, HandedOff: 1},
+		{ID: "U2", Assigned: 1, Approved: 1},
+	}, stats.Reviewers)
+
+	// Without a label every label of the channel is covered
+	stats, err = LoadReviewStats(db, "T1", "C_STATS", "", now.AddDate(0, 0, -7))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.Labeled)
+	assert.Len(t, stats.Reviewers, 3)
+
+	// The same channel ID in another workspace has none of them
+	stats, err = LoadReviewStats(db, "T2", "C_STATS", "", now.AddDate(0, 0, -7))
+	assert.NoError(t, err)
+	assert.Zero(t, stats.Labeled)
+	assert.Empty(t, stats.Reviewers)
+}
+
+func TestRecordReviewEvent_KeepsTeam(t *testing.T) {
+	db := setupTestDB(t)
+
+	RecordReviewEvent(db, models.ReviewTask{ID: "t1", TeamID: "T1", SlackChannel: "C1", LabelName: "needs-review"}, ReviewEventLabeled, "UAUTHOR", "")
+
+	var event models.ReviewEvent
+	assert.NoError(t, db.First(&event, "task_id = ?", "t1").Error)
+	assert.Equal(t, "T1", event.TeamID)
+}
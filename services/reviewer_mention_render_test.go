@@ -1,11 +1,9 @@
 package services
 
 import (
-	"os"
 	"slack-review-notify/models"
 	"testing"
 
-	"github.com/h2non/gock"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -13,19 +11,6 @@ import (
 // clickable mention; "@U…" alone stays plain text.
 
 func TestSendOutOfHoursReminderMessage_RendersReviewerAsMention(t *testing.T) {
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() { _ = os.Setenv("SLACK_BOT_TOKEN", originalToken) }()
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	defer gock.Off()
-
-	// json.Marshal escapes "<@U…>" to "<@U…>" in the body.
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		BodyString(`\\u003c@UREVIEWER\\u003e`).
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
-
 	task := models.ReviewTask{
 		ID:           "task-mention",
 		SlackTS:      "1234.5678",
@@ -34,25 +19,16 @@ func TestSendOutOfHoursReminderMessage_RendersReviewerAsMention(t *testing.T) {
 		Status:       "in_review",
 	}
 
-	err := SendOutOfHoursReminderMessage(nil, task)
+	slack := NewFakeSlackClient()
+	err := SendOutOfHoursReminderMessage(slack, task)
 	assert.NoError(t, err)
-	assert.True(t, gock.IsDone(), "expected a chat.postMessage containing <@UREVIEWER>")
+	msgs := slack.Messages()
+	if assert.Len(t, msgs, 1) {
+		assert.Contains(t, messageJSON(t, msgs[0]), "<@UREVIEWER>")
+	}
 }
 
 func TestPostBusinessHoursNotificationToThread_RendersReviewerAsMention(t *testing.T) {
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() { _ = os.Setenv("SLACK_BOT_TOKEN", originalToken) }()
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	defer gock.Off()
-
-	// json.Marshal escapes "<@U…>" to "<@U…>" in the body.
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		BodyString(`\\u003c@UREVIEWER\\u003e`).
-		Reply(200).
-		JSON(map[string]interface{}{"ok": true})
-
 	task := models.ReviewTask{
 		ID:           "task-morning",
 		SlackTS:      "1234.5678",
@@ -61,7 +37,11 @@ func TestPostBusinessHoursNotificationToThread_RendersReviewerAsMention(t *testi
 		Status:       "in_review",
 	}
 
-	err := PostBusinessHoursNotificationToThread(task, "UDEFAULT")
+	slack := NewFakeSlackClient()
+	err := PostBusinessHoursNotificationToThread(slack, task, "UDEFAULT")
 	assert.NoError(t, err)
-	assert.True(t, gock.IsDone(), "expected a chat.postMessage containing <@UREVIEWER>")
+	msgs := slack.Messages()
+	if assert.Len(t, msgs, 1) {
+		assert.Contains(t, messageJSON(t, msgs[0]), "<@UREVIEWER>")
+	}
 }
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"log"
+	"slack-review-notify/models"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Reviewer selection strategies stored in ChannelConfig.ReviewerStrategy.
+const (
+	ReviewerStrategyRandom      = "random"
+	ReviewerStrategyLeastLoaded = "least-loaded"
+	ReviewerStrategyRoundRobin  = "round-robin"
+)
+
+// recentAssignmentWindow is how far back assignments count as "recent" when
+// breaking ties between reviewers with the same number of open reviews.
+const recentAssignmentWindow = 24 * time.Hour
+
+// ReviewerStrategies returns the supported strategies in display order.
+func ReviewerStrategies() []string {
+	return []string{ReviewerStrategyRandom, ReviewerStrategyLeastLoaded, ReviewerStrategyRoundRobin}
+}
+
+// IsValidReviewerStrategy reports whether s is a supported strategy name.
+func IsValidReviewerStrategy(s string) bool {
+	for _, v := range ReviewerStrategies() {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// reviewerWorkload is the load of a single reviewer: open reviews they have not
+// approved yet, and reviews assigned to them within recentAssignmentWindow.
+type reviewerWorkload struct {
+	open   int
+	recent int
+}
+
+// getReviewerWorkloads counts the workload of every reviewer across all channels.
+// Tasks still waiting for business hours have no reviewer yet and are ignored.
+func getReviewerWorkloads(db *gorm.DB) map[string]reviewerWorkload {
+	loads := make(map[string]reviewerWorkload)
+
+	var openTasks []models.ReviewTask
+	if err := db.Where("status = ?", "in_review").Find(&openTasks).Error; err != nil {
+		log.Printf("failed to query open review tasks: %v", err)
+	}
+	for _, task := range openTasks {
+		for _, id := range GetPendingReviewers(task) {
+			l := loads[id]
+			l.open++
+			loads[id] = l
+		}
+	}
+
+	var recentTasks []models.ReviewTask
+	since := time.Now().Add(-recentAssignmentWindow)
+	if err := db.Where("created_at >= ?", since).Find(&recentTasks).Error; err != nil {
+		log.Printf("failed to query recent review tasks: %v", err)
+	}
+	for _, task := range recentTasks {
+		for _, id := range taskReviewerIDs(task) {
+			l := loads[id]
+			l.recent++
+			loads[id] = l
+		}
+	}
+
+	return loads
+}
+
+// taskReviewerIDs returns every reviewer assigned to the task, falling back to
+// the legacy single Reviewer column.
+func taskReviewerIDs(task models.ReviewTask) []string {
+	if task.Reviewers == "" {
+		if task.Reviewer != "" {
+			return []string{task.Reviewer}
+		}
+		return nil
+	}
+	var ids []string
+	for _, id := range strings.Split(task.Reviewers, ",") {
+		if trimmed := strings.TrimSpace(id); trimmed != "" {
+			ids = append(ids, trimmed)
+		}
+	}
+	return ids
+}
+
+// orderByWorkload sorts candidates in place so the least-loaded reviewer comes
+// first: fewer open reviews wins, then fewer recent assignments. The sort is
+// stable so the caller's (shuffled) order breaks remaining ties.
+func orderByWorkload(db *gorm.DB, candidates []string) {
+	loads := getReviewerWorkloads(db)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := loads[candidates[i]], loads[candidates[j]]
+		if a.open != b.open {
+			return a.open < b.open
+		}
+		return a.recent < b.recent
+	})
+}
+
+// nextInRotation picks count reviewers from candidates by walking the full
+// reviewer list in order, starting right after the reviewer stored in the
+// team's channel/label ReviewerRotation cursor and wrapping around. Excluded and
+// away reviewers are skipped (they are not in candidates), and the cursor is
+// advanced to the last reviewer picked.
+func nextInRotation(db *gorm.DB, teamID, channelID, labelName string, reviewerList, candidates []string, count int) []string {
+	var order []string
+	for _, r := range reviewerList {
+		if trimmed := strings.TrimSpace(r); trimmed != "" {
+			order = append(order, trimmed)
+		}
+	}
+
+	var rotation models.ReviewerRotation
+	hasCursor := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", teamID, channelID, labelName).First(&rotation).Error == nil
+
+	// A cursor pointing at someone no longer in the list restarts the rotation.
+	start := 0
+	if hasCursor {
+		for i, id := range order {
+			if id == rotation.LastReviewerID {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	candidateSet := make(map[string]bool, len(candidates))
+	for _, id := range candidates {
+		candidateSet[id] = true
+	}
+
+	picked := make([]string, 0, count)
+	pickedSet := make(map[string]bool, count)
+	for i := 0; i < len(order) && len(picked) < count; i++ {
+		id := order[(start+i)%len(order)]
+		if candidateSet[id] && !pickedSet[id] {
+			picked = append(picked, id)
+			pickedSet[id] = true
+		}
+	}
+
+	if len(picked) == 0 {
+		return picked
+	}
+
+	now := time.Now()
+	rotation.LastReviewerID = picked[len(picked)-1]
+	rotation.UpdatedAt = now
+	if hasCursor {
+		if err := db.Save(&rotation).Error; err != nil {
+			log.Printf("failed to advance reviewer rotation for channel %s label %s: %v", channelID, labelName, err)
+		}
+	} else {
+		rotation.ID = uuid.NewString()
+		rotation.TeamID = teamID
+		rotation.SlackChannelID = channelID
+		rotation.LabelName = labelName
+		rotation.CreatedAt = now
+		if err := db.Create(&rotation).Error; err != nil {
+			log.Printf("failed to create reviewer rotation for channel %s label %s: %v", channelID, labelName, err)
+		}
+	}
+
+	return picked
+}
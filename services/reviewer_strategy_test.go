@@ -0,0 +1,258 @@
+package services
+
+import (
+	"slack-review-notify/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidReviewerStrategy(t *testing.T) {
+	assert.True(t, IsValidReviewerStrategy("random"))
+	assert.True(t, IsValidReviewerStrategy("least-loaded"))
+	assert.False(t, IsValidReviewerStrategy(""))
+	assert.False(t, IsValidReviewerStrategy("fastest"))
+}
+
+// Least-loaded selection picks the reviewer with the fewest open reviews they
+// have not approved yet, regardless of the shuffle.
+func TestSelectRandomReviewers_LeastLoadedPrefersFewestOpenReviews(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.Create(&models.ChannelConfig{
+		ID:               "least-loaded-id",
+		SlackChannelID:   "C_LOAD",
+		LabelName:        "needs-review",
+		DefaultMentionID: "UDEFAULT",
+		ReviewerList:     "U1,U2,U3",
+		ReviewerStrategy: ReviewerStrategyLeastLoaded,
+		IsActive:         true,
+	})
+
+	old := time.Now().Add(-72 * time.Hour)
+	tasks := []models.ReviewTask{
+		{ID: "t1", Reviewers: "U1,U2", Status: "in_review", CreatedAt: old},
+		{ID: "t2", Reviewers: "U1", Status: "in_review", CreatedAt: old},
+		// U3 already approved, so this does not count as open work.
+		{ID: "t3", Reviewers: "U3", ApprovedBy: "U3", Status: "in_review", CreatedAt: old},
+		// Completed tasks are not open work either.
+		{ID: "t4", Reviewers: "U3", Status: "completed", CreatedAt: old},
+	}
+	for _, task := range tasks {
+		db.Create(&task)
+	}
+
+	for i := 0; i < 20; i++ {
+		result := SelectRandomReviewers(db, "", "C_LOAD", "needs-review", 1, nil)
+		assert.Equal(t, []string{"U3"}, result)
+	}
+
+	result := SelectRandomReviewers(db, "", "C_LOAD", "needs-review", 2, nil)
+	assert.Equal(t, []string{"U3", "U2"}, result)
+}
+
+// With equal open reviews, reviewers assigned recently are picked last.
+func TestSelectRandomReviewers_LeastLoadedBreaksTiesByRecentAssignments(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.Create(&models.ChannelConfig{
+		ID:               "least-loaded-recent-id",
+		SlackChannelID:   "C_RECENT",
+		LabelName:        "needs-review",
+		DefaultMentionID: "UDEFAULT",
+		ReviewerList:     "U1,U2",
+		ReviewerStrategy: ReviewerStrategyLeastLoaded,
+		IsActive:         true,
+	})
+	db.Create(&models.ReviewTask{
+		ID:        "recent-done",
+		Reviewers: "U1",
+		Status:    "completed",
+		CreatedAt: time.Now().Add(-1 * time.Hour),
+	})
+
+	for i := 0; i < 20; i++ {
+		result := SelectRandomReviewers(db, "", "C_RECENT", "needs-review", 1, nil)
+		assert.Equal(t, []string{"U2"}, result)
+	}
+}
+
+// Least-loaded selection keeps the exclusion of the PR author and away users.
+func TestSelectRandomReviewers_LeastLoadedKeepsExclusions(t *testing.T) {
+	db := setupTestDB(t)
+
+	now := time.Now()
+	future := now.Add(24 * time.Hour)
+
+	db.Create(&models.ChannelConfig{
+		ID:               "least-loaded-excl-id",
+		SlackChannelID:   "C_LOAD_EXCL",
+		LabelName:        "needs-review",
+		DefaultMentionID: "UDEFAULT",
+		ReviewerList:     "U1,U2,U3",
+		ReviewerStrategy: ReviewerStrategyLeastLoaded,
+		IsActive:         true,
+	})
+	db.Create(&models.ReviewerAvailability{
+		ID:          "away-u2",
+		SlackUserID: "U2",
+		AwayUntil:   &future,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	db.Create(&models.ReviewTask{ID: "busy-u3", Reviewers: "U3", Status: "in_review", CreatedAt: now.Add(-72 * time.Hour)})
+
+	// U1 is the author and U2 is away, so the busy U3 is the only candidate.
+	result := SelectRandomReviewers(db, "", "C_LOAD_EXCL", "needs-review", 1, []string{"U1"})
+	assert.Equal(t, []string{"U3"}, result)
+}
+
+// Round-robin walks ReviewerList in order and wraps around, persisting the
+// cursor so the next call (or the next process) continues from there.
+func TestSelectRandomReviewers_RoundRobinRotatesInOrder(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.Create(&models.ChannelConfig{
+		ID:               "round-robin-id",
+		SlackChannelID:   "C_RR",
+		LabelName:        "needs-review",
+		DefaultMentionID: "UDEFAULT",
+		ReviewerList:     "U1,U2,U3",
+		ReviewerStrategy: ReviewerStrategyRoundRobin,
+		IsActive:         true,
+	})
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		got = append(got, SelectRandomReviewers(db, "", "C_RR", "needs-review", 1, nil)...)
+	}
+	assert.Equal(t, []string{"U1", "U2", "U3", "U1"}, got)
+
+	var rotation models.ReviewerRotation
+	assert.NoError(t, db.Where("slack_channel_id = ? AND label_name = ?", "C_RR", "needs-review").First(&rotation).Error)
+	assert.Equal(t, "U1", rotation.LastReviewerID)
+
+	// Picking two continues after U1 and moves the cursor to the last pick.
+	assert.Equal(t, []string{"U2", "U3"}, SelectRandomReviewers(db, "", "C_RR", "needs-review", 2, nil))
+	assert.Equal(t, []string{"U1"}, SelectRandomReviewers(db, "", "C_RR", "needs-review", 1, nil))
+}
+
+// Round-robin skips the author and away users without losing its place in
+// the list, and the cursor is kept separately per label.
+func TestSelectRandomReviewers_RoundRobinSkipsExcluded(t *testing.T) {
+	db := setupTestDB(t)
+
+	now := time.Now()
+	future := now.Add(24 * time.Hour)
+
+	for _, label := range []string{"needs-review", "backend"} {
+		db.Create(&models.ChannelConfig{
+			ID:               "round-robin-" + label,
+			SlackChannelID:   "C_RR_EXCL",
+			LabelName:        label,
+			DefaultMentionID: "UDEFAULT",
+			ReviewerList:     "U1,U2,U3,U4",
+			ReviewerStrategy: ReviewerStrategyRoundRobin,
+			IsActive:         true,
+		})
+	}
+	db.Create(&models.ReviewerAvailability{
+		ID:          "away-u3",
+		SlackUserID: "U3",
+		AwayUntil:   &future,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	db.Create(&models.ReviewerRotation{
+		ID:             "rotation-needs-review",
+		SlackChannelID: "C_RR_EXCL",
+		LabelName:      "needs-review",
+		LastReviewerID: "U1",
+	})
+
+	// U2 is the author and U3 is away, so the turn after U1 goes to U4.
+	assert.Equal(t, []string{"U4"}, SelectRandomReviewers(db, "", "C_RR_EXCL", "needs-review", 1, []string{"U2"}))
+	assert.Equal(t, []string{"U1"}, SelectRandomReviewers(db, "", "C_RR_EXCL", "needs-review", 1, []string{"U2"}))
+
+	// The other label has no cursor yet and starts from the top of the list.
+	assert.Equal(t, []string{"U1"}, SelectRandomReviewers(db, "", "C_RR_EXCL", "backend", 1, nil))
+}
+
+// Two workspaces may use the same channel ID and label; each keeps its own
+// round-robin cursor.
+func TestSelectRandomReviewers_RoundRobinPerTeam(t *testing.T) {
+	db := setupTestDB(t)
+
+	for _, team := range []string{"T1", "T2"} {
+		db.Create(&models.ChannelConfig{
+			ID:               "round-robin-" + team,
+			TeamID:           team,
+			SlackChannelID:   "C_RR_TEAM",
+			LabelName:        "needs-review",
+			DefaultMentionID: "UDEFAULT",
+			ReviewerList:     "U1,U2,U3",
+			ReviewerStrategy: ReviewerStrategyRoundRobin,
+			IsActive:         true,
+		})
+	}
+
+	assert.Equal(t, []string{"U1"}, SelectRandomReviewers(db, "T1", "C_RR_TEAM", "needs-review", 1, nil))
+	assert.Equal(t, []string{"U2"}, SelectRandomReviewers(db, "T1", "C_RR_TEAM", "needs-review", 1, nil))
+	assert.Equal(t, []string{"U1"}, SelectRandomReviewers(db, "T2", "C_RR_TEAM", "needs-review", 1, nil))
+
+	var count int64
+	db.Model(&models.ReviewerRotation{}).Where("slack_channel_id = ?", "C_RR_TEAM").Count(&count)
+	assert.EqualValues(t, 2, count)
+}
+
+// CODEOWNERS picked ahead of the list leave the round-robin cursor alone, so
+// the rotation continues where it was once they are taken.
+func TestSelectReviewers_RoundRobinKeepsCursorForPreferred(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.Create(&models.ChannelConfig{
+		ID:               "round-robin-preferred",
+		SlackChannelID:   "C_RR_PREF",
+		LabelName:        "needs-review",
+		DefaultMentionID: "UDEFAULT",
+		ReviewerList:     "U1,U2,U3",
+		ReviewerStrategy: ReviewerStrategyRoundRobin,
+		IsActive:         true,
+	})
+	db.Create(&models.ReviewerRotation{
+		ID:             "rotation-preferred",
+		SlackChannelID: "C_RR_PREF",
+		LabelName:      "needs-review",
+		LastReviewerID: "U1",
+	})
+
+	assert.Equal(t, []string{"UOWNER"}, SelectReviewers(db, "", "C_RR_PREF", "needs-review", 1, nil, []string{"UOWNER"}))
+	// The owner plus the next turn from the list
+	assert.Equal(t, []string{"UOWNER", "U2"}, SelectReviewers(db, "", "C_RR_PREF", "needs-review", 2, nil, []string{"UOWNER"}))
+	assert.Equal(t, []string{"U3"}, SelectRandomReviewers(db, "", "C_RR_PREF", "needs-review", 1, nil))
+}
+
+func TestTopUpReviewers(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.ChannelConfig{
+		ID:               "top-up-id",
+		SlackChannelID:   "C_TOPUP",
+		LabelName:        "needs-review",
+		DefaultMentionID: "UDEFAULT",
+		ReviewerList:     "UREQ,U1",
+		IsActive:         true,
+	})
+
+	// Enough requested reviewers: nobody is added.
+	assert.Equal(t, []string{"UREQ", "UOTHER"},
+		TopUpReviewers(db, "", "C_TOPUP", "needs-review", 2, []string{"UREQ", "UOTHER"}, nil, nil))
+
+	// One slot left: filled from the list, never duplicating a requested reviewer.
+	assert.Equal(t, []string{"UREQ", "U1"},
+		TopUpReviewers(db, "", "C_TOPUP", "needs-review", 2, []string{"UREQ"}, nil, nil))
+
+	// Nobody left to top up with: the default mention is not appended.
+	assert.Equal(t, []string{"UREQ"},
+		TopUpReviewers(db, "", "C_TOPUP", "needs-review", 3, []string{"UREQ"}, []string{"U1"}, nil))
+}
@@ -103,6 +103,7 @@ type SettingsForm struct {
 	BusinessHoursEnd         string
 	Timezone                 string
 	RequiredApprovals        int
+	ReviewerStrategy         string
 	Language                 string
 	IsActive                 bool
 }
@@ -148,6 +149,7 @@ func BuildSettingsModalView(in SettingsModalInputs) map[string]any {
 	bhEnd := "18:00"
 	tz := "Asia/Tokyo"
 	requiredApprovals := 1
+	strategy := ReviewerStrategyRandom
 	cfgLang := in.Lang
 	if cfgLang == "" {
 		cfgLang = "ja"
@@ -198,6 +200,9 @@ func BuildSettingsModalView(in SettingsModalInputs) map[string]any {
 		if cfg.RequiredApprovals > 0 {
 			requiredApprovals = cfg.RequiredApprovals
 		}
+		if cfg.ReviewerStrategy != "" {
+			strategy = cfg.ReviewerStrategy
+		}
 		if cfg.Language != "" {
 			cfgLang = cfg.Language
 		}
@@ -293,6 +298,10 @@ func BuildSettingsModalView(in SettingsModalInputs) map[string]any {
 		}
 	}
 
+	strategyOptions := make([]map[string]any, 0, len(ReviewerStrategies()))
+	for _, s := range ReviewerStrategies() {
+		strategyOptions = append(strategyOptions, option(t("modal.reviewer_strategy."+s), s))
+	}
 	langOptions := []map[string]any{
 		option(t("modal.lang.ja"), "ja"),
 		option(t("modal.lang.en"), "en"),
@@ -396,6 +405,7 @@ func BuildSettingsModalView(in SettingsModalInputs) map[string]any {
 		plainInput("business_hours_end", t("modal.business_hours_end"), "HH:MM", bhEnd, false),
 		plainInput("timezone", t("modal.timezone"), t("modal.timezone.hint"), tz, false),
 		plainInput("required_approvals", t("modal.required_approvals"), t("modal.required_approvals.hint"), strconv.Itoa(requiredApprovals), false),
+		staticSelect("reviewer_strategy", t("modal.reviewer_strategy"), strategyOptions, strategy, false),
 		staticSelect("language", t("modal.language"), langOptions, cfgLang, false),
 		staticSelect("is_active", t("modal.is_active"), activeOptions, activeInitial, false),
 	)
@@ -601,6 +611,15 @@ func ParseSettingsModalSubmission(values map[string]map[string]ViewStateValue) (
 		form.RequiredApprovals = approvals
 	}
 
+	// Older views (opened before the strategy field existed) submit without
+	// it, so an absent value keeps the default rather than failing validation.
+	form.ReviewerStrategy = selectField("reviewer_strategy")
+	if form.ReviewerStrategy == "" {
+		form.ReviewerStrategy = ReviewerStrategyRandom
+	} else if !IsValidReviewerStrategy(form.ReviewerStrategy) {
+		errs["reviewer_strategy"] = "unsupported strategy"
+	}
+
 	form.Language = selectField("language")
 	if form.Language != "ja" && form.Language != "en" {
 		errs["language"] = "must be ja or en"
@@ -92,6 +92,7 @@ func TestBuildSettingsModalView_HasAllFields(t *testing.T) {
 		"business_hours_end",
 		"timezone",
 		"required_approvals",
+		"reviewer_strategy",
 		"language",
 		"is_active",
 		"delete_config",
@@ -677,6 +678,43 @@ func TestParseSettingsModalSubmission_ReviewerMerge(t *testing.T) {
 	}
 }
 
+// TestParseSettingsModalSubmission_ReviewerStrategy: the strategy select is
+// optional on submit so views opened before the field existed still save with
+// the random default, while an unknown value is rejected inline.
+func TestParseSettingsModalSubmission_ReviewerStrategy(t *testing.T) {
+	v := minimalValidParseValues()
+	got, err := ParseSettingsModalSubmission(v)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if got.ReviewerStrategy != ReviewerStrategyRandom {
+		t.Errorf("ReviewerStrategy = %q, want %q when absent", got.ReviewerStrategy, ReviewerStrategyRandom)
+	}
+
+	v["reviewer_strategy"] = map[string]ViewStateValue{
+		"reviewer_strategy": {SelectedOption: &ViewSelectedOption{Value: "round-robin"}},
+	}
+	got, err = ParseSettingsModalSubmission(v)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if got.ReviewerStrategy != ReviewerStrategyRoundRobin {
+		t.Errorf("ReviewerStrategy = %q, want %q", got.ReviewerStrategy, ReviewerStrategyRoundRobin)
+	}
+
+	v["reviewer_strategy"] = map[string]ViewStateValue{
+		"reviewer_strategy": {SelectedOption: &ViewSelectedOption{Value: "fastest"}},
+	}
+	_, err = ParseSettingsModalSubmission(v)
+	ve, ok := err.(*ModalValidationError)
+	if !ok {
+		t.Fatalf("expected *ModalValidationError, got %v", err)
+	}
+	if _, ok := ve.Errors["reviewer_strategy"]; !ok {
+		t.Errorf("expected reviewer_strategy error, got %v", ve.Errors)
+	}
+}
+
 // TestBuildMentionText_PassesThroughFreeText covers the buildMentionText
 // branches: U… → <@…>, S… → subteam, "subteam^X" → subteam (legacy), empty →
 // empty, anything else → as-is (no <@…> wrapping for decorative text).
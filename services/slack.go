@@ -1,10 +1,8 @@
 package services
 
 import (
-	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"math/rand"
 	"net/http"
@@ -29,32 +27,6 @@ func SlackAPIBaseURL() string {
 	return "https://slack.com/api"
 }
 
-// Flag indicating whether test mode is enabled
-var IsTestMode bool
-
-type SlackMessage struct {
-	Channel string  `json:"channel"`
-	Blocks  []Block `json:"blocks"`
-}
-
-type Block struct {
-	Type     string      `json:"type"`
-	Text     *TextObject `json:"text,omitempty"`
-	Elements []Button    `json:"elements,omitempty"`
-}
-
-type TextObject struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
-}
-
-type Button struct {
-	Type     string     `json:"type"`
-	Text     TextObject `json:"text"`
-	ActionID string     `json:"action_id"`
-	Style    string     `json:"style,omitempty"`
-}
-
 type SlackPostResponse struct {
 	OK      bool   `json:"ok"`
 	Channel string `json:"channel"`
@@ -63,11 +35,6 @@ type SlackPostResponse struct {
 }
 
 func ValidateSlackRequest(r *http.Request, body []byte) bool {
-	// Always return validation success in test mode
-	if IsTestMode {
-		return true
-	}
-
 	slackSigningSecret := os.Getenv("SLACK_SIGNING_SECRET")
 	if slackSigningSecret == "" {
 		log.Println("SLACK_SIGNING_SECRET is not set")
@@ -95,13 +62,15 @@ func ValidateSlackRequest(r *http.Request, body []byte) bool {
 	return true
 }
 
-func GetSlackUserIDFromGitHub(db *gorm.DB, githubUsername string) string {
+// GetSlackUserIDFromGitHub returns the Slack user the team mapped the GitHub
+// user to, or "" when there is no mapping.
+func GetSlackUserIDFromGitHub(db *gorm.DB, teamID, githubUsername string) string {
 	if githubUsername == "" {
 		return ""
 	}
 
 	var mapping models.UserMapping
-	if err := db.Where("github_username = ?", githubUsername).First(&mapping).Error; err != nil {
+	if err := db.Where("team_id = ? AND github_username = ?", teamID, githubUsername).First(&mapping).Error; err != nil {
 		log.Printf("user mapping not found for github user: %s", githubUsername)
 		return ""
 	}
@@ -109,6 +78,24 @@ func GetSlackUserIDFromGitHub(db *gorm.DB, githubUsername string) string {
 	return mapping.SlackUserID
 }
 
+// GetGitHubUsernamesFromSlack reverse-maps Slack user IDs to GitHub usernames
+// via the team's UserMapping. IDs without a mapping are skipped.
+func GetGitHubUsernamesFromSlack(db *gorm.DB, teamID string, slackUserIDs []string) []string {
+	var logins []string
+	for _, id := range slackUserIDs {
+		if id == "" {
+			continue
+		}
+		var mapping models.UserMapping
+		if err := db.Where("team_id = ? AND slack_user_id = ?", teamID, id).First(&mapping).Error; err != nil {
+			log.Printf("user mapping not found for slack user: %s", id)
+			continue
+		}
+		logins = append(logins, mapping.GithubUsername)
+	}
+	return logins
+}
+
 // looksLikeUserID reports whether s has the shape of a Slack user ID
 // (U or W followed by uppercase alphanumerics). Used to route modal pre-fill
 // and to decide whether to wrap a stored mention value in <@…> markup.
@@ -195,23 +182,63 @@ func GetAwayUserIDs(db *gorm.DB) []string {
 	return ids
 }
 
-// SelectRandomReviewers randomly selects the specified number of reviewers (excluding excludeIDs)
-func SelectRandomReviewers(db *gorm.DB, channelID string, labelName string, count int, excludeIDs []string) []string {
+// SelectRandomReviewers selects the specified number of reviewers (excluding excludeIDs)
+// from the channel's reviewer list. See SelectReviewers.
+func SelectRandomReviewers(db *gorm.DB, teamID, channelID string, labelName string, count int, excludeIDs []string) []string {
+	return SelectReviewers(db, teamID, channelID, labelName, count, excludeIDs, nil)
+}
+
+// SelectReviewers selects the specified number of reviewers (excluding excludeIDs
+// and users on leave) using the channel config's ReviewerStrategy. Candidates are
+// shuffled by default; the least-loaded strategy prefers reviewers with the fewest
+// open reviews and the round-robin strategy walks ReviewerList in order from a
+// persisted cursor.
+//
+// preferredIDs (e.g. the PR's CODEOWNERS) are picked first, whether or not they
+// are in ReviewerList; the channel's reviewer list only tops up the remainder.
+func SelectReviewers(db *gorm.DB, teamID, channelID string, labelName string, count int, excludeIDs []string, preferredIDs []string) []string {
+	selected, _ := selectReviewers(db, teamID, channelID, labelName, count, excludeIDs, preferredIDs)
+	return selected
+}
+
+// TopUpReviewers keeps the preassigned reviewers (e.g. the ones requested on
+// GitHub) and selects more with SelectReviewers only until requiredCount is
+// reached. The DefaultMentionID fallback is not used to top up a non-empty
+// preassigned list.
+func TopUpReviewers(db *gorm.DB, teamID, channelID string, labelName string, requiredCount int, preassignedIDs []string, excludeIDs []string, preferredIDs []string) []string {
+	if len(preassignedIDs) == 0 {
+		return SelectReviewers(db, teamID, channelID, labelName, requiredCount, excludeIDs, preferredIDs)
+	}
+	if len(preassignedIDs) >= requiredCount {
+		return preassignedIDs
+	}
+
+	exclude := append(append([]string{}, excludeIDs...), preassignedIDs...)
+	extra, fallback := selectReviewers(db, teamID, channelID, labelName, requiredCount-len(preassignedIDs), exclude, preferredIDs)
+	if fallback {
+		return preassignedIDs
+	}
+	return append(append([]string{}, preassignedIDs...), extra...)
+}
+
+// selectReviewers implements SelectReviewers. The boolean result reports
+// whether no candidate was left and DefaultMentionID was returned instead.
+func selectReviewers(db *gorm.DB, teamID, channelID string, labelName string, count int, excludeIDs []string, preferredIDs []string) ([]string, bool) {
 	var config models.ChannelConfig
 
-	if err := db.Where("slack_channel_id = ? AND label_name = ?", channelID, labelName).First(&config).Error; err != nil {
+	if err := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", teamID, channelID, labelName).First(&config).Error; err != nil {
 		log.Printf("failed to get channel config: %v", err)
-		return []string{}
+		return []string{}, false
 	}
 
-	if config.ReviewerList == "" {
+	if config.ReviewerList == "" && len(preferredIDs) == 0 {
 		// Falling back to DefaultMentionID is only meaningful when one is
 		// configured. An empty mention here would propagate as a "<@>"
 		// literal downstream, so return an empty slice instead.
 		if config.DefaultMentionID == "" {
-			return []string{}
+			return []string{}, true
 		}
-		return []string{config.DefaultMentionID}
+		return []string{config.DefaultMentionID}, true
 	}
 
 	reviewers := strings.Split(config.ReviewerList, ",")
@@ -231,30 +258,77 @@ func SelectRandomReviewers(db *gorm.DB, channelID string, labelName string, coun
 		}
 	}
 
-	var candidates []string
-	for _, r := range reviewers {
-		if trimmed := strings.TrimSpace(r); trimmed != "" && !excludeSet[trimmed] {
-			candidates = append(candidates, trimmed)
+	filter := func(ids []string) []string {
+		var out []string
+		for _, id := range ids {
+			if trimmed := strings.TrimSpace(id); trimmed != "" && !excludeSet[trimmed] {
+				out = append(out, trimmed)
+			}
 		}
+		return out
 	}
+	preferred := filter(preferredIDs)
+	candidates := filter(reviewers)
 
-	if len(candidates) == 0 {
+	if len(preferred) == 0 && len(candidates) == 0 {
 		if config.DefaultMentionID == "" {
-			return []string{}
+			return []string{}, true
+		}
+		return []string{config.DefaultMentionID}, true
+	}
+
+	if count <= 0 {
+		return []string{}, false
+	}
+
+	// Preferred reviewers (CODEOWNERS) are not on the rotation, so picking
+	// them neither reads nor moves the round-robin cursor
+	selected := pickByStrategy(db, &config, nil, preferred, count)
+	if len(selected) < count {
+		taken := make(map[string]bool, len(selected))
+		for _, id := range selected {
+			taken[id] = true
+		}
+		var rest []string
+		for _, id := range candidates {
+			if !taken[id] {
+				rest = append(rest, id)
+			}
 		}
-		return []string{config.DefaultMentionID}
+		selected = append(selected, pickByStrategy(db, &config, reviewers, rest, count-len(selected))...)
+	}
+
+	return selected, false
+}
+
+// pickByStrategy picks up to count reviewers from candidates according to the
+// config's ReviewerStrategy. order is the list the round-robin cursor walks;
+// without one, round-robin takes the candidates in their given order.
+func pickByStrategy(db *gorm.DB, config *models.ChannelConfig, order, candidates []string, count int) []string {
+	if len(candidates) == 0 {
+		return nil
+	}
+	if count > len(candidates) {
+		count = len(candidates)
 	}
 
+	if config.ReviewerStrategy == ReviewerStrategyRoundRobin {
+		if order == nil {
+			return append([]string(nil), candidates[:count]...)
+		}
+		return nextInRotation(db, config.TeamID, config.SlackChannelID, config.LabelName, order, candidates, count)
+	}
+
+	candidates = append([]string(nil), candidates...)
 	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
 	rng.Shuffle(len(candidates), func(i, j int) {
 		candidates[i], candidates[j] = candidates[j], candidates[i]
 	})
 
-	if count <= 0 {
-		return []string{}
-	}
-	if count > len(candidates) {
-		count = len(candidates)
+	if config.ReviewerStrategy == ReviewerStrategyLeastLoaded {
+		// The shuffle above stays as the tie-breaker: the stable sort keeps
+		// equally loaded candidates in random order.
+		orderByWorkload(db, candidates)
 	}
 
 	return candidates[:count]
@@ -359,9 +433,9 @@ func CountApprovals(task models.ReviewTask) int {
 	return count
 }
 
-// IsReviewFullyApproved determines whether the required number of approvals has been met.
+// EffectiveRequiredApprovals returns the number of approvals the task needs.
 // If the number of actually assigned reviewers is less than requiredApprovals, it uses the assigned count instead.
-func IsReviewFullyApproved(task models.ReviewTask, requiredApprovals int) bool {
+func EffectiveRequiredApprovals(task models.ReviewTask, requiredApprovals int) int {
 	if requiredApprovals <= 0 {
 		requiredApprovals = 1
 	}
@@ -379,22 +453,20 @@ func IsReviewFullyApproved(task models.ReviewTask, requiredApprovals int) bool {
 		}
 	}
 
+	return requiredApprovals
+}
+
+// IsReviewFullyApproved determines whether the required number of approvals has been met.
+func IsReviewFullyApproved(task models.ReviewTask, requiredApprovals int) bool {
 	if task.ApprovedBy == "" {
 		return false
 	}
 
-	count := 0
-	for _, id := range strings.Split(task.ApprovedBy, ",") {
-		if strings.TrimSpace(id) != "" {
-			count++
-		}
-	}
-
-	return count >= requiredApprovals
+	return CountApprovals(task) >= EffectiveRequiredApprovals(task, requiredApprovals)
 }
 
 // SendSlackMessageOffHours sends a message without mentions for off-hours
-func SendSlackMessageOffHours(prURL, title, channel, creatorSlackID, lang string) (string, string, error) {
+func SendSlackMessageOffHours(slack SlackClient, prURL, title, channel, creatorSlackID, lang string) (string, string, error) {
 	t := i18n.L(lang)
 	var message string
 	if creatorSlackID != "" {
@@ -405,49 +477,16 @@ func SendSlackMessageOffHours(prURL, title, channel, creatorSlackID, lang string
 	doneButton := CreateButton(t("button.review_done"), "review_done", "done", "primary")
 	blocks := CreateMessageWithActionBlocks(message, doneButton)
 
-	body := map[string]interface{}{
-		"channel": channel,
-		"blocks":  blocks,
-	}
-
-	jsonData, _ := json.Marshal(body)
-	req, err := http.NewRequest("POST", SlackAPIBaseURL()+"/chat.postMessage", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", "", err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("SLACK_BOT_TOKEN"))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
+	result, err := slack.PostMessage(SlackMessage{Channel: channel, Blocks: blocks})
 	if err != nil {
 		return "", "", err
 	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	var result struct {
-		OK      bool   `json:"ok"`
-		TS      string `json:"ts"`
-		Channel string `json:"channel"`
-		Error   string `json:"error"`
-	}
-
-	bodyBytes, _ := io.ReadAll(resp.Body)
-	if err := json.Unmarshal(bodyBytes, &result); err != nil {
-		return "", "", fmt.Errorf("slack API response parse error: %v", err)
-	}
-
-	if !result.OK {
-		return "", "", fmt.Errorf("slack error: %s", result.Error)
-	}
 
-	return result.TS, result.Channel, nil
+	return result.Ts, result.Channel, nil
 }
 
 // PostBusinessHoursNotificationToThread sends a notification with mentions to a thread when business hours begin
-func PostBusinessHoursNotificationToThread(task models.ReviewTask, mentionID string) error {
+func PostBusinessHoursNotificationToThread(slack SlackClient, task models.ReviewTask, mentionID string) error {
 	t := i18n.L(task.Language)
 	mentionText := buildMentionText(mentionID)
 
@@ -471,47 +510,10 @@ func PostBusinessHoursNotificationToThread(task models.ReviewTask, mentionID str
 		blocks = CreateMessageBlocks(message)
 	}
 
-	body := map[string]interface{}{
-		"channel":   task.SlackChannel,
-		"thread_ts": task.SlackTS,
-		"blocks":    blocks,
-	}
-
-	jsonData, _ := json.Marshal(body)
-	req, err := http.NewRequest("POST", SlackAPIBaseURL()+"/chat.postMessage", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("SLACK_BOT_TOKEN"))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	var result struct {
-		OK    bool   `json:"ok"`
-		Error string `json:"error"`
-	}
-
-	bodyBytes, _ := io.ReadAll(resp.Body)
-	if err := json.Unmarshal(bodyBytes, &result); err != nil {
-		return fmt.Errorf("slack API response parse error: %v", err)
-	}
-
-	if !result.OK {
-		return fmt.Errorf("slack error: %s", result.Error)
-	}
-
-	return nil
+	return slack.Send(SlackMessage{Channel: task.SlackChannel, ThreadTS: task.SlackTS, Blocks: blocks})
 }
 
-func SendSlackMessage(prURL, title, channel, mentionID, creatorSlackID, lang string) (string, string, error) {
+func SendSlackMessage(slack SlackClient, prURL, title, channel, mentionID, creatorSlackID, lang string) (string, string, error) {
 	t := i18n.L(lang)
 	mentionText := buildMentionText(mentionID)
 
@@ -524,181 +526,41 @@ func SendSlackMessage(prURL, title, channel, mentionID, creatorSlackID, lang str
 	doneButton := CreateButton(t("button.review_done"), "review_done", "done", "primary")
 	blocks := CreateMessageWithActionBlocks(message, doneButton)
 
-	body := map[string]interface{}{
-		"channel": channel,
-		"blocks":  blocks,
-	}
-
-	jsonData, _ := json.Marshal(body)
-	req, err := http.NewRequest("POST", SlackAPIBaseURL()+"/chat.postMessage", bytes.NewBuffer(jsonData))
+	slackResp, err := slack.PostMessage(SlackMessage{Channel: channel, Blocks: blocks})
 	if err != nil {
 		return "", "", err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("SLACK_BOT_TOKEN"))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", "", err
-	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	bodyBytes, _ := io.ReadAll(resp.Body)
-
-	var slackResp SlackPostResponse
-	if err := json.Unmarshal(bodyBytes, &slackResp); err != nil {
-		return "", "", err
-	}
-
-	if !slackResp.OK {
-		return "", "", fmt.Errorf("slack error: %s", slackResp.Error)
-	}
-
 	return slackResp.Ts, slackResp.Channel, nil
 }
 
-// PostToThread posts a message to a thread
-func PostToThread(channel, ts, message string) error {
-	body := map[string]interface{}{
-		"channel":   channel,
-		"thread_ts": ts,
-		"text":      message,
-	}
-
-	jsonData, _ := json.Marshal(body)
-	req, err := http.NewRequest("POST", SlackAPIBaseURL()+"/chat.postMessage", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("SLACK_BOT_TOKEN"))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	var result struct {
-		OK    bool   `json:"ok"`
-		Error string `json:"error"`
-	}
-
-	bodyBytes, _ := io.ReadAll(resp.Body)
-	if err := json.Unmarshal(bodyBytes, &result); err != nil {
-		return fmt.Errorf("slack API response parse error: %v", err)
-	}
-
-	log.Printf("slack thread post response: %s", string(bodyBytes))
-
-	if !result.OK {
-		return fmt.Errorf("slack error: %s", result.Error)
-	}
-
-	return nil
+// PostToThread posts a message to a thread. Like every post without a
+// needed response, it is sent with slack.Send, which goes through the Slack
+// outbox in production (see SlackOutbox).
+func PostToThread(slack SlackClient, channel, ts, message string) error {
+	return slack.Send(SlackMessage{Channel: channel, ThreadTS: ts, Text: message})
 }
 
-// PostEphemeral sends an ephemeral message visible only to the given user in the
-// given channel. Returns nil immediately in test mode.
-func PostEphemeral(channel, user, message string) error {
-	if IsTestMode {
-		log.Printf("test mode: would post ephemeral to channel=%s user=%s", channel, user)
-		return nil
-	}
-
-	body := map[string]any{
-		"channel": channel,
-		"user":    user,
-		"text":    message,
-	}
-
-	jsonData, _ := json.Marshal(body)
-	req, err := http.NewRequest("POST", SlackAPIBaseURL()+"/chat.postEphemeral", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("SLACK_BOT_TOKEN"))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	respBytes, _ := io.ReadAll(resp.Body)
-	var result struct {
-		OK    bool   `json:"ok"`
-		Error string `json:"error"`
-	}
-	if err := json.Unmarshal(respBytes, &result); err != nil {
-		return fmt.Errorf("slack API response parse error: %v (body: %s)", err, string(respBytes))
-	}
-	if !result.OK {
-		return fmt.Errorf("slack chat.postEphemeral error: %s", result.Error)
-	}
-	return nil
+// PostChannelMessage posts a top-level message with the given blocks to a
+// channel. text is the notification fallback shown where blocks are not rendered.
+func PostChannelMessage(slack SlackClient, channel, text string, blocks []map[string]interface{}) error {
+	return slack.Send(SlackMessage{Channel: channel, Text: text, Blocks: blocks})
 }
 
 // PostToThreadWithButtons posts a message with buttons to a thread
-func PostToThreadWithButtons(channel, ts, message string, taskID, lang string) error {
+func PostToThreadWithButtons(slack SlackClient, channel, ts, message string, taskID, lang string) error {
 	t := i18n.L(lang)
 	pauseButton := CreateButton(t("button.pause_reminder"), "pause_reminder_thread", taskID, "danger")
 	blocks := CreateMessageWithActionBlocks(message, pauseButton)
 
-	body := map[string]interface{}{
-		"channel":   channel,
-		"thread_ts": ts,
-		"blocks":    blocks,
-	}
-
-	jsonData, _ := json.Marshal(body)
-	req, err := http.NewRequest("POST", SlackAPIBaseURL()+"/chat.postMessage", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("SLACK_BOT_TOKEN"))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	var result struct {
-		OK    bool   `json:"ok"`
-		Error string `json:"error"`
-	}
-
-	bodyBytes, _ := io.ReadAll(resp.Body)
-	if err := json.Unmarshal(bodyBytes, &result); err != nil {
-		return fmt.Errorf("slack API response parse error: %v", err)
-	}
-
-	log.Printf("slack thread post response: %s", string(bodyBytes))
-
-	if !result.OK {
-		return fmt.Errorf("slack error: %s", result.Error)
-	}
-
-	return nil
+	return slack.Send(SlackMessage{Channel: channel, ThreadTS: ts, Blocks: blocks})
 }
 
 // SendReviewerReminderMessage sends a reminder message to reviewers
-func SendReviewerReminderMessage(db *gorm.DB, task models.ReviewTask) error {
+func SendReviewerReminderMessage(db *gorm.DB, slack SlackClient, task models.ReviewTask) error {
 	t := i18n.L(task.Language)
 	// Check if the channel is archived
-	isArchived, err := IsChannelArchived(task.SlackChannel)
+	isArchived, err := IsChannelArchived(slack, task.SlackChannel)
 	if err != nil {
 		log.Printf("channel status check error (channel: %s): %v", task.SlackChannel, err)
 
@@ -714,7 +576,7 @@ func SendReviewerReminderMessage(db *gorm.DB, task models.ReviewTask) error {
 
 			// Also deactivate the channel config
 			var config models.ChannelConfig
-			if result := db.Where("slack_channel_id = ?", task.SlackChannel).First(&config); result.Error == nil {
+			if result := db.Where("team_id = ? AND slack_channel_id = ?", task.TeamID, task.SlackChannel).First(&config); result.Error == nil {
 				config.IsActive = false
 				config.UpdatedAt = time.Now()
 				db.Save(&config)
@@ -735,7 +597,7 @@ func SendReviewerReminderMessage(db *gorm.DB, task models.ReviewTask) error {
 
 		// Also deactivate the channel config
 		var config models.ChannelConfig
-		if result := db.Where("slack_channel_id = ?", task.SlackChannel).First(&config); result.Error == nil {
+		if result := db.Where("team_id = ? AND slack_channel_id = ?", task.TeamID, task.SlackChannel).First(&config); result.Error == nil {
 			config.IsActive = false
 			config.UpdatedAt = time.Now()
 			db.Save(&config)
@@ -763,34 +625,11 @@ func SendReviewerReminderMessage(db *gorm.DB, task models.ReviewTask) error {
 	blocks := CreateMessageWithActionBlocks(message, pauseSelect)
 
 	// Post message with buttons to the thread
-	body := map[string]interface{}{
-		"channel":   task.SlackChannel,
-		"thread_ts": task.SlackTS,
-		"blocks":    blocks,
-	}
-
-	jsonData, _ := json.Marshal(body)
-	req, err := http.NewRequest("POST", SlackAPIBaseURL()+"/chat.postMessage", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("SLACK_BOT_TOKEN"))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	return nil
+	return slack.Send(SlackMessage{Channel: task.SlackChannel, ThreadTS: task.SlackTS, Blocks: blocks})
 }
 
 // SendReminderPausedMessage notifies that the reminder has been paused
-func SendReminderPausedMessage(task models.ReviewTask, duration string) error {
+func SendReminderPausedMessage(slack SlackClient, task models.ReviewTask, duration string) error {
 	t := i18n.L(task.Language)
 	var message string
 
@@ -809,50 +648,7 @@ func SendReminderPausedMessage(task models.ReviewTask, duration string) error {
 		message = t("notify.reminder_paused.default")
 	}
 
-	return PostToThread(task.SlackChannel, task.SlackTS, message)
-}
-
-// GetBotChannels retrieves the list of channels the bot has joined
-func GetBotChannels() ([]string, error) {
-	url := SlackAPIBaseURL() + "/conversations.list?types=public_channel,private_channel"
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("SLACK_BOT_TOKEN"))
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	var result struct {
-		OK       bool `json:"ok"`
-		Channels []struct {
-			ID   string `json:"id"`
-			Name string `json:"name"`
-		} `json:"channels"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
-	}
-
-	if !result.OK {
-		return nil, fmt.Errorf("failed to get channels")
-	}
-
-	channelIDs := make([]string, 0, len(result.Channels))
-	for _, ch := range result.Channels {
-		channelIDs = append(channelIDs, ch.ID)
-	}
-
-	return channelIDs, nil
+	return PostToThread(slack, task.SlackChannel, task.SlackTS, message)
 }
 
 // IsChannelRelatedError determines whether a Slack API error is a channel-related error
@@ -870,50 +666,22 @@ func IsChannelRelatedError(err error) bool {
 }
 
 // IsChannelArchived checks whether a channel is archived
-func IsChannelArchived(channelID string) (bool, error) {
-	url := fmt.Sprintf("%s/conversations.info?channel=%s", SlackAPIBaseURL(), channelID)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return false, err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("SLACK_BOT_TOKEN"))
-
-	resp, err := http.DefaultClient.Do(req)
+func IsChannelArchived(slack SlackClient, channelID string) (bool, error) {
+	channel, err := slack.ConversationInfo(channelID)
 	if err != nil {
-		return false, err
-	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	var result struct {
-		OK      bool `json:"ok"`
-		Channel struct {
-			ID         string `json:"id"`
-			IsArchived bool   `json:"is_archived"`
-		} `json:"channel"`
-		Error string `json:"error"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return false, err
-	}
-
-	if !result.OK {
-		if result.Error == "channel_not_found" {
+		var apiErr *SlackAPIError
+		if errors.As(err, &apiErr) && apiErr.Code == "channel_not_found" {
 			// Treat non-existent channels as archived
 			return true, nil
 		}
-		return false, fmt.Errorf("failed to get channel info: %s", result.Error)
+		return false, fmt.Errorf("failed to get channel info: %w", err)
 	}
 
-	return result.Channel.IsArchived, nil
+	return channel.IsArchived, nil
 }
 
 // PostReviewerAssignedMessageWithChangeButton displays the auto-assigned reviewers and shows a change button
-func PostReviewerAssignedMessageWithChangeButton(task models.ReviewTask) error {
+func PostReviewerAssignedMessageWithChangeButton(slack SlackClient, task models.ReviewTask) error {
 	t := i18n.L(task.Language)
 	message := t("notify.reviewer_auto_assigned", formatReviewerCSVMentions(task.Reviewers, task.Reviewer))
 
@@ -922,30 +690,7 @@ func PostReviewerAssignedMessageWithChangeButton(task models.ReviewTask) error {
 	blocks := CreateMessageWithActionsBlocks(message, changeButton, pauseSelect)
 
 	// Post message with buttons to the thread
-	body := map[string]interface{}{
-		"channel":   task.SlackChannel,
-		"thread_ts": task.SlackTS,
-		"blocks":    blocks,
-	}
-
-	jsonData, _ := json.Marshal(body)
-	req, err := http.NewRequest("POST", SlackAPIBaseURL()+"/chat.postMessage", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("SLACK_BOT_TOKEN"))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	return nil
+	return slack.Send(SlackMessage{Channel: task.SlackChannel, ThreadTS: task.SlackTS, Blocks: blocks})
 }
 
 // formatReviewerCSVMentions builds Slack mentions from a comma-separated reviewer list,
@@ -985,13 +730,13 @@ func formatReviewerMentions(reviewerIDs string) string {
 }
 
 // SendReviewerChangedMessage notifies that the reviewer has been changed
-func SendReviewerChangedMessage(task models.ReviewTask, oldReviewerID string) error {
+func SendReviewerChangedMessage(slack SlackClient, task models.ReviewTask, oldReviewerID string) error {
 	t := i18n.L(task.Language)
 	oldMentions := formatReviewerMentions(oldReviewerID)
 	newMentions := formatReviewerMentions(task.Reviewer)
 
 	message := t("notify.reviewer_changed", oldMentions, newMentions)
-	return PostToThread(task.SlackChannel, task.SlackTS, message)
+	return PostToThread(slack, task.SlackChannel, task.SlackTS, message)
 }
 
 // GetNextBusinessDayMorningWithConfig gets the next business day's opening time from the specified time (supports business hours config)
@@ -1065,7 +810,7 @@ func GetNextBusinessDayMorningWithConfig(now time.Time, config *models.ChannelCo
 }
 
 // SendOutOfHoursReminderMessage sends a reminder message for off-hours
-func SendOutOfHoursReminderMessage(db *gorm.DB, task models.ReviewTask) error {
+func SendOutOfHoursReminderMessage(slack SlackClient, task models.ReviewTask) error {
 	t := i18n.L(task.Language)
 	message := t("notify.out_of_hours_reminder", formatReviewerMentions(task.Reviewer))
 
@@ -1073,78 +818,25 @@ func SendOutOfHoursReminderMessage(db *gorm.DB, task models.ReviewTask) error {
 	blocks := CreateMessageWithActionBlocks(message, pauseSelect)
 
 	// Post message with buttons to the thread
-	body := map[string]interface{}{
-		"channel":   task.SlackChannel,
-		"thread_ts": task.SlackTS,
-		"blocks":    blocks,
-	}
-
-	jsonData, _ := json.Marshal(body)
-	req, err := http.NewRequest("POST", SlackAPIBaseURL()+"/chat.postMessage", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("SLACK_BOT_TOKEN"))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	return nil
+	return slack.Send(SlackMessage{Channel: task.SlackChannel, ThreadTS: task.SlackTS, Blocks: blocks})
 }
 
 // UpdateSlackMessageForCompletedTask updates the Slack message to indicate that the task is completed
-func UpdateSlackMessageForCompletedTask(task models.ReviewTask) error {
+func UpdateSlackMessageForCompletedTask(slack SlackClient, task models.ReviewTask) error {
 	t := i18n.L(task.Language)
-	if IsTestMode {
-		log.Printf("test mode: would update slack message for completed task: %s", task.ID)
-		return nil
-	}
-
 	message := t("notify.task_completed", task.Title, task.PRURL)
 	blocks := CreateMessageBlocks(message)
 
-	// Call the message update API
-	body := map[string]interface{}{
-		"channel": task.SlackChannel,
-		"ts":      task.SlackTS,
-		"blocks":  blocks,
-	}
-
-	jsonData, _ := json.Marshal(body)
-	req, err := http.NewRequest("POST", SlackAPIBaseURL()+"/chat.update", bytes.NewBuffer(jsonData))
-	if err != nil {
+	if err := slack.UpdateMessage(SlackMessage{Channel: task.SlackChannel, TS: task.SlackTS, Blocks: blocks}); err != nil {
 		return err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("SLACK_BOT_TOKEN"))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("slack API error: %s", string(body))
-	}
-
 	log.Printf("slack message updated for completed task: %s", task.ID)
 	return nil
 }
 
 // SendReviewCompletedAutoNotification sends an automatic notification when a review is completed
-func SendReviewCompletedAutoNotification(task models.ReviewTask, reviewerLogin string, reviewState string) error {
+func SendReviewCompletedAutoNotification(slack SlackClient, task models.ReviewTask, reviewerLogin string, reviewState string) error {
 	t := i18n.L(task.Language)
 	var message string
 	var emoji string
@@ -1164,16 +856,12 @@ func SendReviewCompletedAutoNotification(task models.ReviewTask, reviewerLogin s
 		message = t("notify.review_default", emoji, reviewerLogin)
 	}
 
-	return PostToThread(task.SlackChannel, task.SlackTS, message)
+	return PostToThread(slack, task.SlackChannel, task.SlackTS, message)
 }
 
 // PostLabelRemovedNotification notifies the thread about task completion due to label removal
-func PostLabelRemovedNotification(task models.ReviewTask, removedLabels []string) error {
+func PostLabelRemovedNotification(slack SlackClient, task models.ReviewTask, removedLabels []string) error {
 	t := i18n.L(task.Language)
-	if IsTestMode {
-		log.Printf("test mode: would post label removed notification for task: %s", task.ID)
-		return nil
-	}
 
 	var message string
 	if len(removedLabels) == 1 {
@@ -1182,16 +870,12 @@ func PostLabelRemovedNotification(task models.ReviewTask, removedLabels []string
 		message = t("notify.label_removed_multiple", strings.Join(removedLabels, "`, `"))
 	}
 
-	return PostToThread(task.SlackChannel, task.SlackTS, message)
+	return PostToThread(slack, task.SlackChannel, task.SlackTS, message)
 }
 
 // PostPRClosedNotification notifies the thread that the PR has been closed
-func PostPRClosedNotification(task models.ReviewTask, merged bool) error {
+func PostPRClosedNotification(slack SlackClient, task models.ReviewTask, merged bool) error {
 	t := i18n.L(task.Language)
-	if IsTestMode {
-		log.Printf("test mode: would post PR closed notification for task: %s (merged: %v)", task.ID, merged)
-		return nil
-	}
 
 	var message string
 	if merged {
@@ -1200,5 +884,5 @@ func PostPRClosedNotification(task models.ReviewTask, merged bool) error {
 		message = t("notify.pr_closed")
 	}
 
-	return PostToThread(task.SlackChannel, task.SlackTS, message)
+	return PostToThread(slack, task.SlackChannel, task.SlackTS, message)
 }
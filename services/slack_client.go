@@ -0,0 +1,263 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// SlackClient is the Slack Web API as used by the app. Handlers and services
+// receive it as a parameter; main wires the HTTP client (wrapped in the
+// SlackOutbox) and tests use FakeSlackClient.
+type SlackClient interface {
+	// PostMessage posts msg (chat.postMessage) and returns the posted
+	// message's channel and ts.
+	PostMessage(msg SlackMessage) (SlackPostResponse, error)
+	// Send posts msg when the caller does not need the posted message back.
+	// It may be queued and delivered later (see SlackOutbox).
+	Send(msg SlackMessage) error
+	// UpdateMessage replaces the message msg.TS in msg.Channel (chat.update).
+	UpdateMessage(msg SlackMessage) error
+	PostEphemeral(channel, user, text string) error
+	ConversationInfo(channelID string) (SlackChannel, error)
+	GetPermalink(channel, ts string) (string, error)
+	OpenView(triggerID string, view map[string]interface{}) error
+	UpdateView(viewID string, view map[string]interface{}) error
+	PublishView(userID string, view map[string]interface{}) error
+	UserInfo(userID string) (SlackUser, error)
+	// ForTeam returns the client that calls Slack with the bot token of the
+	// team (see SlackWorkspaces). Single-token clients return themselves.
+	ForTeam(teamID string) SlackClient
+}
+
+// SlackMessage is a chat.postMessage / chat.update request.
+type SlackMessage struct {
+	Channel  string                   `json:"channel"`
+	TS       string                   `json:"ts,omitempty"`        // message to update (chat.update)
+	ThreadTS string                   `json:"thread_ts,omitempty"` // thread to reply in
+	Text     string                   `json:"text,omitempty"`
+	Blocks   []map[string]interface{} `json:"blocks,omitempty"`
+}
+
+// SlackChannel is the part of a conversations.info channel the app uses.
+type SlackChannel struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	IsArchived bool   `json:"is_archived"`
+}
+
+// SlackUser is the part of a users.info user the app uses.
+type SlackUser struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	RealName string `json:"real_name"`
+	TZ       string `json:"tz"`
+	Deleted  bool   `json:"deleted"`
+	IsBot    bool   `json:"is_bot"`
+}
+
+// SlackAPIError is a failed Slack Web API call. SlackClient methods return
+// their failures as *SlackAPIError.
+type SlackAPIError struct {
+	Method     string
+	Code       string        // Slack error code such as channel_not_found, or request_failed
+	StatusCode int           // HTTP status, 0 when no response was received
+	RetryAfter time.Duration // from the Retry-After header of a rate-limited call
+	Err        error         // transport error for request_failed
+}
+
+func (e *SlackAPIError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("slack error: %s (%s): %v", e.Code, e.Method, e.Err)
+	}
+	return fmt.Sprintf("slack error: %s (%s)", e.Code, e.Method)
+}
+
+func (e *SlackAPIError) Unwrap() error {
+	return e.Err
+}
+
+// Temporary reports whether the call may succeed when retried.
+func (e *SlackAPIError) Temporary() bool {
+	switch e.Code {
+	case "ratelimited", "request_failed", "internal_error", "fatal_error", "service_unavailable", "request_timeout":
+		return true
+	}
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// IsSlackRateLimited reports whether err is a rate-limited Slack call.
+func IsSlackRateLimited(err error) bool {
+	var apiErr *SlackAPIError
+	return errors.As(err, &apiErr) && (apiErr.Code == "ratelimited" || apiErr.StatusCode == http.StatusTooManyRequests)
+}
+
+// httpSlackClient calls the Slack Web API at SlackAPIBaseURL with a bot token.
+type httpSlackClient struct {
+	token string
+}
+
+// NewSlackClient returns a SlackClient that calls the Slack Web API with the
+// bot token.
+func NewSlackClient(token string) SlackClient {
+	return &httpSlackClient{token: token}
+}
+
+func (c *httpSlackClient) PostMessage(msg SlackMessage) (SlackPostResponse, error) {
+	var result SlackPostResponse
+	err := c.post("chat.postMessage", msg, &result)
+	return result, err
+}
+
+func (c *httpSlackClient) Send(msg SlackMessage) error {
+	return c.post("chat.postMessage", msg, nil)
+}
+
+func (c *httpSlackClient) UpdateMessage(msg SlackMessage) error {
+	return c.post("chat.update", msg, nil)
+}
+
+func (c *httpSlackClient) PostEphemeral(channel, user, text string) error {
+	return c.post("chat.postEphemeral", map[string]interface{}{
+		"channel": channel,
+		"user":    user,
+		"text":    text,
+	}, nil)
+}
+
+func (c *httpSlackClient) ConversationInfo(channelID string) (SlackChannel, error) {
+	var result struct {
+		Channel SlackChannel `json:"channel"`
+	}
+	err := c.get("conversations.info", url.Values{"channel": {channelID}}, &result)
+	return result.Channel, err
+}
+
+func (c *httpSlackClient) GetPermalink(channel, ts string) (string, error) {
+	var result struct {
+		Permalink string `json:"permalink"`
+	}
+	err := c.get("chat.getPermalink", url.Values{"channel": {channel}, "message_ts": {ts}}, &result)
+	return result.Permalink, err
+}
+
+func (c *httpSlackClient) OpenView(triggerID string, view map[string]interface{}) error {
+	return c.post("views.open", map[string]interface{}{
+		"trigger_id": triggerID,
+		"view":       view,
+	}, nil)
+}
+
+func (c *httpSlackClient) UpdateView(viewID string, view map[string]interface{}) error {
+	return c.post("views.update", map[string]interface{}{
+		"view_id": viewID,
+		"view":    view,
+	}, nil)
+}
+
+func (c *httpSlackClient) PublishView(userID string, view map[string]interface{}) error {
+	return c.post("views.publish", map[string]interface{}{
+		"user_id": userID,
+		"view":    view,
+	}, nil)
+}
+
+func (c *httpSlackClient) UserInfo(userID string) (SlackUser, error) {
+	var result struct {
+		User SlackUser `json:"user"`
+	}
+	err := c.get("users.info", url.Values{"user": {userID}}, &result)
+	return result.User, err
+}
+
+func (c *httpSlackClient) ForTeam(teamID string) SlackClient {
+	return c
+}
+
+// post calls the method with payload as its JSON body and decodes the
+// response into result when it is not nil.
+func (c *httpSlackClient) post(method string, payload interface{}, result interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", SlackAPIBaseURL()+"/"+method, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req, method, result)
+}
+
+// get calls the method with params as its query string.
+func (c *httpSlackClient) get(method string, params url.Values, result interface{}) error {
+	req, err := http.NewRequest("GET", SlackAPIBaseURL()+"/"+method+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, method, result)
+}
+
+func (c *httpSlackClient) do(req *http.Request, method string, result interface{}) error {
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := doSlackAPIRequest(req)
+	if err != nil {
+		return &SlackAPIError{Method: method, Code: "request_failed", Err: err}
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	apiErr := &SlackAPIError{
+		Method:     method,
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	var base struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(bodyBytes, &base); err != nil {
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			apiErr.Code = "ratelimited"
+		case resp.StatusCode >= http.StatusInternalServerError:
+			apiErr.Code = fmt.Sprintf("http_%d", resp.StatusCode)
+		default:
+			apiErr.Code = "invalid_response"
+		}
+		return apiErr
+	}
+	if !base.OK {
+		apiErr.Code = base.Error
+		if apiErr.Code == "" {
+			apiErr.Code = "unknown_error"
+		}
+		return apiErr
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(bodyBytes, result); err != nil {
+			return fmt.Errorf("slack API response parse error: %v", err)
+		}
+	}
+	return nil
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds.
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+)
+
+// messageJSON renders a recorded message as JSON without HTML escaping, so
+// mentions such as <@U1> can be matched as written.
+func messageJSON(t *testing.T, msg SlackMessage) string {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(msg); err != nil {
+		t.Fatalf("failed to encode slack message: %v", err)
+	}
+	return buf.String()
+}
+
+func TestSlackClient_PostMessage(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://slack.com").
+		Post("/api/chat.postMessage").
+		MatchHeader("Authorization", "Bearer test-token").
+		MatchHeader("Content-Type", "application/json").
+		BodyString(`"thread_ts":"111.1"`).
+		Reply(200).
+		JSON(map[string]interface{}{"ok": true, "channel": "C1", "ts": "222.2"})
+
+	resp, err := NewSlackClient("test-token").PostMessage(SlackMessage{Channel: "C1", ThreadTS: "111.1", Text: "hi"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "C1", resp.Channel)
+	assert.Equal(t, "222.2", resp.Ts)
+	assert.True(t, gock.IsDone())
+}
+
+func TestSlackClient_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       map[string]interface{}
+		retryAfter string
+		code       string
+		temporary  bool
+	}{
+		{"slack error", 200, map[string]interface{}{"ok": false, "error": "channel_not_found"}, "", "channel_not_found", false},
+		{"rate limited", 429, map[string]interface{}{"ok": false, "error": "ratelimited"}, "30", "ratelimited", true},
+		{"server error without body", 503, nil, "", "http_503", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer gock.Off()
+			reply := gock.New("https://slack.com").
+				Post("/api/chat.update").
+				Reply(tt.status).
+				SetHeader("Retry-After", tt.retryAfter)
+			if tt.body != nil {
+				reply.JSON(tt.body)
+			}
+
+			err := NewSlackClient("test-token").UpdateMessage(SlackMessage{Channel: "C1", TS: "1.1", Text: "x"})
+
+			var apiErr *SlackAPIError
+			if assert.True(t, errors.As(err, &apiErr)) {
+				assert.Equal(t, "chat.update", apiErr.Method)
+				assert.Equal(t, tt.code, apiErr.Code)
+				assert.Equal(t, tt.status, apiErr.StatusCode)
+				assert.Equal(t, tt.temporary, apiErr.Temporary())
+			}
+			if tt.retryAfter != "" {
+				assert.Equal(t, 30*time.Second, apiErr.RetryAfter)
+				assert.True(t, IsSlackRateLimited(err))
+			}
+		})
+	}
+}
+
+func TestSlackClient_RequestFailed(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://slack.com").
+		Post("/api/chat.postEphemeral").
+		ReplyError(errors.New("connection reset"))
+
+	err := NewSlackClient("test-token").PostEphemeral("C1", "U1", "hi")
+
+	var apiErr *SlackAPIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, "request_failed", apiErr.Code)
+		assert.True(t, apiErr.Temporary())
+	}
+}
+
+func TestSlackClient_UserInfo(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://slack.com").
+		Get("/api/users.info").
+		MatchParam("user", "U1").
+		Reply(200).
+		JSON(map[string]interface{}{
+			"ok":   true,
+			"user": map[string]interface{}{"id": "U1", "name": "alice", "tz": "Asia/Tokyo", "is_bot": false},
+		})
+
+	user, err := NewSlackClient("test-token").UserInfo("U1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, SlackUser{ID: "U1", Name: "alice", TZ: "Asia/Tokyo"}, user)
+}
+
+func TestSlackClient_PublishView(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://slack.com").
+		Post("/api/views.publish").
+		BodyString(`"user_id":"U1"`).
+		Reply(200).
+		JSON(map[string]interface{}{"ok": true})
+
+	err := NewSlackClient("test-token").PublishView("U1", map[string]interface{}{"type": "home"})
+
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+}
+
+func TestSlackClient_OpenView_PostsCorrectPayload(t *testing.T) {
+	var gotPath, gotAuth, gotBody string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true,"view":{"id":"V1"}}`))
+	}))
+	defer ts.Close()
+
+	t.Setenv("SLACK_API_BASE_URL", ts.URL)
+
+	view := map[string]interface{}{"type": "modal", "callback_id": "x"}
+	if err := NewSlackClient("xoxb-test").OpenView("trigger123", view); err != nil {
+		t.Fatalf("OpenView returned error: %v", err)
+	}
+	if gotPath != "/views.open" {
+		t.Errorf("path = %s, want /views.open", gotPath)
+	}
+	if gotAuth != "Bearer xoxb-test" {
+		t.Errorf("auth = %s", gotAuth)
+	}
+
+	var payload struct {
+		TriggerID string                 `json:"trigger_id"`
+		View      map[string]interface{} `json:"view"`
+	}
+	if err := json.Unmarshal([]byte(gotBody), &payload); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, gotBody)
+	}
+	if payload.TriggerID != "trigger123" {
+		t.Errorf("trigger_id = %q", payload.TriggerID)
+	}
+	if payload.View["callback_id"] != "x" {
+		t.Errorf("view.callback_id = %v", payload.View["callback_id"])
+	}
+}
+
+func TestSlackClient_OpenView_SlackErrorReturnsError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ok":false,"error":"invalid_trigger_id"}`))
+	}))
+	defer ts.Close()
+
+	t.Setenv("SLACK_API_BASE_URL", ts.URL)
+
+	err := NewSlackClient("xoxb-test").OpenView("trig", map[string]interface{}{})
+	if err == nil {
+		t.Fatalf("expected error from non-ok response")
+	}
+}
+
+func TestFakeSlackClient(t *testing.T) {
+	slack := NewFakeSlackClient()
+	slack.ArchivedChannels["C_OLD"] = true
+
+	first, err := slack.PostMessage(SlackMessage{Channel: "C1", Text: "one"})
+	assert.NoError(t, err)
+	assert.NoError(t, slack.Send(SlackMessage{Channel: "C1", ThreadTS: first.Ts, Text: "two"}))
+	assert.NoError(t, slack.PostEphemeral("C1", "U1", "only you"))
+	assert.NoError(t, slack.OpenView("trigger-1", map[string]interface{}{"type": "modal"}))
+
+	msgs := slack.Messages()
+	if assert.Len(t, msgs, 2) {
+		assert.Equal(t, first.Ts, msgs[1].ThreadTS)
+	}
+	assert.Equal(t, []FakeEphemeral{{Channel: "C1", User: "U1", Text: "only you"}}, slack.Ephemerals())
+	if views := slack.Views(); assert.Len(t, views, 1) {
+		assert.Equal(t, "views.open", views[0].Method)
+		assert.Equal(t, "trigger-1", views[0].Target)
+	}
+
+	archived, err := IsChannelArchived(slack, "C_OLD")
+	assert.NoError(t, err)
+	assert.True(t, archived)
+
+	_, err = slack.UserInfo("U_UNKNOWN")
+	var apiErr *SlackAPIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "user_not_found", apiErr.Code)
+
+	slack.Errors["chat.postMessage"] = &SlackAPIError{Method: "chat.postMessage", Code: "not_in_channel", StatusCode: http.StatusOK}
+	assert.True(t, IsChannelRelatedError(slack.Send(SlackMessage{Channel: "C2", Text: "three"})))
+	assert.Len(t, slack.Messages(), 2)
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+)
+
+// FakeSlackClient is a SlackClient for tests. It records every call instead
+// of calling Slack and answers from its fields.
+type FakeSlackClient struct {
+	// ArchivedChannels are reported as archived by ConversationInfo.
+	ArchivedChannels map[string]bool
+	// Users are returned by UserInfo; unknown users get user_not_found.
+	Users map[string]SlackUser
+	// Errors makes calls to the Slack API method (e.g. "chat.postMessage")
+	// fail with the error.
+	Errors map[string]error
+
+	mu         sync.Mutex
+	messages   []SlackMessage
+	updates    []SlackMessage
+	ephemerals []FakeEphemeral
+	views      []FakeView
+	teams      []string
+	nextTS     int
+}
+
+// FakeEphemeral is a recorded chat.postEphemeral call.
+type FakeEphemeral struct {
+	Channel string
+	User    string
+	Text    string
+}
+
+// FakeView is a recorded views.* call. Target is the trigger ID, view ID or
+// user ID the call was made for.
+type FakeView struct {
+	Method string
+	Target string
+	View   map[string]interface{}
+}
+
+// NewFakeSlackClient returns an empty FakeSlackClient.
+func NewFakeSlackClient() *FakeSlackClient {
+	return &FakeSlackClient{
+		ArchivedChannels: make(map[string]bool),
+		Users:            make(map[string]SlackUser),
+		Errors:           make(map[string]error),
+	}
+}
+
+// Messages returns the messages posted with PostMessage or Send, in order.
+func (f *FakeSlackClient) Messages() []SlackMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]SlackMessage(nil), f.messages...)
+}
+
+// Updates returns the messages passed to UpdateMessage, in order.
+func (f *FakeSlackClient) Updates() []SlackMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]SlackMessage(nil), f.updates...)
+}
+
+// Ephemerals returns the recorded ephemeral messages, in order.
+func (f *FakeSlackClient) Ephemerals() []FakeEphemeral {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeEphemeral(nil), f.ephemerals...)
+}
+
+// Views returns the recorded views.* calls, in order.
+func (f *FakeSlackClient) Views() []FakeView {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeView(nil), f.views...)
+}
+
+func (f *FakeSlackClient) PostMessage(msg SlackMessage) (SlackPostResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.Errors["chat.postMessage"]; err != nil {
+		return SlackPostResponse{}, err
+	}
+	f.messages = append(f.messages, msg)
+	f.nextTS++
+	return SlackPostResponse{OK: true, Channel: msg.Channel, Ts: fmt.Sprintf("1700000000.%06d", f.nextTS)}, nil
+}
+
+func (f *FakeSlackClient) Send(msg SlackMessage) error {
+	_, err := f.PostMessage(msg)
+	return err
+}
+
+func (f *FakeSlackClient) UpdateMessage(msg SlackMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.Errors["chat.update"]; err != nil {
+		return err
+	}
+	f.updates = append(f.updates, msg)
+	return nil
+}
+
+func (f *FakeSlackClient) PostEphemeral(channel, user, text string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.Errors["chat.postEphemeral"]; err != nil {
+		return err
+	}
+	f.ephemerals = append(f.ephemerals, FakeEphemeral{Channel: channel, User: user, Text: text})
+	return nil
+}
+
+func (f *FakeSlackClient) ConversationInfo(channelID string) (SlackChannel, error) {
+	if err := f.Errors["conversations.info"]; err != nil {
+		return SlackChannel{}, err
+	}
+	return SlackChannel{ID: channelID, IsArchived: f.ArchivedChannels[channelID]}, nil
+}
+
+func (f *FakeSlackClient) GetPermalink(channel, ts string) (string, error) {
+	if err := f.Errors["chat.getPermalink"]; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://example.slack.com/archives/%s/p%s", channel, ts), nil
+}
+
+func (f *FakeSlackClient) OpenView(triggerID string, view map[string]interface{}) error {
+	return f.recordView("views.open", triggerID, view)
+}
+
+func (f *FakeSlackClient) UpdateView(viewID string, view map[string]interface{}) error {
+	return f.recordView("views.update", viewID, view)
+}
+
+func (f *FakeSlackClient) PublishView(userID string, view map[string]interface{}) error {
+	return f.recordView("views.publish", userID, view)
+}
+
+func (f *FakeSlackClient) UserInfo(userID string) (SlackUser, error) {
+	if err := f.Errors["users.info"]; err != nil {
+		return SlackUser{}, err
+	}
+	user, ok := f.Users[userID]
+	if !ok {
+		return SlackUser{}, &SlackAPIError{Method: "users.info", Code: "user_not_found"}
+	}
+	return user, nil
+}
+
+// ForTeam records teamID and returns f, so one fake serves every team.
+func (f *FakeSlackClient) ForTeam(teamID string) SlackClient {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.teams = append(f.teams, teamID)
+	return f
+}
+
+// Teams returns the team IDs passed to ForTeam, in order.
+func (f *FakeSlackClient) Teams() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.teams...)
+}
+
+func (f *FakeSlackClient) recordView(method, target string, view map[string]interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.Errors[method]; err != nil {
+		return err
+	}
+	f.views = append(f.views, FakeView{Method: method, Target: target, View: view})
+	return nil
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"slack-review-notify/models"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// slackOAuthAuthorizeURL is where the install flow sends the installer.
+	slackOAuthAuthorizeURL = "https://slack.com/oauth/v2/authorize"
+	// defaultSlackOAuthScopes are the bot scopes requested unless
+	// SLACK_OAUTH_SCOPES is set.
+	defaultSlackOAuthScopes = "chat:write,chat:write.public,commands,channels:read,groups:read"
+)
+
+// SlackTeamKey returns the ID rows and installations of a Slack request are
+// scoped by: the enterprise ID for an Enterprise Grid org-wide install, the
+// workspace (team) ID otherwise.
+func SlackTeamKey(teamID, enterpriseID string, isEnterpriseInstall bool) string {
+	if isEnterpriseInstall && enterpriseID != "" {
+		return enterpriseID
+	}
+	return teamID
+}
+
+// IsSlackOAuthEnabled reports whether the OAuth install flow is configured
+// (SLACK_CLIENT_ID and SLACK_CLIENT_SECRET).
+func IsSlackOAuthEnabled() bool {
+	return os.Getenv("SLACK_CLIENT_ID") != "" && os.Getenv("SLACK_CLIENT_SECRET") != ""
+}
+
+// SlackOAuthAuthorizeURL returns the Slack page that asks the installer to
+// approve the app, coming back to SLACK_OAUTH_REDIRECT_URL with state.
+func SlackOAuthAuthorizeURL(state string) string {
+	scopes := os.Getenv("SLACK_OAUTH_SCOPES")
+	if scopes == "" {
+		scopes = defaultSlackOAuthScopes
+	}
+	params := url.Values{
+		"client_id": {os.Getenv("SLACK_CLIENT_ID")},
+		"scope":     {scopes},
+		"state":     {state},
+	}
+	if redirectURL := os.Getenv("SLACK_OAUTH_REDIRECT_URL"); redirectURL != "" {
+		params.Set("redirect_uri", redirectURL)
+	}
+	return slackOAuthAuthorizeURL + "?" + params.Encode()
+}
+
+// ExchangeSlackOAuthCode trades the code of the OAuth callback for the
+// installation's bot token (oauth.v2.access).
+func ExchangeSlackOAuthCode(code string) (*models.SlackInstallation, error) {
+	form := url.Values{
+		"code":          {code},
+		"client_id":     {os.Getenv("SLACK_CLIENT_ID")},
+		"client_secret": {os.Getenv("SLACK_CLIENT_SECRET")},
+	}
+	if redirectURL := os.Getenv("SLACK_OAUTH_REDIRECT_URL"); redirectURL != "" {
+		form.Set("redirect_uri", redirectURL)
+	}
+	req, err := http.NewRequest("POST", SlackAPIBaseURL()+"/oauth.v2.access", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := doSlackAPIRequest(req)
+	if err != nil {
+		return nil, &SlackAPIError{Method: "oauth.v2.access", Code: "request_failed", Err: err}
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, _ := io.ReadAll(resp.Body)
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Error       string `json:"error"`
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		Scope       string `json:"scope"`
+		BotUserID   string `json:"bot_user_id"`
+		Team        *struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"team"`
+		Enterprise *struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"enterprise"`
+		IsEnterpriseInstall bool `json:"is_enterprise_install"`
+		AuthedUser          struct {
+			ID string `json:"id"`
+		} `json:"authed_user"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, &SlackAPIError{Method: "oauth.v2.access", Code: "invalid_response", StatusCode: resp.StatusCode}
+	}
+	if !result.OK {
+		return nil, &SlackAPIError{Method: "oauth.v2.access", Code: result.Error, StatusCode: resp.StatusCode}
+	}
+	if result.TokenType != "bot" || result.AccessToken == "" {
+		return nil, fmt.Errorf("oauth.v2.access returned no bot token")
+	}
+
+	installation := &models.SlackInstallation{
+		IsEnterpriseInstall: result.IsEnterpriseInstall,
+		BotUserID:           result.BotUserID,
+		BotToken:            result.AccessToken,
+		Scope:               result.Scope,
+		InstalledBy:         result.AuthedUser.ID,
+	}
+	var teamID string
+	if result.Team != nil {
+		teamID = result.Team.ID
+		installation.TeamName = result.Team.Name
+	}
+	if result.Enterprise != nil {
+		installation.EnterpriseID = result.Enterprise.ID
+		if installation.TeamName == "" {
+			installation.TeamName = result.Enterprise.Name
+		}
+	}
+	installation.TeamID = SlackTeamKey(teamID, installation.EnterpriseID, installation.IsEnterpriseInstall)
+	if installation.TeamID == "" {
+		return nil, fmt.Errorf("oauth.v2.access returned no team")
+	}
+	return installation, nil
+}
+
+// SaveSlackInstallation stores the installation, replacing the token of an
+// earlier install to the same team.
+func SaveSlackInstallation(db *gorm.DB, installation *models.SlackInstallation) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "team_id"}},
+		UpdateAll: true,
+	}).Create(installation).Error
+}
+
+// AdoptSlackTeam assigns the rows created before the app supported several
+// workspaces, which have no team, to the team of the SLACK_BOT_TOKEN
+// deployment. teamID comes from SLACK_TEAM_ID; when it is empty the team is
+// looked up with auth.test.
+func AdoptSlackTeam(db *gorm.DB, token, teamID string) error {
+	tables := []string{"channel_configs", "review_tasks", "user_mappings", "dm_digest_subscriptions", "team_mappings", "reviewer_rotations", "review_events"}
+
+	var unscoped bool
+	for _, table := range tables {
+		var count int64
+		if err := db.Table(table).Where("team_id = ''").Count(&count).Error; err != nil {
+			return err
+		}
+		unscoped = unscoped || count > 0
+	}
+	if !unscoped {
+		return nil
+	}
+
+	if teamID == "" {
+		if token == "" {
+			return fmt.Errorf("rows without a Slack team exist; set SLACK_TEAM_ID or SLACK_BOT_TOKEN to assign them")
+		}
+		var auth struct {
+			TeamID              string `json:"team_id"`
+			EnterpriseID        string `json:"enterprise_id"`
+			IsEnterpriseInstall bool   `json:"is_enterprise_install"`
+		}
+		if err := (&httpSlackClient{token: token}).post("auth.test", map[string]interface{}{}, &auth); err != nil {
+			return fmt.Errorf("cannot find the team of SLACK_BOT_TOKEN, set SLACK_TEAM_ID: %w", err)
+		}
+		teamID = SlackTeamKey(auth.TeamID, auth.EnterpriseID, auth.IsEnterpriseInstall)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range tables {
+			result := tx.Table(table).Where("team_id = ''").Update("team_id", teamID)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				log.Printf("assigned %d %s row(s) to slack team %s", result.RowsAffected, table, teamID)
+			}
+		}
+		return nil
+	})
+}
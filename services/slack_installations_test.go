@@ -0,0 +1,217 @@
+package services
+
+import (
+	"net/http"
+	"net/url"
+	"slack-review-notify/models"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlackTeamKey(t *testing.T) {
+	assert.Equal(t, "T1", SlackTeamKey("T1", "", false))
+	assert.Equal(t, "T1", SlackTeamKey("T1", "E1", false))
+	assert.Equal(t, "E1", SlackTeamKey("T1", "E1", true))
+	assert.Equal(t, "E1", SlackTeamKey("", "E1", true))
+}
+
+func TestSlackOAuthAuthorizeURL(t *testing.T) {
+	t.Setenv("SLACK_CLIENT_ID", "123.456")
+	t.Setenv("SLACK_OAUTH_REDIRECT_URL", "https://review.example.com/slack/oauth/callback")
+	t.Setenv("SLACK_OAUTH_SCOPES", "")
+
+	u, err := url.Parse(SlackOAuthAuthorizeURL("state-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "slack.com", u.Host)
+	assert.Equal(t, "/oauth/v2/authorize", u.Path)
+	assert.Equal(t, "123.456", u.Query().Get("client_id"))
+	assert.Equal(t, defaultSlackOAuthScopes, u.Query().Get("scope"))
+	assert.Equal(t, "https://review.example.com/slack/oauth/callback", u.Query().Get("redirect_uri"))
+	assert.Equal(t, "state-1", u.Query().Get("state"))
+}
+
+func TestExchangeSlackOAuthCode(t *testing.T) {
+	t.Setenv("SLACK_CLIENT_ID", "123.456")
+	t.Setenv("SLACK_CLIENT_SECRET", "secret")
+	defer gock.Off()
+
+	t.Run("workspace install", func(t *testing.T) {
+		gock.New("https://slack.com").
+			Post("/api/oauth.v2.access").
+			BodyString("code=code-1").
+			Reply(200).
+			JSON(map[string]interface{}{
+				"ok":           true,
+				"access_token": "xoxb-team-1",
+				"token_type":   "bot",
+				"scope":        "chat:write,commands",
+				"bot_user_id":  "UBOT",
+				"team":         map[string]interface{}{"id": "T1", "name": "Team One"},
+				"enterprise":   nil,
+				"authed_user":  map[string]interface{}{"id": "UINSTALLER"},
+			})
+
+		installation, err := ExchangeSlackOAuthCode("code-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "T1", installation.TeamID)
+		assert.Equal(t, "Team One", installation.TeamName)
+		assert.Equal(t, "xoxb-team-1", installation.BotToken)
+		assert.Equal(t, "UBOT", installation.BotUserID)
+		assert.Equal(t, "UINSTALLER", installation.InstalledBy)
+		assert.False(t, installation.IsEnterpriseInstall)
+	})
+
+	t.Run("org-wide install is keyed by the enterprise", func(t *testing.T) {
+		gock.New("https://slack.com").
+			Post("/api/oauth.v2.access").
+			Reply(200).
+			JSON(map[string]interface{}{
+				"ok":                    true,
+				"access_token":          "xoxb-org",
+				"token_type":            "bot",
+				"team":                  nil,
+				"enterprise":            map[string]interface{}{"id": "E1", "name": "Org"},
+				"is_enterprise_install": true,
+			})
+
+		installation, err := ExchangeSlackOAuthCode("code-2")
+		assert.NoError(t, err)
+		assert.Equal(t, "E1", installation.TeamID)
+		assert.Equal(t, "E1", installation.EnterpriseID)
+		assert.Equal(t, "Org", installation.TeamName)
+		assert.True(t, installation.IsEnterpriseInstall)
+	})
+
+	t.Run("slack error", func(t *testing.T) {
+		gock.New("https://slack.com").
+			Post("/api/oauth.v2.access").
+			Reply(200).
+			JSON(map[string]interface{}{"ok": false, "error": "invalid_code"})
+
+		_, err := ExchangeSlackOAuthCode("bad")
+		var apiErr *SlackAPIError
+		if assert.ErrorAs(t, err, &apiErr) {
+			assert.Equal(t, "invalid_code", apiErr.Code)
+		}
+	})
+
+	assert.True(t, gock.IsDone())
+}
+
+func TestSaveSlackInstallation(t *testing.T) {
+	db := setupTestDB(t)
+
+	assert.NoError(t, SaveSlackInstallation(db, &models.SlackInstallation{TeamID: "T1", TeamName: "Team One", BotToken: "xoxb-old"}))
+	// Reinstalling replaces the token
+	assert.NoError(t, SaveSlackInstallation(db, &models.SlackInstallation{TeamID: "T1", TeamName: "Team One", BotToken: "xoxb-new"}))
+
+	var installations []models.SlackInstallation
+	db.Find(&installations)
+	if assert.Len(t, installations, 1) {
+		assert.Equal(t, "xoxb-new", installations[0].BotToken)
+	}
+}
+
+func TestAdoptSlackTeam(t *testing.T) {
+	t.Run("nothing to adopt", func(t *testing.T) {
+		db := setupTestDB(t)
+		db.Create(&models.ChannelConfig{ID: "c1", TeamID: "T1", SlackChannelID: "C1", LabelName: "needs-review"})
+
+		// Neither SLACK_TEAM_ID nor a token is needed
+		assert.NoError(t, AdoptSlackTeam(db, "", ""))
+	})
+
+	t.Run("SLACK_TEAM_ID", func(t *testing.T) {
+		db := setupTestDB(t)
+		db.Create(&models.ChannelConfig{ID: "c1", SlackChannelID: "C1", LabelName: "needs-review"})
+		db.Create(&models.ChannelConfig{ID: "c2", TeamID: "T2", SlackChannelID: "C2", LabelName: "needs-review"})
+		db.Create(&models.ReviewTask{ID: "r1", SlackChannel: "C1", Status: "in_review"})
+		db.Create(&models.UserMapping{ID: "m1", GithubUsername: "octocat", SlackUserID: "U1"})
+		db.Create(&models.DMDigestSubscription{ID: "d1", SlackUserID: "U1"})
+		db.Create(&models.ReviewerRotation{ID: "rr1", SlackChannelID: "C1", LabelName: "needs-review", LastReviewerID: "U1"})
+
+		assert.NoError(t, AdoptSlackTeam(db, "", "T1"))
+
+		var adopted, other models.ChannelConfig
+		db.First(&adopted, "id = ?", "c1")
+		assert.Equal(t, "T1", adopted.TeamID)
+		db.First(&other, "id = ?", "c2")
+		assert.Equal(t, "T2", other.TeamID)
+		var task models.ReviewTask
+		db.First(&task, "id = ?", "r1")
+		assert.Equal(t, "T1", task.TeamID)
+		var mapping models.UserMapping
+		db.First(&mapping, "id = ?", "m1")
+		assert.Equal(t, "T1", mapping.TeamID)
+		var sub models.DMDigestSubscription
+		db.First(&sub, "id = ?", "d1")
+		assert.Equal(t, "T1", sub.TeamID)
+		var rotation models.ReviewerRotation
+		db.First(&rotation, "id = ?", "rr1")
+		assert.Equal(t, "T1", rotation.TeamID)
+	})
+
+	t.Run("team of the bot token", func(t *testing.T) {
+		db := setupTestDB(t)
+		db.Create(&models.UserMapping{ID: "m1", GithubUsername: "octocat", SlackUserID: "U1"})
+
+		defer gock.Off()
+		gock.New("https://slack.com").
+			Post("/api/auth.test").
+			MatchHeader("Authorization", "Bearer xoxb-default").
+			Reply(200).
+			JSON(map[string]interface{}{"ok": true, "team_id": "T9"})
+
+		assert.NoError(t, AdoptSlackTeam(db, "xoxb-default", ""))
+
+		var mapping models.UserMapping
+		db.First(&mapping, "id = ?", "m1")
+		assert.Equal(t, "T9", mapping.TeamID)
+	})
+
+	t.Run("no way to find the team", func(t *testing.T) {
+		db := setupTestDB(t)
+		db.Create(&models.UserMapping{ID: "m1", GithubUsername: "octocat", SlackUserID: "U1"})
+
+		assert.Error(t, AdoptSlackTeam(db, "", ""))
+	})
+}
+
+func TestSlackWorkspacesRouting(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, SaveSlackInstallation(db, &models.SlackInstallation{TeamID: "T1", BotToken: "xoxb-team-1"}))
+	assert.NoError(t, SaveSlackInstallation(db, &models.SlackInstallation{TeamID: "T2", BotToken: "xoxb-team-2"}))
+	db.Create(&models.ChannelConfig{ID: "c1", TeamID: "T1", SlackChannelID: "C1", LabelName: "needs-review"})
+	db.Create(&models.ReviewTask{ID: "r2", TeamID: "T2", SlackChannel: "C2", Status: "in_review"})
+	db.Create(&models.ChannelConfig{ID: "c3", TeamID: "T3", SlackChannelID: "C3", LabelName: "needs-review"})
+
+	defer gock.Off()
+	var tokens []string
+	gock.New("https://slack.com").
+		Post("/api/chat.postMessage").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			tokens = append(tokens, req.Header.Get("Authorization"))
+			return true, nil
+		}).
+		Persist().
+		Reply(200).
+		JSON(map[string]interface{}{"ok": true, "ts": "1.1"})
+
+	slack := NewSlackWorkspaces(db, "xoxb-default")
+	for _, channel := range []string{"C1", "C2", "C3", "DUNKNOWN"} {
+		_, err := slack.PostMessage(SlackMessage{Channel: channel, Text: "hi"})
+		assert.NoError(t, err)
+	}
+	_, err := slack.ForTeam("T2").PostMessage(SlackMessage{Channel: "C1", Text: "hi"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"Bearer xoxb-team-1",  // config of C1
+		"Bearer xoxb-team-2",  // task of C2
+		"Bearer xoxb-default", // T3 is not installed
+		"Bearer xoxb-default", // unknown channel
+		"Bearer xoxb-team-2",  // explicit team
+	}, tokens)
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"slack-review-notify/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Statuses of a SlackOutboxMessage.
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+	OutboxStatusFailed  = "failed"
+)
+
+const (
+	// outboxMaxAttempts is how many times a temporarily failing message is
+	// tried before it is recorded as failed.
+	outboxMaxAttempts = 8
+	// outboxClaimLease keeps a message that is being sent away from other
+	// senders; it is retried after the lease if the sender dies.
+	outboxClaimLease = time.Minute
+	// outboxBatchSize caps the messages sent per worker pass.
+	outboxBatchSize = 20
+	// outboxMaxBackoff caps the delay between attempts without Retry-After.
+	outboxMaxBackoff = 10 * time.Minute
+	// Sent messages are kept for a week and failed ones for a month.
+	outboxSentRetention   = 7 * 24 * time.Hour
+	outboxFailedRetention = 30 * 24 * time.Hour
+)
+
+// SlackOutbox is a SlackClient whose Send stores messages in the outbox
+// table before delivering them, so they are retried on temporary failures.
+// Every other call goes straight to the wrapped client.
+type SlackOutbox struct {
+	SlackClient
+	db     *gorm.DB
+	teamID string
+}
+
+// NewSlackOutbox wraps client so Send goes through the outbox stored in db.
+func NewSlackOutbox(db *gorm.DB, client SlackClient) *SlackOutbox {
+	return &SlackOutbox{SlackClient: client, db: db}
+}
+
+// Send stores msg and delivers it right away, unless other messages to the
+// same channel are waiting, in which case ProcessSlackOutbox sends them all
+// in order.
+//
+// It returns nil when the message was sent or will be retried, and the
+// *SlackAPIError when it failed permanently (e.g. channel_not_found).
+func (o *SlackOutbox) Send(msg SlackMessage) error {
+	return EnqueueSlackMessage(o.db, o.SlackClient, o.teamID, msg)
+}
+
+// ForTeam returns the outbox whose messages are sent with the team's token,
+// now and on every retry.
+func (o *SlackOutbox) ForTeam(teamID string) SlackClient {
+	return &SlackOutbox{SlackClient: o.SlackClient.ForTeam(teamID), db: o.db, teamID: teamID}
+}
+
+// EnqueueSlackMessage stores msg in the outbox and sends it with client as
+// described for SlackOutbox.Send. teamID is stored with the message so
+// ProcessSlackOutbox retries it with the same team's token.
+func EnqueueSlackMessage(db *gorm.DB, client SlackClient, teamID string, msg SlackMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	channel := msg.Channel
+
+	// The message is always stored first, so a concurrent Send to the channel
+	// sees it and waits behind it
+	now := time.Now()
+	outboxMsg := models.SlackOutboxMessage{
+		ID:            uuid.NewString(),
+		Method:        "chat.postMessage",
+		TeamID:        teamID,
+		Channel:       channel,
+		Payload:       string(data),
+		Status:        OutboxStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := db.Create(&outboxMsg).Error; err != nil {
+		return err
+	}
+
+	// Any other message waiting for the channel, even one stored at the same
+	// time, leaves the sending to ProcessSlackOutbox, which keeps them in order
+	var waiting int64
+	if err := db.Model(&models.SlackOutboxMessage{}).
+		Where("channel = ? AND status = ? AND id <> ?", channel, OutboxStatusPending, outboxMsg.ID).
+		Count(&waiting).Error; err != nil {
+		// Stored, so ProcessSlackOutbox sends it
+		log.Printf("slack outbox search error (message: %s): %v", outboxMsg.ID, err)
+		return nil
+	}
+	if waiting > 0 {
+		log.Printf("slack message queued behind %d waiting message(s) (channel: %s)", waiting, channel)
+		return nil
+	}
+
+	// Claim it like ProcessSlackOutbox does, so only one of them sends it
+	result := db.Model(&models.SlackOutboxMessage{}).
+		Where("id = ? AND status = ? AND attempts = ?", outboxMsg.ID, OutboxStatusPending, 0).
+		Updates(map[string]interface{}{
+			"attempts":        1,
+			"next_attempt_at": now.Add(outboxClaimLease),
+		})
+	if result.Error != nil {
+		log.Printf("slack outbox claim error (message: %s): %v", outboxMsg.ID, result.Error)
+		return nil
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	outboxMsg.Attempts = 1
+	outboxMsg.NextAttemptAt = now.Add(outboxClaimLease)
+
+	if err := deliverOutboxMessage(db, client, &outboxMsg); err != nil && outboxMsg.Status == OutboxStatusFailed {
+		return err
+	}
+	return nil
+}
+
+// ProcessSlackOutbox sends the outbox messages that are due with client,
+// oldest first. A channel's messages stay in order: once one of them is put
+// back for a retry, the later ones wait too. The pass stops on a rate limit.
+func ProcessSlackOutbox(db *gorm.DB, client SlackClient) {
+	now := time.Now()
+	var msgs []models.SlackOutboxMessage
+	if err := db.Where("status = ? AND next_attempt_at <= ?", OutboxStatusPending, now).
+		Order("created_at").Limit(outboxBatchSize).Find(&msgs).Error; err != nil {
+		log.Printf("slack outbox search error: %v", err)
+		return
+	}
+
+	blocked := make(map[string]bool)
+	for _, msg := range msgs {
+		if blocked[msg.Channel] {
+			continue
+		}
+
+		// An older message to the channel is still waiting for its retry
+		var older int64
+		if err := db.Model(&models.SlackOutboxMessage{}).
+			Where("channel = ? AND status = ? AND created_at < ?", msg.Channel, OutboxStatusPending, msg.CreatedAt).
+			Count(&older).Error; err != nil {
+			log.Printf("slack outbox search error: %v", err)
+			continue
+		}
+		if older > 0 {
+			blocked[msg.Channel] = true
+			continue
+		}
+
+		// Claim the message so a concurrent sender skips it
+		result := db.Model(&models.SlackOutboxMessage{}).
+			Where("id = ? AND status = ? AND attempts = ?", msg.ID, OutboxStatusPending, msg.Attempts).
+			Updates(map[string]interface{}{
+				"attempts":        msg.Attempts + 1,
+				"next_attempt_at": now.Add(outboxClaimLease),
+			})
+		if result.Error != nil {
+			log.Printf("slack outbox claim error (message: %s): %v", msg.ID, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		msg.Attempts++
+
+		sender := client
+		if msg.TeamID != "" {
+			sender = client.ForTeam(msg.TeamID)
+		}
+		err := deliverOutboxMessage(db, sender, &msg)
+		if IsSlackRateLimited(err) {
+			break
+		}
+		if msg.Status == OutboxStatusPending {
+			blocked[msg.Channel] = true
+		}
+	}
+}
+
+// deliverOutboxMessage makes the claimed message's Slack call and records the
+// outcome on it: sent, pending with the next attempt scheduled, or failed.
+// It returns the call's error.
+func deliverOutboxMessage(db *gorm.DB, client SlackClient, msg *models.SlackOutboxMessage) error {
+	var slackMsg SlackMessage
+	callErr := json.Unmarshal([]byte(msg.Payload), &slackMsg)
+	if callErr == nil {
+		_, callErr = client.PostMessage(slackMsg)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"updated_at": now}
+	var apiErr *SlackAPIError
+	switch {
+	case callErr == nil:
+		msg.Status = OutboxStatusSent
+		updates["sent_at"] = now
+		updates["last_error"] = ""
+	case errors.As(callErr, &apiErr) && apiErr.Temporary() && msg.Attempts < outboxMaxAttempts:
+		msg.NextAttemptAt = now.Add(outboxRetryDelay(apiErr, msg.Attempts))
+		updates["next_attempt_at"] = msg.NextAttemptAt
+		updates["last_error"] = apiErr.Code
+		log.Printf("slack outbox message %s will be retried at %s (channel: %s, attempt %d/%d): %v",
+			msg.ID, msg.NextAttemptAt.Format(time.RFC3339), msg.Channel, msg.Attempts, outboxMaxAttempts, callErr)
+	default:
+		msg.Status = OutboxStatusFailed
+		updates["last_error"] = callErr.Error()
+		if apiErr != nil {
+			updates["last_error"] = apiErr.Code
+		}
+		log.Printf("slack outbox message %s failed permanently (channel: %s, attempts: %d): %v",
+			msg.ID, msg.Channel, msg.Attempts, callErr)
+	}
+	updates["status"] = msg.Status
+
+	if err := db.Model(&models.SlackOutboxMessage{}).Where("id = ?", msg.ID).Updates(updates).Error; err != nil {
+		log.Printf("slack outbox update error (message: %s): %v", msg.ID, err)
+	}
+	RecordOutboxDelivery(outboxDeliveryResult(msg.Status))
+	return callErr
+}
+
+// outboxRetryDelay waits as long as Slack asks for a rate-limited call, and
+// backs off exponentially from 5 seconds otherwise.
+func outboxRetryDelay(err *SlackAPIError, attempts int) time.Duration {
+	if err.RetryAfter > 0 {
+		return err.RetryAfter
+	}
+	delay := 5 * time.Second << (attempts - 1)
+	if delay <= 0 || delay > outboxMaxBackoff {
+		return outboxMaxBackoff
+	}
+	return delay
+}
+
+func outboxDeliveryResult(status string) string {
+	if status == OutboxStatusPending {
+		return "retried"
+	}
+	return status
+}
+
+// CleanupSlackOutbox deletes sent and failed messages past their retention.
+func CleanupSlackOutbox(db *gorm.DB) {
+	now := time.Now()
+	result := db.Where("(status = ? AND updated_at < ?) OR (status = ? AND updated_at < ?)",
+		OutboxStatusSent, now.Add(-outboxSentRetention),
+		OutboxStatusFailed, now.Add(-outboxFailedRetention)).
+		Delete(&models.SlackOutboxMessage{})
+	if result.Error != nil {
+		log.Printf("slack outbox delete error: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("old slack outbox messages deleted: %d", result.RowsAffected)
+	}
+}
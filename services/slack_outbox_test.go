@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"slack-review-notify/models"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func outboxMessages(t *testing.T, db *gorm.DB) []models.SlackOutboxMessage {
+	var msgs []models.SlackOutboxMessage
+	assert.NoError(t, db.Order("created_at").Find(&msgs).Error)
+	return msgs
+}
+
+func TestSlackOutboxSend_Sent(t *testing.T) {
+	db := setupTestDB(t)
+	slack := NewFakeSlackClient()
+
+	assert.NoError(t, PostToThread(NewSlackOutbox(db, slack), "C_OUT", "111.1", "hello"))
+
+	msgs := outboxMessages(t, db)
+	if assert.Len(t, msgs, 1) {
+		assert.Equal(t, OutboxStatusSent, msgs[0].Status)
+		assert.Equal(t, 1, msgs[0].Attempts)
+		assert.NotNil(t, msgs[0].SentAt)
+		assert.Contains(t, msgs[0].Payload, `"thread_ts":"111.1"`)
+	}
+	assert.Len(t, slack.Messages(), 1)
+}
+
+func TestSlackOutboxSend_PermanentFailure(t *testing.T) {
+	db := setupTestDB(t)
+	slack := NewFakeSlackClient()
+	slack.Errors["chat.postMessage"] = &SlackAPIError{Method: "chat.postMessage", Code: "channel_not_found"}
+
+	err := PostToThread(NewSlackOutbox(db, slack), "C_GONE", "111.1", "hello")
+
+	var apiErr *SlackAPIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, "channel_not_found", apiErr.Code)
+	}
+	assert.True(t, IsChannelRelatedError(err))
+
+	msgs := outboxMessages(t, db)
+	if assert.Len(t, msgs, 1) {
+		assert.Equal(t, OutboxStatusFailed, msgs[0].Status)
+		assert.Equal(t, "channel_not_found", msgs[0].LastError)
+	}
+}
+
+func TestSlackOutboxSend_RateLimitedIsRetried(t *testing.T) {
+	db := setupTestDB(t)
+	slack := NewFakeSlackClient()
+	slack.Errors["chat.postMessage"] = &SlackAPIError{
+		Method: "chat.postMessage", Code: "ratelimited", StatusCode: http.StatusTooManyRequests, RetryAfter: 30 * time.Second,
+	}
+	outbox := NewSlackOutbox(db, slack)
+
+	before := time.Now()
+	// Queued for a retry, so the caller sees no error
+	assert.NoError(t, PostToThread(outbox, "C_OUT", "111.1", "first"))
+
+	msgs := outboxMessages(t, db)
+	if !assert.Len(t, msgs, 1) {
+		return
+	}
+	assert.Equal(t, OutboxStatusPending, msgs[0].Status)
+	assert.Equal(t, "ratelimited", msgs[0].LastError)
+	assert.WithinDuration(t, before.Add(30*time.Second), msgs[0].NextAttemptAt, 2*time.Second)
+
+	// Later messages to the channel wait behind it instead of overtaking it
+	delete(slack.Errors, "chat.postMessage")
+	assert.NoError(t, PostToThread(outbox, "C_OUT", "111.1", "second"))
+	assert.Len(t, outboxMessages(t, db), 2)
+
+	// Nothing is due yet
+	ProcessSlackOutbox(db, slack)
+	assert.Empty(t, slack.Messages())
+
+	db.Model(&models.SlackOutboxMessage{}).Where("status = ?", OutboxStatusPending).
+		Update("next_attempt_at", time.Now().Add(-time.Second))
+
+	ProcessSlackOutbox(db, slack)
+
+	posted := slack.Messages()
+	if assert.Len(t, posted, 2) {
+		assert.Equal(t, "first", posted[0].Text)
+		assert.Equal(t, "second", posted[1].Text)
+	}
+	for _, msg := range outboxMessages(t, db) {
+		assert.Equal(t, OutboxStatusSent, msg.Status)
+	}
+}
+
+func TestSlackOutboxSend_ConcurrentSendWaits(t *testing.T) {
+	db := setupTestDB(t)
+	slack := NewFakeSlackClient()
+
+	// Another Send stores its message for the channel right after this one
+	concurrent := models.SlackOutboxMessage{
+		ID: "concurrent", Method: "chat.postMessage", Channel: "C_OUT", Payload: `{"channel":"C_OUT","text":"other"}`,
+		Status: OutboxStatusPending, NextAttemptAt: time.Now(), CreatedAt: time.Now().Add(time.Second),
+	}
+	inserted := false
+	assert.NoError(t, db.Callback().Create().After("gorm:create").Register("concurrent_send", func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Dest.(*models.SlackOutboxMessage); ok && !inserted {
+			inserted = true
+			tx.Session(&gorm.Session{NewDB: true}).Create(&concurrent)
+		}
+	}))
+	defer func() { _ = db.Callback().Create().Remove("concurrent_send") }()
+
+	// Neither is sent on the spot; the worker sends both in order
+	assert.NoError(t, PostToThread(NewSlackOutbox(db, slack), "C_OUT", "111.1", "mine"))
+	assert.Empty(t, slack.Messages())
+
+	ProcessSlackOutbox(db, slack)
+	posted := slack.Messages()
+	if assert.Len(t, posted, 2) {
+		assert.Equal(t, "mine", posted[0].Text)
+		assert.Equal(t, "other", posted[1].Text)
+	}
+}
+
+func TestProcessSlackOutbox_GivesUpAfterMaxAttempts(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.SlackOutboxMessage{
+		ID: "out-1", Method: "chat.postMessage", Channel: "C_OUT", Payload: `{"channel":"C_OUT","text":"x"}`,
+		Status: OutboxStatusPending, Attempts: outboxMaxAttempts - 1, NextAttemptAt: time.Now().Add(-time.Second),
+	})
+
+	defer gock.Off()
+	gock.New("https://slack.com").
+		Post("/api/chat.postMessage").
+		Reply(503)
+
+	ProcessSlackOutbox(db, NewSlackClient("test-token"))
+
+	msgs := outboxMessages(t, db)
+	if assert.Len(t, msgs, 1) {
+		assert.Equal(t, OutboxStatusFailed, msgs[0].Status)
+		assert.Equal(t, outboxMaxAttempts, msgs[0].Attempts)
+		assert.Equal(t, "http_503", msgs[0].LastError)
+	}
+}
+
+func TestSendOutOfHoursReminderMessage_ReturnsSlackError(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://slack.com").
+		Post("/api/chat.postMessage").
+		Reply(200).
+		JSON(map[string]interface{}{"ok": false, "error": "not_in_channel"})
+
+	err := SendOutOfHoursReminderMessage(NewSlackClient("test-token"), models.ReviewTask{ID: "t1", SlackChannel: "C1", SlackTS: "1.1", Reviewer: "U1", Language: "en"})
+	assert.EqualError(t, err, "slack error: not_in_channel (chat.postMessage)")
+}
+
+func TestOutboxRetryDelay(t *testing.T) {
+	assert.Equal(t, 30*time.Second, outboxRetryDelay(&SlackAPIError{RetryAfter: 30 * time.Second}, 1))
+	assert.Equal(t, 5*time.Second, outboxRetryDelay(&SlackAPIError{}, 1))
+	assert.Equal(t, 20*time.Second, outboxRetryDelay(&SlackAPIError{}, 3))
+	assert.Equal(t, outboxMaxBackoff, outboxRetryDelay(&SlackAPIError{}, 20))
+}
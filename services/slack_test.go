@@ -2,7 +2,6 @@ package services
 
 import (
 	"fmt"
-	"os"
 	"slack-review-notify/models"
 	"testing"
 	"time"
@@ -12,14 +11,7 @@ import (
 )
 
 func TestSendSlackMessage(t *testing.T) {
-	// Save environment variables before test and restore after
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-
-	// Set test environment variables
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
+	slack := NewSlackClient("test-token")
 
 	// Set up mocks
 	defer gock.Off() // Clear mocks when test ends
@@ -38,6 +30,7 @@ func TestSendSlackMessage(t *testing.T) {
 
 	// Execute function
 	ts, channel, err := SendSlackMessage(
+		slack,
 		"https://github.com/owner/repo/pull/1",
 		"Test PR Title",
 		"C12345",
@@ -63,6 +56,7 @@ func TestSendSlackMessage(t *testing.T) {
 
 	// Execute function
 	_, _, err = SendSlackMessage(
+		slack,
 		"https://github.com/owner/repo/pull/1",
 		"Test PR Title",
 		"INVALID",
@@ -78,14 +72,7 @@ func TestSendSlackMessage(t *testing.T) {
 }
 
 func TestPostToThread(t *testing.T) {
-	// Save environment variables before test and restore after
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-
-	// Set test environment variables
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
+	slack := NewSlackClient("test-token")
 
 	// Set up mocks
 	defer gock.Off() // Clear mocks when test ends
@@ -101,7 +88,7 @@ func TestPostToThread(t *testing.T) {
 		})
 
 	// Execute function
-	err := PostToThread("C12345", "1234.5678", "テストメッセージ")
+	err := PostToThread(slack, "C12345", "1234.5678", "テストメッセージ")
 
 	// Assertions
 	assert.NoError(t, err)
@@ -117,7 +104,7 @@ func TestPostToThread(t *testing.T) {
 		})
 
 	// Execute function
-	err = PostToThread("C12345", "invalid", "テストメッセージ")
+	err = PostToThread(slack, "C12345", "invalid", "テストメッセージ")
 
 	// Assertions
 	assert.Error(t, err)
@@ -126,14 +113,7 @@ func TestPostToThread(t *testing.T) {
 }
 
 func TestIsChannelArchived(t *testing.T) {
-	// Save environment variables before test and restore after
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-
-	// Set test environment variables
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
+	slack := NewSlackClient("test-token")
 
 	// Set up mocks
 	defer gock.Off() // Clear mocks when test ends
@@ -152,7 +132,7 @@ func TestIsChannelArchived(t *testing.T) {
 		})
 
 	// Execute function
-	isArchived, err := IsChannelArchived("C12345")
+	isArchived, err := IsChannelArchived(slack, "C12345")
 
 	// Assertions
 	assert.NoError(t, err)
@@ -173,7 +153,7 @@ func TestIsChannelArchived(t *testing.T) {
 		})
 
 	// Execute function
-	isArchived, err = IsChannelArchived("C67890")
+	isArchived, err = IsChannelArchived(slack, "C67890")
 
 	// Assertions
 	assert.NoError(t, err)
@@ -191,7 +171,7 @@ func TestIsChannelArchived(t *testing.T) {
 		})
 
 	// Execute function
-	isArchived, err = IsChannelArchived("INVALID")
+	isArchived, err = IsChannelArchived(slack, "INVALID")
 
 	// Assertions
 	assert.True(t, isArchived) // Non-existent channels are also treated as archived
@@ -203,38 +183,8 @@ func TestSendReminderMessage(t *testing.T) {
 	// Set up test DB
 	db := setupTestDB(t)
 
-	// Save environment variables before test and restore after
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-
-	// Set test environment variables
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	// Set up mocks
-	defer gock.Off() // Clear mocks when test ends
-
-	// Mock for channel info retrieval
-	gock.New("https://slack.com").
-		Get("/api/conversations.info").
-		MatchParam("channel", "C12345").
-		Reply(200).
-		JSON(map[string]interface{}{
-			"ok": true,
-			"channel": map[string]interface{}{
-				"id":          "C12345",
-				"is_archived": false,
-			},
-		})
-
-	// Mock for message sending
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Reply(200).
-		JSON(map[string]interface{}{
-			"ok": true,
-		})
+	slack := NewFakeSlackClient()
+	slack.ArchivedChannels["C67890"] = true
 
 	// Create a test task
 	task := models.ReviewTask{
@@ -251,25 +201,17 @@ func TestSendReminderMessage(t *testing.T) {
 	}
 
 	// Execute function
-	err := SendReviewerReminderMessage(db, task)
+	err := SendReviewerReminderMessage(db, slack, task)
 
 	// Assertions
 	assert.NoError(t, err)
-	assert.True(t, gock.IsDone(), "Not all mocks were used")
+	msgs := slack.Messages()
+	if assert.Len(t, msgs, 1) {
+		assert.Equal(t, "C12345", msgs[0].Channel)
+		assert.Equal(t, "1234.5678", msgs[0].ThreadTS)
+	}
 
 	// Test when channel is archived
-	gock.New("https://slack.com").
-		Get("/api/conversations.info").
-		MatchParam("channel", "C67890").
-		Reply(200).
-		JSON(map[string]interface{}{
-			"ok": true,
-			"channel": map[string]interface{}{
-				"id":          "C67890",
-				"is_archived": true,
-			},
-		})
-
 	// Create test task and channel config
 	task2 := models.ReviewTask{
 		ID:           "test-id-2",
@@ -298,7 +240,7 @@ func TestSendReminderMessage(t *testing.T) {
 	db.Create(&config)
 
 	// Execute function
-	err = SendReviewerReminderMessage(db, task2)
+	err = SendReviewerReminderMessage(db, slack, task2)
 
 	// Assertions
 	assert.Error(t, err)
@@ -312,46 +254,15 @@ func TestSendReminderMessage(t *testing.T) {
 	var updatedConfig models.ChannelConfig
 	db.Where("slack_channel_id = ?", "C67890").First(&updatedConfig)
 	assert.False(t, updatedConfig.IsActive)
-
-	assert.True(t, gock.IsDone(), "Not all mocks were used")
+	assert.Len(t, slack.Messages(), 1, "nothing is posted to an archived channel")
 }
 
 func TestSendReviewerReminderMessage(t *testing.T) {
 	// Set up test DB
 	db := setupTestDB(t)
 
-	// Save environment variables before test and restore after
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-
-	// Set test environment variables
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	// Set up mocks
-	defer gock.Off() // Clear mocks when test ends
-
-	// Mock for channel info retrieval
-	gock.New("https://slack.com").
-		Get("/api/conversations.info").
-		MatchParam("channel", "C12345").
-		Reply(200).
-		JSON(map[string]interface{}{
-			"ok": true,
-			"channel": map[string]interface{}{
-				"id":          "C12345",
-				"is_archived": false,
-			},
-		})
-
-	// Mock for message sending
-	gock.New("https://slack.com").
-		Post("/api/chat.postMessage").
-		Reply(200).
-		JSON(map[string]interface{}{
-			"ok": true,
-		})
+	slack := NewFakeSlackClient()
+	slack.ArchivedChannels["C67890"] = true
 
 	// Create a test task
 	task := models.ReviewTask{
@@ -369,25 +280,17 @@ func TestSendReviewerReminderMessage(t *testing.T) {
 	}
 
 	// Execute function
-	err := SendReviewerReminderMessage(db, task)
+	err := SendReviewerReminderMessage(db, slack, task)
 
 	// Assertions
 	assert.NoError(t, err)
-	assert.True(t, gock.IsDone(), "Not all mocks were used")
+	msgs := slack.Messages()
+	if assert.Len(t, msgs, 1) {
+		assert.Equal(t, "C12345", msgs[0].Channel)
+		assert.Equal(t, "1234.5678", msgs[0].ThreadTS)
+	}
 
 	// Test when channel is archived
-	gock.New("https://slack.com").
-		Get("/api/conversations.info").
-		MatchParam("channel", "C67890").
-		Reply(200).
-		JSON(map[string]interface{}{
-			"ok": true,
-			"channel": map[string]interface{}{
-				"id":          "C67890",
-				"is_archived": true,
-			},
-		})
-
 	// Create test task and channel config
 	task2 := models.ReviewTask{
 		ID:           "test-id-2",
@@ -417,7 +320,7 @@ func TestSendReviewerReminderMessage(t *testing.T) {
 	db.Create(&config)
 
 	// Execute function
-	err = SendReviewerReminderMessage(db, task2)
+	err = SendReviewerReminderMessage(db, slack, task2)
 
 	// Assertions
 	assert.Error(t, err)
@@ -431,23 +334,10 @@ func TestSendReviewerReminderMessage(t *testing.T) {
 	var updatedConfig models.ChannelConfig
 	db.Where("slack_channel_id = ?", "C67890").First(&updatedConfig)
 	assert.False(t, updatedConfig.IsActive)
-
-	assert.True(t, gock.IsDone(), "Not all mocks were used")
+	assert.Len(t, slack.Messages(), 1, "nothing is posted to an archived channel")
 }
 
 func TestSendReminderPausedMessage(t *testing.T) {
-	// Save environment variables before test and restore after
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-
-	// Set test environment variables
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	// Set up mocks
-	defer gock.Off() // Clear mocks when test ends
-
 	testCases := []struct {
 		name     string
 		duration string
@@ -463,14 +353,7 @@ func TestSendReminderPausedMessage(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			// Mock for thread message sending
-			gock.New("https://slack.com").
-				Post("/api/chat.postMessage").
-				MatchHeader("Authorization", "Bearer test-token").
-				Reply(200).
-				JSON(map[string]interface{}{
-					"ok": true,
-				})
+			slack := NewFakeSlackClient()
 
 			// Create a test task
 			task := models.ReviewTask{
@@ -481,11 +364,15 @@ func TestSendReminderPausedMessage(t *testing.T) {
 			}
 
 			// Execute function
-			err := SendReminderPausedMessage(task, tc.duration)
+			err := SendReminderPausedMessage(slack, task, tc.duration)
 
 			// Assertions
 			assert.NoError(t, err)
-			assert.True(t, gock.IsDone(), "Not all mocks were used")
+			msgs := slack.Messages()
+			if assert.Len(t, msgs, 1) {
+				assert.Equal(t, "1234.5678", msgs[0].ThreadTS)
+				assert.Equal(t, tc.message, msgs[0].Text)
+			}
 		})
 	}
 }
@@ -569,18 +456,6 @@ func TestGetNextBusinessDayMorning(t *testing.T) {
 }
 
 func TestSendReviewCompletedAutoNotification(t *testing.T) {
-	// Save environment variables before test and restore after
-	originalToken := os.Getenv("SLACK_BOT_TOKEN")
-	defer func() {
-		_ = os.Setenv("SLACK_BOT_TOKEN", originalToken)
-	}()
-
-	// Set test environment variables
-	_ = os.Setenv("SLACK_BOT_TOKEN", "test-token")
-
-	// Set up mocks
-	defer gock.Off() // Clear mocks when test ends
-
 	testCases := []struct {
 		name          string
 		reviewerLogin string
@@ -595,14 +470,7 @@ func TestSendReviewCompletedAutoNotification(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			// Mock for thread message sending
-			gock.New("https://slack.com").
-				Post("/api/chat.postMessage").
-				MatchHeader("Authorization", "Bearer test-token").
-				Reply(200).
-				JSON(map[string]interface{}{
-					"ok": true,
-				})
+			slack := NewFakeSlackClient()
 
 			// Create a test task
 			task := models.ReviewTask{
@@ -613,11 +481,15 @@ func TestSendReviewCompletedAutoNotification(t *testing.T) {
 			}
 
 			// Execute function
-			err := SendReviewCompletedAutoNotification(task, tc.reviewerLogin, tc.reviewState)
+			err := SendReviewCompletedAutoNotification(slack, task, tc.reviewerLogin, tc.reviewState)
 
 			// Assertions
 			assert.NoError(t, err)
-			assert.True(t, gock.IsDone(), "Not all mocks were used")
+			msgs := slack.Messages()
+			if assert.Len(t, msgs, 1) {
+				assert.Equal(t, "1234.5678", msgs[0].ThreadTS)
+				assert.Equal(t, tc.expectedMsg, msgs[0].Text)
+			}
 		})
 	}
 }
@@ -690,7 +562,7 @@ func TestSelectRandomReviewers_Basic(t *testing.T) {
 	db.Create(&testConfig)
 
 	// Select 2
-	result := SelectRandomReviewers(db, "C_MULTI", "needs-review", 2, nil)
+	result := SelectRandomReviewers(db, "", "C_MULTI", "needs-review", 2, nil)
 	assert.Equal(t, 2, len(result))
 	// No duplicates
 	assert.NotEqual(t, result[0], result[1])
@@ -710,7 +582,7 @@ func TestSelectRandomReviewers_ExcludeIDs(t *testing.T) {
 	db.Create(&testConfig)
 
 	// Select 2 excluding U1
-	result := SelectRandomReviewers(db, "C_EXCL", "needs-review", 2, []string{"U1"})
+	result := SelectRandomReviewers(db, "", "C_EXCL", "needs-review", 2, []string{"U1"})
 	assert.Equal(t, 2, len(result))
 	for _, id := range result {
 		assert.NotEqual(t, "U1", id, "Excluded U1 was included")
@@ -731,7 +603,7 @@ func TestSelectRandomReviewers_InsufficientAfterExclusion(t *testing.T) {
 	db.Create(&testConfig)
 
 	// Request 2 excluding U1 -> only U2 is a candidate -> returns only 1
-	result := SelectRandomReviewers(db, "C_INSUF", "needs-review", 2, []string{"U1"})
+	result := SelectRandomReviewers(db, "", "C_INSUF", "needs-review", 2, []string{"U1"})
 	assert.Equal(t, 1, len(result))
 	assert.Equal(t, "U2", result[0])
 }
@@ -769,7 +641,7 @@ func TestSelectRandomReviewers_ExcludesPRAuthorAcrossFullList(t *testing.T) {
 	// Run several iterations to defeat the random shuffle: if the author leaks
 	// through, at least one run will surface it.
 	for i := 0; i < 50; i++ {
-		result := SelectRandomReviewers(db, "C_AUTHOR", "needs-review", 2, []string{authorID})
+		result := SelectRandomReviewers(db, "", "C_AUTHOR", "needs-review", 2, []string{authorID})
 		assert.Equal(t, 2, len(result), "iteration %d: should return exactly 2 reviewers", i)
 		for _, id := range result {
 			assert.NotEqual(t, authorID, id, "iteration %d: PR author %q was returned as a reviewer", i, authorID)
@@ -793,7 +665,7 @@ func TestSelectRandomReviewers_NoReviewersNoDefault(t *testing.T) {
 		IsActive:         true,
 	})
 
-	result := SelectRandomReviewers(db, "C_NOMENT", "needs-review", 1, nil)
+	result := SelectRandomReviewers(db, "", "C_NOMENT", "needs-review", 1, nil)
 	if len(result) != 0 {
 		t.Errorf("expected empty slice when no reviewers and no default mention, got %v", result)
 	}
@@ -812,7 +684,7 @@ func TestSelectRandomReviewers_AllExcludedNoDefault(t *testing.T) {
 		IsActive:         true,
 	})
 
-	result := SelectRandomReviewers(db, "C_ALLX_NOM", "needs-review", 1, []string{"U1", "U2"})
+	result := SelectRandomReviewers(db, "", "C_ALLX_NOM", "needs-review", 1, []string{"U1", "U2"})
 	if len(result) != 0 {
 		t.Errorf("expected empty slice, got %v", result)
 	}
@@ -832,7 +704,7 @@ func TestSelectRandomReviewers_AllExcluded(t *testing.T) {
 	db.Create(&testConfig)
 
 	// All excluded -> returns DefaultMentionID
-	result := SelectRandomReviewers(db, "C_ALLX", "needs-review", 1, []string{"U1", "U2"})
+	result := SelectRandomReviewers(db, "", "C_ALLX", "needs-review", 1, []string{"U1", "U2"})
 	assert.Equal(t, 1, len(result))
 	assert.Equal(t, "UDEFAULT", result[0])
 }
@@ -1104,7 +976,7 @@ func TestSelectRandomReviewers_ExcludesAwayUsers(t *testing.T) {
 
 	// Repeat 100 times to verify U2 is never selected
 	for i := 0; i < 100; i++ {
-		result := SelectRandomReviewers(db, "C_AWAY", "needs-review", 2, nil)
+		result := SelectRandomReviewers(db, "", "C_AWAY", "needs-review", 2, nil)
 		for _, id := range result {
 			assert.NotEqual(t, "U2", id, "U2 on leave was selected")
 		}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"log"
+	"slack-review-notify/models"
+
+	"gorm.io/gorm"
+)
+
+// SlackWorkspaces is the SlackClient of a deployment shared by several Slack
+// workspaces. Every call uses the bot token of the team that owns it: ForTeam
+// picks the team of a Slack request, and calls on a channel made without
+// ForTeam go through the team of the channel's config or review task. A team
+// without a SlackInstallation uses the default token (SLACK_BOT_TOKEN), so a
+// single-workspace deployment works without the OAuth install.
+type SlackWorkspaces struct {
+	db           *gorm.DB
+	defaultToken string
+}
+
+// NewSlackWorkspaces returns a SlackWorkspaces reading installations from db.
+func NewSlackWorkspaces(db *gorm.DB, defaultToken string) *SlackWorkspaces {
+	return &SlackWorkspaces{db: db, defaultToken: defaultToken}
+}
+
+// ForTeam returns a client calling Slack with the team's bot token. For an
+// unknown team ("") it returns w, which routes by channel.
+func (w *SlackWorkspaces) ForTeam(teamID string) SlackClient {
+	if teamID == "" {
+		return w
+	}
+	return NewSlackClient(w.token(teamID))
+}
+
+func (w *SlackWorkspaces) token(teamID string) string {
+	var installations []models.SlackInstallation
+	if err := w.db.Where("team_id = ?", teamID).Limit(1).Find(&installations).Error; err != nil {
+		log.Printf("slack installation search error (team: %s): %v", teamID, err)
+		return w.defaultToken
+	}
+	if len(installations) == 0 {
+		if w.defaultToken == "" {
+			log.Printf("slack app is not installed to team %s", teamID)
+		}
+		return w.defaultToken
+	}
+	return installations[0].BotToken
+}
+
+// channelClient returns the client of the team that owns channel.
+func (w *SlackWorkspaces) channelClient(channel string) SlackClient {
+	if teamID := ChannelTeamID(w.db, channel); teamID != "" {
+		return NewSlackClient(w.token(teamID))
+	}
+	return NewSlackClient(w.defaultToken)
+}
+
+func (w *SlackWorkspaces) PostMessage(msg SlackMessage) (SlackPostResponse, error) {
+	return w.channelClient(msg.Channel).PostMessage(msg)
+}
+
+func (w *SlackWorkspaces) Send(msg SlackMessage) error {
+	return w.channelClient(msg.Channel).Send(msg)
+}
+
+func (w *SlackWorkspaces) UpdateMessage(msg SlackMessage) error {
+	return w.channelClient(msg.Channel).UpdateMessage(msg)
+}
+
+func (w *SlackWorkspaces) PostEphemeral(channel, user, text string) error {
+	return w.channelClient(channel).PostEphemeral(channel, user, text)
+}
+
+func (w *SlackWorkspaces) ConversationInfo(channelID string) (SlackChannel, error) {
+	return w.channelClient(channelID).ConversationInfo(channelID)
+}
+
+func (w *SlackWorkspaces) GetPermalink(channel, ts string) (string, error) {
+	return w.channelClient(channel).GetPermalink(channel, ts)
+}
+
+// Views and users carry no channel, so without ForTeam these use the default
+// token.
+
+func (w *SlackWorkspaces) OpenView(triggerID string, view map[string]interface{}) error {
+	return NewSlackClient(w.defaultToken).OpenView(triggerID, view)
+}
+
+func (w *SlackWorkspaces) UpdateView(viewID string, view map[string]interface{}) error {
+	return NewSlackClient(w.defaultToken).UpdateView(viewID, view)
+}
+
+func (w *SlackWorkspaces) PublishView(userID string, view map[string]interface{}) error {
+	return NewSlackClient(w.defaultToken).PublishView(userID, view)
+}
+
+func (w *SlackWorkspaces) UserInfo(userID string) (SlackUser, error) {
+	return NewSlackClient(w.defaultToken).UserInfo(userID)
+}
+
+// ChannelTeamID returns the team of the channel's config, or of its review
+// tasks when the channel has no config left. It returns "" for an unknown
+// channel, such as a DM.
+func ChannelTeamID(db *gorm.DB, channel string) string {
+	var teams []string
+	db.Model(&models.ChannelConfig{}).
+		Where("slack_channel_id = ? AND team_id <> ''", channel).
+		Limit(1).Pluck("team_id", &teams)
+	if len(teams) == 0 {
+		db.Model(&models.ReviewTask{}).
+			Where("slack_channel = ? AND team_id <> ''", channel).
+			Limit(1).Pluck("team_id", &teams)
+	}
+	if len(teams) == 0 {
+		return ""
+	}
+	return teams[0]
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"log"
+	"os"
+	"slack-review-notify/i18n"
+	"slack-review-notify/models"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v71/github"
+	"gorm.io/gorm"
+)
+
+// The task reconciler catches up on webhook events that never arrived. For
+// every PR with an active task it asks the GitHub API for the PR's state and
+// passes what the tasks missed to the webhook handlers: the closing of a
+// closed or merged PR, the removal of labels the task's config requires,
+// and approvals not yet in ApprovedBy. It runs every RECONCILE_INTERVAL and
+// on demand with the resync command.
+
+// defaultReconcileInterval is how often tasks are reconciled when
+// RECONCILE_INTERVAL is unset.
+const defaultReconcileInterval = 1 * time.Hour
+
+// ReconcileInterval returns RECONCILE_INTERVAL (e.g. "30m"), or the default
+// of an hour. "0" turns the periodic reconciliation off; the resync command
+// still works.
+func ReconcileInterval() time.Duration {
+	value := os.Getenv("RECONCILE_INTERVAL")
+	if value == "" {
+		return defaultReconcileInterval
+	}
+	if value == "0" {
+		return 0
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < time.Minute {
+		log.Printf("invalid RECONCILE_INTERVAL %q, using %s", value, defaultReconcileInterval)
+		return defaultReconcileInterval
+	}
+	return interval
+}
+
+// ReconcileRequest asks for the active tasks of a channel to be reconciled
+// now, on behalf of the user who ran the resync command.
+type ReconcileRequest struct {
+	TeamID    string
+	ChannelID string
+	UserID    string
+	Language  string
+}
+
+// ReconcileResult counts what a reconciliation found.
+type ReconcileResult struct {
+	PullRequests int // PRs with active tasks that were checked
+	Closed       int // closed or merged PRs whose tasks were still active
+	Unlabeled    int // PRs that lost a label a task's config requires
+	Approvals    int // approvals missing from ApprovedBy
+	Failed       int // PRs that could not be checked
+}
+
+// reconcileRequests holds the resync requests for the reconciler loop. A
+// request beyond its capacity is refused rather than queued.
+var reconcileRequests = make(chan ReconcileRequest, 8)
+
+// RequestReconcile queues a resync request for the reconciler loop. It
+// returns false when too many requests are already waiting.
+func RequestReconcile(req ReconcileRequest) bool {
+	select {
+	case reconcileRequests <- req:
+		return true
+	default:
+		return false
+	}
+}
+
+// ReconcileRequests returns the queued resync requests.
+func ReconcileRequests() <-chan ReconcileRequest {
+	return reconcileRequests
+}
+
+// ReconcileTasks reconciles the active tasks of the channel of the team with
+// GitHub, or every active task when channelID is "". The changes found are
+// passed to handle as the webhook events GitHub would have sent.
+func ReconcileTasks(db *gorm.DB, handle GitHubEventHandler, teamID, channelID string) ReconcileResult {
+	query := db.Where("status IN ?", activePollTaskStatuses)
+	if channelID != "" {
+		query = query.Where("team_id = ? AND slack_channel = ?", teamID, channelID)
+	}
+	var tasks []models.ReviewTask
+	if err := query.Find(&tasks).Error; err != nil {
+		log.Printf("reconcile task search error: %v", err)
+		return ReconcileResult{}
+	}
+
+	// One check per PR, whichever channels are watching it
+	type prKey struct {
+		repo   string
+		number int
+	}
+	byPR := map[prKey][]models.ReviewTask{}
+	var keys []prKey
+	for _, task := range tasks {
+		key := prKey{task.Repo, task.PRNumber}
+		if _, ok := byPR[key]; !ok {
+			keys = append(keys, key)
+		}
+		byPR[key] = append(byPR[key], task)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].repo != keys[j].repo {
+			return keys[i].repo < keys[j].repo
+		}
+		return keys[i].number < keys[j].number
+	})
+
+	var result ReconcileResult
+	for _, key := range keys {
+		result.PullRequests++
+		if err := reconcilePullRequest(db, handle, key.repo, key.number, byPR[key], &result); err != nil {
+			log.Printf("reconcile error (repo: %s, pr: %d): %v", key.repo, key.number, err)
+			result.Failed++
+		}
+	}
+
+	log.Printf("reconciled %d pull request(s): closed=%d, unlabeled=%d, approvals=%d, failed=%d",
+		result.PullRequests, result.Closed, result.Unlabeled, result.Approvals, result.Failed)
+	return result
+}
+
+// reconcilePullRequest checks one PR against its active tasks.
+func reconcilePullRequest(db *gorm.DB, handle GitHubEventHandler, repoFullName string, number int, tasks []models.ReviewTask, result *ReconcileResult) error {
+	pr, err := GetPullRequest(db, repoFullName, number)
+	if err != nil {
+		return err
+	}
+	repo := pr.GetBase().GetRepo()
+
+	if pr.GetState() == "closed" {
+		result.Closed++
+		return handle("pull_request", &github.PullRequestEvent{Action: github.Ptr("closed"), PullRequest: pr, Repo: repo})
+	}
+
+	// The unlabeled handler completes only the tasks whose config no longer
+	// matches, so one event covers every channel
+	for _, task := range tasks {
+		config, err := GetChannelConfig(db, task.TeamID, task.SlackChannel, task.LabelName)
+		if err != nil {
+			continue
+		}
+		missing := GetMissingLabels(config, pr.Labels)
+		if !IsLabelMatched(config, pr.Labels) && len(missing) > 0 {
+			result.Unlabeled++
+			// Like a webhook, the event names a removed label
+			if err := handle("pull_request", &github.PullRequestEvent{
+				Action: github.Ptr("unlabeled"), Label: &github.Label{Name: github.Ptr(missing[0])}, PullRequest: pr, Repo: repo,
+			}); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	reviews, err := GetPullRequestReviews(db, repoFullName, number)
+	if err != nil {
+		return err
+	}
+	approvals := currentApprovals(reviews)
+	if len(approvals) == 0 {
+		return nil
+	}
+
+	// Tasks the label check left active, as the review handler sees them
+	var active []models.ReviewTask
+	db.Where("repo = ? AND pr_number = ? AND status IN ?", repoFullName, number, activePollTaskStatuses).
+		Order("created_at DESC").Find(&active)
+	latest := map[string]models.ReviewTask{}
+	for _, task := range active {
+		if _, ok := latest[task.SlackChannel]; !ok {
+			latest[task.SlackChannel] = task
+		}
+	}
+
+	for _, review := range approvals {
+		login := review.GetUser().GetLogin()
+		missing := false
+		for _, task := range latest {
+			approvalID := GetSlackUserIDFromGitHub(db, task.TeamID, login)
+			if approvalID == "" {
+				approvalID = login
+			}
+			if !isInCSV(task.ApprovedBy, approvalID) {
+				missing = true
+				break
+			}
+		}
+		if !missing {
+			continue
+		}
+		result.Approvals++
+		approved := *review
+		approved.State = github.Ptr("approved")
+		if err := handle("pull_request_review", &github.PullRequestReviewEvent{
+			Action: github.Ptr("submitted"), Review: &approved, PullRequest: pr, Repo: repo,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// currentApprovals returns the latest approving review of each reviewer
+// whose approval stands: not followed by a request for changes and not
+// dismissed. Comments leave a reviewer's approval as it is.
+func currentApprovals(reviews []*github.PullRequestReview) []*github.PullRequestReview {
+	standing := map[string]*github.PullRequestReview{}
+	var logins []string
+	for _, review := range reviews {
+		login := review.GetUser().GetLogin()
+		if login == "" {
+			continue
+		}
+		switch strings.ToUpper(review.GetState()) {
+		case "APPROVED":
+			if !containsString(logins, login) {
+				logins = append(logins, login)
+			}
+			standing[login] = review
+		case "CHANGES_REQUESTED", "DISMISSED":
+			standing[login] = nil
+		}
+	}
+
+	var approvals []*github.PullRequestReview
+	for _, login := range logins {
+		if review := standing[login]; review != nil {
+			approvals = append(approvals, review)
+		}
+	}
+	return approvals
+}
+
+// HandleReconcileRequest reconciles the channel of a resync request and
+// tells the user who asked what was found.
+func HandleReconcileRequest(db *gorm.DB, slack SlackClient, handle GitHubEventHandler, req ReconcileRequest) {
+	result := ReconcileTasks(db, handle, req.TeamID, req.ChannelID)
+
+	t := i18n.L(req.Language)
+	text := t("reconcile.done", result.PullRequests, result.Closed, result.Unlabeled, result.Approvals)
+	if result.Failed > 0 {
+		text += "\n" + t("reconcile.failed", result.Failed)
+	}
+	if err := slack.ForTeam(req.TeamID).PostEphemeral(req.ChannelID, req.UserID, text); err != nil {
+		log.Printf("failed to post resync result (channel: %s): %v", req.ChannelID, err)
+	}
+}
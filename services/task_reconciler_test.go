@@ -0,0 +1,91 @@
+package services
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slack-review-notify/models"
+	"testing"
+
+	"github.com/google/go-github/v71/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileTasks(t *testing.T) {
+	mux := http.NewServeMux()
+	serve := func(pattern, body string) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, body)
+		})
+	}
+	// #1 merged, #2 lost its label, #3 was approved by alice, #4 is up to date
+	serve("GET /repos/acme/api/pulls/1", `{"number": 1, "state": "closed", "merged": true, `+polledRepo+`}`)
+	serve("GET /repos/acme/api/pulls/2", `{"number": 2, "state": "open", "labels": [], `+polledRepo+`}`)
+	serve("GET /repos/acme/api/pulls/2/reviews", `[]`)
+	serve("GET /repos/acme/api/pulls/3", `{"number": 3, "state": "open", "labels": [{"name": "needs-review"}], `+polledRepo+`}`)
+	serve("GET /repos/acme/api/pulls/3/reviews", `[
+		{"id": 1, "state": "APPROVED", "user": {"login": "alice"}},
+		{"id": 2, "state": "COMMENTED", "user": {"login": "alice"}},
+		{"id": 3, "state": "APPROVED", "user": {"login": "bob"}},
+		{"id": 4, "state": "CHANGES_REQUESTED", "user": {"login": "bob"}}
+	]`)
+	serve("GET /repos/acme/api/pulls/4", `{"number": 4, "state": "open", "labels": [{"name": "needs-review"}], `+polledRepo+`}`)
+	serve("GET /repos/acme/api/pulls/4/reviews", `[{"id": 5, "state": "APPROVED", "user": {"login": "alice"}}]`)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	t.Setenv("GITHUB_API_BASE_URL", server.URL)
+	t.Setenv("GITHUB_TOKEN", "test-github-token")
+
+	db := setupTestDB(t)
+	require.NoError(t, db.Create(&models.ChannelConfig{
+		ID: "cfg-reconcile", TeamID: "T1", SlackChannelID: "C1", LabelName: "needs-review", IsActive: true,
+	}).Error)
+	for _, task := range []models.ReviewTask{
+		{ID: "task-1", TeamID: "T1", Repo: "acme/api", PRNumber: 1, SlackChannel: "C1", LabelName: "needs-review", Status: "in_review"},
+		{ID: "task-2", TeamID: "T1", Repo: "acme/api", PRNumber: 2, SlackChannel: "C1", LabelName: "needs-review", Status: "in_review"},
+		{ID: "task-3", TeamID: "T1", Repo: "acme/api", PRNumber: 3, SlackChannel: "C1", LabelName: "needs-review", Status: "pending"},
+		{ID: "task-4", TeamID: "T1", Repo: "acme/api", PRNumber: 4, SlackChannel: "C1", LabelName: "needs-review", Status: "in_review", ApprovedBy: "alice"},
+		{ID: "task-5", TeamID: "T1", Repo: "acme/api", PRNumber: 5, SlackChannel: "C1", LabelName: "needs-review", Status: "completed"},
+	} {
+		require.NoError(t, db.Create(&task).Error)
+	}
+
+	var events []polledEvent
+	result := ReconcileTasks(db, recordPolledEvents(&events), "", "")
+
+	assert.Equal(t, []polledEvent{
+		{Action: "closed", PR: 1},
+		{Action: "unlabeled", Label: "needs-review", PR: 2},
+		{Action: "submitted", State: "approved", PR: 3},
+	}, events)
+	assert.Equal(t, ReconcileResult{PullRequests: 4, Closed: 1, Unlabeled: 1, Approvals: 1}, result)
+
+	// Limited to a channel with no tasks, nothing is checked
+	events = nil
+	result = ReconcileTasks(db, recordPolledEvents(&events), "T1", "C_OTHER")
+	assert.Empty(t, events)
+	assert.Zero(t, result.PullRequests)
+}
+
+func TestCurrentApprovals(t *testing.T) {
+	review := func(id int64, login, state string) *github.PullRequestReview {
+		return &github.PullRequestReview{ID: github.Ptr(id), State: github.Ptr(state), User: &github.User{Login: github.Ptr(login)}}
+	}
+
+	approvals := currentApprovals([]*github.PullRequestReview{
+		review(1, "alice", "CHANGES_REQUESTED"),
+		review(2, "alice", "APPROVED"),
+		review(3, "bob", "APPROVED"),
+		review(4, "bob", "DISMISSED"),
+		review(5, "carol", "APPROVED"),
+		review(6, "carol", "APPROVED"),
+		review(7, "dave", "COMMENTED"),
+	})
+
+	var ids []int64
+	for _, a := range approvals {
+		ids = append(ids, a.GetID())
+	}
+	assert.Equal(t, []int64{2, 6}, ids)
+}
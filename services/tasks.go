@@ -13,7 +13,7 @@ import (
 )
 
 // CheckBusinessHoursTasks processes tasks waiting for business hours when business hours begin
-func CheckBusinessHoursTasks(db *gorm.DB) {
+func CheckBusinessHoursTasks(db *gorm.DB, slack SlackClient) {
 	// Get current time
 	now := time.Now()
 
@@ -33,7 +33,7 @@ func CheckBusinessHoursTasks(db *gorm.DB) {
 			labelName = "needs-review"
 		}
 
-		if err := db.Where("slack_channel_id = ? AND label_name = ?", task.SlackChannel, labelName).First(&config).Error; err != nil {
+		if err := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", task.TeamID, task.SlackChannel, labelName).First(&config).Error; err != nil {
 			log.Printf("channel config not found for waiting task: %s, error: %v", task.ID, err)
 			continue
 		}
@@ -43,7 +43,7 @@ func CheckBusinessHoursTasks(db *gorm.DB) {
 			continue // Outside business hours, skip processing
 		}
 
-		if err := activateBusinessHoursTask(db, task, config, labelName); err != nil {
+		if err := activateBusinessHoursTask(db, slack, task, config, labelName); err != nil {
 			log.Printf("activate waiting_business_hours task failed (task: %s): %v", task.ID, err)
 			continue
 		}
@@ -55,8 +55,9 @@ func CheckBusinessHoursTasks(db *gorm.DB) {
 // Reviewers are assigned to the task before the notification is sent so the morning
 // greeting can mention them. The task is persisted as in_review only after the
 // notification succeeds, so a failed notification leaves it to be retried next tick.
-func activateBusinessHoursTask(db *gorm.DB, task models.ReviewTask, config models.ChannelConfig, labelName string) error {
-	// Randomly select reviewers (excluding PR author)
+func activateBusinessHoursTask(db *gorm.DB, slack SlackClient, task models.ReviewTask, config models.ChannelConfig, labelName string) error {
+	// Keep reviewers requested on GitHub when the PR was labeled and top them up
+	// (excluding PR author), preferring the CODEOWNERS of the changed files
 	excludeIDs := []string{}
 	if task.PRAuthorSlackID != "" {
 		excludeIDs = append(excludeIDs, task.PRAuthorSlackID)
@@ -65,7 +66,12 @@ func activateBusinessHoursTask(db *gorm.DB, task models.ReviewTask, config model
 	if requiredApprovals <= 0 {
 		requiredApprovals = 1
 	}
-	reviewerIDs := SelectRandomReviewers(db, task.SlackChannel, labelName, requiredApprovals, excludeIDs)
+	requestedIDs := taskReviewerIDs(task)
+	var codeownerIDs []string
+	if len(requestedIDs) < requiredApprovals {
+		codeownerIDs = GetCodeownerSlackIDs(db, task.TeamID, task.Repo, task.PRNumber, pullRequestBaseRef(db, task.Repo, task.PRNumber))
+	}
+	reviewerIDs := TopUpReviewers(db, task.TeamID, task.SlackChannel, labelName, requiredApprovals, requestedIDs, excludeIDs, codeownerIDs)
 	reviewerID := ""
 	if len(reviewerIDs) > 0 {
 		reviewerID = reviewerIDs[0]
@@ -78,7 +84,7 @@ func activateBusinessHoursTask(db *gorm.DB, task models.ReviewTask, config model
 	task.Reviewers = strings.Join(reviewerIDs, ",")
 
 	// Send business hours notification to thread (mentions the assigned reviewers)
-	if err := PostBusinessHoursNotificationToThread(task, config.DefaultMentionID); err != nil {
+	if err := PostBusinessHoursNotificationToThread(slack, task, config.DefaultMentionID); err != nil {
 		return fmt.Errorf("business hours notification error: %w", err)
 	}
 
@@ -90,12 +96,16 @@ func activateBusinessHoursTask(db *gorm.DB, task models.ReviewTask, config model
 	}
 
 	log.Printf("waiting_business_hours task activated: %s", task.ID)
+	RecordReviewerAssignments(db, task, reviewerIDs)
+
+	// Request the assigned reviewers on GitHub (when enabled for the config)
+	PushReviewersToGitHub(db, task, reviewerIDs, nil)
 
 	return nil
 }
 
 // CheckPendingReReviewNotifications sends deferred re-review notifications when business hours begin
-func CheckPendingReReviewNotifications(db *gorm.DB) {
+func CheckPendingReReviewNotifications(db *gorm.DB, slack SlackClient) {
 	now := time.Now()
 
 	var tasks []models.ReviewTask
@@ -114,7 +124,7 @@ func CheckPendingReReviewNotifications(db *gorm.DB) {
 			labelName = "needs-review"
 		}
 
-		if err := db.Where("slack_channel_id = ? AND label_name = ?", task.SlackChannel, labelName).First(&config).Error; err != nil {
+		if err := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", task.TeamID, task.SlackChannel, labelName).First(&config).Error; err != nil {
 			// Config deleted or not found: clear pending flag to avoid infinite retry
 			log.Printf("channel config not found for pending re-review task: %s, clearing pending flag: %v", task.ID, err)
 			if err := clearPendingReReviewFlags(db, task.ID, task.UpdatedAt, now); err != nil {
@@ -133,7 +143,7 @@ func CheckPendingReReviewNotifications(db *gorm.DB) {
 		t := i18n.L(task.Language)
 		for idx := 0; idx < len(senders) && idx < len(reviewers); idx++ {
 			message := t("notify.re_review_requested", senders[idx], reviewers[idx])
-			if err := PostToThread(task.SlackChannel, task.SlackTS, message); err != nil {
+			if err := PostToThread(slack, task.SlackChannel, task.SlackTS, message); err != nil {
 				log.Printf("deferred re-review notification error (task: %s, idx: %d): %v", task.ID, idx, err)
 				// Continue to try remaining notifications
 			}
@@ -168,7 +178,7 @@ func clearPendingReReviewFlags(db *gorm.DB, taskID string, expectedUpdatedAt tim
 }
 
 // CheckInReviewTasks checks tasks that are in review and sends reminders as needed
-func CheckInReviewTasks(db *gorm.DB) {
+func CheckInReviewTasks(db *gorm.DB, slack SlackClient) {
 	var tasks []models.ReviewTask
 
 	// Search for tasks in "in_review" status that are not in "archived" status
@@ -203,7 +213,7 @@ func CheckInReviewTasks(db *gorm.DB) {
 			labelName = "needs-review" // Default label name
 		}
 
-		if err := db.Where("slack_channel_id = ? AND label_name = ?", task.SlackChannel, labelName).First(&config).Error; err == nil {
+		if err := db.Where("team_id = ? AND slack_channel_id = ? AND label_name = ?", task.TeamID, task.SlackChannel, labelName).First(&config).Error; err == nil {
 			if config.ReviewerReminderInterval > 0 {
 				reminderInterval = config.ReviewerReminderInterval
 			}
@@ -218,7 +228,7 @@ func CheckInReviewTasks(db *gorm.DB) {
 				reminderTime := now.Add(-time.Duration(reminderInterval) * time.Minute)
 				if task.UpdatedAt.Before(reminderTime) {
 					// Send off-hours reminder message
-					err := SendOutOfHoursReminderMessage(db, task)
+					err := SendOutOfHoursReminderMessage(slack, task)
 					if err != nil {
 						log.Printf("out of hours reminder send error (task id: %s): %v", task.ID, err)
 
@@ -228,6 +238,8 @@ func CheckInReviewTasks(db *gorm.DB) {
 							continue
 						}
 					} else {
+						RecordReminderSent("out_of_hours")
+
 						// Pause until the next business day's opening time
 						nextBusinessDay := GetNextBusinessDayMorningWithConfig(now, &config)
 						task.ReminderPausedUntil = &nextBusinessDay
@@ -257,7 +269,7 @@ func CheckInReviewTasks(db *gorm.DB) {
 			// Normal reminder processing
 			reminderTime := now.Add(-time.Duration(reminderInterval) * time.Minute)
 			if task.UpdatedAt.Before(reminderTime) {
-				err := SendReviewerReminderMessage(db, task)
+				err := SendReviewerReminderMessage(db, slack, task)
 				if err != nil {
 					log.Printf("reviewer reminder send error (task id: %s): %v", task.ID, err)
 
@@ -267,6 +279,7 @@ func CheckInReviewTasks(db *gorm.DB) {
 						continue
 					}
 				} else {
+					RecordReminderSent("reviewer")
 					task.UpdatedAt = now
 					if err := db.Model(&task).Update("updated_at", now).Error; err != nil {
 						log.Printf("task update error: %v", err)
@@ -347,6 +360,9 @@ func CleanupOldTasks(db *gorm.DB) {
 	if totalDeleted > 0 {
 		log.Printf("total task deleted: %d", totalDeleted)
 	}
+
+	// Lifecycle events are kept much longer than tasks for review stats
+	CleanupOldReviewEvents(db)
 }
 
 // CleanupExpiredAvailability permanently deletes expired leave records
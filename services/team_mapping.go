@@ -0,0 +1,98 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"slack-review-notify/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// NormalizeTeamSlug strips the leading "@" of a GitHub team reference and
+// lowercases it ("@My-Org/Backend" → "my-org/backend"). Team slugs are only
+// unique within an organization, so mappings are keyed by "org/slug".
+func NormalizeTeamSlug(team string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(team), "@"))
+}
+
+// GetTeamMapping returns the mapping a Slack team registered for a GitHub
+// team, given as "org/slug".
+func GetTeamMapping(db *gorm.DB, teamID, teamSlug string) (*models.TeamMapping, error) {
+	var mapping models.TeamMapping
+	if err := db.Where("team_id = ? AND github_team_slug = ?", teamID, NormalizeTeamSlug(teamSlug)).First(&mapping).Error; err != nil {
+		return nil, err
+	}
+	return &mapping, nil
+}
+
+// TeamMention returns the Slack mention for the whole team: the user group if
+// one is mapped, otherwise every member.
+func TeamMention(mapping *models.TeamMapping) string {
+	if mapping.SlackUserGroupID != "" {
+		return buildMentionText(mapping.SlackUserGroupID)
+	}
+	var mentions []string
+	for _, id := range strings.Split(mapping.MemberIDs, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			mentions = append(mentions, fmt.Sprintf("<@%s>", id))
+		}
+	}
+	return strings.Join(mentions, " ")
+}
+
+// AssignTeamMember picks a member of the team as reviewer of the task when the
+// mapping has PickReviewer set. A member who already reviews the task is kept;
+// otherwise an available member not in excludeIDs is chosen at random and
+// added to the task's reviewers. It returns "" when no member can be picked.
+func AssignTeamMember(db *gorm.DB, mapping *models.TeamMapping, task *models.ReviewTask, excludeIDs []string) string {
+	if !mapping.PickReviewer {
+		return ""
+	}
+
+	excluded := make(map[string]bool)
+	for _, id := range excludeIDs {
+		excluded[id] = true
+	}
+	for _, id := range GetAwayUserIDs(db) {
+		excluded[id] = true
+	}
+
+	var candidates []string
+	for _, id := range strings.Split(mapping.MemberIDs, ",") {
+		if id = strings.TrimSpace(id); id != "" && !excluded[id] {
+			candidates = append(candidates, id)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	current := taskReviewerIDs(*task)
+	for _, id := range current {
+		for _, candidate := range candidates {
+			if id == candidate {
+				return id
+			}
+		}
+	}
+
+	picked := candidates[rand.Intn(len(candidates))]
+	reviewers := append(current, picked)
+	updates := map[string]interface{}{"reviewers": strings.Join(reviewers, ",")}
+	if task.Reviewer == "" {
+		updates["reviewer"] = picked
+	}
+	if err := db.Model(&models.ReviewTask{}).Where("id = ?", task.ID).Updates(updates).Error; err != nil {
+		log.Printf("failed to assign team member as reviewer (task: %s): %v", task.ID, err)
+		return ""
+	}
+	task.Reviewers = strings.Join(reviewers, ",")
+	if task.Reviewer == "" {
+		task.Reviewer = picked
+	}
+	log.Printf("team member assigned as reviewer: task=%s, team=%s, reviewer=%s", task.ID, mapping.GithubTeamSlug, picked)
+	RecordReviewerAssignments(db, *task, []string{picked})
+	return picked
+}
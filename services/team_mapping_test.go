@@ -0,0 +1,72 @@
+package services
+
+import (
+	"slack-review-notify/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTeamSlug(t *testing.T) {
+	assert.Equal(t, "my-org/backend", NormalizeTeamSlug("@my-org/Backend"))
+	assert.Equal(t, "backend", NormalizeTeamSlug("backend"))
+	assert.Equal(t, "", NormalizeTeamSlug(" "))
+}
+
+func TestGetTeamMapping(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.TeamMapping{ID: "tm-a", TeamID: "T1", GithubTeamSlug: "org-a/backend", SlackUserGroupID: "S0A"})
+	db.Create(&models.TeamMapping{ID: "tm-b", TeamID: "T1", GithubTeamSlug: "org-b/backend", SlackUserGroupID: "S0B"})
+
+	// Teams of the same slug in different organizations are told apart
+	mapping, err := GetTeamMapping(db, "T1", "Org-B/backend")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "S0B", mapping.SlackUserGroupID)
+	}
+	_, err = GetTeamMapping(db, "T1", "org-c/backend")
+	assert.Error(t, err)
+	_, err = GetTeamMapping(db, "T2", "org-a/backend")
+	assert.Error(t, err)
+}
+
+func TestTeamMention(t *testing.T) {
+	assert.Equal(t, "<!subteam^S01TEAM>", TeamMention(&models.TeamMapping{SlackUserGroupID: "S01TEAM", MemberIDs: "U1"}))
+	assert.Equal(t, "<@U1> <@U2>", TeamMention(&models.TeamMapping{MemberIDs: "U1,U2"}))
+}
+
+func TestAssignTeamMember(t *testing.T) {
+	db := setupTestDB(t)
+
+	now := time.Now()
+	future := now.Add(24 * time.Hour)
+	db.Create(&models.ReviewerAvailability{
+		ID:          "away-u2",
+		SlackUserID: "U2",
+		AwayUntil:   &future,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+
+	task := models.ReviewTask{ID: "team-task", Reviewer: "UOLD", Reviewers: "UOLD", Status: "in_review"}
+	db.Create(&task)
+
+	// Without PickReviewer nobody is assigned.
+	mapping := models.TeamMapping{GithubTeamSlug: "my-org/backend", MemberIDs: "U1,U2,U3"}
+	assert.Equal(t, "", AssignTeamMember(db, &mapping, &task, nil))
+
+	// U1 is the author and U2 is away, so U3 is picked and added to the task.
+	mapping.PickReviewer = true
+	assert.Equal(t, "U3", AssignTeamMember(db, &mapping, &task, []string{"U1"}))
+
+	var saved models.ReviewTask
+	db.Where("id = ?", "team-task").First(&saved)
+	assert.Equal(t, "UOLD,U3", saved.Reviewers)
+	assert.Equal(t, "UOLD", saved.Reviewer)
+
+	// A member who already reviews the task is asked again instead of adding another.
+	mapping.MemberIDs = "U1,U3,U4"
+	assert.Equal(t, "U3", AssignTeamMember(db, &mapping, &saved, []string{"U1"}))
+	db.Where("id = ?", "team-task").First(&saved)
+	assert.Equal(t, "UOLD,U3", saved.Reviewers)
+}
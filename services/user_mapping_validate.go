@@ -27,6 +27,12 @@ func LooksLikeResolvedSlackUserID(s string) bool {
 	return true
 }
 
+// LooksLikeSlackUserGroupID reports whether s is a bare Slack user group
+// (subteam) id of the form `S…` followed by uppercase alphanumerics.
+func LooksLikeSlackUserGroupID(s string) bool {
+	return looksLikeSubteamID(s)
+}
+
 // FindLegacyUserMappings returns every UserMapping row whose SlackUserID is
 // not a resolved user id. Operators can use this to spot rows that need to be
 // re-mapped via the modal picker.
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"slack-review-notify/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Statuses of a WebhookDelivery.
+const (
+	WebhookDeliveryProcessing = "processing"
+	WebhookDeliveryProcessed  = "processed" // passed to an event handler
+	WebhookDeliveryIgnored    = "ignored"   // no handler for the event or action
+	WebhookDeliveryFailed     = "failed"
+)
+
+const (
+	// webhookDeliveryRetention is how long deliveries are kept for
+	// deduplication and replay. GitHub redelivers for a few days at most.
+	webhookDeliveryRetention = 14 * 24 * time.Hour
+	// webhookDeliveryLease is how long a delivery may stay processing before
+	// it counts as interrupted. Processing takes seconds, so a delivery this
+	// old was left behind by a server that stopped.
+	webhookDeliveryLease = 15 * time.Minute
+	// DefaultWebhookDeliveryLimit and MaxWebhookDeliveryLimit bound the
+	// deliveries listed at once.
+	DefaultWebhookDeliveryLimit = 50
+	MaxWebhookDeliveryLimit     = 500
+)
+
+var (
+	// ErrDuplicateWebhookDelivery is returned for a delivery that has already
+	// been processed or is being processed.
+	ErrDuplicateWebhookDelivery = errors.New("duplicate webhook delivery")
+	// ErrWebhookDeliveryInProgress is returned when replaying a delivery that
+	// is still being processed.
+	ErrWebhookDeliveryInProgress = errors.New("webhook delivery is being processed")
+)
+
+// BeginWebhookDelivery stores a received delivery as processing. It returns
+// ErrDuplicateWebhookDelivery when the delivery ID is already stored, unless
+// that delivery failed, in which case it is processed again.
+func BeginWebhookDelivery(db *gorm.DB, id, eventType, action string, payload []byte) error {
+	now := time.Now()
+	delivery := models.WebhookDelivery{
+		ID:        id,
+		EventType: eventType,
+		Action:    action,
+		Payload:   string(payload),
+		Status:    WebhookDeliveryProcessing,
+		Attempts:  1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	createErr := db.Create(&delivery).Error
+	if createErr == nil {
+		return nil
+	}
+
+	var existing models.WebhookDelivery
+	if err := db.Where("id = ?", id).First(&existing).Error; err != nil {
+		return createErr
+	}
+	if existing.Status != WebhookDeliveryFailed {
+		return ErrDuplicateWebhookDelivery
+	}
+
+	// Claim the failed delivery so a concurrent redelivery stays a duplicate
+	result := db.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ?", id, WebhookDeliveryFailed).
+		Updates(map[string]interface{}{
+			"status":     WebhookDeliveryProcessing,
+			"error":      "",
+			"attempts":   gorm.Expr("attempts + 1"),
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDuplicateWebhookDelivery
+	}
+	return nil
+}
+
+// BeginWebhookDeliveryReplay marks a stored delivery as processing again and
+// returns it. It returns gorm.ErrRecordNotFound for an unknown ID and
+// ErrWebhookDeliveryInProgress while the delivery is being processed.
+func BeginWebhookDeliveryReplay(db *gorm.DB, id string) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := db.Where("id = ?", id).First(&delivery).Error; err != nil {
+		return nil, err
+	}
+
+	result := db.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status <> ?", id, WebhookDeliveryProcessing).
+		Updates(map[string]interface{}{
+			"status":     WebhookDeliveryProcessing,
+			"error":      "",
+			"attempts":   gorm.Expr("attempts + 1"),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrWebhookDeliveryInProgress
+	}
+	delivery.Status = WebhookDeliveryProcessing
+	delivery.Attempts++
+	return &delivery, nil
+}
+
+// FinishWebhookDelivery records the outcome of processing a delivery.
+func FinishWebhookDelivery(db *gorm.DB, id, status, errMsg string) {
+	now := time.Now()
+	if err := db.Model(&models.WebhookDelivery{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       status,
+		"error":        errMsg,
+		"processed_at": now,
+		"updated_at":   now,
+	}).Error; err != nil {
+		log.Printf("webhook delivery update error (delivery: %s): %v", id, err)
+	}
+}
+
+// ListWebhookDeliveries returns the most recent deliveries, newest first,
+// optionally only those with the given status.
+func ListWebhookDeliveries(db *gorm.DB, status string, limit int) ([]models.WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = DefaultWebhookDeliveryLimit
+	}
+	if limit > MaxWebhookDeliveryLimit {
+		limit = MaxWebhookDeliveryLimit
+	}
+
+	query := db.Order("created_at DESC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var deliveries []models.WebhookDelivery
+	err := query.Find(&deliveries).Error
+	return deliveries, err
+}
+
+// InterruptedWebhookDeliveries returns the deliveries left processing for
+// longer than webhookDeliveryLease, oldest first. Deliveries processed right
+// now, by this server or another one, are left alone.
+func InterruptedWebhookDeliveries(db *gorm.DB) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := db.Where("status = ? AND updated_at < ?", WebhookDeliveryProcessing, time.Now().Add(-webhookDeliveryLease)).
+		Order("created_at").Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ClaimInterruptedWebhookDelivery takes over an interrupted delivery by
+// renewing its lease. It returns false when the delivery changed since it was
+// found, e.g. because another server claimed it first.
+func ClaimInterruptedWebhookDelivery(db *gorm.DB, delivery models.WebhookDelivery) (bool, error) {
+	result := db.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND updated_at = ?", delivery.ID, WebhookDeliveryProcessing, delivery.UpdatedAt).
+		Updates(map[string]interface{}{
+			"attempts":   gorm.Expr("attempts + 1"),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CleanupWebhookDeliveries deletes deliveries past their retention.
+func CleanupWebhookDeliveries(db *gorm.DB) {
+	result := db.Where("created_at < ?", time.Now().Add(-webhookDeliveryRetention)).
+		Delete(&models.WebhookDelivery{})
+	if result.Error != nil {
+		log.Printf("webhook delivery delete error: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("old webhook deliveries deleted: %d", result.RowsAffected)
+	}
+}
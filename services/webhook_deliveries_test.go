@@ -0,0 +1,138 @@
+package services
+
+import (
+	"slack-review-notify/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestBeginWebhookDelivery_SkipsDuplicates(t *testing.T) {
+	db := setupTestDB(t)
+
+	assert.NoError(t, BeginWebhookDelivery(db, "d-1", "pull_request_review", "submitted", []byte(`{}`)))
+	// Still being processed
+	assert.ErrorIs(t, BeginWebhookDelivery(db, "d-1", "pull_request_review", "submitted", []byte(`{}`)), ErrDuplicateWebhookDelivery)
+
+	FinishWebhookDelivery(db, "d-1", WebhookDeliveryProcessed, "")
+	assert.ErrorIs(t, BeginWebhookDelivery(db, "d-1", "pull_request_review", "submitted", []byte(`{}`)), ErrDuplicateWebhookDelivery)
+
+	var delivery models.WebhookDelivery
+	assert.NoError(t, db.First(&delivery, "id = ?", "d-1").Error)
+	assert.Equal(t, WebhookDeliveryProcessed, delivery.Status)
+	assert.Equal(t, "submitted", delivery.Action)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.NotNil(t, delivery.ProcessedAt)
+}
+
+func TestBeginWebhookDelivery_RetriesFailedDelivery(t *testing.T) {
+	db := setupTestDB(t)
+
+	assert.NoError(t, BeginWebhookDelivery(db, "d-1", "pull_request", "labeled", []byte(`{}`)))
+	FinishWebhookDelivery(db, "d-1", WebhookDeliveryFailed, "boom")
+
+	assert.NoError(t, BeginWebhookDelivery(db, "d-1", "pull_request", "labeled", []byte(`{}`)))
+	// The redelivery has claimed it
+	assert.ErrorIs(t, BeginWebhookDelivery(db, "d-1", "pull_request", "labeled", []byte(`{}`)), ErrDuplicateWebhookDelivery)
+
+	var delivery models.WebhookDelivery
+	assert.NoError(t, db.First(&delivery, "id = ?", "d-1").Error)
+	assert.Equal(t, WebhookDeliveryProcessing, delivery.Status)
+	assert.Empty(t, delivery.Error)
+	assert.Equal(t, 2, delivery.Attempts)
+}
+
+func TestBeginWebhookDeliveryReplay(t *testing.T) {
+	db := setupTestDB(t)
+
+	_, err := BeginWebhookDeliveryReplay(db, "missing")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	assert.NoError(t, BeginWebhookDelivery(db, "d-1", "pull_request", "closed", []byte(`{"action":"closed"}`)))
+	_, err = BeginWebhookDeliveryReplay(db, "d-1")
+	assert.ErrorIs(t, err, ErrWebhookDeliveryInProgress)
+
+	FinishWebhookDelivery(db, "d-1", WebhookDeliveryProcessed, "")
+	delivery, err := BeginWebhookDeliveryReplay(db, "d-1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"action":"closed"}`, delivery.Payload)
+		assert.Equal(t, WebhookDeliveryProcessing, delivery.Status)
+		assert.Equal(t, 2, delivery.Attempts)
+	}
+}
+
+func TestClaimInterruptedWebhookDelivery(t *testing.T) {
+	db := setupTestDB(t)
+
+	old := time.Now().Add(-time.Hour)
+	db.Create(&models.WebhookDelivery{ID: "d-stale", EventType: "pull_request", Status: WebhookDeliveryProcessing, Attempts: 1, CreatedAt: old, UpdatedAt: old})
+	db.Create(&models.WebhookDelivery{ID: "d-fresh", EventType: "pull_request", Status: WebhookDeliveryProcessing, Attempts: 1})
+	db.Create(&models.WebhookDelivery{ID: "d-failed", EventType: "pull_request", Status: WebhookDeliveryFailed, Attempts: 1, CreatedAt: old, UpdatedAt: old})
+
+	deliveries, err := InterruptedWebhookDeliveries(db)
+	assert.NoError(t, err)
+	if assert.Len(t, deliveries, 1) {
+		assert.Equal(t, "d-stale", deliveries[0].ID)
+	}
+
+	// Two servers found it; only the first claim succeeds
+	claimed, err := ClaimInterruptedWebhookDelivery(db, deliveries[0])
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	claimed, err = ClaimInterruptedWebhookDelivery(db, deliveries[0])
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	// The claim renewed its lease
+	deliveries, err = InterruptedWebhookDeliveries(db)
+	assert.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	var delivery models.WebhookDelivery
+	assert.NoError(t, db.First(&delivery, "id = ?", "d-stale").Error)
+	assert.Equal(t, 2, delivery.Attempts)
+}
+
+func TestListWebhookDeliveries(t *testing.T) {
+	db := setupTestDB(t)
+	now := time.Now()
+	db.Create(&models.WebhookDelivery{ID: "old", Status: WebhookDeliveryFailed, CreatedAt: now.Add(-2 * time.Hour)})
+	db.Create(&models.WebhookDelivery{ID: "mid", Status: WebhookDeliveryProcessed, CreatedAt: now.Add(-time.Hour)})
+	db.Create(&models.WebhookDelivery{ID: "new", Status: WebhookDeliveryIgnored, CreatedAt: now})
+
+	ids := func(deliveries []models.WebhookDelivery) []string {
+		var result []string
+		for _, d := range deliveries {
+			result = append(result, d.ID)
+		}
+		return result
+	}
+
+	deliveries, err := ListWebhookDeliveries(db, "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"new", "mid", "old"}, ids(deliveries))
+
+	deliveries, err = ListWebhookDeliveries(db, "", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"new", "mid"}, ids(deliveries))
+
+	deliveries, err = ListWebhookDeliveries(db, WebhookDeliveryFailed, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"old"}, ids(deliveries))
+}
+
+func TestCleanupWebhookDeliveries(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.WebhookDelivery{ID: "expired", Status: WebhookDeliveryProcessed, CreatedAt: time.Now().Add(-webhookDeliveryRetention - time.Hour)})
+	db.Create(&models.WebhookDelivery{ID: "recent", Status: WebhookDeliveryProcessed, CreatedAt: time.Now().Add(-time.Hour)})
+
+	CleanupWebhookDeliveries(db)
+
+	var remaining []models.WebhookDelivery
+	db.Find(&remaining)
+	if assert.Len(t, remaining, 1) {
+		assert.Equal(t, "recent", remaining[0].ID)
+	}
+}
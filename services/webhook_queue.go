@@ -0,0 +1,186 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultWebhookWorkers is the number of webhook workers unless
+	// WEBHOOK_WORKERS is set.
+	defaultWebhookWorkers = 4
+	// webhookQueueSize is how many jobs each worker buffers before Enqueue
+	// blocks.
+	webhookQueueSize = 100
+	// webhookJobMaxAttempts and webhookJobRetryDelay bound the retries of a
+	// job that fails with a database lock error; the delay doubles each time.
+	webhookJobMaxAttempts = 3
+	webhookJobRetryDelay  = 100 * time.Millisecond
+)
+
+// ErrWebhookQueueClosed is returned when enqueueing after Close.
+var ErrWebhookQueueClosed = errors.New("webhook queue is closed")
+
+// WebhookJob is the processing of one webhook event.
+type WebhookJob struct {
+	// Key serializes jobs: jobs with the same key (e.g. the same PR) run one
+	// at a time, in the order they were enqueued.
+	Key string
+	// Run processes the event. It is retried when it fails with a database
+	// lock error.
+	Run func() error
+	// Done, when set, receives Run's final error.
+	Done func(err error)
+}
+
+// WebhookQueue runs webhook jobs on a fixed set of workers. Jobs are assigned
+// to a worker by key, so jobs with the same key never run concurrently and
+// keep their order.
+type WebhookQueue struct {
+	mu      sync.RWMutex
+	workers []chan WebhookJob
+	closed  bool
+}
+
+// NewWebhookQueue starts a queue with the given number of workers. With no
+// workers, Enqueue runs each job right away in the caller (used by tests).
+func NewWebhookQueue(workers int) *WebhookQueue {
+	q := &WebhookQueue{}
+	for i := 0; i < workers; i++ {
+		jobs := make(chan WebhookJob, webhookQueueSize)
+		q.workers = append(q.workers, jobs)
+		Go(func() {
+			for job := range jobs {
+				runWebhookJob(job)
+			}
+		})
+	}
+	return q
+}
+
+// WebhookWorkers returns the number of webhook workers, from WEBHOOK_WORKERS
+// (default 4).
+func WebhookWorkers() int {
+	if n, err := strconv.Atoi(os.Getenv("WEBHOOK_WORKERS")); err == nil && n > 0 {
+		return n
+	}
+	return defaultWebhookWorkers
+}
+
+// Enqueue adds job to its worker's queue, waiting while the queue is full.
+func (q *WebhookQueue) Enqueue(job WebhookJob) error {
+	if len(q.workers) == 0 {
+		runWebhookJob(job)
+		return nil
+	}
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrWebhookQueueClosed
+	}
+	q.workers[q.worker(job.Key)] <- job
+	return nil
+}
+
+// Do enqueues job and waits until it has run, returning Run's final error.
+func (q *WebhookQueue) Do(job WebhookJob) error {
+	result := make(chan error, 1)
+	done := job.Done
+	job.Done = func(err error) {
+		if done != nil {
+			done(err)
+		}
+		result <- err
+	}
+	if err := q.Enqueue(job); err != nil {
+		return err
+	}
+	return <-result
+}
+
+// Close stops accepting jobs. The workers return once they have run the jobs
+// already queued; shutdown waits for them with WaitForBackgroundTasks.
+func (q *WebhookQueue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	for _, jobs := range q.workers {
+		close(jobs)
+	}
+}
+
+func (q *WebhookQueue) worker(key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(q.workers)))
+}
+
+// runWebhookJob runs the job, retrying database lock errors with exponential
+// backoff, and passes the final error to Done. A panic fails the job instead
+// of stopping the worker.
+func runWebhookJob(job WebhookJob) {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = runWebhookJobOnce(job)
+		if err == nil || !IsDBLockError(err) || attempt >= webhookJobMaxAttempts {
+			break
+		}
+		delay := webhookJobRetryDelay * time.Duration(1<<(attempt-1))
+		log.Printf("database lock detected for webhook job %s, retrying in %v (attempt %d/%d)",
+			job.Key, delay, attempt, webhookJobMaxAttempts)
+		RecordDBLockRetry()
+		time.Sleep(delay)
+	}
+
+	if err != nil {
+		log.Printf("webhook job %s failed: %v", job.Key, err)
+	}
+	if job.Done != nil {
+		job.Done(err)
+	}
+}
+
+func runWebhookJobOnce(job WebhookJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run()
+}
+
+// dbLockErrors are the messages of lock errors that go away when the
+// statement is retried: SQLite's busy database, a PostgreSQL deadlock or
+// serialization failure, and a MySQL deadlock or lock wait timeout.
+var dbLockErrors = []string{
+	"database is locked",
+	"deadlock detected",
+	"could not serialize access",
+	"Deadlock found",
+	"Lock wait timeout exceeded",
+}
+
+// IsDBLockError reports whether err is a database lock error, which goes away
+// when the statement is retried.
+func IsDBLockError(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, msg := range dbLockErrors {
+		if strings.Contains(err.Error(), msg) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookQueue_SerializesJobsPerKey(t *testing.T) {
+	q := NewWebhookQueue(4)
+
+	var mu sync.Mutex
+	order := make(map[string][]int)
+	running := make(map[string]int)
+	overlapped := false
+
+	for i := 0; i < 20; i++ {
+		for _, key := range []string{"owner/repo#1", "owner/repo#2", "owner/other#1"} {
+			i, key := i, key
+			assert.NoError(t, q.Enqueue(WebhookJob{Key: key, Run: func() error {
+				mu.Lock()
+				running[key]++
+				if running[key] > 1 {
+					overlapped = true
+				}
+				mu.Unlock()
+
+				time.Sleep(time.Millisecond)
+
+				mu.Lock()
+				running[key]--
+				order[key] = append(order[key], i)
+				mu.Unlock()
+				return nil
+			}}))
+		}
+	}
+
+	q.Close()
+	assert.NoError(t, WaitForBackgroundTasks(context.Background()))
+
+	assert.False(t, overlapped, "jobs with the same key must not run concurrently")
+	for key, got := range order {
+		want := make([]int, 20)
+		for i := range want {
+			want[i] = i
+		}
+		assert.Equal(t, want, got, "jobs for %s ran out of order", key)
+	}
+}
+
+func TestWebhookQueue_RetriesDBLockErrors(t *testing.T) {
+	q := NewWebhookQueue(0)
+
+	var calls int
+	err := q.Do(WebhookJob{Key: "k", Run: func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("database is locked")
+		}
+		return nil
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = q.Do(WebhookJob{Key: "k", Run: func() error {
+		calls++
+		return errors.New("database is locked")
+	}})
+	assert.True(t, IsDBLockError(err))
+	assert.Equal(t, webhookJobMaxAttempts, calls, "gives up after the last attempt")
+
+	calls = 0
+	err = q.Do(WebhookJob{Key: "k", Run: func() error {
+		calls++
+		return errors.New("no such table")
+	}})
+	assert.EqualError(t, err, "no such table")
+	assert.Equal(t, 1, calls, "other errors are not retried")
+}
+
+func TestWebhookQueue_PanicFailsJob(t *testing.T) {
+	q := NewWebhookQueue(1)
+	defer func() {
+		q.Close()
+		assert.NoError(t, WaitForBackgroundTasks(context.Background()))
+	}()
+
+	err := q.Do(WebhookJob{Key: "k", Run: func() error { panic("boom") }})
+	assert.EqualError(t, err, "panic: boom")
+
+	// The worker keeps going
+	var ran atomic.Bool
+	assert.NoError(t, q.Do(WebhookJob{Key: "k", Run: func() error {
+		ran.Store(true)
+		return nil
+	}}))
+	assert.True(t, ran.Load())
+}
+
+func TestWebhookQueue_Close(t *testing.T) {
+	q := NewWebhookQueue(2)
+
+	var done atomic.Int32
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, q.Enqueue(WebhookJob{
+			Key:  fmt.Sprintf("k%d", i),
+			Run:  func() error { return nil },
+			Done: func(error) { done.Add(1) },
+		}))
+	}
+
+	q.Close()
+	assert.ErrorIs(t, q.Enqueue(WebhookJob{Key: "late", Run: func() error { return nil }}), ErrWebhookQueueClosed)
+
+	// Jobs queued before Close still run
+	assert.NoError(t, WaitForBackgroundTasks(context.Background()))
+	assert.EqualValues(t, 10, done.Load())
+}
+
+func TestIsDBLockError(t *testing.T) {
+	for _, msg := range []string{
+		"database is locked",
+		"ERROR: deadlock detected (SQLSTATE 40P01)",
+		"ERROR: could not serialize access due to concurrent update (SQLSTATE 40001)",
+		"Error 1213 (40001): Deadlock found when trying to get lock; try restarting transaction",
+		"Error 1205 (HY000): Lock wait timeout exceeded; try restarting transaction",
+	} {
+		assert.True(t, IsDBLockError(errors.New(msg)), msg)
+	}
+	assert.False(t, IsDBLockError(errors.New("UNIQUE constraint failed: webhook_deliveries.id")))
+	assert.False(t, IsDBLockError(nil))
+}